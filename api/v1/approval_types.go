@@ -0,0 +1,40 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+const (
+	// ApprovedRevisionAnnotation is the annotation used to approve a new
+	// revision of a Source, by setting it to the revision or the digest of
+	// the pending Artifact.
+	ApprovedRevisionAnnotation = "source.toolkit.fluxcd.io/approved-revision"
+)
+
+// RevisionApproval specifies whether new revisions of the upstream source
+// must be approved before their Artifact is served.
+type RevisionApproval struct {
+	// Required makes every new revision after the first result in a pending
+	// Artifact, which is only served once the revision or digest of the
+	// Artifact is set in the 'source.toolkit.fluxcd.io/approved-revision'
+	// annotation of the object.
+	// +optional
+	Required bool `json:"required,omitempty"`
+}
+
+// IsRequired returns true if the approval of new revisions is required.
+func (in *RevisionApproval) IsRequired() bool {
+	return in != nil && in.Required
+}
@@ -60,6 +60,25 @@ const (
 	// This is a "negative polarity" or "abnormal-true" type, and is only
 	// present on the resource if it is True.
 	StorageOperationFailedCondition string = "StorageOperationFailed"
+
+	// DependencyNotReadyCondition indicates one of the dependencies of the
+	// Source is not ready. For example, because it does not exist, is not
+	// Ready, or its Artifact does not satisfy the constraints.
+	// This is a "negative polarity" or "abnormal-true" type, and is only
+	// present on the resource if it is True.
+	DependencyNotReadyCondition string = "DependencyNotReady"
+
+	// RevisionHeldCondition indicates a new revision of the upstream Source
+	// is available, but no Artifact is produced for it until the schedule of
+	// the Source opens.
+	// This Condition is only present on the resource if it is True.
+	RevisionHeldCondition string = "RevisionHeld"
+
+	// PendingApprovalCondition indicates a new revision of the upstream
+	// Source is available as a pending Artifact, which is not served until
+	// it is approved.
+	// This Condition is only present on the resource if it is True.
+	PendingApprovalCondition string = "PendingApproval"
 )
 
 // Reasons are provided as utility, and not part of the declarative API.
@@ -108,4 +127,39 @@ const (
 	// PatchOperationFailedReason signals a failure in patching a kubernetes API
 	// object.
 	PatchOperationFailedReason string = "PatchOperationFailed"
+
+	// DependencyNotSatisfiedReason signals that the Artifact of a dependency
+	// does not satisfy the constraints of the dependent Source.
+	DependencyNotSatisfiedReason string = "DependencyNotSatisfied"
+
+	// ScheduleClosedReason signals that a new revision is held, as the
+	// schedule of the Source is closed.
+	ScheduleClosedReason string = "ScheduleClosed"
+
+	// InvalidScheduleReason signals that the schedule of the Source can not
+	// be evaluated.
+	InvalidScheduleReason string = "InvalidSchedule"
+
+	// AwaitingApprovalReason signals that a new revision awaits approval.
+	AwaitingApprovalReason string = "AwaitingApproval"
+
+	// HostLimitedReason signals that a remote operation is delayed by the
+	// concurrency or rate limits of the remote host.
+	HostLimitedReason string = "HostLimited"
+
+	// HostUnavailableReason signals that remote operations on a host fail
+	// fast, as its circuit breaker opened after consecutive failures.
+	HostUnavailableReason string = "HostUnavailable"
+
+	// HostAvailableReason signals that the circuit breaker of a host closed,
+	// as a remote operation on the host succeeded.
+	HostAvailableReason string = "HostAvailable"
+
+	// ServiceAccountRequiredReason signals that the Source does not name a
+	// ServiceAccount, which is required in multi-tenant lockdown mode.
+	ServiceAccountRequiredReason string = "ServiceAccountRequired"
+
+	// ImpersonationFailedReason signals a failure in creating a client which
+	// impersonates the ServiceAccount of the Source.
+	ImpersonationFailedReason string = "ImpersonationFailed"
 )
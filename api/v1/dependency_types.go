@@ -0,0 +1,47 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// SourceDependency specifies a local reference to a source which must be
+// Ready before the dependent source is reconciled.
+type SourceDependency struct {
+	// Kind of the referent.
+	// +kubebuilder:validation:Enum=GitRepository;HelmRepository;HelmChart;Bucket;OCIRepository;HelmChartSet;HTTPSource;CompositeSource;ConfigSource;ReleaseSource
+	// +required
+	Kind string `json:"kind"`
+
+	// Name of the referent, in the namespace of the dependent source.
+	// +required
+	Name string `json:"name"`
+
+	// SemVer is the range the revision of the Artifact of the referent must
+	// be within. For revisions in the format '<name>@<digest>', the name
+	// part is taken into account.
+	// +optional
+	SemVer string `json:"semver,omitempty"`
+
+	// MatchMetadata specifies the entries the metadata of the Artifact of the
+	// referent must contain.
+	// +optional
+	MatchMetadata map[string]string `json:"matchMetadata,omitempty"`
+}
+
+// HasArtifactConstraints returns true if the dependency puts constraints on
+// the Artifact of the referent.
+func (in SourceDependency) HasArtifactConstraints() bool {
+	return in.SemVer != "" || len(in.MatchMetadata) > 0
+}
@@ -121,6 +121,29 @@ type GitRepositorySpec struct {
 	// +optional
 	Suspend bool `json:"suspend,omitempty"`
 
+	// DependsOn specifies the sources which must be Ready before this source
+	// is reconciled, optionally with constraints on their Artifact.
+	// +optional
+	DependsOn []SourceDependency `json:"dependsOn,omitempty"`
+
+	// Schedule specifies the windows in which a new revision of the upstream
+	// source may result in a new Artifact. Outside of them, the current
+	// Artifact keeps being served.
+	// +optional
+	Schedule *ReconcileSchedule `json:"schedule,omitempty"`
+
+	// Approval specifies whether new revisions of the upstream source must be
+	// approved before their Artifact is served.
+	// +optional
+	Approval *RevisionApproval `json:"approval,omitempty"`
+
+	// ServiceAccountName is the name of the Kubernetes ServiceAccount which is
+	// impersonated to read the Secrets referenced by this GitRepository, when the
+	// controller runs in multi-tenant lockdown mode. Defaults to the
+	// ServiceAccount configured with the --default-service-account flag.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
 	// RecurseSubmodules enables the initialization of all submodules within
 	// the GitRepository as cloned from the URL, using their default settings.
 	// +optional
@@ -228,6 +251,11 @@ type GitRepositoryStatus struct {
 	// +optional
 	Artifact *Artifact `json:"artifact,omitempty"`
 
+	// PendingArtifact is the Artifact of the latest revision awaiting
+	// approval.
+	// +optional
+	PendingArtifact *Artifact `json:"pendingArtifact,omitempty"`
+
 	// IncludedArtifacts contains a list of the last successfully included
 	// Artifacts as instructed by GitRepositorySpec.Include.
 	// +optional
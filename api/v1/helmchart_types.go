@@ -35,7 +35,9 @@ type HelmChartSpec struct {
 	Chart string `json:"chart"`
 
 	// Version is the chart version semver expression, ignored for charts from
-	// GitRepository and Bucket sources. Defaults to latest when omitted.
+	// GitRepository, Bucket and OCIRepository sources. Defaults to latest when
+	// omitted. For charts from an OCI HelmRepository, the version can be pinned
+	// to a manifest digest using the '<version>@<digest>' format.
 	// +kubebuilder:default:=*
 	// +optional
 	Version string `json:"version,omitempty"`
@@ -79,6 +81,29 @@ type HelmChartSpec struct {
 	// +optional
 	Suspend bool `json:"suspend,omitempty"`
 
+	// DependsOn specifies the sources which must be Ready before this source
+	// is reconciled, optionally with constraints on their Artifact.
+	// +optional
+	DependsOn []SourceDependency `json:"dependsOn,omitempty"`
+
+	// Schedule specifies the windows in which a new revision of the upstream
+	// source may result in a new Artifact. Outside of them, the current
+	// Artifact keeps being served.
+	// +optional
+	Schedule *ReconcileSchedule `json:"schedule,omitempty"`
+
+	// Approval specifies whether new revisions of the upstream source must be
+	// approved before their Artifact is served.
+	// +optional
+	Approval *RevisionApproval `json:"approval,omitempty"`
+
+	// ServiceAccountName is the name of the Kubernetes ServiceAccount which is
+	// impersonated to read the Secrets referenced by this HelmChart, when the
+	// controller runs in multi-tenant lockdown mode. Defaults to the
+	// ServiceAccount configured with the --default-service-account flag.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
 	// Verify contains the secret name containing the trusted public keys
 	// used to verify the signature and specifies which provider to use to check
 	// whether OCI image is authentic.
@@ -86,6 +111,120 @@ type HelmChartSpec struct {
 	// Chart dependencies, which are not bundled in the umbrella chart artifact, are not verified.
 	// +optional
 	Verify *OCIRepositoryVerification `json:"verify,omitempty"`
+
+	// RenderTest enables a template render of the packaged chart with its
+	// merged values after every build. The build fails if the chart does not
+	// render, and on success the rendered manifests are stored as a secondary
+	// artifact.
+	// +optional
+	RenderTest *HelmChartRenderTest `json:"renderTest,omitempty"`
+
+	// DependencyCredentials is a list of credentials used to access the
+	// repositories of the chart dependencies, matched by URL prefix.
+	// It takes precedence over the credentials of a HelmRepository with a
+	// matching URL. Only applicable to charts built from GitRepository,
+	// Bucket and OCIRepository sources.
+	// +optional
+	DependencyCredentials []HelmChartDependencyCredentials `json:"dependencyCredentials,omitempty"`
+
+	// DependencyMode defines how the chart dependencies are resolved when
+	// building a chart from a GitRepository, Bucket or OCIRepository source.
+	// 'Resolve' fetches missing dependencies from their repository, using the
+	// Chart.lock versions if present.
+	// 'Locked' requires a Chart.lock in sync with the chart dependencies, and
+	// fails if a dependency does not match the locked version or digest.
+	// 'Offline' only uses dependencies vendored in the charts/ directory or
+	// referenced by a local path, without making any network calls.
+	// Defaults to 'Resolve' when omitted.
+	// +kubebuilder:validation:Enum=Resolve;Locked;Offline
+	// +optional
+	DependencyMode string `json:"dependencyMode,omitempty"`
+
+	// VersionPolicy further restricts the chart versions which can be
+	// selected by the Version constraint. Only applicable to charts built
+	// from a HelmRepository source.
+	// +optional
+	VersionPolicy *HelmChartVersionPolicy `json:"versionPolicy,omitempty"`
+}
+
+// HelmChartVersionPolicy defines the policy chart versions must satisfy in
+// addition to the version constraint, before they can be selected.
+type HelmChartVersionPolicy struct {
+	// ExcludePrerelease excludes pre-release versions, even when they match
+	// the version constraint.
+	// +optional
+	ExcludePrerelease bool `json:"excludePrerelease,omitempty"`
+
+	// SoakTime is the minimum time since a version was published before it
+	// can be selected, based on the 'created' timestamp in the repository
+	// index. Versions without a timestamp are excluded. Not supported for
+	// HelmRepository sources of type 'oci'.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +optional
+	SoakTime *metav1.Duration `json:"soakTime,omitempty"`
+
+	// Allow is a list of versions or SemVer constraints, of which at least
+	// one must match a version for it to be selected.
+	// All versions are allowed when omitted.
+	// +optional
+	Allow []string `json:"allow,omitempty"`
+
+	// Deny is a list of versions or SemVer constraints, of which none must
+	// match a version for it to be selected.
+	// +optional
+	Deny []string `json:"deny,omitempty"`
+}
+
+// HelmChartDependencyCredentials contains the references to the Secrets used
+// to access the repositories of chart dependencies with a matching URL.
+type HelmChartDependencyCredentials struct {
+	// URLPrefix is the prefix a dependency repository URL must start with
+	// for the credentials to be used, e.g. 'https://charts.example.com/' or
+	// 'oci://ghcr.io/example'. The URL must have the same scheme and host as
+	// the prefix, and its path must start with the path of the prefix on a
+	// '/' boundary. When multiple prefixes match, the longest wins.
+	// +kubebuilder:validation:Pattern="^(http|https|oci)://.*$"
+	// +required
+	URLPrefix string `json:"urlPrefix"`
+
+	// SecretRef specifies the Secret containing authentication credentials
+	// for the dependency repository.
+	// For HTTP/S basic auth the secret must contain 'username' and 'password'
+	// fields.
+	// For OCI repositories the secret must be of type
+	// 'kubernetes.io/dockerconfigjson', or contain 'username' and 'password'
+	// fields.
+	// +optional
+	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// CertSecretRef can be given the name of a Secret containing
+	// either or both of
+	//
+	// - a PEM-encoded client certificate (`tls.crt`) and private
+	// key (`tls.key`);
+	// - a PEM-encoded CA certificate (`ca.crt`)
+	//
+	// and whichever are supplied, will be used for connecting to the
+	// dependency repository.
+	// +optional
+	CertSecretRef *meta.LocalObjectReference `json:"certSecretRef,omitempty"`
+}
+
+// HelmChartRenderTest defines the capabilities used to render the chart
+// during a render test.
+type HelmChartRenderTest struct {
+	// KubeVersion is the Kubernetes version the chart is rendered against,
+	// e.g. 'v1.29.0'. Defaults to the Helm library default when omitted.
+	// +optional
+	KubeVersion string `json:"kubeVersion,omitempty"`
+
+	// APIVersions is a list of API versions made available as capabilities
+	// during the render, in addition to the Helm library defaults. Entries
+	// may be a group version (e.g. 'monitoring.coreos.com/v1'), or a group
+	// version with kind (e.g. 'monitoring.coreos.com/v1/ServiceMonitor').
+	// +optional
+	APIVersions []string `json:"apiVersions,omitempty"`
 }
 
 const (
@@ -104,8 +243,8 @@ type LocalHelmChartSourceReference struct {
 	APIVersion string `json:"apiVersion,omitempty"`
 
 	// Kind of the referent, valid values are ('HelmRepository', 'GitRepository',
-	// 'Bucket').
-	// +kubebuilder:validation:Enum=HelmRepository;GitRepository;Bucket
+	// 'Bucket', 'OCIRepository').
+	// +kubebuilder:validation:Enum=HelmRepository;GitRepository;Bucket;OCIRepository
 	// +required
 	Kind string `json:"kind"`
 
@@ -151,6 +290,22 @@ type HelmChartStatus struct {
 	// +optional
 	Artifact *Artifact `json:"artifact,omitempty"`
 
+	// PendingArtifact is the Artifact of the latest revision awaiting
+	// approval.
+	// +optional
+	PendingArtifact *Artifact `json:"pendingArtifact,omitempty"`
+
+	// RenderedArtifact represents the rendered manifests of the chart in
+	// Artifact, as produced by the render test.
+	// +optional
+	RenderedArtifact *Artifact `json:"renderedArtifact,omitempty"`
+
+	// LatestUpstreamVersion is the newest stable version of the chart
+	// available in the HelmRepository at the last reconciliation, regardless
+	// of the version constraint and policy.
+	// +optional
+	LatestUpstreamVersion string `json:"latestUpstreamVersion,omitempty"`
+
 	meta.ReconcileRequestStatus `json:",inline"`
 }
 
@@ -186,6 +341,12 @@ func (in *HelmChart) GetArtifact() *Artifact {
 	return in.Status.Artifact
 }
 
+// GetRenderedArtifact returns the latest rendered artifact from the source
+// if present in the status sub-resource.
+func (in *HelmChart) GetRenderedArtifact() *Artifact {
+	return in.Status.RenderedArtifact
+}
+
 // GetValuesFiles returns a merged list of HelmChartSpec.ValuesFiles.
 func (in *HelmChart) GetValuesFiles() []string {
 	return in.Spec.ValuesFiles
@@ -42,8 +42,10 @@ const (
 // Artifact for a Helm repository index YAML.
 type HelmRepositorySpec struct {
 	// URL of the Helm repository, a valid URL contains at least a protocol and
-	// host.
-	// +kubebuilder:validation:Pattern="^(http|https|oci)://.*$"
+	// host. Helm repositories hosted in an Amazon S3 or Google Cloud Storage
+	// bucket are supported with the 's3://<bucket>/<path>' and
+	// 'gs://<bucket>/<path>' URLs.
+	// +kubebuilder:validation:Pattern="^(http|https|oci|s3|gs)://.*$"
 	// +required
 	URL string `json:"url"`
 
@@ -51,6 +53,8 @@ type HelmRepositorySpec struct {
 	// for the HelmRepository.
 	// For HTTP/S basic auth the secret must contain 'username' and 'password'
 	// fields.
+	// For S3 buckets the secret must contain 'accesskey' and 'secretkey'
+	// fields, and for GCS buckets the 'serviceaccount' field.
 	// Support for TLS auth using the 'certFile' and 'keyFile', and/or 'caFile'
 	// keys is deprecated. Please use `.spec.certSecretRef` instead.
 	// +optional
@@ -110,6 +114,29 @@ type HelmRepositorySpec struct {
 	// +optional
 	Suspend bool `json:"suspend,omitempty"`
 
+	// DependsOn specifies the sources which must be Ready before this source
+	// is reconciled, optionally with constraints on their Artifact.
+	// +optional
+	DependsOn []SourceDependency `json:"dependsOn,omitempty"`
+
+	// Schedule specifies the windows in which a new revision of the upstream
+	// source may result in a new Artifact. Outside of them, the current
+	// Artifact keeps being served.
+	// +optional
+	Schedule *ReconcileSchedule `json:"schedule,omitempty"`
+
+	// Approval specifies whether new revisions of the upstream source must be
+	// approved before their Artifact is served.
+	// +optional
+	Approval *RevisionApproval `json:"approval,omitempty"`
+
+	// ServiceAccountName is the name of the Kubernetes ServiceAccount which is
+	// impersonated to read the Secrets referenced by this HelmRepository, when the
+	// controller runs in multi-tenant lockdown mode. Defaults to the
+	// ServiceAccount configured with the --default-service-account flag.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
 	// AccessFrom specifies an Access Control List for allowing cross-namespace
 	// references to this object.
 	// NOTE: Not implemented, provisional as of https://github.com/fluxcd/flux2/pull/2092
@@ -123,12 +150,105 @@ type HelmRepositorySpec struct {
 	Type string `json:"type,omitempty"`
 
 	// Provider used for authentication, can be 'aws', 'azure', 'gcp' or 'generic'.
-	// This field is optional, and only taken into account if the .spec.type field is set to 'oci'.
+	// This field is optional, and only taken into account if the .spec.type field is set to 'oci',
+	// or for S3 buckets, which use the IAM credentials of the controller with 'aws'.
 	// When not specified, defaults to 'generic'.
 	// +kubebuilder:validation:Enum=generic;aws;azure;gcp
 	// +kubebuilder:default:=generic
 	// +optional
 	Provider string `json:"provider,omitempty"`
+
+	// Mirrors is an ordered list of alternate URLs of the Helm repository,
+	// each with its own credentials. When the index or a chart can not be
+	// fetched from the URL, the mirrors are tried in order.
+	// This field is only taken into account if the .spec.type field is set to 'default'.
+	// +optional
+	Mirrors []HelmRepositoryMirror `json:"mirrors,omitempty"`
+
+	// Proxy enables the proxy mode, in which the selected charts are mirrored
+	// to the storage of the controller, and the Artifact is an index with the
+	// chart URLs pointing to the mirrored chart tarballs instead of the
+	// upstream index.
+	// This field is only taken into account if the .spec.type field is set to 'default'.
+	// +optional
+	Proxy *HelmRepositoryProxy `json:"proxy,omitempty"`
+
+	// Summary enables the publication of a summary of the charts in the
+	// index of the Artifact in the .status.summary field.
+	// This field is only taken into account if the .spec.type field is set to 'default'.
+	// +optional
+	Summary bool `json:"summary,omitempty"`
+
+	// Probe enables probing the registry of an OCI Helm repository at every
+	// interval, by logging in to the registry and listing the tags of the
+	// charts referenced by HelmCharts. The Ready condition reflects the
+	// result of the probe, and the listed tags are shared with the HelmCharts.
+	// This field is only taken into account if the .spec.type field is set to 'oci'.
+	// +optional
+	Probe bool `json:"probe,omitempty"`
+}
+
+// HelmRepositoryMirror specifies an alternate URL of a Helm repository, and
+// the credentials to access it.
+type HelmRepositoryMirror struct {
+	// URL of the Helm repository mirror, a valid URL contains at least a
+	// protocol and host.
+	// +kubebuilder:validation:Pattern="^(http|https)://.*$"
+	// +required
+	URL string `json:"url"`
+
+	// SecretRef specifies the Secret containing authentication credentials
+	// for the mirror, in the same format as the `.spec.secretRef` of the
+	// HelmRepository.
+	// +optional
+	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// CertSecretRef specifies the Secret containing TLS certificates for
+	// the mirror, in the same format as the `.spec.certSecretRef` of the
+	// HelmRepository.
+	// +optional
+	CertSecretRef *meta.LocalObjectReference `json:"certSecretRef,omitempty"`
+}
+
+// HelmRepositoryProxy specifies the charts mirrored by a HelmRepository in
+// proxy mode.
+type HelmRepositoryProxy struct {
+	// Charts is the list of charts to mirror.
+	// +kubebuilder:validation:MinItems=1
+	// +required
+	Charts []HelmRepositoryProxyChart `json:"charts"`
+}
+
+// HelmRepositoryProxyChart selects the versions of a chart to mirror.
+type HelmRepositoryProxyChart struct {
+	// Name of the chart in the Helm repository.
+	// +required
+	Name string `json:"name"`
+
+	// Version is the SemVer version constraint the mirrored versions must
+	// match. Defaults to '*'.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// VersionPolicy restricts the versions which can be mirrored, in addition
+	// to the version constraint.
+	// +optional
+	VersionPolicy *HelmChartVersionPolicy `json:"versionPolicy,omitempty"`
+
+	// MaxVersions is the maximum number of versions to mirror, starting from
+	// the latest version which satisfies the version constraint and policy.
+	// Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxVersions int `json:"maxVersions,omitempty"`
+}
+
+// GetMaxVersions returns the maximum number of versions to mirror.
+func (in HelmRepositoryProxyChart) GetMaxVersions() int {
+	if in.MaxVersions > 0 {
+		return in.MaxVersions
+	}
+	return 1
 }
 
 // HelmRepositoryStatus records the observed state of the HelmRepository.
@@ -152,13 +272,75 @@ type HelmRepositoryStatus struct {
 	// +optional
 	Artifact *Artifact `json:"artifact,omitempty"`
 
+	// PendingArtifact is the Artifact of the latest revision awaiting
+	// approval.
+	// +optional
+	PendingArtifact *Artifact `json:"pendingArtifact,omitempty"`
+
+	// ActiveURL is the URL of the Helm repository, or of one of its mirrors,
+	// the index of the Artifact was fetched from.
+	// +optional
+	ActiveURL string `json:"activeURL,omitempty"`
+
+	// ChartArtifacts are the chart tarballs mirrored in proxy mode, referenced
+	// by the index of the Artifact.
+	// +optional
+	ChartArtifacts []Artifact `json:"chartArtifacts,omitempty"`
+
+	// Summary summarizes the charts in the index of the Artifact, if enabled
+	// by the .spec.summary field.
+	// +optional
+	Summary *HelmRepositorySummary `json:"summary,omitempty"`
+
 	meta.ReconcileRequestStatus `json:",inline"`
 }
 
+// MaxHelmRepositorySummaryCharts is the maximum number of charts listed in
+// the summary of a HelmRepository, to bound the size of the object.
+const MaxHelmRepositorySummaryCharts = 100
+
+// HelmRepositorySummary summarizes the charts in a Helm repository index.
+type HelmRepositorySummary struct {
+	// ChartCount is the number of charts in the index.
+	// +required
+	ChartCount int `json:"chartCount"`
+
+	// Charts summarizes the versions of the charts in the index, sorted by
+	// name. At most MaxHelmRepositorySummaryCharts (100) charts are listed,
+	// and the ChartCount can therefore be larger than the number of charts
+	// in the list.
+	// +optional
+	Charts []HelmRepositoryChartSummary `json:"charts,omitempty"`
+}
+
+// HelmRepositoryChartSummary summarizes the versions of a chart in a Helm
+// repository index.
+type HelmRepositoryChartSummary struct {
+	// Name of the chart.
+	// +required
+	Name string `json:"name"`
+
+	// LatestVersion is the highest SemVer version of the chart.
+	// +required
+	LatestVersion string `json:"latestVersion"`
+
+	// Versions is the number of versions of the chart.
+	// +required
+	Versions int `json:"versions"`
+}
+
 const (
 	// IndexationFailedReason signals that the HelmRepository index fetch
 	// failed.
 	IndexationFailedReason string = "IndexationFailed"
+
+	// ChartMirrorFailedReason signals that the mirroring of a chart by a
+	// HelmRepository in proxy mode failed.
+	ChartMirrorFailedReason string = "ChartMirrorFailed"
+
+	// ProbeFailedReason signals that the registry of an OCI HelmRepository
+	// could not be probed.
+	ProbeFailedReason string = "ProbeFailed"
 )
 
 // GetConditions returns the status conditions of the object.
@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReconcileSchedule specifies the windows in which a new revision of the
+// upstream source may result in a new Artifact.
+type ReconcileSchedule struct {
+	// Windows specifies the recurring windows in which a new revision may
+	// result in a new Artifact. When empty, this is at any time outside of
+	// the DenyWindows.
+	// +optional
+	Windows []ScheduleWindow `json:"windows,omitempty"`
+
+	// DenyWindows specifies the recurring windows in which a new revision
+	// must not result in a new Artifact, e.g. change freezes. They take
+	// precedence over the Windows.
+	// +optional
+	DenyWindows []ScheduleWindow `json:"denyWindows,omitempty"`
+
+	// TimeZone is the IANA name of the time zone the windows are evaluated
+	// in, e.g. 'Europe/Amsterdam'. Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// ScheduleWindow specifies a recurring window of time.
+type ScheduleWindow struct {
+	// Cron is the expression matching the start of the window, in the
+	// standard five field format '<minute> <hour> <day of month> <month>
+	// <day of week>', e.g. '0 9 * * 1-5'.
+	// +required
+	Cron string `json:"cron"`
+
+	// Duration of the window, e.g. '7h'.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +required
+	Duration metav1.Duration `json:"duration"`
+}
@@ -126,6 +126,29 @@ type BucketSpec struct {
 	// +optional
 	Suspend bool `json:"suspend,omitempty"`
 
+	// DependsOn specifies the sources which must be Ready before this source
+	// is reconciled, optionally with constraints on their Artifact.
+	// +optional
+	DependsOn []apiv1.SourceDependency `json:"dependsOn,omitempty"`
+
+	// Schedule specifies the windows in which a new revision of the upstream
+	// source may result in a new Artifact. Outside of them, the current
+	// Artifact keeps being served.
+	// +optional
+	Schedule *apiv1.ReconcileSchedule `json:"schedule,omitempty"`
+
+	// Approval specifies whether new revisions of the upstream source must be
+	// approved before their Artifact is served.
+	// +optional
+	Approval *apiv1.RevisionApproval `json:"approval,omitempty"`
+
+	// ServiceAccountName is the name of the Kubernetes ServiceAccount which is
+	// impersonated to read the Secrets referenced by this Bucket, when the
+	// controller runs in multi-tenant lockdown mode. Defaults to the
+	// ServiceAccount configured with the --default-service-account flag.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
 	// AccessFrom specifies an Access Control List for allowing cross-namespace
 	// references to this object.
 	// NOTE: Not implemented, provisional as of https://github.com/fluxcd/flux2/pull/2092
@@ -153,6 +176,11 @@ type BucketStatus struct {
 	// +optional
 	Artifact *apiv1.Artifact `json:"artifact,omitempty"`
 
+	// PendingArtifact is the Artifact of the latest revision awaiting
+	// approval.
+	// +optional
+	PendingArtifact *apiv1.Artifact `json:"pendingArtifact,omitempty"`
+
 	// ObservedIgnore is the observed exclusion patterns used for constructing
 	// the source artifact.
 	// +optional
@@ -0,0 +1,218 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+const (
+	// CompositeSourceKind is the string representation of a CompositeSource.
+	CompositeSourceKind = "CompositeSource"
+)
+
+const (
+	// SourceUnavailableCondition indicates one of the sources of a
+	// CompositeSource is not available. For example, because it does not
+	// exist, or does not have an Artifact.
+	// This is a "negative polarity" or "abnormal-true" type, and is only
+	// present on the resource if it is True.
+	SourceUnavailableCondition string = "SourceUnavailable"
+)
+
+// CompositeSourceSpec specifies the sources of which the Artifact (sub-)contents
+// are combined into a single Artifact.
+type CompositeSourceSpec struct {
+	// Sources specifies the sources of which the Artifact (sub-)contents must
+	// be included in the Artifact, and where they should be placed.
+	// +kubebuilder:validation:MinItems=1
+	// +required
+	Sources []CompositeSourceInput `json:"sources"`
+
+	// Ignore overrides the set of excluded patterns in the .sourceignore format
+	// (which is the same as .gitignore). If not provided, a default will be used,
+	// consult the documentation for your version to find out what those are.
+	// +optional
+	Ignore *string `json:"ignore,omitempty"`
+
+	// Interval at which the CompositeSource is reconciled.
+	// This interval is approximate and may be subject to jitter to ensure
+	// efficient use of resources.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +required
+	Interval metav1.Duration `json:"interval"`
+
+	// Suspend tells the controller to suspend the reconciliation of this
+	// CompositeSource.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// DependsOn specifies the sources which must be Ready before this source
+	// is reconciled, optionally with constraints on their Artifact.
+	// +optional
+	DependsOn []apiv1.SourceDependency `json:"dependsOn,omitempty"`
+
+	// Schedule specifies the windows in which a new revision of the upstream
+	// source may result in a new Artifact. Outside of them, the current
+	// Artifact keeps being served.
+	// +optional
+	Schedule *apiv1.ReconcileSchedule `json:"schedule,omitempty"`
+
+	// Approval specifies whether new revisions of the upstream source must be
+	// approved before their Artifact is served.
+	// +optional
+	Approval *apiv1.RevisionApproval `json:"approval,omitempty"`
+}
+
+// CompositeSourceInput specifies a local reference to a source which Artifact
+// (sub-)contents must be included, and where they should be placed.
+type CompositeSourceInput struct {
+	// Kind of the referent.
+	// +kubebuilder:validation:Enum=GitRepository;OCIRepository;Bucket;HTTPSource
+	// +required
+	Kind string `json:"kind"`
+
+	// Name of the referent.
+	// +required
+	Name string `json:"name"`
+
+	// FromPath specifies the path to copy contents from, defaults to the root
+	// of the Artifact.
+	// +optional
+	FromPath string `json:"fromPath,omitempty"`
+
+	// ToPath specifies the path to copy contents to, defaults to the name of
+	// the referent.
+	// +optional
+	ToPath string `json:"toPath,omitempty"`
+}
+
+// GetFromPath returns the specified FromPath.
+func (in *CompositeSourceInput) GetFromPath() string {
+	return in.FromPath
+}
+
+// GetToPath returns the specified ToPath, falling back to the name of the
+// referent.
+func (in *CompositeSourceInput) GetToPath() string {
+	if in.ToPath == "" {
+		return in.Name
+	}
+	return in.ToPath
+}
+
+// CompositeSourceStatus records the observed state of a CompositeSource.
+type CompositeSourceStatus struct {
+	// ObservedGeneration is the last observed generation of the CompositeSource
+	// object.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions holds the conditions for the CompositeSource.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// URL is the dynamic fetch link for the latest Artifact.
+	// It is provided on a "best effort" basis, and using the precise
+	// CompositeSourceStatus.Artifact data is recommended.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Artifact represents the last successful CompositeSource reconciliation.
+	// +optional
+	Artifact *apiv1.Artifact `json:"artifact,omitempty"`
+
+	// PendingArtifact is the Artifact of the latest revision awaiting
+	// approval.
+	// +optional
+	PendingArtifact *apiv1.Artifact `json:"pendingArtifact,omitempty"`
+
+	// SourceArtifacts represents the source Artifacts used to produce the
+	// Artifact, in the order of the Sources.
+	// +optional
+	SourceArtifacts []*apiv1.Artifact `json:"sourceArtifacts,omitempty"`
+
+	// ObservedSources is the observed list of sources used for constructing
+	// the Artifact.
+	// +optional
+	ObservedSources []CompositeSourceInput `json:"observedSources,omitempty"`
+
+	// ObservedIgnore is the observed exclusion patterns used for constructing
+	// the Artifact.
+	// +optional
+	ObservedIgnore *string `json:"observedIgnore,omitempty"`
+
+	meta.ReconcileRequestStatus `json:",inline"`
+}
+
+// GetConditions returns the status conditions of the object.
+func (in CompositeSource) GetConditions() []metav1.Condition {
+	return in.Status.Conditions
+}
+
+// SetConditions sets the status conditions on the object.
+func (in *CompositeSource) SetConditions(conditions []metav1.Condition) {
+	in.Status.Conditions = conditions
+}
+
+// GetRequeueAfter returns the duration after which the source must be reconciled again.
+func (in CompositeSource) GetRequeueAfter() time.Duration {
+	return in.Spec.Interval.Duration
+}
+
+// GetArtifact returns the latest artifact from the source if present in the status sub-resource.
+func (in *CompositeSource) GetArtifact() *apiv1.Artifact {
+	return in.Status.Artifact
+}
+
+// +genclient
+// +kubebuilder:storageversion
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=compsrc
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+
+// CompositeSource is the Schema for the compositesources API.
+type CompositeSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CompositeSourceSpec `json:"spec,omitempty"`
+	// +kubebuilder:default={"observedGeneration":-1}
+	Status CompositeSourceStatus `json:"status,omitempty"`
+}
+
+// CompositeSourceList contains a list of CompositeSource objects.
+// +kubebuilder:object:root=true
+type CompositeSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CompositeSource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CompositeSource{}, &CompositeSourceList{})
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+const (
+	// ConfigSourceKind is the string representation of a ConfigSource.
+	ConfigSourceKind = "ConfigSource"
+)
+
+// ConfigSourceSpec specifies the ConfigMaps and Secrets of which the data is
+// written to an Artifact.
+type ConfigSourceSpec struct {
+	// Objects specifies the ConfigMaps and Secrets in the same namespace as
+	// the ConfigSource of which the data must be included in the Artifact.
+	// +kubebuilder:validation:MinItems=1
+	// +required
+	Objects []ConfigSourceObject `json:"objects"`
+
+	// Interval at which the ConfigSource is reconciled.
+	// This interval is approximate and may be subject to jitter to ensure
+	// efficient use of resources.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +required
+	Interval metav1.Duration `json:"interval"`
+
+	// Suspend tells the controller to suspend the reconciliation of this
+	// ConfigSource.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// DependsOn specifies the sources which must be Ready before this source
+	// is reconciled, optionally with constraints on their Artifact.
+	// +optional
+	DependsOn []apiv1.SourceDependency `json:"dependsOn,omitempty"`
+
+	// Schedule specifies the windows in which a new revision of the upstream
+	// source may result in a new Artifact. Outside of them, the current
+	// Artifact keeps being served.
+	// +optional
+	Schedule *apiv1.ReconcileSchedule `json:"schedule,omitempty"`
+
+	// Approval specifies whether new revisions of the upstream source must be
+	// approved before their Artifact is served.
+	// +optional
+	Approval *apiv1.RevisionApproval `json:"approval,omitempty"`
+
+	// ServiceAccountName is the name of the Kubernetes ServiceAccount which is
+	// impersonated to read the Secrets referenced by this ConfigSource, when the
+	// controller runs in multi-tenant lockdown mode. Defaults to the
+	// ServiceAccount configured with the --default-service-account flag.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// ConfigSourceObject selects ConfigMaps or Secrets by name or label, and
+// specifies where their data should be placed. Exactly one of Name or
+// LabelSelector must be set.
+type ConfigSourceObject struct {
+	// Kind of the selected objects. Selecting Secrets requires the
+	// ConfigSourceSecrets feature gate to be enabled on the controller.
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	// +required
+	Kind string `json:"kind"`
+
+	// Name of the selected object.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// LabelSelector selects the objects by label.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// ToPath specifies the path to write the data of the selected objects
+	// to, defaults to the root of the Artifact. The keys of every object are
+	// written as files to a directory named after the object in this path.
+	// +optional
+	ToPath string `json:"toPath,omitempty"`
+}
+
+// ConfigSourceStatus records the observed state of a ConfigSource.
+type ConfigSourceStatus struct {
+	// ObservedGeneration is the last observed generation of the ConfigSource
+	// object.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions holds the conditions for the ConfigSource.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// URL is the dynamic fetch link for the latest Artifact.
+	// It is provided on a "best effort" basis, and using the precise
+	// ConfigSourceStatus.Artifact data is recommended.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Artifact represents the last successful ConfigSource reconciliation.
+	// +optional
+	Artifact *apiv1.Artifact `json:"artifact,omitempty"`
+
+	// PendingArtifact is the Artifact of the latest revision awaiting
+	// approval.
+	// +optional
+	PendingArtifact *apiv1.Artifact `json:"pendingArtifact,omitempty"`
+
+	meta.ReconcileRequestStatus `json:",inline"`
+}
+
+// GetConditions returns the status conditions of the object.
+func (in ConfigSource) GetConditions() []metav1.Condition {
+	return in.Status.Conditions
+}
+
+// SetConditions sets the status conditions on the object.
+func (in *ConfigSource) SetConditions(conditions []metav1.Condition) {
+	in.Status.Conditions = conditions
+}
+
+// GetRequeueAfter returns the duration after which the source must be reconciled again.
+func (in ConfigSource) GetRequeueAfter() time.Duration {
+	return in.Spec.Interval.Duration
+}
+
+// GetArtifact returns the latest artifact from the source if present in the status sub-resource.
+func (in *ConfigSource) GetArtifact() *apiv1.Artifact {
+	return in.Status.Artifact
+}
+
+// +genclient
+// +kubebuilder:storageversion
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=cfgsrc
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+
+// ConfigSource is the Schema for the configsources API.
+type ConfigSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ConfigSourceSpec `json:"spec,omitempty"`
+	// +kubebuilder:default={"observedGeneration":-1}
+	Status ConfigSourceStatus `json:"status,omitempty"`
+}
+
+// ConfigSourceList contains a list of ConfigSource objects.
+// +kubebuilder:object:root=true
+type ConfigSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConfigSource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConfigSource{}, &ConfigSourceList{})
+}
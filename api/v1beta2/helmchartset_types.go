@@ -0,0 +1,252 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+const (
+	// HelmChartSetKind is the string representation of a HelmChartSet.
+	HelmChartSetKind = "HelmChartSet"
+
+	// HelmChartSetNameLabel is the label set on the HelmChart objects
+	// generated by a HelmChartSet, with the name of the HelmChartSet.
+	// Names longer than 63 characters are truncated, and suffixed with a
+	// hash of the name.
+	HelmChartSetNameLabel = "source.toolkit.fluxcd.io/helmchartset"
+)
+
+// HelmChartSetSpec specifies the desired state of a set of Helm charts
+// discovered in a Source.
+type HelmChartSetSpec struct {
+	// SourceRef is the reference to the Source the charts are discovered in.
+	// +required
+	SourceRef apiv1.LocalHelmChartSourceReference `json:"sourceRef"`
+
+	// Pattern is the glob the discovered charts must match, using the syntax
+	// of Go's path.Match. For GitRepository, Bucket and OCIRepository sources,
+	// it is matched against the path of every directory with a Chart.yaml
+	// file, e.g. 'charts/*'. For HelmRepository sources, it is matched
+	// against the chart names in the repository index, e.g. 'podinfo-*'.
+	// +required
+	Pattern string `json:"pattern"`
+
+	// Interval at which the HelmChartSet SourceRef is checked for changes.
+	// This interval is approximate and may be subject to jitter to ensure
+	// efficient use of resources.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +required
+	Interval metav1.Duration `json:"interval"`
+
+	// Template is the template of the generated HelmChart objects.
+	// +optional
+	Template HelmChartSetTemplate `json:"template,omitempty"`
+
+	// Suspend tells the controller to suspend the reconciliation of this
+	// HelmChartSet.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// DependsOn specifies the sources which must be Ready before this source
+	// is reconciled, optionally with constraints on their Artifact.
+	// +optional
+	DependsOn []apiv1.SourceDependency `json:"dependsOn,omitempty"`
+
+	// Schedule specifies the windows in which a new revision of the upstream
+	// source may result in a new Artifact. Outside of them, the current
+	// Artifact keeps being served.
+	// +optional
+	Schedule *apiv1.ReconcileSchedule `json:"schedule,omitempty"`
+
+	// Approval specifies whether new revisions of the upstream source must be
+	// approved before their Artifact is served.
+	// +optional
+	Approval *apiv1.RevisionApproval `json:"approval,omitempty"`
+}
+
+// HelmChartSetTemplate defines the template of the HelmChart objects
+// generated by a HelmChartSet.
+type HelmChartSetTemplate struct {
+	// Metadata contains the labels and annotations of the generated
+	// HelmChart objects.
+	// +optional
+	Metadata HelmChartSetTemplateMetadata `json:"metadata,omitempty"`
+
+	// Spec contains the fields of the generated HelmChart objects which are
+	// not derived from the discovered chart.
+	// +optional
+	Spec HelmChartSetTemplateSpec `json:"spec,omitempty"`
+}
+
+// HelmChartSetTemplateMetadata defines the labels and annotations of the
+// HelmChart objects generated by a HelmChartSet.
+type HelmChartSetTemplateMetadata struct {
+	// Labels of the generated HelmChart objects.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations of the generated HelmChart objects.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// HelmChartSetTemplateSpec defines the spec of the HelmChart objects
+// generated by a HelmChartSet.
+type HelmChartSetTemplateSpec struct {
+	// Version is the chart version semver expression, ignored for charts from
+	// GitRepository, Bucket and OCIRepository sources. Defaults to latest when
+	// omitted.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Interval at which the generated HelmChart objects are reconciled.
+	// Defaults to the interval of the HelmChartSet when omitted.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// ReconcileStrategy determines what enables the creation of a new
+	// artifact for the generated HelmChart objects.
+	// Valid values are ('ChartVersion', 'Revision').
+	// Defaults to ChartVersion when omitted.
+	// +kubebuilder:validation:Enum=ChartVersion;Revision
+	// +optional
+	ReconcileStrategy string `json:"reconcileStrategy,omitempty"`
+
+	// DependencyMode defines how the chart dependencies of the generated
+	// HelmChart objects are resolved, see the HelmChart API.
+	// +kubebuilder:validation:Enum=Resolve;Locked;Offline
+	// +optional
+	DependencyMode string `json:"dependencyMode,omitempty"`
+
+	// VersionPolicy further restricts the chart versions which can be
+	// selected by the Version constraint, see the HelmChart API.
+	// +optional
+	VersionPolicy *apiv1.HelmChartVersionPolicy `json:"versionPolicy,omitempty"`
+
+	// ServiceAccountName is the name of the Kubernetes ServiceAccount of the
+	// generated HelmChart objects, see the HelmChart API.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// HelmChartSetStatus records the observed state of the HelmChartSet.
+type HelmChartSetStatus struct {
+	// ObservedGeneration is the last observed generation of the HelmChartSet
+	// object.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ObservedSourceArtifactRevision is the last observed Artifact.Revision
+	// of the HelmChartSetSpec.SourceRef.
+	// +optional
+	ObservedSourceArtifactRevision string `json:"observedSourceArtifactRevision,omitempty"`
+
+	// Conditions holds the conditions for the HelmChartSet.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Charts is the list of HelmChart objects generated for the charts
+	// discovered in the Source.
+	// +optional
+	Charts []HelmChartSetEntry `json:"charts,omitempty"`
+
+	meta.ReconcileRequestStatus `json:",inline"`
+}
+
+// HelmChartSetEntry records a HelmChart object generated by a HelmChartSet.
+type HelmChartSetEntry struct {
+	// Name of the generated HelmChart object.
+	// +required
+	Name string `json:"name"`
+
+	// Chart is the name or path of the discovered chart in the Source.
+	// +required
+	Chart string `json:"chart"`
+}
+
+const (
+	// HelmChartsGeneratedCondition indicates the HelmChart objects of a
+	// HelmChartSet are generated for the last observed Source revision.
+	HelmChartsGeneratedCondition string = "HelmChartsGenerated"
+
+	// GenerationFailedReason signals that the HelmChart objects of a
+	// HelmChartSet could not be generated.
+	GenerationFailedReason string = "GenerationFailed"
+
+	// GenerationSucceededReason signals that the HelmChart objects of a
+	// HelmChartSet were generated.
+	GenerationSucceededReason string = "GenerationSucceeded"
+)
+
+// GetConditions returns the status conditions of the object.
+func (in HelmChartSet) GetConditions() []metav1.Condition {
+	return in.Status.Conditions
+}
+
+// SetConditions sets the status conditions on the object.
+func (in *HelmChartSet) SetConditions(conditions []metav1.Condition) {
+	in.Status.Conditions = conditions
+}
+
+// GetRequeueAfter returns the duration after which the HelmChartSet must be
+// reconciled again.
+func (in HelmChartSet) GetRequeueAfter() time.Duration {
+	return in.Spec.Interval.Duration
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=hcs
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Pattern",type=string,JSONPath=`.spec.pattern`
+// +kubebuilder:printcolumn:name="Source Kind",type=string,JSONPath=`.spec.sourceRef.kind`
+// +kubebuilder:printcolumn:name="Source Name",type=string,JSONPath=`.spec.sourceRef.name`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+
+// HelmChartSet is the Schema for the helmchartsets API.
+type HelmChartSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HelmChartSetSpec `json:"spec,omitempty"`
+	// +kubebuilder:default={"observedGeneration":-1}
+	Status HelmChartSetStatus `json:"status,omitempty"`
+}
+
+// HelmChartSetList contains a list of HelmChartSet objects.
+// +kubebuilder:object:root=true
+type HelmChartSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HelmChartSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HelmChartSet{}, &HelmChartSetList{})
+}
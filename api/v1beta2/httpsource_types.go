@@ -0,0 +1,258 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+const (
+	// HTTPSourceKind is the string representation of an HTTPSource.
+	HTTPSourceKind = "HTTPSource"
+)
+
+// HTTPSourceSpec specifies the required configuration to produce an Artifact
+// for a file served over HTTP/S.
+type HTTPSourceSpec struct {
+	// URL of the file to fetch, e.g. a release tarball or a single YAML file.
+	// +kubebuilder:validation:Pattern="^(http|https)://.*$"
+	// +required
+	URL string `json:"url"`
+
+	// SecretRef specifies the Secret containing authentication credentials
+	// for the URL. For HTTP/S basic auth the Secret must contain 'username'
+	// and 'password' fields, for bearer token auth it must contain a
+	// 'bearerToken' field.
+	// +optional
+	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// CertSecretRef can be given the name of a Secret containing
+	// either or both of
+	//
+	// - a PEM-encoded client certificate (`tls.crt`) and private
+	// key (`tls.key`);
+	// - a PEM-encoded CA certificate (`ca.crt`)
+	//
+	// and whichever are supplied, will be used for connecting to the
+	// URL. The client cert and key are useful if you are
+	// authenticating with a certificate; the CA cert is useful if
+	// you are using a self-signed server certificate. The Secret must
+	// be of type `Opaque` or `kubernetes.io/tls`.
+	// +optional
+	CertSecretRef *meta.LocalObjectReference `json:"certSecretRef,omitempty"`
+
+	// ProxySecretRef specifies the Secret containing the proxy configuration
+	// to use while fetching the URL.
+	// +optional
+	ProxySecretRef *meta.LocalObjectReference `json:"proxySecretRef,omitempty"`
+
+	// Checksum specifies the checksum the fetched file is verified against.
+	// +optional
+	Checksum *HTTPSourceChecksum `json:"checksum,omitempty"`
+
+	// Extract the fetched file as a gzip compressed tarball into the
+	// Artifact. When false, the fetched file is stored as-is in the
+	// Artifact, using the last element of the URL path as file name.
+	// +optional
+	Extract bool `json:"extract,omitempty"`
+
+	// Ignore overrides the set of excluded patterns in the .sourceignore format
+	// (which is the same as .gitignore). If not provided, a default will be used,
+	// consult the documentation for your version to find out what those are.
+	// This field is only taken into account if Extract is true.
+	// +optional
+	Ignore *string `json:"ignore,omitempty"`
+
+	// Interval at which the URL is checked for updates.
+	// This interval is approximate and may be subject to jitter to ensure
+	// efficient use of resources.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +required
+	Interval metav1.Duration `json:"interval"`
+
+	// Timeout for fetch operations, defaults to 60s.
+	// +kubebuilder:default="60s"
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m))+$"
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Suspend tells the controller to suspend the reconciliation of this
+	// HTTPSource.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// DependsOn specifies the sources which must be Ready before this source
+	// is reconciled, optionally with constraints on their Artifact.
+	// +optional
+	DependsOn []apiv1.SourceDependency `json:"dependsOn,omitempty"`
+
+	// Schedule specifies the windows in which a new revision of the upstream
+	// source may result in a new Artifact. Outside of them, the current
+	// Artifact keeps being served.
+	// +optional
+	Schedule *apiv1.ReconcileSchedule `json:"schedule,omitempty"`
+
+	// Approval specifies whether new revisions of the upstream source must be
+	// approved before their Artifact is served.
+	// +optional
+	Approval *apiv1.RevisionApproval `json:"approval,omitempty"`
+
+	// ServiceAccountName is the name of the Kubernetes ServiceAccount which is
+	// impersonated to read the Secrets referenced by this HTTPSource, when the
+	// controller runs in multi-tenant lockdown mode. Defaults to the
+	// ServiceAccount configured with the --default-service-account flag.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// HTTPSourceChecksum specifies the expected checksum of the file fetched by
+// an HTTPSource. Exactly one of Digest or URL must be set.
+type HTTPSourceChecksum struct {
+	// Digest of the file in the format '<algorithm>:<hex>', e.g.
+	// 'sha256:3b8e...'. Supported algorithms are sha256, sha384 and sha512.
+	// +kubebuilder:validation:Pattern="^(sha256|sha384|sha512):[a-f0-9]+$"
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// URL of a checksum file in the format of the sha256sum, sha384sum and
+	// sha512sum utilities. The checksum of the file is looked up by the last
+	// element of the URL path of the HTTPSource. A checksum file with a
+	// single checksum without file name is accepted as well. The checksum
+	// file is fetched with the same credentials as the file.
+	// +kubebuilder:validation:Pattern="^(http|https)://.*$"
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// HTTPSourceStatus records the observed state of an HTTPSource.
+type HTTPSourceStatus struct {
+	// ObservedGeneration is the last observed generation of the HTTPSource
+	// object.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions holds the conditions for the HTTPSource.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// URL is the dynamic fetch link for the latest Artifact.
+	// It is provided on a "best effort" basis, and using the precise
+	// HTTPSourceStatus.Artifact data is recommended.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Artifact represents the last successful HTTPSource reconciliation.
+	// +optional
+	Artifact *apiv1.Artifact `json:"artifact,omitempty"`
+
+	// PendingArtifact is the Artifact of the latest revision awaiting
+	// approval.
+	// +optional
+	PendingArtifact *apiv1.Artifact `json:"pendingArtifact,omitempty"`
+
+	// ObservedETag is the ETag response header of the file the Artifact was
+	// produced from, used for conditional requests.
+	// +optional
+	ObservedETag string `json:"observedETag,omitempty"`
+
+	// ObservedLastModified is the Last-Modified response header of the file
+	// the Artifact was produced from, used for conditional requests.
+	// +optional
+	ObservedLastModified string `json:"observedLastModified,omitempty"`
+
+	// ObservedExtract is the observed extract setting used for constructing
+	// the source artifact.
+	// +optional
+	ObservedExtract bool `json:"observedExtract,omitempty"`
+
+	// ObservedIgnore is the observed exclusion patterns used for constructing
+	// the source artifact.
+	// +optional
+	ObservedIgnore *string `json:"observedIgnore,omitempty"`
+
+	meta.ReconcileRequestStatus `json:",inline"`
+}
+
+const (
+	// HTTPOperationFailedReason signals that fetching the URL of the
+	// HTTPSource failed.
+	HTTPOperationFailedReason string = "HTTPOperationFailed"
+
+	// ChecksumVerificationFailedReason signals that the checksum of the file
+	// fetched by the HTTPSource could not be verified.
+	ChecksumVerificationFailedReason string = "ChecksumVerificationFailed"
+)
+
+// GetConditions returns the status conditions of the object.
+func (in HTTPSource) GetConditions() []metav1.Condition {
+	return in.Status.Conditions
+}
+
+// SetConditions sets the status conditions on the object.
+func (in *HTTPSource) SetConditions(conditions []metav1.Condition) {
+	in.Status.Conditions = conditions
+}
+
+// GetRequeueAfter returns the duration after which the source must be reconciled again.
+func (in HTTPSource) GetRequeueAfter() time.Duration {
+	return in.Spec.Interval.Duration
+}
+
+// GetArtifact returns the latest artifact from the source if present in the status sub-resource.
+func (in *HTTPSource) GetArtifact() *apiv1.Artifact {
+	return in.Status.Artifact
+}
+
+// +genclient
+// +kubebuilder:storageversion
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=httpsrc
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+
+// HTTPSource is the Schema for the httpsources API.
+type HTTPSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HTTPSourceSpec `json:"spec,omitempty"`
+	// +kubebuilder:default={"observedGeneration":-1}
+	Status HTTPSourceStatus `json:"status,omitempty"`
+}
+
+// HTTPSourceList contains a list of HTTPSource objects.
+// +kubebuilder:object:root=true
+type HTTPSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HTTPSource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HTTPSource{}, &HTTPSourceList{})
+}
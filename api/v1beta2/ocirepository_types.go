@@ -95,6 +95,9 @@ type OCIRepositorySpec struct {
 	// ServiceAccountName is the name of the Kubernetes ServiceAccount used to authenticate
 	// the image pull if the service account has attached pull secrets. For more information:
 	// https://kubernetes.io/docs/tasks/configure-pod-container/configure-service-account/#add-imagepullsecrets-to-a-service-account
+	// When the controller runs in multi-tenant lockdown mode, the ServiceAccount
+	// is impersonated to read the Secrets referenced by this OCIRepository, and
+	// defaults to the ServiceAccount configured with the --default-service-account flag.
 	// +optional
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 
@@ -144,6 +147,22 @@ type OCIRepositorySpec struct {
 	// This flag tells the controller to suspend the reconciliation of this source.
 	// +optional
 	Suspend bool `json:"suspend,omitempty"`
+
+	// DependsOn specifies the sources which must be Ready before this source
+	// is reconciled, optionally with constraints on their Artifact.
+	// +optional
+	DependsOn []apiv1.SourceDependency `json:"dependsOn,omitempty"`
+
+	// Schedule specifies the windows in which a new revision of the upstream
+	// source may result in a new Artifact. Outside of them, the current
+	// Artifact keeps being served.
+	// +optional
+	Schedule *apiv1.ReconcileSchedule `json:"schedule,omitempty"`
+
+	// Approval specifies whether new revisions of the upstream source must be
+	// approved before their Artifact is served.
+	// +optional
+	Approval *apiv1.RevisionApproval `json:"approval,omitempty"`
 }
 
 // OCIRepositoryRef defines the image reference for the OCIRepository's URL
@@ -202,6 +221,11 @@ type OCIRepositoryStatus struct {
 	// +optional
 	Artifact *apiv1.Artifact `json:"artifact,omitempty"`
 
+	// PendingArtifact is the Artifact of the latest revision awaiting
+	// approval.
+	// +optional
+	PendingArtifact *apiv1.Artifact `json:"pendingArtifact,omitempty"`
+
 	// ContentConfigChecksum is a checksum of all the configurations related to
 	// the content of the source artifact:
 	//  - .spec.ignore
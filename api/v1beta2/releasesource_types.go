@@ -0,0 +1,277 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+const (
+	// ReleaseSourceKind is the string representation of a ReleaseSource.
+	ReleaseSourceKind = "ReleaseSource"
+)
+
+const (
+	// GitHubReleaseProvider provides releases from GitHub (Enterprise).
+	GitHubReleaseProvider string = "github"
+	// GitLabReleaseProvider provides releases from GitLab.
+	GitLabReleaseProvider string = "gitlab"
+	// GiteaReleaseProvider provides releases from Gitea or Forgejo.
+	GiteaReleaseProvider string = "gitea"
+)
+
+// ReleaseSourceSpec specifies the required configuration to produce an
+// Artifact for the assets of a release of a repository on a forge.
+type ReleaseSourceSpec struct {
+	// Provider of the repository.
+	// +kubebuilder:validation:Enum=github;gitlab;gitea
+	// +required
+	Provider string `json:"provider"`
+
+	// Repository to query the releases of. For GitHub and Gitea in the
+	// format '<owner>/<name>', for GitLab the full path of the project.
+	// +required
+	Repository string `json:"repository"`
+
+	// APIURL is the base URL of the API of the forge, e.g.
+	// 'https://github.example.com/api/v3'. Defaults to the API of the public
+	// instance of the provider.
+	// +kubebuilder:validation:Pattern="^(http|https)://.*$"
+	// +optional
+	APIURL string `json:"apiURL,omitempty"`
+
+	// SecretRef specifies the Secret containing the API token in a 'token'
+	// field, used to authenticate requests to the API and asset downloads.
+	// +optional
+	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// CertSecretRef can be given the name of a Secret containing
+	// either or both of
+	//
+	// - a PEM-encoded client certificate (`tls.crt`) and private
+	// key (`tls.key`);
+	// - a PEM-encoded CA certificate (`ca.crt`)
+	//
+	// and whichever are supplied, will be used for connecting to the
+	// API. The client cert and key are useful if you are
+	// authenticating with a certificate; the CA cert is useful if
+	// you are using a self-signed server certificate. The Secret must
+	// be of type `Opaque` or `kubernetes.io/tls`.
+	// +optional
+	CertSecretRef *meta.LocalObjectReference `json:"certSecretRef,omitempty"`
+
+	// SemVer is the range of release tags to select the latest release
+	// within, defaults to the latest stable release.
+	// +kubebuilder:default:="*"
+	// +optional
+	SemVer string `json:"semver,omitempty"`
+
+	// SemverFilter is a regex pattern to filter the release tags within the
+	// SemVer range.
+	// +optional
+	SemverFilter string `json:"semverFilter,omitempty"`
+
+	// Prereleases includes releases marked as pre-release on the forge in
+	// the selection.
+	// +optional
+	Prereleases bool `json:"prereleases,omitempty"`
+
+	// Assets specifies shell file name patterns of the assets of the
+	// release to include in the Artifact, e.g. 'install.yaml' or
+	// '*_linux_amd64.tar.gz'. Every pattern must match at least one asset.
+	// +kubebuilder:validation:MinItems=1
+	// +required
+	Assets []string `json:"assets"`
+
+	// Verify contains the configuration to verify the assets with the
+	// checksums and signature shipped with the release.
+	// +optional
+	Verify *ReleaseSourceVerification `json:"verify,omitempty"`
+
+	// Interval at which the releases are checked for updates.
+	// This interval is approximate and may be subject to jitter to ensure
+	// efficient use of resources.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +required
+	Interval metav1.Duration `json:"interval"`
+
+	// Timeout for API and download operations, defaults to 60s.
+	// +kubebuilder:default="60s"
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m))+$"
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Suspend tells the controller to suspend the reconciliation of this
+	// ReleaseSource.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// DependsOn specifies the sources which must be Ready before this source
+	// is reconciled, optionally with constraints on their Artifact.
+	// +optional
+	DependsOn []apiv1.SourceDependency `json:"dependsOn,omitempty"`
+
+	// Schedule specifies the windows in which a new revision of the upstream
+	// source may result in a new Artifact. Outside of them, the current
+	// Artifact keeps being served.
+	// +optional
+	Schedule *apiv1.ReconcileSchedule `json:"schedule,omitempty"`
+
+	// Approval specifies whether new revisions of the upstream source must be
+	// approved before their Artifact is served.
+	// +optional
+	Approval *apiv1.RevisionApproval `json:"approval,omitempty"`
+
+	// ServiceAccountName is the name of the Kubernetes ServiceAccount which is
+	// impersonated to read the Secrets referenced by this ReleaseSource, when the
+	// controller runs in multi-tenant lockdown mode. Defaults to the
+	// ServiceAccount configured with the --default-service-account flag.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// ReleaseSourceVerification specifies how the assets of a release are
+// verified.
+type ReleaseSourceVerification struct {
+	// ChecksumAsset is the name of the asset of the release containing the
+	// checksums of the other assets, in the format of the sha256sum,
+	// sha384sum and sha512sum utilities.
+	// +required
+	ChecksumAsset string `json:"checksumAsset"`
+
+	// SignatureAsset is the name of the asset of the release containing the
+	// Cosign signature of the ChecksumAsset, defaults to the name of the
+	// ChecksumAsset with a '.sig' suffix. The signature is only verified
+	// when a SecretRef is specified.
+	// +optional
+	SignatureAsset string `json:"signatureAsset,omitempty"`
+
+	// SecretRef specifies the Kubernetes Secret containing the trusted
+	// Cosign public keys (with a '.pub' suffix) to verify the signature of
+	// the ChecksumAsset with.
+	// +optional
+	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
+// GetSignatureAsset returns the name of the signature asset, defaulting to
+// the name of the checksum asset with a '.sig' suffix.
+func (in ReleaseSourceVerification) GetSignatureAsset() string {
+	if in.SignatureAsset != "" {
+		return in.SignatureAsset
+	}
+	return in.ChecksumAsset + ".sig"
+}
+
+// ReleaseSourceStatus records the observed state of a ReleaseSource.
+type ReleaseSourceStatus struct {
+	// ObservedGeneration is the last observed generation of the ReleaseSource
+	// object.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions holds the conditions for the ReleaseSource.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// URL is the dynamic fetch link for the latest Artifact.
+	// It is provided on a "best effort" basis, and using the precise
+	// ReleaseSourceStatus.Artifact data is recommended.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Artifact represents the last successful ReleaseSource reconciliation.
+	// +optional
+	Artifact *apiv1.Artifact `json:"artifact,omitempty"`
+
+	// PendingArtifact is the Artifact of the latest revision awaiting
+	// approval.
+	// +optional
+	PendingArtifact *apiv1.Artifact `json:"pendingArtifact,omitempty"`
+
+	// ObservedAssets is the list of names of the assets included in the
+	// Artifact.
+	// +optional
+	ObservedAssets []string `json:"observedAssets,omitempty"`
+
+	meta.ReconcileRequestStatus `json:",inline"`
+}
+
+const (
+	// ReleaseOperationFailedReason signals that querying the releases of the
+	// ReleaseSource, or downloading their assets failed.
+	ReleaseOperationFailedReason string = "ReleaseOperationFailed"
+)
+
+// GetConditions returns the status conditions of the object.
+func (in ReleaseSource) GetConditions() []metav1.Condition {
+	return in.Status.Conditions
+}
+
+// SetConditions sets the status conditions on the object.
+func (in *ReleaseSource) SetConditions(conditions []metav1.Condition) {
+	in.Status.Conditions = conditions
+}
+
+// GetRequeueAfter returns the duration after which the source must be reconciled again.
+func (in ReleaseSource) GetRequeueAfter() time.Duration {
+	return in.Spec.Interval.Duration
+}
+
+// GetArtifact returns the latest artifact from the source if present in the status sub-resource.
+func (in *ReleaseSource) GetArtifact() *apiv1.Artifact {
+	return in.Status.Artifact
+}
+
+// +genclient
+// +kubebuilder:storageversion
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=relsrc
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Repository",type=string,JSONPath=`.spec.repository`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+
+// ReleaseSource is the Schema for the releasesources API.
+type ReleaseSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ReleaseSourceSpec `json:"spec,omitempty"`
+	// +kubebuilder:default={"observedGeneration":-1}
+	Status ReleaseSourceStatus `json:"status,omitempty"`
+}
+
+// ReleaseSourceList contains a list of ReleaseSource objects.
+// +kubebuilder:object:root=true
+type ReleaseSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReleaseSource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReleaseSource{}, &ReleaseSourceList{})
+}
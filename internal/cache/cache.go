@@ -30,6 +30,8 @@ type Item struct {
 	Object interface{}
 	// Expiration is the item's expiration time.
 	Expiration int64
+	// Size is the item's size in bytes, as given when it was set.
+	Size int64
 }
 
 type cache struct {
@@ -37,8 +39,13 @@ type cache struct {
 	Items map[string]Item
 	// MaxItems is the maximum number of items the cache can hold.
 	MaxItems int
-	mu       sync.RWMutex
-	janitor  *janitor
+	// MaxBytes is the maximum total size in bytes of the items the cache
+	// can hold.
+	MaxBytes int64
+	// size is the total size in bytes of the items in the cache.
+	size    int64
+	mu      sync.RWMutex
+	janitor *janitor
 }
 
 // ItemCount returns the number of items in the cache.
@@ -50,32 +57,71 @@ func (c *cache) ItemCount() int {
 	return n
 }
 
-func (c *cache) set(key string, value interface{}, expiration time.Duration) {
+// Size returns the total size in bytes of the items in the cache, as given
+// when they were set.
+func (c *cache) Size() int64 {
+	c.mu.RLock()
+	n := c.size
+	c.mu.RUnlock()
+	return n
+}
+
+func (c *cache) set(key string, value interface{}, size int64, expiration time.Duration) {
 	var e int64
 	if expiration > 0 {
 		e = time.Now().Add(expiration).UnixNano()
 	}
 
+	c.size += size - c.Items[key].Size
 	c.Items[key] = Item{
 		Object:     value,
 		Expiration: e,
+		Size:       size,
+	}
+}
+
+// hasRoom returns if the cache can hold the given number of additional
+// items, with the given additional size in bytes.
+func (c *cache) hasRoom(items int, size int64) bool {
+	if c.MaxItems <= 0 && c.MaxBytes <= 0 {
+		return false
+	}
+	if c.MaxItems > 0 && len(c.Items)+items > c.MaxItems {
+		return false
 	}
+	if c.MaxBytes > 0 && c.size+size > c.MaxBytes {
+		return false
+	}
+	return true
 }
 
 // Set adds an item to the cache, replacing any existing item.
 // If expiration is zero, the item never expires.
 // If the cache is full, Set will return an error.
 func (c *cache) Set(key string, value interface{}, expiration time.Duration) error {
+	return c.SetWithSize(key, value, 0, expiration)
+}
+
+// SetWithSize adds an item with the given size in bytes to the cache,
+// replacing any existing item.
+// If expiration is zero, the item never expires.
+// If the cache is full, or can not hold the size of the item, SetWithSize
+// will return an error.
+func (c *cache) SetWithSize(key string, value interface{}, size int64, expiration time.Duration) error {
 	c.mu.Lock()
-	_, found := c.Items[key]
+	item, found := c.Items[key]
 	if found {
-		c.set(key, value, expiration)
+		if c.MaxBytes > 0 && c.size-item.Size+size > c.MaxBytes {
+			c.mu.Unlock()
+			return fmt.Errorf("Cache is full")
+		}
+		c.set(key, value, size, expiration)
 		c.mu.Unlock()
 		return nil
 	}
 
-	if c.MaxItems > 0 && len(c.Items) < c.MaxItems {
-		c.set(key, value, expiration)
+	if c.hasRoom(1, size) {
+		c.set(key, value, size, expiration)
 		c.mu.Unlock()
 		return nil
 	}
@@ -95,8 +141,8 @@ func (c *cache) Add(key string, value interface{}, expiration time.Duration) err
 		return fmt.Errorf("Item %s already exists", key)
 	}
 
-	if c.MaxItems > 0 && len(c.Items) < c.MaxItems {
-		c.set(key, value, expiration)
+	if c.hasRoom(1, 0) {
+		c.set(key, value, 0, expiration)
 		c.mu.Unlock()
 		return nil
 	}
@@ -127,16 +173,22 @@ func (c *cache) Get(key string) (interface{}, bool) {
 // Delete an item from the cache. Does nothing if the key is not in the cache.
 func (c *cache) Delete(key string) {
 	c.mu.Lock()
-	delete(c.Items, key)
+	c.delete(key)
 	c.mu.Unlock()
 }
 
+func (c *cache) delete(key string) {
+	c.size -= c.Items[key].Size
+	delete(c.Items, key)
+}
+
 // Clear all items from the cache.
 // This reallocates the underlying array holding the items,
 // so that the memory used by the items is reclaimed.
 func (c *cache) Clear() {
 	c.mu.Lock()
 	c.Items = make(map[string]Item)
+	c.size = 0
 	c.mu.Unlock()
 }
 
@@ -195,7 +247,7 @@ func (c *cache) DeleteExpired() {
 	c.mu.Lock()
 	for k, v := range c.Items {
 		if v.Expiration > 0 && v.Expiration < time.Now().UnixNano() {
-			delete(c.Items, k)
+			c.delete(k)
 		}
 	}
 	c.mu.Unlock()
@@ -225,9 +277,20 @@ func stopJanitor(c *Cache) {
 
 // New creates a new cache with the given configuration.
 func New(maxItems int, interval time.Duration) *Cache {
+	return newCache(maxItems, 0, interval)
+}
+
+// NewWithMaxBytes creates a new cache which can hold items up to the given
+// total size in bytes, as set with SetWithSize.
+func NewWithMaxBytes(maxBytes int64, interval time.Duration) *Cache {
+	return newCache(0, maxBytes, interval)
+}
+
+func newCache(maxItems int, maxBytes int64, interval time.Duration) *Cache {
 	c := &cache{
 		Items:    make(map[string]Item),
 		MaxItems: maxItems,
+		MaxBytes: maxBytes,
 		janitor: &janitor{
 			interval: interval,
 			stop:     make(chan bool),
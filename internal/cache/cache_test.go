@@ -85,3 +85,36 @@ func TestCache(t *testing.T) {
 	g.Expect(found).To(BeFalse())
 	g.Expect(item).To(BeNil())
 }
+
+func TestCache_MaxBytes(t *testing.T) {
+	g := NewWithT(t)
+	// create a cache that can hold 10 bytes and have no cleanup
+	cache := NewWithMaxBytes(10, 0)
+
+	// Add items to the cache
+	g.Expect(cache.SetWithSize("key1", "value1", 4, 0)).To(Succeed())
+	g.Expect(cache.SetWithSize("key2", "value2", 4, 0)).To(Succeed())
+	g.Expect(cache.Size()).To(Equal(int64(8)))
+	g.Expect(cache.ItemCount()).To(Equal(2))
+
+	// Add an item which exceeds the size of the cache
+	g.Expect(cache.SetWithSize("key3", "value3", 4, 0)).ToNot(Succeed())
+	g.Expect(cache.Size()).To(Equal(int64(8)))
+
+	// Replace an item in the cache
+	g.Expect(cache.SetWithSize("key2", "value3", 6, 0)).To(Succeed())
+	g.Expect(cache.Size()).To(Equal(int64(10)))
+	g.Expect(cache.SetWithSize("key2", "value4", 7, 0)).ToNot(Succeed())
+	item, found := cache.Get("key2")
+	g.Expect(found).To(BeTrue())
+	g.Expect(item).To(Equal("value3"))
+
+	// Delete an item from the cache
+	cache.Delete("key1")
+	g.Expect(cache.Size()).To(Equal(int64(6)))
+
+	// Clear the cache
+	cache.Clear()
+	g.Expect(cache.Size()).To(Equal(int64(0)))
+	g.Expect(cache.ItemCount()).To(Equal(0))
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrDigestMismatch is returned by ChartCache.Set when a tarball does not
+// match the given digest.
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+// ChartCache is a thread-safe in-memory cache of chart tarballs, bounded by
+// the total size in bytes of the stored tarballs. When the cache is full,
+// the least recently used tarballs are evicted.
+// The SHA-256 digest of a tarball is recorded when it is added, and verified
+// every time it is retrieved.
+type ChartCache struct {
+	// maxBytes is the maximum total size of the stored tarballs.
+	maxBytes int64
+	// size is the current total size of the stored tarballs.
+	size int64
+	// ll holds the entries, ordered from most to least recently used.
+	ll *list.List
+	// items indexes the list elements by key.
+	items map[string]*list.Element
+	mu    sync.Mutex
+}
+
+// chartEntry is an entry of the ChartCache.
+type chartEntry struct {
+	key    string
+	data   []byte
+	digest string
+}
+
+// NewChartCache returns a new ChartCache which can hold up to maxBytes of
+// chart tarballs.
+func NewChartCache(maxBytes int64) *ChartCache {
+	return &ChartCache{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns a copy of the tarball stored for the key, and marks it as
+// recently used. If the tarball does not match the digest recorded when it
+// was added, it is removed from the cache and not returned.
+func (c *ChartCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*chartEntry)
+	if sha256Hex(entry.data) != entry.digest {
+		c.removeElement(e)
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+
+	data := make([]byte, len(entry.data))
+	copy(data, entry.data)
+	return data, true
+}
+
+// Set adds a copy of the tarball for the key to the cache, replacing any
+// existing tarball, and evicts the least recently used tarballs to stay
+// within the size bound. If digest is not empty, it is expected to be the
+// (optionally 'sha256:' prefixed) hex encoded SHA-256 digest of data, and
+// an error is returned if it does not match.
+// An error is returned if the tarball is larger than the cache.
+func (c *ChartCache) Set(key string, data []byte, digest string) error {
+	sum := sha256Hex(data)
+	if digest != "" && strings.TrimPrefix(digest, "sha256:") != sum {
+		return fmt.Errorf("%w for '%s': expected '%s', got '%s'", ErrDigestMismatch, key, digest, sum)
+	}
+	size := int64(len(data))
+	if size > c.maxBytes {
+		return fmt.Errorf("size of '%s' (%d bytes) exceeds cache size (%d bytes)", key, size, c.maxBytes)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.removeElement(e)
+	}
+	for c.size+size > c.maxBytes {
+		c.removeElement(c.ll.Back())
+	}
+
+	entry := &chartEntry{
+		key:    key,
+		data:   make([]byte, len(data)),
+		digest: sum,
+	}
+	copy(entry.data, data)
+	c.items[key] = c.ll.PushFront(entry)
+	c.size += size
+	return nil
+}
+
+// Delete removes the tarball for the key from the cache.
+func (c *ChartCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.removeElement(e)
+	}
+}
+
+// ItemCount returns the number of tarballs in the cache.
+func (c *ChartCache) ItemCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Size returns the total size in bytes of the tarballs in the cache.
+func (c *ChartCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+func (c *ChartCache) removeElement(e *list.Element) {
+	entry := c.ll.Remove(e).(*chartEntry)
+	delete(c.items, entry.key)
+	c.size -= int64(len(entry.data))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
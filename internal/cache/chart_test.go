@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestChartCache(t *testing.T) {
+	g := NewWithT(t)
+	// create a cache that can hold 10 bytes
+	cache := NewChartCache(10)
+
+	// Get a tarball from the cache
+	_, found := cache.Get("key1")
+	g.Expect(found).To(BeFalse())
+
+	// Add a tarball to the cache
+	g.Expect(cache.Set("key1", []byte("abcd"), "")).To(Succeed())
+	g.Expect(cache.Size()).To(Equal(int64(4)))
+
+	// Get the tarball from the cache
+	data, found := cache.Get("key1")
+	g.Expect(found).To(BeTrue())
+	g.Expect(data).To(Equal([]byte("abcd")))
+
+	// Modifying the returned data does not modify the cache
+	data[0] = 'x'
+	data, _ = cache.Get("key1")
+	g.Expect(data).To(Equal([]byte("abcd")))
+
+	// Add a tarball with a mismatching and matching digest
+	g.Expect(cache.Set("key2", []byte("efgh"), "sha256:invalid")).To(MatchError(ErrDigestMismatch))
+	g.Expect(cache.Set("key2", []byte("efgh"),
+		"sha256:e5e088a0b66163a0a26a5e053d2a4496dc16ab6e0e3dd1adf2d16aa84a078c9d")).To(Succeed())
+	g.Expect(cache.ItemCount()).To(Equal(2))
+
+	// Mark key1 as recently used, and add a tarball which requires eviction
+	_, found = cache.Get("key1")
+	g.Expect(found).To(BeTrue())
+	g.Expect(cache.Set("key3", []byte("ijkl"), "")).To(Succeed())
+	g.Expect(cache.ItemCount()).To(Equal(2))
+	g.Expect(cache.Size()).To(Equal(int64(8)))
+	_, found = cache.Get("key2")
+	g.Expect(found).To(BeFalse())
+	_, found = cache.Get("key1")
+	g.Expect(found).To(BeTrue())
+
+	// Replace a tarball in the cache
+	g.Expect(cache.Set("key1", []byte("mn"), "")).To(Succeed())
+	g.Expect(cache.Size()).To(Equal(int64(6)))
+	data, _ = cache.Get("key1")
+	g.Expect(data).To(Equal([]byte("mn")))
+
+	// Add a tarball which exceeds the cache size
+	g.Expect(cache.Set("key4", []byte("abcdefghijk"), "")).ToNot(Succeed())
+	g.Expect(cache.ItemCount()).To(Equal(2))
+
+	// Corrupted tarballs are removed
+	cache.items["key3"].Value.(*chartEntry).data[0] = 'x'
+	_, found = cache.Get("key3")
+	g.Expect(found).To(BeFalse())
+	g.Expect(cache.ItemCount()).To(Equal(1))
+
+	// Delete a tarball from the cache
+	cache.Delete("key1")
+	g.Expect(cache.ItemCount()).To(Equal(0))
+	g.Expect(cache.Size()).To(Equal(int64(0)))
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"time"
+)
+
+// TagCache is a thread-safe in-memory cache of the tags of charts in OCI
+// registries, bounded by the number of stored tag lists. The tag lists
+// expire after the TTL of the cache.
+type TagCache struct {
+	cache *Cache
+	ttl   time.Duration
+}
+
+// NewTagCache returns a new TagCache which can hold up to maxItems tag
+// lists, which expire after the given TTL. Expired tag lists are purged at
+// the given interval.
+func NewTagCache(maxItems int, ttl, purgeInterval time.Duration) *TagCache {
+	return &TagCache{
+		cache: New(maxItems, purgeInterval),
+		ttl:   ttl,
+	}
+}
+
+// Get returns a copy of the tags stored for the key.
+func (c *TagCache) Get(key string) ([]string, bool) {
+	v, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	tags := v.([]string)
+	return append(make([]string, 0, len(tags)), tags...), true
+}
+
+// Set stores a copy of the tags for the key, replacing any existing tags,
+// and resets their expiration. The tags are not stored if the cache is
+// full.
+func (c *TagCache) Set(key string, tags []string) {
+	_ = c.cache.Set(key, append(make([]string, 0, len(tags)), tags...), c.ttl)
+}
+
+// ItemCount returns the number of tag lists in the cache.
+func (c *TagCache) ItemCount() int {
+	return c.cache.ItemCount()
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTagCache(t *testing.T) {
+	g := NewWithT(t)
+	// create a cache that can hold 2 tag lists
+	cache := NewTagCache(2, 100*time.Millisecond, 0)
+
+	// Get tags from the cache
+	_, found := cache.Get("key1")
+	g.Expect(found).To(BeFalse())
+
+	// Add tags to the cache
+	tags := []string{"0.1.0", "0.2.0"}
+	cache.Set("key1", tags)
+	g.Expect(cache.ItemCount()).To(Equal(1))
+
+	// Modifying the given tags does not modify the cache
+	tags[0] = "x"
+	got, found := cache.Get("key1")
+	g.Expect(found).To(BeTrue())
+	g.Expect(got).To(Equal([]string{"0.1.0", "0.2.0"}))
+
+	// Modifying the returned tags does not modify the cache
+	got[0] = "x"
+	got, _ = cache.Get("key1")
+	g.Expect(got).To(Equal([]string{"0.1.0", "0.2.0"}))
+
+	// Tags are not stored when the cache is full
+	cache.Set("key2", []string{"1.0.0"})
+	cache.Set("key3", []string{"1.0.0"})
+	g.Expect(cache.ItemCount()).To(Equal(2))
+	_, found = cache.Get("key3")
+	g.Expect(found).To(BeFalse())
+
+	// Replace tags in the cache
+	cache.Set("key1", []string{"0.3.0"})
+	got, _ = cache.Get("key1")
+	g.Expect(got).To(Equal([]string{"0.3.0"}))
+
+	// Tags expire after the TTL
+	time.Sleep(150 * time.Millisecond)
+	_, found = cache.Get("key1")
+	g.Expect(found).To(BeFalse())
+}
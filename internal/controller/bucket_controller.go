@@ -55,7 +55,10 @@ import (
 	bucketv1 "github.com/fluxcd/source-controller/api/v1beta2"
 	intdigest "github.com/fluxcd/source-controller/internal/digest"
 	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/hostlimit"
+	"github.com/fluxcd/source-controller/internal/impersonation"
 	"github.com/fluxcd/source-controller/internal/index"
+	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
 	"github.com/fluxcd/source-controller/internal/tls"
@@ -81,6 +84,9 @@ const maxConcurrentBucketFetches = 100
 var bucketReadyCondition = summarize.Conditions{
 	Target: meta.ReadyCondition,
 	Owned: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.RevisionHeldCondition,
+		sourcev1.PendingApprovalCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.ArtifactOutdatedCondition,
@@ -90,6 +96,7 @@ var bucketReadyCondition = summarize.Conditions{
 		meta.StalledCondition,
 	},
 	Summarize: []string{
+		sourcev1.DependencyNotReadyCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.ArtifactOutdatedCondition,
@@ -98,6 +105,7 @@ var bucketReadyCondition = summarize.Conditions{
 		meta.ReconcilingCondition,
 	},
 	NegativePolarity: []string{
+		sourcev1.DependencyNotReadyCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.ArtifactOutdatedCondition,
@@ -126,11 +134,22 @@ type BucketReconciler struct {
 	Storage        *Storage
 	ControllerName string
 
+	// HostLimiter limits the concurrency and rate of remote operations per
+	// host. It is shared between reconcilers, and disabled when nil.
+	HostLimiter *hostlimit.Limiter
+
+	// Impersonator impersonates the ServiceAccount of the object to read its
+	// Secrets in multi-tenant lockdown mode. It is disabled when nil.
+	Impersonator *impersonation.Impersonator
+
+	requeueDependency time.Duration
+
 	patchOptions []patch.Option
 }
 
 type BucketReconcilerOptions struct {
-	RateLimiter ratelimiter.RateLimiter
+	DependencyRequeueInterval time.Duration
+	RateLimiter               ratelimiter.RateLimiter
 }
 
 // BucketProvider is an interface for fetching objects from a storage provider
@@ -166,10 +185,12 @@ func (r *BucketReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 func (r *BucketReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts BucketReconcilerOptions) error {
 	r.patchOptions = getPatchOptions(bucketReadyCondition.Owned, r.ControllerName)
+	r.requeueDependency = opts.DependencyRequeueInterval
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&bucketv1.Bucket{}).
-		WithEventFilter(predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{})).
+		WithEventFilter(predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+			intpredicates.ApprovedRevisionPredicate{})).
 		WithOptions(controller.Options{
 			RateLimiter: opts.RateLimiter,
 		}).
@@ -206,7 +227,8 @@ func (r *BucketReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 				summarize.RecordReconcileReq,
 			),
 			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
-				RequeueAfter: jitter.JitteredIntervalDuration(obj.GetRequeueAfter()),
+				RequeueAfter: holdRequeueAfter(obj, obj.Spec.Schedule,
+					jitter.JitteredIntervalDuration(obj.GetRequeueAfter()), time.Now()),
 			}),
 			summarize.WithPatchFieldOwner(r.ControllerName),
 		}
@@ -241,6 +263,12 @@ func (r *BucketReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 		return
 	}
 
+	// Wait for the dependencies of the object to be ready.
+	if err := checkSourceDependencies(ctx, r.Client, obj, obj.Spec.DependsOn, r.requeueDependency); err != nil {
+		recResult, retErr = sreconcile.ResultEmpty, err
+		return
+	}
+
 	// Reconcile actual object
 	reconcilers := []bucketReconcileFunc{
 		r.reconcileStorage,
@@ -423,7 +451,12 @@ func (r *BucketReconciler) reconcileStorage(ctx context.Context, sp *patch.Seria
 // the provider. If this fails, it records v1beta2.FetchFailedCondition=True on
 // the object and returns early.
 func (r *BucketReconciler) reconcileSource(ctx context.Context, sp *patch.SerialPatcher, obj *bucketv1.Bucket, index *index.Digester, dir string) (sreconcile.Result, error) {
-	secret, err := r.getSecret(ctx, obj.Spec.SecretRef, obj.GetNamespace())
+	secrets, err := secretClient(obj, r.Client, r.Impersonator, obj.Spec.ServiceAccountName)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
+	secret, err := r.getSecret(ctx, secrets, obj.Spec.SecretRef, obj.GetNamespace())
 	if err != nil {
 		e := serror.NewGeneric(err, sourcev1.AuthenticationFailedReason)
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
@@ -462,21 +495,31 @@ func (r *BucketReconciler) reconcileSource(ctx context.Context, sp *patch.Serial
 			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
 			return sreconcile.ResultEmpty, e
 		}
-		tlsConfig, err := r.getTLSConfig(ctx, obj)
+		tlsConfig, err := r.getTLSConfig(ctx, secrets, obj)
 		if err != nil {
 			e := serror.NewGeneric(err, sourcev1.AuthenticationFailedReason)
 			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
 			return sreconcile.ResultEmpty, e
 		}
-		if provider, err = minio.NewClient(obj, secret, tlsConfig); err != nil {
+		// Respect the Retry-After header of the bucket endpoint
+		if provider, err = minio.NewClient(obj, secret, tlsConfig, minio.WithTransportWrapper(r.HostLimiter.RoundTripper)); err != nil {
 			e := serror.NewGeneric(err, "ClientError")
 			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
 			return sreconcile.ResultEmpty, e
 		}
 	}
 
+	// Limit the remote operations on the bucket endpoint
+	res, err := reserveHost(obj, r.HostLimiter, obj.Spec.Endpoint)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+	defer res.Release()
+
 	// Fetch etag index
-	if err = fetchEtagIndex(ctx, provider, obj, index, dir); err != nil {
+	err = fetchEtagIndex(ctx, provider, obj, index, dir)
+	recordHostResult(r.EventRecorder, obj, res, err)
+	if err != nil {
 		e := serror.NewGeneric(err, bucketv1.BucketOperationFailedReason)
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
 		return sreconcile.ResultEmpty, e
@@ -541,12 +584,21 @@ func (r *BucketReconciler) reconcileArtifact(ctx context.Context, sp *patch.Seri
 			curRev := digest.Digest(curArtifact.Revision)
 			if curRev.Validate() == nil && index.Digest(curRev.Algorithm()) == curRev {
 				conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+				conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+				obj.Status.PendingArtifact = nil
 				conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, meta.SucceededReason,
 					"stored artifact: revision '%s'", artifact.Revision)
 			}
 		}
 	}()
 
+	// Hold the new revision while the schedule is closed
+	if held, err := holdRevision(obj, obj.Spec.Schedule, obj.GetArtifact(), artifact.Revision, time.Now()); err != nil {
+		return sreconcile.ResultEmpty, err
+	} else if held {
+		return sreconcile.ResultSuccess, nil
+	}
+
 	// The artifact is up-to-date
 	if curArtifact := obj.GetArtifact(); curArtifact != nil && curArtifact.Revision != "" {
 		curRev := digest.Digest(curArtifact.Revision)
@@ -601,6 +653,13 @@ func (r *BucketReconciler) reconcileArtifact(ctx context.Context, sp *patch.Seri
 		return sreconcile.ResultEmpty, e
 	}
 
+	// Keep serving the current artifact until the new revision is approved
+	if awaitArtifactApproval(obj, obj.Spec.Approval, r.Storage, obj.GetArtifact(), &artifact) {
+		obj.Status.PendingArtifact = artifact.DeepCopy()
+		return sreconcile.ResultSuccess, nil
+	}
+	obj.Status.PendingArtifact = nil
+
 	// Record it on the object
 	obj.Status.Artifact = artifact.DeepCopy()
 	obj.Status.ObservedIgnore = obj.Spec.Ignore
@@ -652,10 +711,15 @@ func (r *BucketReconciler) garbageCollect(ctx context.Context, obj *bucketv1.Buc
 				"garbage collected artifacts for deleted resource")
 		}
 		obj.Status.Artifact = nil
+		obj.Status.PendingArtifact = nil
 		return nil
 	}
 	if obj.GetArtifact() != nil {
-		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5)
+		var keep []sourcev1.Artifact
+		if pending := obj.Status.PendingArtifact; pending != nil {
+			keep = append(keep, *pending)
+		}
+		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5, keep...)
 		if err != nil {
 			return serror.NewGeneric(
 				fmt.Errorf("garbage collection of artifacts failed: %w", err),
@@ -672,7 +736,7 @@ func (r *BucketReconciler) garbageCollect(ctx context.Context, obj *bucketv1.Buc
 }
 
 // getSecret attempts to fetch a Secret reference if specified. It returns any client error.
-func (r *BucketReconciler) getSecret(ctx context.Context, secretRef *meta.LocalObjectReference,
+func (r *BucketReconciler) getSecret(ctx context.Context, c client.Reader, secretRef *meta.LocalObjectReference,
 	namespace string) (*corev1.Secret, error) {
 	if secretRef == nil {
 		return nil, nil
@@ -682,14 +746,14 @@ func (r *BucketReconciler) getSecret(ctx context.Context, secretRef *meta.LocalO
 		Name:      secretRef.Name,
 	}
 	secret := &corev1.Secret{}
-	if err := r.Get(ctx, secretName, secret); err != nil {
+	if err := c.Get(ctx, secretName, secret); err != nil {
 		return nil, fmt.Errorf("failed to get secret '%s': %w", secretName.String(), err)
 	}
 	return secret, nil
 }
 
-func (r *BucketReconciler) getTLSConfig(ctx context.Context, obj *bucketv1.Bucket) (*stdtls.Config, error) {
-	certSecret, err := r.getSecret(ctx, obj.Spec.CertSecretRef, obj.GetNamespace())
+func (r *BucketReconciler) getTLSConfig(ctx context.Context, secrets client.Reader, obj *bucketv1.Bucket) (*stdtls.Config, error) {
+	certSecret, err := r.getSecret(ctx, secrets, obj.Spec.CertSecretRef, obj.GetNamespace())
 	if err != nil || certSecret == nil {
 		return nil, err
 	}
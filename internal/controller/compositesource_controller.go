@@ -0,0 +1,827 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/opencontainers/go-digest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kuberecorder "k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	helper "github.com/fluxcd/pkg/runtime/controller"
+	"github.com/fluxcd/pkg/runtime/jitter"
+	"github.com/fluxcd/pkg/runtime/patch"
+	"github.com/fluxcd/pkg/runtime/predicates"
+	rreconcile "github.com/fluxcd/pkg/runtime/reconcile"
+	"github.com/fluxcd/pkg/sourceignore"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	intdigest "github.com/fluxcd/source-controller/internal/digest"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
+)
+
+// compositeSourceReadyCondition contains the information required to
+// summarize a v1beta2.CompositeSource Ready Condition.
+var compositeSourceReadyCondition = summarize.Conditions{
+	Target: meta.ReadyCondition,
+	Owned: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.RevisionHeldCondition,
+		sourcev1.PendingApprovalCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1beta2.SourceUnavailableCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		sourcev1.ArtifactInStorageCondition,
+		meta.ReadyCondition,
+		meta.ReconcilingCondition,
+		meta.StalledCondition,
+	},
+	Summarize: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1beta2.SourceUnavailableCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		sourcev1.ArtifactInStorageCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+	NegativePolarity: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1beta2.SourceUnavailableCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+}
+
+// compositeSourceFailConditions contains the conditions that represent a
+// failure.
+var compositeSourceFailConditions = []string{
+	sourcev1beta2.SourceUnavailableCondition,
+	sourcev1.StorageOperationFailedCondition,
+}
+
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=compositesources,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=compositesources/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=compositesources/finalizers,verbs=get;create;update;patch;delete
+
+// CompositeSourceReconciler reconciles a v1beta2.CompositeSource object.
+type CompositeSourceReconciler struct {
+	client.Client
+	kuberecorder.EventRecorder
+	helper.Metrics
+
+	Storage        *Storage
+	ControllerName string
+
+	requeueDependency time.Duration
+	patchOptions      []patch.Option
+}
+
+type CompositeSourceReconcilerOptions struct {
+	DependencyRequeueInterval time.Duration
+	RateLimiter               ratelimiter.RateLimiter
+}
+
+// compositeSourceReconcileFunc is the function type for all the
+// v1beta2.CompositeSource (sub)reconcile functions. The type implementations
+// are grouped and executed serially to perform the complete reconcile of the
+// object.
+type compositeSourceReconcileFunc func(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.CompositeSource, inputs *artifactSet, dir string) (sreconcile.Result, error)
+
+func (r *CompositeSourceReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	return r.SetupWithManagerAndOptions(ctx, mgr, CompositeSourceReconcilerOptions{})
+}
+
+func (r *CompositeSourceReconciler) SetupWithManagerAndOptions(ctx context.Context, mgr ctrl.Manager, opts CompositeSourceReconcilerOptions) error {
+	r.patchOptions = getPatchOptions(compositeSourceReadyCondition.Owned, r.ControllerName)
+	r.requeueDependency = opts.DependencyRequeueInterval
+
+	if err := mgr.GetCache().IndexField(ctx, &sourcev1beta2.CompositeSource{}, sourcev1beta2.SourceIndexKey,
+		r.indexCompositeSourceBySource); err != nil {
+		return fmt.Errorf("failed setting index fields: %w", err)
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&sourcev1beta2.CompositeSource{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+				intpredicates.ApprovedRevisionPredicate{}),
+		)).
+		Watches(
+			&sourcev1.GitRepository{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSourceChange),
+			builder.WithPredicates(SourceRevisionChangePredicate{}),
+		).
+		Watches(
+			&sourcev1beta2.OCIRepository{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSourceChange),
+			builder.WithPredicates(SourceRevisionChangePredicate{}),
+		).
+		Watches(
+			&sourcev1beta2.Bucket{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSourceChange),
+			builder.WithPredicates(SourceRevisionChangePredicate{}),
+		).
+		Watches(
+			&sourcev1beta2.HTTPSource{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSourceChange),
+			builder.WithPredicates(SourceRevisionChangePredicate{}),
+		).
+		WithOptions(controller.Options{
+			RateLimiter: opts.RateLimiter,
+		}).
+		Complete(r)
+}
+
+func (r *CompositeSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
+	start := time.Now()
+	log := ctrl.LoggerFrom(ctx)
+
+	// Fetch the CompositeSource
+	obj := &sourcev1beta2.CompositeSource{}
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Initialize the patch helper with the current version of the object.
+	serialPatcher := patch.NewSerialPatcher(obj, r.Client)
+
+	// recResult stores the abstracted reconcile result.
+	var recResult sreconcile.Result
+
+	// Always attempt to patch the object and status after each reconciliation
+	// NOTE: The final runtime result and error are set in this block.
+	defer func() {
+		summarizeHelper := summarize.NewHelper(r.EventRecorder, serialPatcher)
+		summarizeOpts := []summarize.Option{
+			summarize.WithConditions(compositeSourceReadyCondition),
+			summarize.WithReconcileResult(recResult),
+			summarize.WithReconcileError(retErr),
+			summarize.WithIgnoreNotFound(),
+			summarize.WithProcessors(
+				summarize.ErrorActionHandler,
+				summarize.RecordReconcileReq,
+			),
+			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
+				RequeueAfter: holdRequeueAfter(obj, obj.Spec.Schedule,
+					jitter.JitteredIntervalDuration(obj.GetRequeueAfter()), time.Now()),
+			}),
+			summarize.WithPatchFieldOwner(r.ControllerName),
+		}
+		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
+
+		// Always record suspend, readiness and duration metrics.
+		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
+		r.Metrics.RecordReadiness(ctx, obj)
+		r.Metrics.RecordDuration(ctx, obj, start)
+	}()
+
+	// Examine if the object is under deletion.
+	if !obj.ObjectMeta.DeletionTimestamp.IsZero() {
+		recResult, retErr = r.reconcileDelete(ctx, obj)
+		return
+	}
+
+	// Add finalizer first if not exist to avoid the race condition between init
+	// and delete.
+	// Note: Finalizers in general can only be added when the deletionTimestamp
+	// is not set.
+	if !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
+		controllerutil.AddFinalizer(obj, sourcev1.SourceFinalizer)
+		recResult = sreconcile.ResultRequeue
+		return
+	}
+
+	// Return if the object is suspended.
+	if obj.Spec.Suspend {
+		log.Info("reconciliation is suspended for this object")
+		recResult, retErr = sreconcile.ResultEmpty, nil
+		return
+	}
+
+	// Wait for the dependencies of the object to be ready.
+	if err := checkSourceDependencies(ctx, r.Client, obj, obj.Spec.DependsOn, r.requeueDependency); err != nil {
+		recResult, retErr = sreconcile.ResultEmpty, err
+		return
+	}
+
+	// Reconcile actual object
+	reconcilers := []compositeSourceReconcileFunc{
+		r.reconcileStorage,
+		r.reconcileSource,
+		r.reconcileArtifact,
+	}
+	recResult, retErr = r.reconcile(ctx, serialPatcher, obj, reconcilers)
+	return
+}
+
+// reconcile iterates through the compositeSourceReconcileFunc tasks for the
+// object. It returns early on the first call that returns
+// reconcile.ResultRequeue, or produces an error.
+func (r *CompositeSourceReconciler) reconcile(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.CompositeSource, reconcilers []compositeSourceReconcileFunc) (sreconcile.Result, error) {
+	oldObj := obj.DeepCopy()
+
+	rreconcile.ProgressiveStatus(false, obj, meta.ProgressingReason, "reconciliation in progress")
+
+	var recAtVal string
+	if v, ok := meta.ReconcileAnnotationValue(obj.GetAnnotations()); ok {
+		recAtVal = v
+	}
+
+	// Persist reconciling if generation differs or reconciliation is requested.
+	switch {
+	case obj.Generation != obj.Status.ObservedGeneration:
+		rreconcile.ProgressiveStatus(false, obj, meta.ProgressingReason,
+			"processing object: new generation %d -> %d", obj.Status.ObservedGeneration, obj.Generation)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	case recAtVal != obj.Status.GetLastHandledReconcileRequest():
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	}
+
+	// Create temp working dir
+	tmpDir, err := os.MkdirTemp("", fmt.Sprintf("%s-%s-%s-", obj.Kind, obj.Namespace, obj.Name))
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to create temporary working directory: %w", err),
+			sourcev1.DirCreationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	defer func() {
+		if err = os.RemoveAll(tmpDir); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to remove temporary working directory")
+		}
+	}()
+	conditions.Delete(obj, sourcev1.StorageOperationFailedCondition)
+
+	// Run the sub-reconcilers and build the result of reconciliation.
+	var (
+		res    sreconcile.Result
+		resErr error
+		inputs = &artifactSet{}
+	)
+
+	for _, rec := range reconcilers {
+		recResult, err := rec(ctx, sp, obj, inputs, tmpDir)
+		// Exit immediately on ResultRequeue.
+		if recResult == sreconcile.ResultRequeue {
+			return sreconcile.ResultRequeue, nil
+		}
+		// If an error is received, prioritize the returned results because an
+		// error also means immediate requeue.
+		if err != nil {
+			resErr = err
+			res = recResult
+			break
+		}
+		// Prioritize requeue request in the result.
+		res = sreconcile.LowestRequeuingResult(res, recResult)
+	}
+
+	r.notify(ctx, oldObj, obj, res, resErr)
+
+	return res, resErr
+}
+
+// notify emits notification related to the reconciliation.
+func (r *CompositeSourceReconciler) notify(ctx context.Context, oldObj, newObj *sourcev1beta2.CompositeSource, res sreconcile.Result, resErr error) {
+	// Notify successful reconciliation for new artifact and recovery from any
+	// failure.
+	if resErr == nil && res == sreconcile.ResultSuccess && newObj.Status.Artifact != nil {
+		annotations := map[string]string{
+			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaRevisionKey): newObj.Status.Artifact.Revision,
+			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaDigestKey):   newObj.Status.Artifact.Digest,
+		}
+
+		message := fmt.Sprintf("stored artifact for %d source(s) with revision '%s'",
+			len(newObj.Status.SourceArtifacts), newObj.Status.Artifact.Revision)
+
+		// Notify on new artifact and failure recovery.
+		if !oldObj.GetArtifact().HasDigest(newObj.GetArtifact().Digest) {
+			r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
+				"NewArtifact", message)
+			ctrl.LoggerFrom(ctx).Info(message)
+		} else {
+			if sreconcile.FailureRecovery(oldObj, newObj, compositeSourceFailConditions) {
+				r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
+					meta.SucceededReason, message)
+				ctrl.LoggerFrom(ctx).Info(message)
+			}
+		}
+	}
+}
+
+// reconcileStorage ensures the current state of the storage matches the
+// desired and previously observed state.
+//
+// The garbage collection is executed based on the flag configured settings and
+// may remove files that are beyond their TTL or the maximum number of files
+// to survive a collection cycle.
+// If the Artifact in the Status of the object disappeared from the Storage,
+// it is removed from the object.
+// If the object does not have an Artifact in its Status, a Reconciling
+// condition is added.
+// The hostname of any URL in the Status of the object are updated, to ensure
+// they match the Storage server hostname of current runtime.
+func (r *CompositeSourceReconciler) reconcileStorage(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.CompositeSource, _ *artifactSet, _ string) (sreconcile.Result, error) {
+	// Garbage collect previous advertised artifact(s) from storage
+	_ = r.garbageCollect(ctx, obj)
+
+	var artifactMissing bool
+	if artifact := obj.GetArtifact(); artifact != nil {
+		// Determine if the advertised artifact is still in storage
+		if !r.Storage.ArtifactExist(*artifact) {
+			artifactMissing = true
+		}
+
+		// If the artifact is in storage, verify if the advertised digest still
+		// matches the actual artifact
+		if !artifactMissing {
+			if err := r.Storage.VerifyArtifact(*artifact); err != nil {
+				r.Eventf(obj, corev1.EventTypeWarning, "ArtifactVerificationFailed", "failed to verify integrity of artifact: %s", err.Error())
+
+				if err = r.Storage.Remove(*artifact); err != nil {
+					return sreconcile.ResultEmpty, fmt.Errorf("failed to remove artifact after digest mismatch: %w", err)
+				}
+
+				artifactMissing = true
+			}
+		}
+
+		// If the artifact is missing, remove it from the object
+		if artifactMissing {
+			obj.Status.Artifact = nil
+			obj.Status.URL = ""
+		}
+	}
+
+	// Record that we do not have an artifact
+	if obj.GetArtifact() == nil {
+		msg := "building artifact"
+		if artifactMissing {
+			msg += ": disappeared from storage"
+		}
+		rreconcile.ProgressiveStatus(true, obj, meta.ProgressingReason, msg)
+		conditions.Delete(obj, sourcev1.ArtifactInStorageCondition)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// Always update URLs to ensure hostname is up-to-date
+	r.Storage.SetArtifactURL(obj.GetArtifact())
+	obj.Status.URL = r.Storage.SetHostname(obj.Status.URL)
+
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileSource collects the Artifacts of the sources of the object into
+// the given artifactSet, in the order of the sources.
+//
+// If a source does not exist, or does not have an Artifact in the Storage,
+// the SourceUnavailable condition is set to True and the object is requeued
+// after the dependency requeue interval. On success, the SourceUnavailable
+// condition is removed, and the ArtifactOutdated condition is set to True if
+// the sources or their revisions differ from the current Artifact.
+func (r *CompositeSourceReconciler) reconcileSource(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.CompositeSource, inputs *artifactSet, _ string) (sreconcile.Result, error) {
+	artifacts := make(artifactSet, len(obj.Spec.Sources))
+	for i, input := range obj.Spec.Sources {
+		s, err := getCompositeSourceInput(ctx, r.Client, obj.GetNamespace(), input)
+		if err != nil {
+			e := serror.NewWaiting(
+				fmt.Errorf("could not get %s source '%s': %w", input.Kind, input.Name, err),
+				"NotFound",
+			)
+			e.RequeueAfter = r.requeueDependency
+			conditions.MarkTrue(obj, sourcev1beta2.SourceUnavailableCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+
+		// Confirm the source has an artifact in storage
+		artifact := s.GetArtifact()
+		if artifact == nil || !r.Storage.ArtifactExist(*artifact) {
+			e := serror.NewWaiting(
+				fmt.Errorf("no artifact available for %s source '%s'", input.Kind, input.Name),
+				"NoArtifact",
+			)
+			e.RequeueAfter = r.requeueDependency
+			conditions.MarkTrue(obj, sourcev1beta2.SourceUnavailableCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+
+		artifacts[i] = artifact.DeepCopy()
+	}
+	*inputs = artifacts
+
+	// We now know all the sources are available.
+	conditions.Delete(obj, sourcev1beta2.SourceUnavailableCondition)
+
+	// Mark observations about the revision on the object
+	revision := compositeSourceRevision(obj.Spec.Sources, artifacts).String()
+	if !obj.GetArtifact().HasRevision(revision) || compositeSourceContentConfigChanged(obj, inputs) {
+		message := fmt.Sprintf("new revision '%s' for %d source(s)", revision, len(artifacts))
+		if obj.GetArtifact() != nil {
+			conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", message)
+		}
+		rreconcile.ProgressiveStatus(true, obj, meta.ProgressingReason, "building artifact: %s", message)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	}
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileArtifact archives a new Artifact to the Storage, if the current
+// (Status) data on the object does not match the given artifactSet.
+//
+// The (sub)contents of the Artifacts of the sources are copied into dir,
+// in the layout configured on the object, before the directory is archived.
+// On a successful archive, the Artifact, source Artifacts and observed
+// configuration in the Status of the object are set.
+// If the Artifact is up-to-date, the ArtifactInStorage condition is set to
+// True.
+func (r *CompositeSourceReconciler) reconcileArtifact(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.CompositeSource, inputs *artifactSet, dir string) (sreconcile.Result, error) {
+	revision := compositeSourceRevision(obj.Spec.Sources, *inputs)
+
+	// Create artifact
+	artifact := r.Storage.NewArtifactFor(obj.Kind, obj, revision.String(), fmt.Sprintf("%s.tar.gz", revision.Encoded()))
+
+	// Set the ArtifactInStorageCondition if there's no drift.
+	defer func() {
+		if obj.GetArtifact().HasRevision(artifact.Revision) && !compositeSourceContentConfigChanged(obj, inputs) {
+			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+			conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+			obj.Status.PendingArtifact = nil
+			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, meta.SucceededReason,
+				"stored artifact for revision '%s'", artifact.Revision)
+		}
+	}()
+
+	// Hold the new revision while the schedule is closed
+	if held, err := holdRevision(obj, obj.Spec.Schedule, obj.GetArtifact(), artifact.Revision, time.Now()); err != nil {
+		return sreconcile.ResultEmpty, err
+	} else if held {
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// The artifact is up-to-date
+	if obj.GetArtifact().HasRevision(artifact.Revision) && !compositeSourceContentConfigChanged(obj, inputs) {
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason, "artifact up-to-date with remote revision: '%s'", artifact.Revision)
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// Copy the (sub)contents of the source artifacts into the configured
+	// directories.
+	for i, input := range obj.Spec.Sources {
+		// Do this first as it is much cheaper than copy operations
+		toPath, err := securejoin.SecureJoin(dir, input.GetToPath())
+		if err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("path calculation for %s source '%s' failed: %w", input.Kind, input.Name, err),
+				"IllegalPath",
+			)
+			conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+
+		if err := r.Storage.CopyToPath((*inputs)[i], input.GetFromPath(), toPath); err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to copy %s source '%s' from %s to %s: %w", input.Kind, input.Name, input.GetFromPath(), input.GetToPath(), err),
+				"CopyFailure",
+			)
+			conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+	}
+
+	// Ensure artifact directory exists and acquire lock
+	if err := r.Storage.MkdirAll(artifact); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to create artifact directory: %w", err),
+			sourcev1.DirCreationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	unlock, err := r.Storage.Lock(artifact)
+	if err != nil {
+		return sreconcile.ResultEmpty, serror.NewGeneric(
+			fmt.Errorf("failed to acquire lock for artifact: %w", err),
+			meta.FailedReason,
+		)
+	}
+	defer unlock()
+
+	// Load ignore rules for archiving
+	ignoreDomain := strings.Split(dir, string(filepath.Separator))
+	ps, err := sourceignore.LoadIgnorePatterns(dir, ignoreDomain)
+	if err != nil {
+		return sreconcile.ResultEmpty, serror.NewGeneric(
+			fmt.Errorf("failed to load source ignore patterns: %w", err),
+			"SourceIgnoreError",
+		)
+	}
+	if obj.Spec.Ignore != nil {
+		ps = append(ps, sourceignore.ReadPatterns(strings.NewReader(*obj.Spec.Ignore), ignoreDomain)...)
+	}
+
+	// Archive directory to storage
+	if err := r.Storage.Archive(&artifact, dir, SourceIgnoreFilter(ps, ignoreDomain)); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("unable to archive artifact to storage: %s", err),
+			sourcev1.ArchiveOperationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	// Keep serving the current artifact until the new revision is approved
+	if awaitArtifactApproval(obj, obj.Spec.Approval, r.Storage, obj.GetArtifact(), &artifact) {
+		obj.Status.PendingArtifact = artifact.DeepCopy()
+		return sreconcile.ResultSuccess, nil
+	}
+	obj.Status.PendingArtifact = nil
+
+	// Record it on the object
+	obj.Status.Artifact = artifact.DeepCopy()
+	obj.Status.SourceArtifacts = *inputs
+	obj.Status.ObservedSources = obj.Spec.Sources
+	obj.Status.ObservedIgnore = obj.Spec.Ignore
+
+	// Update symlink on a "best effort" basis
+	url, err := r.Storage.Symlink(artifact, "latest.tar.gz")
+	if err != nil {
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.SymlinkUpdateFailedReason,
+			"failed to update status URL symlink: %s", err)
+	}
+	if url != "" {
+		obj.Status.URL = url
+	}
+	conditions.Delete(obj, sourcev1.StorageOperationFailedCondition)
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileDelete handles the deletion of the object.
+// It first garbage collects all Artifacts for the object from the Storage.
+// Removing the finalizer from the object if successful.
+func (r *CompositeSourceReconciler) reconcileDelete(ctx context.Context, obj *sourcev1beta2.CompositeSource) (sreconcile.Result, error) {
+	// Garbage collect the resource's artifacts
+	if err := r.garbageCollect(ctx, obj); err != nil {
+		// Return the error so we retry the failed garbage collection
+		return sreconcile.ResultEmpty, err
+	}
+
+	// Remove our finalizer from the list
+	controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+
+	// Stop reconciliation as the object is being deleted
+	return sreconcile.ResultEmpty, nil
+}
+
+// garbageCollect performs a garbage collection for the given object.
+//
+// It removes all but the current Artifact from the Storage, unless the
+// deletion timestamp on the object is set. Which will result in the
+// removal of all Artifacts for the objects.
+func (r *CompositeSourceReconciler) garbageCollect(ctx context.Context, obj *sourcev1beta2.CompositeSource) error {
+	if !obj.DeletionTimestamp.IsZero() {
+		if deleted, err := r.Storage.RemoveAll(r.Storage.NewArtifactFor(obj.Kind, obj.GetObjectMeta(), "", "*")); err != nil {
+			return serror.NewGeneric(
+				fmt.Errorf("garbage collection for deleted resource failed: %s", err),
+				"GarbageCollectionFailed",
+			)
+		} else if deleted != "" {
+			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "GarbageCollectionSucceeded",
+				"garbage collected artifacts for deleted resource")
+		}
+		obj.Status.Artifact = nil
+		obj.Status.PendingArtifact = nil
+		return nil
+	}
+	if obj.GetArtifact() != nil {
+		var keep []sourcev1.Artifact
+		if pending := obj.Status.PendingArtifact; pending != nil {
+			keep = append(keep, *pending)
+		}
+		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5, keep...)
+		if err != nil {
+			return serror.NewGeneric(
+				fmt.Errorf("garbage collection of artifacts failed: %w", err),
+				"GarbageCollectionFailed",
+			)
+		}
+		if len(delFiles) > 0 {
+			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "GarbageCollectionSucceeded",
+				fmt.Sprintf("garbage collected %d artifacts", len(delFiles)))
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *CompositeSourceReconciler) indexCompositeSourceBySource(o client.Object) []string {
+	cs, ok := o.(*sourcev1beta2.CompositeSource)
+	if !ok {
+		panic(fmt.Sprintf("Expected a CompositeSource, got %T", o))
+	}
+	var keys []string
+	for _, input := range cs.Spec.Sources {
+		keys = append(keys, fmt.Sprintf("%s/%s", input.Kind, input.Name))
+	}
+	return keys
+}
+
+func (r *CompositeSourceReconciler) requestsForSourceChange(ctx context.Context, o client.Object) []reconcile.Request {
+	var kind string
+	switch o.(type) {
+	case *sourcev1.GitRepository:
+		kind = sourcev1.GitRepositoryKind
+	case *sourcev1beta2.OCIRepository:
+		kind = sourcev1beta2.OCIRepositoryKind
+	case *sourcev1beta2.Bucket:
+		kind = sourcev1beta2.BucketKind
+	case *sourcev1beta2.HTTPSource:
+		kind = sourcev1beta2.HTTPSourceKind
+	default:
+		ctrl.LoggerFrom(ctx).Error(fmt.Errorf("expected a Source, got %T", o), "failed to get requests for Source change")
+		return nil
+	}
+
+	s, ok := o.(sourcev1.Source)
+	// If we do not have an artifact, we have no requests to make
+	if !ok || s.GetArtifact() == nil {
+		return nil
+	}
+
+	var list sourcev1beta2.CompositeSourceList
+	if err := r.List(ctx, &list, client.InNamespace(o.GetNamespace()), client.MatchingFields{
+		sourcev1beta2.SourceIndexKey: fmt.Sprintf("%s/%s", kind, o.GetName()),
+	}); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list CompositeSources for Source change")
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for i, v := range list.Items {
+		for j, input := range v.Spec.Sources {
+			if input.Kind != kind || input.Name != o.GetName() {
+				continue
+			}
+			if j >= len(v.Status.SourceArtifacts) || !s.GetArtifact().HasRevision(v.Status.SourceArtifacts[j].Revision) {
+				reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+				break
+			}
+		}
+	}
+	return reqs
+}
+
+// getCompositeSourceInput returns the source object referenced by the given
+// v1beta2.CompositeSourceInput.
+func getCompositeSourceInput(ctx context.Context, c client.Reader, namespace string, input sourcev1beta2.CompositeSourceInput) (sourcev1.Source, error) {
+	namespacedName := types.NamespacedName{
+		Namespace: namespace,
+		Name:      input.Name,
+	}
+	var s sourcev1.Source
+	switch input.Kind {
+	case sourcev1.GitRepositoryKind:
+		var repo sourcev1.GitRepository
+		if err := c.Get(ctx, namespacedName, &repo); err != nil {
+			return nil, err
+		}
+		s = &repo
+	case sourcev1beta2.OCIRepositoryKind:
+		var repo sourcev1beta2.OCIRepository
+		if err := c.Get(ctx, namespacedName, &repo); err != nil {
+			return nil, err
+		}
+		s = &repo
+	case sourcev1beta2.BucketKind:
+		var bucket sourcev1beta2.Bucket
+		if err := c.Get(ctx, namespacedName, &bucket); err != nil {
+			return nil, err
+		}
+		s = &bucket
+	case sourcev1beta2.HTTPSourceKind:
+		var src sourcev1beta2.HTTPSource
+		if err := c.Get(ctx, namespacedName, &src); err != nil {
+			return nil, err
+		}
+		s = &src
+	default:
+		return nil, fmt.Errorf("unsupported source kind '%s', must be one of: %v", input.Kind, []string{
+			sourcev1.GitRepositoryKind, sourcev1beta2.OCIRepositoryKind, sourcev1beta2.BucketKind, sourcev1beta2.HTTPSourceKind})
+	}
+	return s, nil
+}
+
+// compositeSourceRevision returns the revision of a v1beta2.CompositeSource
+// for the given sources and their Artifacts. The revision is the digest of a
+// '<kind>/<name>@<revision>' line for every source, in the order of the
+// sources.
+func compositeSourceRevision(sources []sourcev1beta2.CompositeSourceInput, artifacts artifactSet) digest.Digest {
+	var b strings.Builder
+	for i, input := range sources {
+		var revision string
+		if i < len(artifacts) && artifacts[i] != nil {
+			revision = artifacts[i].Revision
+		}
+		fmt.Fprintf(&b, "%s/%s@%s\n", input.Kind, input.Name, revision)
+	}
+	return intdigest.Canonical.FromString(b.String())
+}
+
+// compositeSourceContentConfigChanged evaluates the current spec with the
+// observations of the artifact in the status to determine if artifact content
+// configuration has changed and requires rebuilding the artifact. Rebuilding
+// the artifact is also required if the digest of a source Artifact changed
+// without a change of revision.
+func compositeSourceContentConfigChanged(obj *sourcev1beta2.CompositeSource, inputs *artifactSet) bool {
+	if !ptr.Equal(obj.Spec.Ignore, obj.Status.ObservedIgnore) {
+		return true
+	}
+	if len(obj.Spec.Sources) != len(obj.Status.ObservedSources) {
+		return true
+	}
+	artifacts := []*sourcev1.Artifact(*inputs)
+	if len(obj.Spec.Sources) != len(artifacts) || len(obj.Spec.Sources) != len(obj.Status.SourceArtifacts) {
+		return true
+	}
+
+	// The order of spec.sources, status.sourceArtifacts and
+	// status.observedSources are the same. Compare the values by index.
+	for i, input := range obj.Spec.Sources {
+		if input != obj.Status.ObservedSources[i] {
+			return true
+		}
+		if !obj.Status.SourceArtifacts[i].HasDigest(artifacts[i].Digest) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventLogf records events, and logs at the same time.
+//
+// This log is different from the debug log in the EventRecorder, in the sense
+// that this is a simple log. While the debug log contains complete details
+// about the event.
+func (r *CompositeSourceReconciler) eventLogf(ctx context.Context, obj runtime.Object, eventType string, reason string, messageFmt string, args ...interface{}) {
+	msg := fmt.Sprintf(messageFmt, args...)
+	// Log and emit event.
+	if eventType == corev1.EventTypeWarning {
+		ctrl.LoggerFrom(ctx).Error(errors.New(reason), msg)
+	} else {
+		ctrl.LoggerFrom(ctx).Info(msg)
+	}
+	r.Eventf(obj, eventType, reason, msg)
+}
@@ -0,0 +1,417 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/fluxcd/pkg/runtime/patch"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+)
+
+func TestCompositeSourceReconciler_reconcileSource(t *testing.T) {
+	g := NewWithT(t)
+
+	repositoryPath, err := filepath.Abs("testdata/git/repository")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	gitArtifact := &sourcev1.Artifact{
+		Path:     "composite/git.tar.gz",
+		Revision: "main@sha1:5394cb7f48332b2de7c17dd8b8384bbc84b7e738",
+	}
+	g.Expect(testStorage.MkdirAll(*gitArtifact)).To(Succeed())
+	g.Expect(testStorage.Archive(gitArtifact, repositoryPath, nil)).To(Succeed())
+	bucketArtifact := &sourcev1.Artifact{
+		Path:     "composite/bucket.tar.gz",
+		Revision: "sha256:8fb62a09c9e48ace5463bf940dc15e85f525be4f230e223bbceef6e13024110c",
+	}
+	g.Expect(testStorage.MkdirAll(*bucketArtifact)).To(Succeed())
+	g.Expect(testStorage.Archive(bucketArtifact, repositoryPath, nil)).To(Succeed())
+
+	sources := []sourcev1beta2.CompositeSourceInput{
+		{Kind: sourcev1.GitRepositoryKind, Name: "git"},
+		{Kind: sourcev1beta2.BucketKind, Name: "bucket"},
+	}
+
+	tests := []struct {
+		name             string
+		objects          []client.Object
+		beforeFunc       func(obj *sourcev1beta2.CompositeSource)
+		want             sreconcile.Result
+		wantErr          bool
+		wantInputs       artifactSet
+		assertConditions []metav1.Condition
+	}{
+		{
+			name: "Available sources make Reconciling=True",
+			objects: []client.Object{
+				&sourcev1.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "default"},
+					Status:     sourcev1.GitRepositoryStatus{Artifact: gitArtifact},
+				},
+				&sourcev1beta2.Bucket{
+					ObjectMeta: metav1.ObjectMeta{Name: "bucket", Namespace: "default"},
+					Status:     sourcev1beta2.BucketStatus{Artifact: bucketArtifact},
+				},
+			},
+			want:       sreconcile.ResultSuccess,
+			wantInputs: artifactSet{gitArtifact, bucketArtifact},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+			},
+		},
+		{
+			name: "Changed source revision makes ArtifactOutdated=True",
+			objects: []client.Object{
+				&sourcev1.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "default"},
+					Status:     sourcev1.GitRepositoryStatus{Artifact: gitArtifact},
+				},
+				&sourcev1beta2.Bucket{
+					ObjectMeta: metav1.ObjectMeta{Name: "bucket", Namespace: "default"},
+					Status:     sourcev1beta2.BucketStatus{Artifact: bucketArtifact},
+				},
+			},
+			beforeFunc: func(obj *sourcev1beta2.CompositeSource) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "sha256:old"}
+			},
+			want:       sreconcile.ResultSuccess,
+			wantInputs: artifactSet{gitArtifact, bucketArtifact},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new revision 'sha256:"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+			},
+		},
+		{
+			name: "Up-to-date artifact does not make ArtifactOutdated=True",
+			objects: []client.Object{
+				&sourcev1.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "default"},
+					Status:     sourcev1.GitRepositoryStatus{Artifact: gitArtifact},
+				},
+				&sourcev1beta2.Bucket{
+					ObjectMeta: metav1.ObjectMeta{Name: "bucket", Namespace: "default"},
+					Status:     sourcev1beta2.BucketStatus{Artifact: bucketArtifact},
+				},
+			},
+			beforeFunc: func(obj *sourcev1beta2.CompositeSource) {
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: compositeSourceRevision(sources, artifactSet{gitArtifact, bucketArtifact}).String(),
+				}
+				obj.Status.SourceArtifacts = []*sourcev1.Artifact{gitArtifact, bucketArtifact}
+				obj.Status.ObservedSources = sources
+			},
+			want:       sreconcile.ResultSuccess,
+			wantInputs: artifactSet{gitArtifact, bucketArtifact},
+		},
+		{
+			name: "Missing source makes SourceUnavailable=True",
+			objects: []client.Object{
+				&sourcev1.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "default"},
+					Status:     sourcev1.GitRepositoryStatus{Artifact: gitArtifact},
+				},
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1beta2.SourceUnavailableCondition, "NotFound", "could not get Bucket source 'bucket'"),
+			},
+		},
+		{
+			name: "Source without artifact makes SourceUnavailable=True",
+			objects: []client.Object{
+				&sourcev1.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "default"},
+				},
+				&sourcev1beta2.Bucket{
+					ObjectMeta: metav1.ObjectMeta{Name: "bucket", Namespace: "default"},
+					Status:     sourcev1beta2.BucketStatus{Artifact: bucketArtifact},
+				},
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1beta2.SourceUnavailableCondition, "NoArtifact", "no artifact available for GitRepository source 'git'"),
+			},
+		},
+		{
+			name: "Source artifact missing from storage makes SourceUnavailable=True",
+			objects: []client.Object{
+				&sourcev1.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "default"},
+					Status: sourcev1.GitRepositoryStatus{Artifact: &sourcev1.Artifact{
+						Path:     "composite/missing.tar.gz",
+						Revision: "main@sha1:5394cb7f48332b2de7c17dd8b8384bbc84b7e738",
+					}},
+				},
+				&sourcev1beta2.Bucket{
+					ObjectMeta: metav1.ObjectMeta{Name: "bucket", Namespace: "default"},
+					Status:     sourcev1beta2.BucketStatus{Artifact: bucketArtifact},
+				},
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1beta2.SourceUnavailableCondition, "NoArtifact", "no artifact available for GitRepository source 'git'"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1beta2.CompositeSource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test-compositesource",
+					Namespace:  "default",
+					Generation: 1,
+				},
+				Spec: sourcev1beta2.CompositeSourceSpec{
+					Sources:  sources,
+					Interval: metav1.Duration{Duration: interval},
+				},
+			}
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			r := &CompositeSourceReconciler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithObjects(append(tt.objects, obj)...).
+					WithStatusSubresource(&sourcev1beta2.CompositeSource{}).
+					Build(),
+				EventRecorder:     record.NewFakeRecorder(32),
+				Storage:           testStorage,
+				requeueDependency: 5 * time.Second,
+				patchOptions:      getPatchOptions(compositeSourceReadyCondition.Owned, "sc"),
+			}
+
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			var inputs artifactSet
+			got, err := r.reconcileSource(context.TODO(), sp, obj, &inputs, t.TempDir())
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+			g.Expect(inputs.Diff(tt.wantInputs)).To(BeFalse())
+		})
+	}
+}
+
+func TestCompositeSourceReconciler_reconcileArtifact(t *testing.T) {
+	g := NewWithT(t)
+
+	repositoryPath, err := filepath.Abs("testdata/git/repository")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	gitArtifact := &sourcev1.Artifact{
+		Path:     "composite/git.tar.gz",
+		Revision: "main@sha1:5394cb7f48332b2de7c17dd8b8384bbc84b7e738",
+	}
+	g.Expect(testStorage.MkdirAll(*gitArtifact)).To(Succeed())
+	g.Expect(testStorage.Archive(gitArtifact, repositoryPath, nil)).To(Succeed())
+	httpArtifact := &sourcev1.Artifact{
+		Path:     "composite/http.tar.gz",
+		Revision: "sha256:8fb62a09c9e48ace5463bf940dc15e85f525be4f230e223bbceef6e13024110c",
+	}
+	g.Expect(testStorage.MkdirAll(*httpArtifact)).To(Succeed())
+	g.Expect(testStorage.Archive(httpArtifact, repositoryPath, nil)).To(Succeed())
+	inputs := artifactSet{gitArtifact, httpArtifact}
+
+	tests := []struct {
+		name             string
+		sources          []sourcev1beta2.CompositeSourceInput
+		beforeFunc       func(obj *sourcev1beta2.CompositeSource)
+		wantFiles        []string
+		want             sreconcile.Result
+		wantErr          bool
+		assertConditions []metav1.Condition
+	}{
+		{
+			name: "Archiving combined artifact to storage makes ArtifactInStorage=True",
+			sources: []sourcev1beta2.CompositeSourceInput{
+				{Kind: sourcev1.GitRepositoryKind, Name: "git"},
+				{Kind: sourcev1beta2.HTTPSourceKind, Name: "http", ToPath: "./deploy/http"},
+			},
+			wantFiles: []string{"git/manifest.yaml", "deploy/http/manifest.yaml"},
+			want:      sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for revision 'sha256:"),
+			},
+		},
+		{
+			name: "Changed layout rebuilds up-to-date artifact",
+			sources: []sourcev1beta2.CompositeSourceInput{
+				{Kind: sourcev1.GitRepositoryKind, Name: "git", ToPath: "app"},
+				{Kind: sourcev1beta2.HTTPSourceKind, Name: "http"},
+			},
+			beforeFunc: func(obj *sourcev1beta2.CompositeSource) {
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: compositeSourceRevision(obj.Spec.Sources, inputs).String(),
+				}
+				obj.Status.SourceArtifacts = inputs
+				obj.Status.ObservedSources = []sourcev1beta2.CompositeSourceInput{
+					{Kind: sourcev1.GitRepositoryKind, Name: "git"},
+					{Kind: sourcev1beta2.HTTPSourceKind, Name: "http"},
+				}
+				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", "foo")
+			},
+			wantFiles: []string{"app/manifest.yaml", "http/manifest.yaml"},
+			want:      sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for revision 'sha256:"),
+			},
+		},
+		{
+			name: "Up-to-date artifact is not rebuilt",
+			sources: []sourcev1beta2.CompositeSourceInput{
+				{Kind: sourcev1.GitRepositoryKind, Name: "git"},
+				{Kind: sourcev1beta2.HTTPSourceKind, Name: "http"},
+			},
+			beforeFunc: func(obj *sourcev1beta2.CompositeSource) {
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: compositeSourceRevision(obj.Spec.Sources, inputs).String(),
+				}
+				obj.Status.SourceArtifacts = inputs
+				obj.Status.ObservedSources = obj.Spec.Sources
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for revision 'sha256:"),
+			},
+		},
+		{
+			name: "Invalid FromPath makes StorageOperationFailed=True",
+			sources: []sourcev1beta2.CompositeSourceInput{
+				{Kind: sourcev1.GitRepositoryKind, Name: "git", FromPath: "../../../path"},
+				{Kind: sourcev1beta2.HTTPSourceKind, Name: "http"},
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.StorageOperationFailedCondition, "CopyFailure", "unpack/path: no such file or directory"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &CompositeSourceReconciler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithStatusSubresource(&sourcev1beta2.CompositeSource{}).
+					Build(),
+				EventRecorder: record.NewFakeRecorder(32),
+				Storage:       testStorage,
+				patchOptions:  getPatchOptions(compositeSourceReadyCondition.Owned, "sc"),
+			}
+
+			obj := &sourcev1beta2.CompositeSource{
+				TypeMeta: metav1.TypeMeta{
+					Kind: sourcev1beta2.CompositeSourceKind,
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-compositesource-",
+					Generation:   1,
+					Namespace:    "default",
+				},
+				Spec: sourcev1beta2.CompositeSourceSpec{
+					Sources:  tt.sources,
+					Interval: metav1.Duration{Duration: interval},
+				},
+			}
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			in := inputs
+			got, err := r.reconcileArtifact(context.TODO(), sp, obj, &in, t.TempDir())
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+
+			if len(tt.wantFiles) > 0 {
+				g.Expect(obj.GetArtifact()).ToNot(BeNil())
+				g.Expect(obj.GetArtifact().Revision).To(Equal(compositeSourceRevision(tt.sources, inputs).String()))
+				g.Expect(obj.Status.URL).ToNot(BeEmpty())
+				g.Expect(obj.Status.SourceArtifacts).To(HaveLen(len(inputs)))
+				g.Expect(obj.Status.ObservedSources).To(Equal(tt.sources))
+
+				dir := filepath.Join(t.TempDir(), "artifact")
+				g.Expect(testStorage.CopyToPath(obj.GetArtifact(), "", dir)).To(Succeed())
+				for _, f := range tt.wantFiles {
+					g.Expect(filepath.Join(dir, f)).To(BeARegularFile())
+				}
+				// The .sourceignore of the sources excludes foo.txt.
+				g.Expect(filepath.Join(dir, "git", "foo.txt")).ToNot(BeAnExistingFile())
+			}
+		})
+	}
+}
+
+func Test_compositeSourceRevision(t *testing.T) {
+	g := NewWithT(t)
+
+	a := &sourcev1.Artifact{Revision: "main@sha1:5394cb7f48332b2de7c17dd8b8384bbc84b7e738"}
+	b := &sourcev1.Artifact{Revision: "sha256:8fb62a09c9e48ace5463bf940dc15e85f525be4f230e223bbceef6e13024110c"}
+	sources := []sourcev1beta2.CompositeSourceInput{
+		{Kind: sourcev1.GitRepositoryKind, Name: "a"},
+		{Kind: sourcev1beta2.BucketKind, Name: "b"},
+	}
+
+	rev := compositeSourceRevision(sources, artifactSet{a, b})
+	g.Expect(rev.Validate()).To(Succeed())
+	g.Expect(compositeSourceRevision(sources, artifactSet{a, b})).To(Equal(rev))
+
+	// The revision changes with any of the input revisions.
+	c := &sourcev1.Artifact{Revision: "main@sha1:a0e3b1a8f0b4e4a0d8bb8c1dbb4b1d3a1c8a5d7e"}
+	g.Expect(compositeSourceRevision(sources, artifactSet{c, b})).ToNot(Equal(rev))
+
+	// The revision changes with the referenced sources.
+	reordered := []sourcev1beta2.CompositeSourceInput{sources[1], sources[0]}
+	g.Expect(compositeSourceRevision(reordered, artifactSet{b, a})).ToNot(Equal(rev))
+
+	// The revision does not change with the layout.
+	moved := []sourcev1beta2.CompositeSourceInput{
+		{Kind: sourcev1.GitRepositoryKind, Name: "a", ToPath: "app"},
+		{Kind: sourcev1beta2.BucketKind, Name: "b", FromPath: "config"},
+	}
+	g.Expect(compositeSourceRevision(moved, artifactSet{a, b})).To(Equal(rev))
+}
@@ -0,0 +1,824 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kuberecorder "k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	helper "github.com/fluxcd/pkg/runtime/controller"
+	"github.com/fluxcd/pkg/runtime/jitter"
+	"github.com/fluxcd/pkg/runtime/patch"
+	"github.com/fluxcd/pkg/runtime/predicates"
+	rreconcile "github.com/fluxcd/pkg/runtime/reconcile"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	intdigest "github.com/fluxcd/source-controller/internal/digest"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/features"
+	"github.com/fluxcd/source-controller/internal/impersonation"
+	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
+)
+
+const (
+	configMapKind = "ConfigMap"
+	secretKind    = "Secret"
+)
+
+// configSourceReadyCondition contains the information required to
+// summarize a v1beta2.ConfigSource Ready Condition.
+var configSourceReadyCondition = summarize.Conditions{
+	Target: meta.ReadyCondition,
+	Owned: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.RevisionHeldCondition,
+		sourcev1.PendingApprovalCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1.FetchFailedCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		sourcev1.ArtifactInStorageCondition,
+		meta.ReadyCondition,
+		meta.ReconcilingCondition,
+		meta.StalledCondition,
+	},
+	Summarize: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1.FetchFailedCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		sourcev1.ArtifactInStorageCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+	NegativePolarity: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1.FetchFailedCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+}
+
+// configSourceFailConditions contains the conditions that represent a
+// failure.
+var configSourceFailConditions = []string{
+	sourcev1.FetchFailedCondition,
+	sourcev1.StorageOperationFailedCondition,
+}
+
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=configsources,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=configsources/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=configsources/finalizers,verbs=get;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// ConfigSourceReconciler reconciles a v1beta2.ConfigSource object.
+type ConfigSourceReconciler struct {
+	client.Client
+	kuberecorder.EventRecorder
+	helper.Metrics
+
+	Storage        *Storage
+	ControllerName string
+
+	// Impersonator impersonates the ServiceAccount of the object to read its
+	// Secrets in multi-tenant lockdown mode. It is disabled when nil.
+	Impersonator *impersonation.Impersonator
+
+	allowSecrets      bool
+	requeueDependency time.Duration
+	patchOptions      []patch.Option
+}
+
+type ConfigSourceReconcilerOptions struct {
+	// AllowSecrets allows ConfigSources to select Secrets.
+	AllowSecrets              bool
+	DependencyRequeueInterval time.Duration
+	RateLimiter               ratelimiter.RateLimiter
+}
+
+// configSourceContent holds the files collected for a v1beta2.ConfigSource.
+type configSourceContent struct {
+	// Revision is the digest of the files.
+	Revision string
+	// Files maps the relative paths of the files in the Artifact to their
+	// contents.
+	Files map[string][]byte
+}
+
+// configSourceReconcileFunc is the function type for all the
+// v1beta2.ConfigSource (sub)reconcile functions. The type implementations
+// are grouped and executed serially to perform the complete reconcile of the
+// object.
+type configSourceReconcileFunc func(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.ConfigSource, content *configSourceContent, dir string) (sreconcile.Result, error)
+
+func (r *ConfigSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return r.SetupWithManagerAndOptions(mgr, ConfigSourceReconcilerOptions{})
+}
+
+func (r *ConfigSourceReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts ConfigSourceReconcilerOptions) error {
+	r.patchOptions = getPatchOptions(configSourceReadyCondition.Owned, r.ControllerName)
+	r.requeueDependency = opts.DependencyRequeueInterval
+	r.allowSecrets = opts.AllowSecrets
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&sourcev1beta2.ConfigSource{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+				intpredicates.ApprovedRevisionPredicate{}),
+		)).
+		WatchesMetadata(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForObjectChange(configMapKind)),
+		)
+	if r.allowSecrets {
+		b = b.WatchesMetadata(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForObjectChange(secretKind)),
+		)
+	}
+	return b.WithOptions(controller.Options{
+		RateLimiter: opts.RateLimiter,
+	}).Complete(r)
+}
+
+func (r *ConfigSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
+	start := time.Now()
+	log := ctrl.LoggerFrom(ctx)
+
+	// Fetch the ConfigSource
+	obj := &sourcev1beta2.ConfigSource{}
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Initialize the patch helper with the current version of the object.
+	serialPatcher := patch.NewSerialPatcher(obj, r.Client)
+
+	// recResult stores the abstracted reconcile result.
+	var recResult sreconcile.Result
+
+	// Always attempt to patch the object and status after each reconciliation
+	// NOTE: The final runtime result and error are set in this block.
+	defer func() {
+		summarizeHelper := summarize.NewHelper(r.EventRecorder, serialPatcher)
+		summarizeOpts := []summarize.Option{
+			summarize.WithConditions(configSourceReadyCondition),
+			summarize.WithReconcileResult(recResult),
+			summarize.WithReconcileError(retErr),
+			summarize.WithIgnoreNotFound(),
+			summarize.WithProcessors(
+				summarize.ErrorActionHandler,
+				summarize.RecordReconcileReq,
+			),
+			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
+				RequeueAfter: holdRequeueAfter(obj, obj.Spec.Schedule,
+					jitter.JitteredIntervalDuration(obj.GetRequeueAfter()), time.Now()),
+			}),
+			summarize.WithPatchFieldOwner(r.ControllerName),
+		}
+		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
+
+		// Always record suspend, readiness and duration metrics.
+		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
+		r.Metrics.RecordReadiness(ctx, obj)
+		r.Metrics.RecordDuration(ctx, obj, start)
+	}()
+
+	// Examine if the object is under deletion.
+	if !obj.ObjectMeta.DeletionTimestamp.IsZero() {
+		recResult, retErr = r.reconcileDelete(ctx, obj)
+		return
+	}
+
+	// Add finalizer first if not exist to avoid the race condition between init
+	// and delete.
+	// Note: Finalizers in general can only be added when the deletionTimestamp
+	// is not set.
+	if !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
+		controllerutil.AddFinalizer(obj, sourcev1.SourceFinalizer)
+		recResult = sreconcile.ResultRequeue
+		return
+	}
+
+	// Return if the object is suspended.
+	if obj.Spec.Suspend {
+		log.Info("reconciliation is suspended for this object")
+		recResult, retErr = sreconcile.ResultEmpty, nil
+		return
+	}
+
+	// Wait for the dependencies of the object to be ready.
+	if err := checkSourceDependencies(ctx, r.Client, obj, obj.Spec.DependsOn, r.requeueDependency); err != nil {
+		recResult, retErr = sreconcile.ResultEmpty, err
+		return
+	}
+
+	// Reconcile actual object
+	reconcilers := []configSourceReconcileFunc{
+		r.reconcileStorage,
+		r.reconcileSource,
+		r.reconcileArtifact,
+	}
+	recResult, retErr = r.reconcile(ctx, serialPatcher, obj, reconcilers)
+	return
+}
+
+// reconcile iterates through the configSourceReconcileFunc tasks for the
+// object. It returns early on the first call that returns
+// reconcile.ResultRequeue, or produces an error.
+func (r *ConfigSourceReconciler) reconcile(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.ConfigSource, reconcilers []configSourceReconcileFunc) (sreconcile.Result, error) {
+	oldObj := obj.DeepCopy()
+
+	rreconcile.ProgressiveStatus(false, obj, meta.ProgressingReason, "reconciliation in progress")
+
+	var recAtVal string
+	if v, ok := meta.ReconcileAnnotationValue(obj.GetAnnotations()); ok {
+		recAtVal = v
+	}
+
+	// Persist reconciling if generation differs or reconciliation is requested.
+	switch {
+	case obj.Generation != obj.Status.ObservedGeneration:
+		rreconcile.ProgressiveStatus(false, obj, meta.ProgressingReason,
+			"processing object: new generation %d -> %d", obj.Status.ObservedGeneration, obj.Generation)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	case recAtVal != obj.Status.GetLastHandledReconcileRequest():
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	}
+
+	// Create temp working dir
+	tmpDir, err := os.MkdirTemp("", fmt.Sprintf("%s-%s-%s-", obj.Kind, obj.Namespace, obj.Name))
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to create temporary working directory: %w", err),
+			sourcev1.DirCreationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	defer func() {
+		if err = os.RemoveAll(tmpDir); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to remove temporary working directory")
+		}
+	}()
+	conditions.Delete(obj, sourcev1.StorageOperationFailedCondition)
+
+	// Run the sub-reconcilers and build the result of reconciliation.
+	var (
+		res     sreconcile.Result
+		resErr  error
+		content = &configSourceContent{}
+	)
+
+	for _, rec := range reconcilers {
+		recResult, err := rec(ctx, sp, obj, content, tmpDir)
+		// Exit immediately on ResultRequeue.
+		if recResult == sreconcile.ResultRequeue {
+			return sreconcile.ResultRequeue, nil
+		}
+		// If an error is received, prioritize the returned results because an
+		// error also means immediate requeue.
+		if err != nil {
+			resErr = err
+			res = recResult
+			break
+		}
+		// Prioritize requeue request in the result.
+		res = sreconcile.LowestRequeuingResult(res, recResult)
+	}
+
+	r.notify(ctx, oldObj, obj, res, resErr)
+
+	return res, resErr
+}
+
+// notify emits notification related to the reconciliation.
+func (r *ConfigSourceReconciler) notify(ctx context.Context, oldObj, newObj *sourcev1beta2.ConfigSource, res sreconcile.Result, resErr error) {
+	// Notify successful reconciliation for new artifact and recovery from any
+	// failure.
+	if resErr == nil && res == sreconcile.ResultSuccess && newObj.Status.Artifact != nil {
+		annotations := map[string]string{
+			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaRevisionKey): newObj.Status.Artifact.Revision,
+			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaDigestKey):   newObj.Status.Artifact.Digest,
+		}
+
+		message := fmt.Sprintf("stored artifact with revision '%s'", newObj.Status.Artifact.Revision)
+
+		// Notify on new artifact and failure recovery.
+		if !oldObj.GetArtifact().HasDigest(newObj.GetArtifact().Digest) {
+			r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
+				"NewArtifact", message)
+			ctrl.LoggerFrom(ctx).Info(message)
+		} else {
+			if sreconcile.FailureRecovery(oldObj, newObj, configSourceFailConditions) {
+				r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
+					meta.SucceededReason, message)
+				ctrl.LoggerFrom(ctx).Info(message)
+			}
+		}
+	}
+}
+
+// reconcileStorage ensures the current state of the storage matches the
+// desired and previously observed state.
+//
+// The garbage collection is executed based on the flag configured settings and
+// may remove files that are beyond their TTL or the maximum number of files
+// to survive a collection cycle.
+// If the Artifact in the Status of the object disappeared from the Storage,
+// it is removed from the object.
+// If the object does not have an Artifact in its Status, a Reconciling
+// condition is added.
+// The hostname of any URL in the Status of the object are updated, to ensure
+// they match the Storage server hostname of current runtime.
+func (r *ConfigSourceReconciler) reconcileStorage(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.ConfigSource, _ *configSourceContent, _ string) (sreconcile.Result, error) {
+	// Garbage collect previous advertised artifact(s) from storage
+	_ = r.garbageCollect(ctx, obj)
+
+	var artifactMissing bool
+	if artifact := obj.GetArtifact(); artifact != nil {
+		// Determine if the advertised artifact is still in storage
+		if !r.Storage.ArtifactExist(*artifact) {
+			artifactMissing = true
+		}
+
+		// If the artifact is in storage, verify if the advertised digest still
+		// matches the actual artifact
+		if !artifactMissing {
+			if err := r.Storage.VerifyArtifact(*artifact); err != nil {
+				r.Eventf(obj, corev1.EventTypeWarning, "ArtifactVerificationFailed", "failed to verify integrity of artifact: %s", err.Error())
+
+				if err = r.Storage.Remove(*artifact); err != nil {
+					return sreconcile.ResultEmpty, fmt.Errorf("failed to remove artifact after digest mismatch: %w", err)
+				}
+
+				artifactMissing = true
+			}
+		}
+
+		// If the artifact is missing, remove it from the object
+		if artifactMissing {
+			obj.Status.Artifact = nil
+			obj.Status.URL = ""
+		}
+	}
+
+	// Record that we do not have an artifact
+	if obj.GetArtifact() == nil {
+		msg := "building artifact"
+		if artifactMissing {
+			msg += ": disappeared from storage"
+		}
+		rreconcile.ProgressiveStatus(true, obj, meta.ProgressingReason, msg)
+		conditions.Delete(obj, sourcev1.ArtifactInStorageCondition)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// Always update URLs to ensure hostname is up-to-date
+	r.Storage.SetArtifactURL(obj.GetArtifact())
+	obj.Status.URL = r.Storage.SetHostname(obj.Status.URL)
+
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileSource collects the data of the ConfigMaps and Secrets selected
+// by the object into the given configSourceContent, and calculates the
+// revision.
+//
+// If an object can not be selected, the FetchFailed condition is set to True
+// and an error is returned. Invalid selectors, and the selection of Secrets
+// while this is not allowed, stall the object. On success, the FetchFailed
+// condition is removed, and the ArtifactOutdated condition is set to True if
+// the revision differs from the current Artifact.
+func (r *ConfigSourceReconciler) reconcileSource(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.ConfigSource, content *configSourceContent, _ string) (sreconcile.Result, error) {
+	secrets, err := secretClient(obj, r.Client, r.Impersonator, obj.Spec.ServiceAccountName)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
+	files := make(map[string][]byte)
+	for _, sel := range obj.Spec.Objects {
+		if (sel.Name == "") == (sel.LabelSelector == nil) {
+			e := serror.NewStalling(
+				fmt.Errorf("invalid %s selection: exactly one of name or labelSelector must be set", sel.Kind),
+				"InvalidSelector",
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		if sel.Kind == secretKind && !r.allowSecrets {
+			e := serror.NewStalling(
+				fmt.Errorf("selecting Secrets is not allowed, the %s feature gate must be enabled", features.ConfigSourceSecrets),
+				"SecretsNotAllowed",
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+
+		if sel.LabelSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(sel.LabelSelector); err != nil {
+				e := serror.NewStalling(
+					fmt.Errorf("invalid %s label selector: %w", sel.Kind, err),
+					"InvalidSelector",
+				)
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+				return sreconcile.ResultEmpty, e
+			}
+		}
+
+		objects, err := r.selectObjects(ctx, secrets, obj.GetNamespace(), sel)
+		if err != nil {
+			e := serror.NewGeneric(err, sourcev1.ReadOperationFailedReason)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		for name, data := range objects {
+			for key, value := range data {
+				files[configSourceFilePath(sel.ToPath, name, key)] = value
+			}
+		}
+	}
+	content.Files = files
+	content.Revision = configSourceRevision(files).String()
+
+	// We now know all the objects could be selected.
+	conditions.Delete(obj, sourcev1.FetchFailedCondition)
+
+	// Mark observations about the revision on the object
+	if !obj.GetArtifact().HasRevision(content.Revision) {
+		message := fmt.Sprintf("new revision '%s' for %d file(s)", content.Revision, len(files))
+		if obj.GetArtifact() != nil {
+			conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", message)
+		}
+		rreconcile.ProgressiveStatus(true, obj, meta.ProgressingReason, "building artifact: %s", message)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	}
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileArtifact archives a new Artifact to the Storage, if the current
+// (Status) data on the object does not match the given configSourceContent.
+//
+// The files of the configSourceContent are written to dir before the
+// directory is archived. On a successful archive, the Artifact in the Status
+// of the object is set. If the Artifact is up-to-date, the ArtifactInStorage
+// condition is set to True.
+func (r *ConfigSourceReconciler) reconcileArtifact(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.ConfigSource, content *configSourceContent, dir string) (sreconcile.Result, error) {
+	revision := digest.Digest(content.Revision)
+
+	// Create artifact
+	artifact := r.Storage.NewArtifactFor(obj.Kind, obj, content.Revision, fmt.Sprintf("%s.tar.gz", revision.Encoded()))
+
+	// Set the ArtifactInStorageCondition if there's no drift.
+	defer func() {
+		if obj.GetArtifact().HasRevision(artifact.Revision) {
+			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+			conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+			obj.Status.PendingArtifact = nil
+			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, meta.SucceededReason,
+				"stored artifact for revision '%s'", artifact.Revision)
+		}
+	}()
+
+	// Hold the new revision while the schedule is closed
+	if held, err := holdRevision(obj, obj.Spec.Schedule, obj.GetArtifact(), artifact.Revision, time.Now()); err != nil {
+		return sreconcile.ResultEmpty, err
+	} else if held {
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// The artifact is up-to-date
+	if obj.GetArtifact().HasRevision(artifact.Revision) {
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason, "artifact up-to-date with revision: '%s'", artifact.Revision)
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// Write the files to the directory
+	for p, data := range content.Files {
+		fp := filepath.Join(dir, p)
+		if err := os.MkdirAll(filepath.Dir(fp), 0o750); err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to create directory for '%s': %w", p, err),
+				sourcev1.DirCreationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		if err := os.WriteFile(fp, data, 0o640); err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to write '%s': %w", p, err),
+				"WriteFailure",
+			)
+			conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+	}
+
+	// Ensure artifact directory exists and acquire lock
+	if err := r.Storage.MkdirAll(artifact); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to create artifact directory: %w", err),
+			sourcev1.DirCreationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	unlock, err := r.Storage.Lock(artifact)
+	if err != nil {
+		return sreconcile.ResultEmpty, serror.NewGeneric(
+			fmt.Errorf("failed to acquire lock for artifact: %w", err),
+			meta.FailedReason,
+		)
+	}
+	defer unlock()
+
+	// Archive directory to storage, without excluding any of the files
+	if err := r.Storage.Archive(&artifact, dir, func(string, os.FileInfo) bool { return false }); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("unable to archive artifact to storage: %s", err),
+			sourcev1.ArchiveOperationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	// Keep serving the current artifact until the new revision is approved
+	if awaitArtifactApproval(obj, obj.Spec.Approval, r.Storage, obj.GetArtifact(), &artifact) {
+		obj.Status.PendingArtifact = artifact.DeepCopy()
+		return sreconcile.ResultSuccess, nil
+	}
+	obj.Status.PendingArtifact = nil
+
+	// Record it on the object
+	obj.Status.Artifact = artifact.DeepCopy()
+
+	// Update symlink on a "best effort" basis
+	url, err := r.Storage.Symlink(artifact, "latest.tar.gz")
+	if err != nil {
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.SymlinkUpdateFailedReason,
+			"failed to update status URL symlink: %s", err)
+	}
+	if url != "" {
+		obj.Status.URL = url
+	}
+	conditions.Delete(obj, sourcev1.StorageOperationFailedCondition)
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileDelete handles the deletion of the object.
+// It first garbage collects all Artifacts for the object from the Storage.
+// Removing the finalizer from the object if successful.
+func (r *ConfigSourceReconciler) reconcileDelete(ctx context.Context, obj *sourcev1beta2.ConfigSource) (sreconcile.Result, error) {
+	// Garbage collect the resource's artifacts
+	if err := r.garbageCollect(ctx, obj); err != nil {
+		// Return the error so we retry the failed garbage collection
+		return sreconcile.ResultEmpty, err
+	}
+
+	// Remove our finalizer from the list
+	controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+
+	// Stop reconciliation as the object is being deleted
+	return sreconcile.ResultEmpty, nil
+}
+
+// garbageCollect performs a garbage collection for the given object.
+//
+// It removes all but the current Artifact from the Storage, unless the
+// deletion timestamp on the object is set. Which will result in the
+// removal of all Artifacts for the objects.
+func (r *ConfigSourceReconciler) garbageCollect(ctx context.Context, obj *sourcev1beta2.ConfigSource) error {
+	if !obj.DeletionTimestamp.IsZero() {
+		if deleted, err := r.Storage.RemoveAll(r.Storage.NewArtifactFor(obj.Kind, obj.GetObjectMeta(), "", "*")); err != nil {
+			return serror.NewGeneric(
+				fmt.Errorf("garbage collection for deleted resource failed: %s", err),
+				"GarbageCollectionFailed",
+			)
+		} else if deleted != "" {
+			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "GarbageCollectionSucceeded",
+				"garbage collected artifacts for deleted resource")
+		}
+		obj.Status.Artifact = nil
+		obj.Status.PendingArtifact = nil
+		return nil
+	}
+	if obj.GetArtifact() != nil {
+		var keep []sourcev1.Artifact
+		if pending := obj.Status.PendingArtifact; pending != nil {
+			keep = append(keep, *pending)
+		}
+		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5, keep...)
+		if err != nil {
+			return serror.NewGeneric(
+				fmt.Errorf("garbage collection of artifacts failed: %w", err),
+				"GarbageCollectionFailed",
+			)
+		}
+		if len(delFiles) > 0 {
+			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "GarbageCollectionSucceeded",
+				fmt.Sprintf("garbage collected %d artifacts", len(delFiles)))
+			return nil
+		}
+	}
+	return nil
+}
+
+// selectObjects returns the data of the ConfigMaps or Secrets selected by
+// the given v1beta2.ConfigSourceObject, indexed by object name. Secrets are
+// read with the given secrets client.
+func (r *ConfigSourceReconciler) selectObjects(ctx context.Context, secrets client.Reader, namespace string,
+	sel sourcev1beta2.ConfigSourceObject) (map[string]map[string][]byte, error) {
+	objects := make(map[string]map[string][]byte)
+
+	if sel.Name != "" {
+		key := types.NamespacedName{Namespace: namespace, Name: sel.Name}
+		switch sel.Kind {
+		case configMapKind:
+			var cm corev1.ConfigMap
+			if err := r.Get(ctx, key, &cm); err != nil {
+				return nil, fmt.Errorf("failed to get ConfigMap '%s': %w", key, err)
+			}
+			objects[cm.Name] = configMapData(&cm)
+		case secretKind:
+			var secret corev1.Secret
+			if err := secrets.Get(ctx, key, &secret); err != nil {
+				return nil, fmt.Errorf("failed to get Secret '%s': %w", key, err)
+			}
+			objects[secret.Name] = secret.Data
+		}
+		return objects, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(sel.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s label selector: %w", sel.Kind, err)
+	}
+	opts := []client.ListOption{
+		client.InNamespace(namespace),
+		client.MatchingLabelsSelector{Selector: selector},
+	}
+	switch sel.Kind {
+	case configMapKind:
+		var list corev1.ConfigMapList
+		if err := r.List(ctx, &list, opts...); err != nil {
+			return nil, fmt.Errorf("failed to list ConfigMaps: %w", err)
+		}
+		for i := range list.Items {
+			objects[list.Items[i].Name] = configMapData(&list.Items[i])
+		}
+	case secretKind:
+		var list corev1.SecretList
+		if err := secrets.List(ctx, &list, opts...); err != nil {
+			return nil, fmt.Errorf("failed to list Secrets: %w", err)
+		}
+		for i := range list.Items {
+			objects[list.Items[i].Name] = list.Items[i].Data
+		}
+	}
+	return objects, nil
+}
+
+// requestsForObjectChange returns a handler.MapFunc which enqueues the
+// ConfigSources in the namespace of a changed object of the given kind, which
+// select the object by name or label.
+func (r *ConfigSourceReconciler) requestsForObjectChange(kind string) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []reconcile.Request {
+		var list sourcev1beta2.ConfigSourceList
+		if err := r.List(ctx, &list, client.InNamespace(o.GetNamespace())); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to list ConfigSources for object change")
+			return nil
+		}
+
+		var reqs []reconcile.Request
+		for i, v := range list.Items {
+			for _, sel := range v.Spec.Objects {
+				if sel.Kind != kind {
+					continue
+				}
+				if configSourceObjectSelects(sel, o) {
+					reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+					break
+				}
+			}
+		}
+		return reqs
+	}
+}
+
+// configSourceObjectSelects returns true if the given
+// v1beta2.ConfigSourceObject selects the object by name or label.
+func configSourceObjectSelects(sel sourcev1beta2.ConfigSourceObject, o client.Object) bool {
+	if sel.Name != "" {
+		return sel.Name == o.GetName()
+	}
+	selector, err := metav1.LabelSelectorAsSelector(sel.LabelSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(o.GetLabels()))
+}
+
+// configMapData returns the data and binary data of the given ConfigMap.
+func configMapData(cm *corev1.ConfigMap) map[string][]byte {
+	data := make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+	for k, v := range cm.Data {
+		data[k] = []byte(v)
+	}
+	for k, v := range cm.BinaryData {
+		data[k] = v
+	}
+	return data
+}
+
+// configSourceFilePath returns the path in the Artifact of the given key of
+// the named object. The path is confined to the root of the Artifact.
+func configSourceFilePath(toPath, name, key string) string {
+	return strings.TrimPrefix(filepath.Join("/", toPath, name, key), "/")
+}
+
+// configSourceRevision returns the revision of a v1beta2.ConfigSource for
+// the given files. The revision is the digest of the paths and contents of
+// the files, in the lexical order of the paths.
+func configSourceRevision(files map[string][]byte) digest.Digest {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	d := intdigest.Canonical.Digester()
+	for _, p := range paths {
+		_, _ = fmt.Fprintf(d.Hash(), "%s\x00%d\x00", p, len(files[p]))
+		_, _ = d.Hash().Write(files[p])
+	}
+	return d.Digest()
+}
+
+// eventLogf records events, and logs at the same time.
+//
+// This log is different from the debug log in the EventRecorder, in the sense
+// that this is a simple log. While the debug log contains complete details
+// about the event.
+func (r *ConfigSourceReconciler) eventLogf(ctx context.Context, obj runtime.Object, eventType string, reason string, messageFmt string, args ...interface{}) {
+	msg := fmt.Sprintf(messageFmt, args...)
+	// Log and emit event.
+	if eventType == corev1.EventTypeWarning {
+		ctrl.LoggerFrom(ctx).Error(errors.New(reason), msg)
+	} else {
+		ctrl.LoggerFrom(ctx).Info(msg)
+	}
+	r.Eventf(obj, eventType, reason, msg)
+}
@@ -0,0 +1,455 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/fluxcd/pkg/runtime/patch"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+)
+
+func TestConfigSourceReconciler_reconcileSource(t *testing.T) {
+	objects := []client.Object{
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app",
+				Namespace: "default",
+				Labels:    map[string]string{"app": "podinfo"},
+			},
+			Data:       map[string]string{"values.yaml": "replicas: 2"},
+			BinaryData: map[string][]byte{"logo.png": {0x89, 0x50}},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other",
+				Namespace: "default",
+			},
+			Data: map[string]string{"other.yaml": "foo: bar"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "creds",
+				Namespace: "default",
+				Labels:    map[string]string{"app": "podinfo"},
+			},
+			Data: map[string][]byte{"token": []byte("secret")},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		selection        []sourcev1beta2.ConfigSourceObject
+		allowSecrets     bool
+		beforeFunc       func(obj *sourcev1beta2.ConfigSource)
+		want             sreconcile.Result
+		wantErr          bool
+		wantFiles        map[string]string
+		assertConditions []metav1.Condition
+	}{
+		{
+			name: "ConfigMap by name makes Reconciling=True",
+			selection: []sourcev1beta2.ConfigSourceObject{
+				{Kind: "ConfigMap", Name: "app", ToPath: "./config"},
+			},
+			want: sreconcile.ResultSuccess,
+			wantFiles: map[string]string{
+				"config/app/values.yaml": "replicas: 2",
+				"config/app/logo.png":    "\x89\x50",
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+			},
+		},
+		{
+			name: "Objects by label",
+			selection: []sourcev1beta2.ConfigSourceObject{
+				{Kind: "ConfigMap", LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "podinfo"}}},
+				{Kind: "Secret", LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "podinfo"}}, ToPath: "secrets"},
+			},
+			allowSecrets: true,
+			want:         sreconcile.ResultSuccess,
+			wantFiles: map[string]string{
+				"app/values.yaml":     "replicas: 2",
+				"app/logo.png":        "\x89\x50",
+				"secrets/creds/token": "secret",
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+			},
+		},
+		{
+			name: "ToPath is confined to the artifact",
+			selection: []sourcev1beta2.ConfigSourceObject{
+				{Kind: "ConfigMap", Name: "other", ToPath: "../../etc"},
+			},
+			want: sreconcile.ResultSuccess,
+			wantFiles: map[string]string{
+				"etc/other/other.yaml": "foo: bar",
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+			},
+		},
+		{
+			name: "Changed data makes ArtifactOutdated=True",
+			selection: []sourcev1beta2.ConfigSourceObject{
+				{Kind: "ConfigMap", Name: "other"},
+			},
+			beforeFunc: func(obj *sourcev1beta2.ConfigSource) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "sha256:old"}
+			},
+			want: sreconcile.ResultSuccess,
+			wantFiles: map[string]string{
+				"other/other.yaml": "foo: bar",
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new revision 'sha256:"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new revision 'sha256:"),
+			},
+		},
+		{
+			name: "Secrets are not allowed by default",
+			selection: []sourcev1beta2.ConfigSourceObject{
+				{Kind: "Secret", Name: "creds"},
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, "SecretsNotAllowed", "the ConfigSourceSecrets feature gate must be enabled"),
+			},
+		},
+		{
+			name: "Name and label selector makes FetchFailed=True",
+			selection: []sourcev1beta2.ConfigSourceObject{
+				{Kind: "ConfigMap", Name: "app", LabelSelector: &metav1.LabelSelector{}},
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, "InvalidSelector", "exactly one of name or labelSelector must be set"),
+			},
+		},
+		{
+			name: "Missing object makes FetchFailed=True",
+			selection: []sourcev1beta2.ConfigSourceObject{
+				{Kind: "ConfigMap", Name: "missing"},
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.ReadOperationFailedReason, "failed to get ConfigMap 'default/missing'"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1beta2.ConfigSource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test-configsource",
+					Namespace:  "default",
+					Generation: 1,
+				},
+				Spec: sourcev1beta2.ConfigSourceSpec{
+					Objects:  tt.selection,
+					Interval: metav1.Duration{Duration: interval},
+				},
+			}
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			r := &ConfigSourceReconciler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithObjects(append(objects, obj)...).
+					WithStatusSubresource(&sourcev1beta2.ConfigSource{}).
+					Build(),
+				EventRecorder: record.NewFakeRecorder(32),
+				Storage:       testStorage,
+				allowSecrets:  tt.allowSecrets,
+				patchOptions:  getPatchOptions(configSourceReadyCondition.Owned, "sc"),
+			}
+
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			content := &configSourceContent{}
+			got, err := r.reconcileSource(context.TODO(), sp, obj, content, t.TempDir())
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+
+			if tt.wantFiles != nil {
+				files := make(map[string]string, len(content.Files))
+				for p, data := range content.Files {
+					files[p] = string(data)
+				}
+				g.Expect(files).To(Equal(tt.wantFiles))
+				g.Expect(content.Revision).To(Equal(configSourceRevision(content.Files).String()))
+			}
+		})
+	}
+}
+
+func TestConfigSourceReconciler_reconcileArtifact(t *testing.T) {
+	files := map[string][]byte{
+		"app/values.yaml": []byte("replicas: 2"),
+		"app/.gitignore":  []byte("*.png"),
+	}
+	revision := configSourceRevision(files).String()
+
+	tests := []struct {
+		name             string
+		beforeFunc       func(obj *sourcev1beta2.ConfigSource)
+		wantArchive      bool
+		wantPending      bool
+		want             sreconcile.Result
+		assertConditions []metav1.Condition
+	}{
+		{
+			name:        "Archiving artifact to storage makes ArtifactInStorage=True",
+			wantArchive: true,
+			want:        sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for revision 'sha256:"),
+			},
+		},
+		{
+			name: "Up-to-date artifact is not rebuilt",
+			beforeFunc: func(obj *sourcev1beta2.ConfigSource) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: revision}
+				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", "foo")
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for revision 'sha256:"),
+			},
+		},
+		{
+			name: "New revision in deny window makes RevisionHeld=True",
+			beforeFunc: func(obj *sourcev1beta2.ConfigSource) {
+				obj.Spec.Schedule = &sourcev1.ReconcileSchedule{
+					DenyWindows: []sourcev1.ScheduleWindow{
+						{Cron: "* * * * *", Duration: metav1.Duration{Duration: time.Hour}},
+					},
+				}
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "sha256:old"}
+				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", "foo")
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.RevisionHeldCondition, sourcev1.ScheduleClosedReason, "new revision 'sha256:"),
+			},
+		},
+		{
+			name: "New revision awaiting approval makes PendingApproval=True",
+			beforeFunc: func(obj *sourcev1beta2.ConfigSource) {
+				obj.Spec.Approval = &sourcev1.RevisionApproval{Required: true}
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "sha256:old"}
+				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", "foo")
+			},
+			wantPending: true,
+			want:        sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.PendingApprovalCondition, sourcev1.AwaitingApprovalReason, "new revision 'sha256:"),
+			},
+		},
+		{
+			name: "Approved new revision makes ArtifactInStorage=True",
+			beforeFunc: func(obj *sourcev1beta2.ConfigSource) {
+				obj.Spec.Approval = &sourcev1.RevisionApproval{Required: true}
+				obj.SetAnnotations(map[string]string{sourcev1.ApprovedRevisionAnnotation: revision})
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "sha256:old"}
+				obj.Status.PendingArtifact = &sourcev1.Artifact{Revision: revision}
+				conditions.MarkTrue(obj, sourcev1.PendingApprovalCondition, sourcev1.AwaitingApprovalReason, "foo")
+			},
+			wantArchive: true,
+			want:        sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for revision 'sha256:"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &ConfigSourceReconciler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithStatusSubresource(&sourcev1beta2.ConfigSource{}).
+					Build(),
+				EventRecorder: record.NewFakeRecorder(32),
+				Storage:       testStorage,
+				patchOptions:  getPatchOptions(configSourceReadyCondition.Owned, "sc"),
+			}
+
+			obj := &sourcev1beta2.ConfigSource{
+				TypeMeta: metav1.TypeMeta{
+					Kind: sourcev1beta2.ConfigSourceKind,
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-configsource-",
+					Generation:   1,
+					Namespace:    "default",
+				},
+			}
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			content := &configSourceContent{Revision: revision, Files: files}
+			got, err := r.reconcileArtifact(context.TODO(), sp, obj, content, t.TempDir())
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+
+			if tt.wantPending {
+				g.Expect(obj.Status.PendingArtifact).ToNot(BeNil())
+				g.Expect(obj.Status.PendingArtifact.Revision).To(Equal(revision))
+				g.Expect(obj.GetArtifact().Revision).To(Equal("sha256:old"))
+			} else {
+				g.Expect(obj.Status.PendingArtifact).To(BeNil())
+			}
+
+			if tt.wantArchive {
+				g.Expect(obj.GetArtifact()).ToNot(BeNil())
+				g.Expect(obj.GetArtifact().Revision).To(Equal(revision))
+				g.Expect(obj.Status.URL).ToNot(BeEmpty())
+
+				// All files are included, regardless of ignore rules.
+				dir := filepath.Join(t.TempDir(), "artifact")
+				g.Expect(testStorage.CopyToPath(obj.GetArtifact(), "", dir)).To(Succeed())
+				for p, data := range files {
+					b, err := os.ReadFile(filepath.Join(dir, p))
+					g.Expect(err).ToNot(HaveOccurred())
+					g.Expect(b).To(Equal(data))
+				}
+			}
+		})
+	}
+}
+
+func Test_configSourceObjectSelects(t *testing.T) {
+	obj := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "app",
+			Labels: map[string]string{"app": "podinfo", "tier": "frontend"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		sel  sourcev1beta2.ConfigSourceObject
+		want bool
+	}{
+		{
+			name: "matching name",
+			sel:  sourcev1beta2.ConfigSourceObject{Name: "app"},
+			want: true,
+		},
+		{
+			name: "other name",
+			sel:  sourcev1beta2.ConfigSourceObject{Name: "other"},
+			want: false,
+		},
+		{
+			name: "matching labels",
+			sel: sourcev1beta2.ConfigSourceObject{LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "podinfo"},
+			}},
+			want: true,
+		},
+		{
+			name: "matching expression",
+			sel: sourcev1beta2.ConfigSourceObject{LabelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"frontend", "backend"}},
+				},
+			}},
+			want: true,
+		},
+		{
+			name: "other labels",
+			sel: sourcev1beta2.ConfigSourceObject{LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "other"},
+			}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(configSourceObjectSelects(tt.sel, obj)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_configSourceRevision(t *testing.T) {
+	g := NewWithT(t)
+
+	files := map[string][]byte{
+		"a/values.yaml": []byte("replicas: 2"),
+		"b/values.yaml": []byte("replicas: 3"),
+	}
+	rev := configSourceRevision(files)
+	g.Expect(rev.Validate()).To(Succeed())
+	g.Expect(configSourceRevision(files)).To(Equal(rev))
+
+	// The revision changes with the contents of a file.
+	g.Expect(configSourceRevision(map[string][]byte{
+		"a/values.yaml": []byte("replicas: 2"),
+		"b/values.yaml": []byte("replicas: 4"),
+	})).ToNot(Equal(rev))
+
+	// The revision changes with the path of a file.
+	g.Expect(configSourceRevision(map[string][]byte{
+		"a/values.yaml": []byte("replicas: 2"),
+		"c/values.yaml": []byte("replicas: 3"),
+	})).ToNot(Equal(rev))
+
+	// The boundaries between paths and contents are unambiguous.
+	g.Expect(configSourceRevision(map[string][]byte{"ab": []byte("c")})).
+		ToNot(Equal(configSourceRevision(map[string][]byte{"a": []byte("bc")})))
+}
@@ -59,6 +59,9 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	serror "github.com/fluxcd/source-controller/internal/error"
 	"github.com/fluxcd/source-controller/internal/features"
+	"github.com/fluxcd/source-controller/internal/hostlimit"
+	"github.com/fluxcd/source-controller/internal/impersonation"
+	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
 	"github.com/fluxcd/source-controller/internal/util"
@@ -69,6 +72,9 @@ import (
 var gitRepositoryReadyCondition = summarize.Conditions{
 	Target: meta.ReadyCondition,
 	Owned: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.RevisionHeldCondition,
+		sourcev1.PendingApprovalCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.IncludeUnavailableCondition,
@@ -80,6 +86,7 @@ var gitRepositoryReadyCondition = summarize.Conditions{
 		meta.StalledCondition,
 	},
 	Summarize: []string{
+		sourcev1.DependencyNotReadyCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.IncludeUnavailableCondition,
@@ -90,6 +97,7 @@ var gitRepositoryReadyCondition = summarize.Conditions{
 		meta.ReconcilingCondition,
 	},
 	NegativePolarity: []string{
+		sourcev1.DependencyNotReadyCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.IncludeUnavailableCondition,
@@ -129,6 +137,14 @@ type GitRepositoryReconciler struct {
 	Storage        *Storage
 	ControllerName string
 
+	// HostLimiter limits the concurrency and rate of remote operations per
+	// host. It is shared between reconcilers, and disabled when nil.
+	HostLimiter *hostlimit.Limiter
+
+	// Impersonator impersonates the ServiceAccount of the object to read its
+	// Secrets in multi-tenant lockdown mode. It is disabled when nil.
+	Impersonator *impersonation.Impersonator
+
 	requeueDependency time.Duration
 	features          map[string]bool
 
@@ -159,7 +175,8 @@ func (r *GitRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, o
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&sourcev1.GitRepository{}, builder.WithPredicates(
-			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+				intpredicates.ApprovedRevisionPredicate{}),
 		)).
 		WithOptions(controller.Options{
 			RateLimiter: opts.RateLimiter,
@@ -198,7 +215,8 @@ func (r *GitRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				summarize.RecordReconcileReq,
 			),
 			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
-				RequeueAfter: jitter.JitteredIntervalDuration(obj.GetRequeueAfter()),
+				RequeueAfter: holdRequeueAfter(obj, obj.Spec.Schedule,
+					jitter.JitteredIntervalDuration(obj.GetRequeueAfter()), time.Now()),
 			}),
 			summarize.WithPatchFieldOwner(r.ControllerName),
 		}
@@ -233,6 +251,12 @@ func (r *GitRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return
 	}
 
+	// Wait for the dependencies of the object to be ready.
+	if err := checkSourceDependencies(ctx, r.Client, obj, obj.Spec.DependsOn, r.requeueDependency); err != nil {
+		recResult, retErr = sreconcile.ResultEmpty, err
+		return
+	}
+
 	// Reconcile actual object
 	reconcilers := []gitRepositoryReconcileFunc{
 		r.reconcileStorage,
@@ -477,10 +501,14 @@ func (r *GitRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 		conditions.Delete(obj, sourcev1.SourceVerifiedCondition)
 	}
 
+	secrets, err := secretClient(obj, r.Client, r.Impersonator, obj.Spec.ServiceAccountName)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
 	var proxyOpts *transport.ProxyOptions
 	if obj.Spec.ProxySecretRef != nil {
-		var err error
-		proxyOpts, err = r.getProxyOpts(ctx, obj.Spec.ProxySecretRef.Name, obj.GetNamespace())
+		proxyOpts, err = r.getProxyOpts(ctx, secrets, obj.Spec.ProxySecretRef.Name, obj.GetNamespace())
 		if err != nil {
 			e := serror.NewGeneric(
 				fmt.Errorf("failed to configure proxy options: %w", err),
@@ -502,7 +530,7 @@ func (r *GitRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 		return sreconcile.ResultEmpty, e
 	}
 
-	authOpts, err := r.getAuthOpts(ctx, obj, *u)
+	authOpts, err := r.getAuthOpts(ctx, secrets, obj, *u)
 	if err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("failed to configure authentication options: %w", err),
@@ -589,7 +617,7 @@ func (r *GitRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 	conditions.Delete(obj, sourcev1.FetchFailedCondition)
 
 	// Verify commit signature
-	if result, err := r.verifySignature(ctx, obj, *commit); err != nil || result == sreconcile.ResultEmpty {
+	if result, err := r.verifySignature(ctx, secrets, obj, *commit); err != nil || result == sreconcile.ResultEmpty {
 		return result, err
 	}
 
@@ -609,9 +637,9 @@ func (r *GitRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 
 // getProxyOpts fetches the secret containing the proxy settings, constructs a
 // transport.ProxyOptions object using those settings and then returns it.
-func (r *GitRepositoryReconciler) getProxyOpts(ctx context.Context, proxySecretName,
+func (r *GitRepositoryReconciler) getProxyOpts(ctx context.Context, secrets client.Reader, proxySecretName,
 	proxySecretNamespace string) (*transport.ProxyOptions, error) {
-	proxyData, err := r.getSecretData(ctx, proxySecretName, proxySecretNamespace)
+	proxyData, err := r.getSecretData(ctx, secrets, proxySecretName, proxySecretNamespace)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get proxy secret '%s/%s': %w", proxySecretNamespace, proxySecretName, err)
 	}
@@ -631,11 +659,12 @@ func (r *GitRepositoryReconciler) getProxyOpts(ctx context.Context, proxySecretN
 // getAuthOpts fetches the secret containing the auth options (if specified),
 // constructs a git.AuthOptions object using those options along with the provided
 // URL and returns it.
-func (r *GitRepositoryReconciler) getAuthOpts(ctx context.Context, obj *sourcev1.GitRepository, u url.URL) (*git.AuthOptions, error) {
+func (r *GitRepositoryReconciler) getAuthOpts(ctx context.Context, secrets client.Reader, obj *sourcev1.GitRepository,
+	u url.URL) (*git.AuthOptions, error) {
 	var authData map[string][]byte
 	if obj.Spec.SecretRef != nil {
 		var err error
-		authData, err = r.getSecretData(ctx, obj.Spec.SecretRef.Name, obj.GetNamespace())
+		authData, err = r.getSecretData(ctx, secrets, obj.Spec.SecretRef.Name, obj.GetNamespace())
 		if err != nil {
 			return nil, fmt.Errorf("failed to get secret '%s/%s': %w", obj.GetNamespace(), obj.Spec.SecretRef.Name, err)
 		}
@@ -649,13 +678,13 @@ func (r *GitRepositoryReconciler) getAuthOpts(ctx context.Context, obj *sourcev1
 	return authOpts, nil
 }
 
-func (r *GitRepositoryReconciler) getSecretData(ctx context.Context, name, namespace string) (map[string][]byte, error) {
+func (r *GitRepositoryReconciler) getSecretData(ctx context.Context, secrets client.Reader, name, namespace string) (map[string][]byte, error) {
 	key := types.NamespacedName{
 		Namespace: namespace,
 		Name:      name,
 	}
 	var secret corev1.Secret
-	if err := r.Client.Get(ctx, key, &secret); err != nil {
+	if err := secrets.Get(ctx, key, &secret); err != nil {
 		return nil, err
 	}
 	return secret.Data, nil
@@ -684,11 +713,20 @@ func (r *GitRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 			!includes.Diff(obj.Status.IncludedArtifacts) &&
 			!gitContentConfigChanged(obj, includes) {
 			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+			conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+			obj.Status.PendingArtifact = nil
 			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, meta.SucceededReason,
 				"stored artifact for revision '%s'", curArtifact.Revision)
 		}
 	}()
 
+	// Hold the new revision while the schedule is closed
+	if held, err := holdRevision(obj, obj.Spec.Schedule, obj.GetArtifact(), artifact.Revision, time.Now()); err != nil {
+		return sreconcile.ResultEmpty, err
+	} else if held {
+		return sreconcile.ResultSuccess, nil
+	}
+
 	// The artifact is up-to-date
 	if curArtifact := obj.GetArtifact(); curArtifact.HasRevision(artifact.Revision) &&
 		!includes.Diff(obj.Status.IncludedArtifacts) &&
@@ -755,6 +793,13 @@ func (r *GitRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 		return sreconcile.ResultEmpty, e
 	}
 
+	// Keep serving the current artifact until the new revision is approved
+	if awaitArtifactApproval(obj, obj.Spec.Approval, r.Storage, obj.GetArtifact(), &artifact) {
+		obj.Status.PendingArtifact = artifact.DeepCopy()
+		return sreconcile.ResultSuccess, nil
+	}
+	obj.Status.PendingArtifact = nil
+
 	// Record the observations on the object.
 	obj.Status.Artifact = artifact.DeepCopy()
 	obj.Status.IncludedArtifacts = *includes
@@ -833,6 +878,13 @@ func (r *GitRepositoryReconciler) reconcileInclude(ctx context.Context, sp *patc
 // performs a git checkout.
 func (r *GitRepositoryReconciler) gitCheckout(ctx context.Context, obj *sourcev1.GitRepository,
 	authOpts *git.AuthOptions, proxyOpts *transport.ProxyOptions, dir string, optimized bool) (*git.Commit, error) {
+	// Limit the remote operations on the Git host.
+	res, err := reserveHost(obj, r.HostLimiter, obj.Spec.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Release()
+
 	// Configure checkout strategy.
 	cloneOpts := repository.CloneConfig{
 		RecurseSubmodules: obj.Spec.RecurseSubmodules,
@@ -878,6 +930,7 @@ func (r *GitRepositoryReconciler) gitCheckout(ctx context.Context, obj *sourcev1
 	defer gitReader.Close()
 
 	commit, err := gitReader.Clone(gitCtx, obj.Spec.URL, cloneOpts)
+	recordHostResult(r.EventRecorder, obj, res, err)
 	if err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("failed to checkout and determine revision: %w", err),
@@ -933,7 +986,8 @@ func (r *GitRepositoryReconciler) fetchIncludes(ctx context.Context, obj *source
 // When successful, it records v1beta2.SourceVerifiedCondition=True.
 // If no verification mode is specified on the object, the
 // v1beta2.SourceVerifiedCondition Condition is removed.
-func (r *GitRepositoryReconciler) verifySignature(ctx context.Context, obj *sourcev1.GitRepository, commit git.Commit) (sreconcile.Result, error) {
+func (r *GitRepositoryReconciler) verifySignature(ctx context.Context, secrets client.Reader, obj *sourcev1.GitRepository,
+	commit git.Commit) (sreconcile.Result, error) {
 	// Check if there is a commit verification is configured and remove any old
 	// observations if there is none
 	if obj.Spec.Verification == nil || obj.Spec.Verification.Mode == "" {
@@ -948,7 +1002,7 @@ func (r *GitRepositoryReconciler) verifySignature(ctx context.Context, obj *sour
 		Name:      obj.Spec.Verification.SecretRef.Name,
 	}
 	secret := &corev1.Secret{}
-	if err := r.Client.Get(ctx, publicKeySecret, secret); err != nil {
+	if err := secrets.Get(ctx, publicKeySecret, secret); err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("PGP public keys secret error: %w", err),
 			"VerificationError",
@@ -1066,10 +1120,15 @@ func (r *GitRepositoryReconciler) garbageCollect(ctx context.Context, obj *sourc
 				"garbage collected artifacts for deleted resource")
 		}
 		obj.Status.Artifact = nil
+		obj.Status.PendingArtifact = nil
 		return nil
 	}
 	if obj.GetArtifact() != nil {
-		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5)
+		var keep []sourcev1.Artifact
+		if pending := obj.Status.PendingArtifact; pending != nil {
+			keep = append(keep, *pending)
+		}
+		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5, keep...)
 		if err != nil {
 			return serror.NewGeneric(
 				fmt.Errorf("garbage collection of artifacts failed: %w", err),
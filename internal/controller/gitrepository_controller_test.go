@@ -1981,7 +1981,7 @@ func TestGitRepositoryReconciler_verifySignature(t *testing.T) {
 				tt.beforeFunc(obj)
 			}
 
-			got, err := r.verifySignature(context.TODO(), obj, tt.commit)
+			got, err := r.verifySignature(context.TODO(), r.Client, obj, tt.commit)
 			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
 			g.Expect(err != nil).To(Equal(tt.wantErr))
 			if tt.err != nil {
@@ -2057,7 +2057,7 @@ func TestGitRepositoryReconciler_getProxyOpts(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
-			opts, err := r.getProxyOpts(context.TODO(), tt.secret, "default")
+			opts, err := r.getProxyOpts(context.TODO(), r.Client, tt.secret, "default")
 			if opts != nil {
 				g.Expect(err).ToNot(HaveOccurred())
 				g.Expect(opts).To(Equal(tt.proxyOpts))
@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -35,7 +36,6 @@ import (
 	"github.com/sigstore/cosign/v2/pkg/cosign"
 	helmgetter "helm.sh/helm/v3/pkg/getter"
 	helmreg "helm.sh/helm/v3/pkg/registry"
-	helmrepo "helm.sh/helm/v3/pkg/repo"
 	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -71,10 +71,13 @@ import (
 	"github.com/fluxcd/source-controller/internal/helm/chart"
 	"github.com/fluxcd/source-controller/internal/helm/getter"
 	"github.com/fluxcd/source-controller/internal/helm/repository"
+	"github.com/fluxcd/source-controller/internal/hostlimit"
+	"github.com/fluxcd/source-controller/internal/impersonation"
 	"github.com/fluxcd/source-controller/internal/oci"
 	soci "github.com/fluxcd/source-controller/internal/oci"
 	scosign "github.com/fluxcd/source-controller/internal/oci/cosign"
 	"github.com/fluxcd/source-controller/internal/oci/notation"
+	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
 	"github.com/fluxcd/source-controller/internal/util"
@@ -85,6 +88,9 @@ import (
 var helmChartReadyCondition = summarize.Conditions{
 	Target: meta.ReadyCondition,
 	Owned: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.RevisionHeldCondition,
+		sourcev1.PendingApprovalCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.BuildFailedCondition,
@@ -96,6 +102,7 @@ var helmChartReadyCondition = summarize.Conditions{
 		meta.StalledCondition,
 	},
 	Summarize: []string{
+		sourcev1.DependencyNotReadyCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.BuildFailedCondition,
@@ -106,6 +113,7 @@ var helmChartReadyCondition = summarize.Conditions{
 		meta.ReconcilingCondition,
 	},
 	NegativePolarity: []string{
+		sourcev1.DependencyNotReadyCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.BuildFailedCondition,
@@ -142,6 +150,25 @@ type HelmChartReconciler struct {
 	TTL   time.Duration
 	*cache.CacheRecorder
 
+	// ChartCache is used to share downloaded chart tarballs between
+	// builds. Caching is disabled when nil.
+	ChartCache *cache.ChartCache
+
+	// TagCache is used to share the tags of charts in OCI registries
+	// between builds, and with the HelmRepositoryReconciler. Caching is
+	// disabled when nil.
+	TagCache *cache.TagCache
+
+	// HostLimiter limits the concurrency and rate of remote operations per
+	// host. It is shared between reconcilers, and disabled when nil.
+	HostLimiter *hostlimit.Limiter
+
+	// Impersonator impersonates the ServiceAccount of the object to read its
+	// Secrets in multi-tenant lockdown mode. It is disabled when nil.
+	Impersonator *impersonation.Impersonator
+
+	requeueDependency time.Duration
+
 	patchOptions []patch.Option
 }
 
@@ -156,7 +183,8 @@ func (r *HelmChartReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Man
 }
 
 type HelmChartReconcilerOptions struct {
-	RateLimiter ratelimiter.RateLimiter
+	DependencyRequeueInterval time.Duration
+	RateLimiter               ratelimiter.RateLimiter
 }
 
 // helmChartReconcileFunc is the function type for all the v1.HelmChart
@@ -166,6 +194,7 @@ type helmChartReconcileFunc func(ctx context.Context, sp *patch.SerialPatcher, o
 
 func (r *HelmChartReconciler) SetupWithManagerAndOptions(ctx context.Context, mgr ctrl.Manager, opts HelmChartReconcilerOptions) error {
 	r.patchOptions = getPatchOptions(helmChartReadyCondition.Owned, r.ControllerName)
+	r.requeueDependency = opts.DependencyRequeueInterval
 
 	if err := mgr.GetCache().IndexField(ctx, &sourcev1.HelmRepository{}, sourcev1.HelmRepositoryURLIndexKey,
 		r.indexHelmRepositoryByURL); err != nil {
@@ -178,7 +207,8 @@ func (r *HelmChartReconciler) SetupWithManagerAndOptions(ctx context.Context, mg
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&sourcev1.HelmChart{}, builder.WithPredicates(
-			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+				intpredicates.ApprovedRevisionPredicate{}),
 		)).
 		Watches(
 			&sourcev1.HelmRepository{},
@@ -195,6 +225,11 @@ func (r *HelmChartReconciler) SetupWithManagerAndOptions(ctx context.Context, mg
 			handler.EnqueueRequestsFromMapFunc(r.requestsForBucketChange),
 			builder.WithPredicates(SourceRevisionChangePredicate{}),
 		).
+		Watches(
+			&sourcev1beta2.OCIRepository{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForOCIRepositoryChange),
+			builder.WithPredicates(SourceRevisionChangePredicate{}),
+		).
 		WithOptions(controller.Options{
 			RateLimiter: opts.RateLimiter,
 		}).
@@ -232,7 +267,8 @@ func (r *HelmChartReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 				summarize.RecordReconcileReq,
 			),
 			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
-				RequeueAfter: jitter.JitteredIntervalDuration(obj.GetRequeueAfter()),
+				RequeueAfter: holdRequeueAfter(obj, obj.Spec.Schedule,
+					jitter.JitteredIntervalDuration(obj.GetRequeueAfter()), time.Now()),
 			}),
 			summarize.WithPatchFieldOwner(r.ControllerName),
 		}
@@ -267,6 +303,12 @@ func (r *HelmChartReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return
 	}
 
+	// Wait for the dependencies of the object to be ready.
+	if err := checkSourceDependencies(ctx, r.Client, obj, obj.Spec.DependsOn, r.requeueDependency); err != nil {
+		recResult, retErr = sreconcile.ResultEmpty, err
+		return
+	}
+
 	// Reconcile actual object
 	reconcilers := []helmChartReconcileFunc{
 		r.reconcileStorage,
@@ -401,6 +443,12 @@ func (r *HelmChartReconciler) reconcileStorage(ctx context.Context, sp *patch.Se
 		}
 	}
 
+	// Remove the rendered artifact from the object if it disappeared from
+	// storage, it is recreated during the next build
+	if rendered := obj.GetRenderedArtifact(); rendered != nil && !r.Storage.ArtifactExist(*rendered) {
+		obj.Status.RenderedArtifact = nil
+	}
+
 	// Record that we do not have an artifact
 	if obj.GetArtifact() == nil {
 		msg := "building artifact"
@@ -418,6 +466,9 @@ func (r *HelmChartReconciler) reconcileStorage(ctx context.Context, sp *patch.Se
 	// Always update URLs to ensure hostname is up-to-date
 	// TODO(hidde): we may want to send out an event only if we notice the URL has changed
 	r.Storage.SetArtifactURL(obj.GetArtifact())
+	if rendered := obj.GetRenderedArtifact(); rendered != nil {
+		r.Storage.SetArtifactURL(rendered)
+	}
 	obj.Status.URL = r.Storage.SetHostname(obj.Status.URL)
 
 	return sreconcile.ResultSuccess, nil
@@ -502,7 +553,7 @@ func (r *HelmChartReconciler) reconcileSource(ctx context.Context, sp *patch.Ser
 	switch typedSource := s.(type) {
 	case *sourcev1.HelmRepository:
 		return r.buildFromHelmRepository(ctx, obj, typedSource, build)
-	case *sourcev1.GitRepository, *sourcev1beta2.Bucket:
+	case *sourcev1.GitRepository, *sourcev1beta2.Bucket, *sourcev1beta2.OCIRepository:
 		return r.buildFromTarballArtifact(ctx, obj, *typedSource.GetArtifact(), build)
 	default:
 		// Ending up here should generally not be possible
@@ -527,7 +578,18 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 		return chartRepoConfigErrorReturn(err, obj)
 	}
 
-	clientOpts, certsTmpDir, err := getter.GetClientOpts(ctxTimeout, r.Client, repo, normalizedURL)
+	// The Secrets of the repository are read as the ServiceAccount of the
+	// HelmRepository, and the Secrets of the chart as its own.
+	repoSecrets, err := secretClient(obj, r.Client, r.Impersonator, repo.Spec.ServiceAccountName)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+	secrets, err := secretClient(obj, r.Client, r.Impersonator, obj.Spec.ServiceAccountName)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
+	clientOpts, certsTmpDir, err := getter.GetClientOpts(ctxTimeout, repoSecrets, repo, normalizedURL)
 	if err != nil && !errors.Is(err, getter.ErrDeprecatedTLSConfig) {
 		e := serror.NewGeneric(
 			err,
@@ -547,6 +609,11 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 
 	getterOpts := clientOpts.GetterOpts
 
+	versionPolicy, err := versionPolicyFor(obj.Spec.VersionPolicy)
+	if err != nil {
+		return sreconcile.ResultEmpty, &chart.BuildError{Reason: chart.ErrChartReference, Err: err}
+	}
+
 	// Initialize the chart repository
 	var chartRepo repository.Downloader
 	switch repo.Spec.Type {
@@ -582,7 +649,7 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 		var verifiers []soci.Verifier
 		if obj.Spec.Verify != nil {
 			provider := obj.Spec.Verify.Provider
-			verifiers, err = r.makeVerifiers(ctx, obj, *clientOpts)
+			verifiers, err = r.makeVerifiers(ctx, secrets, obj, *clientOpts)
 			if err != nil {
 				if obj.Spec.Verify.SecretRef == nil && obj.Spec.Verify.Provider == "cosign" {
 					provider = fmt.Sprintf("%s keyless", provider)
@@ -603,7 +670,18 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 			repository.WithOCIGetterOptions(getterOpts),
 			repository.WithOCIRegistryClient(registryClient),
 			repository.WithVerifiers(verifiers),
+			repository.WithVersionPolicy(versionPolicy),
+			repository.WithTagCache(helmTagCacheFor(r.TagCache, repo)),
 		}
+		remoteOpts := append(remoteAuthOptions(*clientOpts), remote.WithContext(ctxTimeout))
+		if clientOpts.TlsConfig != nil {
+			tr := remote.DefaultTransport.(*http.Transport).Clone()
+			tr.TLSClientConfig = clientOpts.TlsConfig
+			remoteOpts = append(remoteOpts, remote.WithTransport(r.HostLimiter.RoundTripper(tr)))
+		} else if r.HostLimiter != nil {
+			remoteOpts = append(remoteOpts, remote.WithTransport(r.HostLimiter.RoundTripper(remote.DefaultTransport)))
+		}
+		chartRepoOpts = append(chartRepoOpts, repository.WithRemoteOptions(remoteOpts...))
 		if repo.Spec.Insecure {
 			chartRepoOpts = append(chartRepoOpts, repository.WithInsecureHTTP())
 		}
@@ -626,12 +704,29 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 				return sreconcile.ResultEmpty, e
 			}
 		}
+
+		// Limit the tag listings and chart downloads on the registry host
+		if r.HostLimiter != nil {
+			ociChartRepo.Client = &hostLimitedGetter{
+				Getter:   ociChartRepo.Client,
+				limiter:  r.HostLimiter,
+				recorder: r.EventRecorder,
+				obj:      obj,
+			}
+			ociChartRepo.RegistryClient = &hostLimitedRegistryClient{
+				RegistryClient: ociChartRepo.RegistryClient,
+				limiter:        r.HostLimiter,
+				recorder:       r.EventRecorder,
+				obj:            obj,
+			}
+		}
 		chartRepo = ociChartRepo
 	default:
-		httpChartRepo, err := repository.NewChartRepository(normalizedURL, r.Storage.LocalPath(*repo.GetArtifact()), r.Getters, clientOpts.TlsConfig, getterOpts...)
+		httpChartRepo, err := repository.NewChartRepository(normalizedURL, r.Storage.LocalPath(*repo.GetArtifact()), clientOpts.Getters(r.Getters), clientOpts.TlsConfig, getterOpts...)
 		if err != nil {
 			return chartRepoConfigErrorReturn(err, obj)
 		}
+		httpChartRepo.VersionPolicy = versionPolicy
 
 		// NB: this needs to be deferred first, as otherwise the Index will disappear
 		// before we had a chance to cache it.
@@ -641,27 +736,34 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 			}
 		}()
 
-		// Attempt to load the index from the cache.
-		if r.Cache != nil {
-			if index, ok := r.Cache.Get(repo.GetArtifact().Path); ok {
-				r.IncCacheEvents(cache.CacheEventTypeHit, repo.Name, repo.Namespace)
-				r.Cache.SetExpiration(repo.GetArtifact().Path, r.TTL)
-				httpChartRepo.Index = index.(*helmrepo.IndexFile)
-			} else {
-				r.IncCacheEvents(cache.CacheEventTypeMiss, repo.Name, repo.Namespace)
-				defer func() {
-					// If we succeed in loading the index, cache it.
-					if httpChartRepo.Index != nil {
-						if err = r.Cache.Set(repo.GetArtifact().Path, httpChartRepo.Index, r.TTL); err != nil {
-							r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.CacheOperationFailedReason, "failed to cache index: %s", err)
-						}
-					}
-				}()
+		// Only load the entries of the charts required for the build, and
+		// attempt to load them from the cache. Outside of proxy mode, the
+		// stored index only contains the entries of the referenced charts,
+		// and the remote index is used for charts which are not (yet) in it.
+		httpChartRepo.PartialIndex = true
+		httpChartRepo.FallbackToRemote = repo.Spec.Proxy == nil
+		httpChartRepo.IndexCache = r.helmIndexCacheFor(repo.GetArtifact().Path, repo.Name, repo.Namespace)
+		httpChartRepo.Revision = digest.Digest(repo.GetArtifact().Revision)
+
+		// Limit the index fetches and chart downloads on the repository
+		// and mirror hosts
+		mirrors := r.mirrorChartRepositories(ctx, repoSecrets, repo)
+		if r.HostLimiter != nil {
+			for _, cr := range append([]*repository.ChartRepository{httpChartRepo}, mirrors...) {
+				cr.Client = &hostLimitedGetter{
+					Getter:   cr.Client,
+					limiter:  r.HostLimiter,
+					recorder: r.EventRecorder,
+					obj:      obj,
+				}
 			}
 		}
-		chartRepo = httpChartRepo
+		chartRepo = repository.NewFailoverDownloader(httpChartRepo, mirrors...)
 	}
 
+	chartRepo = repository.NewCachingDownloader(chartRepo, chartCacheScope(repo, normalizedURL), r.ChartCache,
+		r.chartCacheEventRecorder(obj.GetName(), obj.GetNamespace()))
+
 	// Construct the chart builder with scoped configuration
 	cb := chart.NewRemoteBuilder(chartRepo)
 	opts := chart.BuildOptions{
@@ -672,6 +774,7 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 		// an artifact exists with the same name and version and `Force` is false.
 		// It will however try to verify the chart if `obj.Spec.Verify` is set, at every reconciliation.
 		Verify: obj.Spec.Verify != nil && obj.Spec.Verify.Provider != "",
+		Render: renderOptionsForObj(obj),
 	}
 	if artifact := obj.GetArtifact(); artifact != nil {
 		opts.CachedChart = r.Storage.LocalPath(*artifact)
@@ -688,6 +791,12 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 	ref := chart.RemoteReference{Name: obj.Spec.Chart, Version: obj.Spec.Version}
 	build, err := cb.Build(ctx, ref, util.TempPathForObj("", ".tgz", obj), opts)
 	if err != nil {
+		// Return the error of a remote operation which may not start yet
+		// due to the limits of its host as is, to requeue the object once
+		// it may
+		if e := new(serror.Waiting); errors.As(err, &e) {
+			return sreconcile.ResultEmpty, e
+		}
 		return sreconcile.ResultEmpty, err
 	}
 
@@ -701,6 +810,11 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 // In case of a failure it records v1.FetchFailedCondition on the chart
 // object, and returns early.
 func (r *HelmChartReconciler) buildFromTarballArtifact(ctx context.Context, obj *sourcev1.HelmChart, source sourcev1.Artifact, b *chart.Build) (sreconcile.Result, error) {
+	secrets, err := secretClient(obj, r.Client, r.Impersonator, obj.Spec.ServiceAccountName)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
 	// Create temporary working directory
 	tmpDir, err := util.TempDirForObj("", obj)
 	if err != nil {
@@ -750,7 +864,9 @@ func (r *HelmChartReconciler) buildFromTarballArtifact(ctx context.Context, obj
 
 	// Setup dependency manager
 	dm := chart.NewDependencyManager(
-		chart.WithDownloaderCallback(r.namespacedChartRepositoryCallback(ctx, obj.GetName(), obj.GetNamespace())),
+		chart.WithDownloaderCallback(r.namespacedChartRepositoryCallback(ctx, secrets, obj.GetName(), obj.GetNamespace(),
+			obj.Spec.DependencyCredentials)),
+		chart.WithDependencyMode(obj.Spec.DependencyMode),
 	)
 	defer func() {
 		err := dm.Clear()
@@ -765,6 +881,7 @@ func (r *HelmChartReconciler) buildFromTarballArtifact(ctx context.Context, obj
 		ValuesFiles:              obj.GetValuesFiles(),
 		IgnoreMissingValuesFiles: obj.Spec.IgnoreMissingValuesFiles,
 		Force:                    obj.Generation != obj.Status.ObservedGeneration,
+		Render:                   renderOptionsForObj(obj),
 	}
 	if artifact := obj.GetArtifact(); artifact != nil {
 		opts.CachedChart = r.Storage.LocalPath(*artifact)
@@ -782,7 +899,14 @@ func (r *HelmChartReconciler) buildFromTarballArtifact(ctx context.Context, obj
 				rev = dig.Encoded()
 			}
 		}
-		if kind := obj.Spec.SourceRef.Kind; kind == sourcev1.GitRepositoryKind || kind == sourcev1beta2.BucketKind {
+		if obj.Spec.SourceRef.Kind == sourcev1beta2.OCIRepositoryKind {
+			// The revision of an OCIRepository is in the format '<tag>@<digest>'
+			if dig := digest.Digest(rev[strings.LastIndex(rev, "@")+1:]); dig.Validate() == nil {
+				rev = dig.Encoded()
+			}
+		}
+		if kind := obj.Spec.SourceRef.Kind; kind == sourcev1.GitRepositoryKind || kind == sourcev1beta2.BucketKind ||
+			kind == sourcev1beta2.OCIRepositoryKind {
 			// The SemVer from the metadata is at times used in e.g. the label metadata for a resource
 			// in a chart, which has a limited length of 63 characters.
 			// To not fill most of this space with a full length SHA hex (40 characters for SHA-1, and
@@ -835,10 +959,16 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, _ *patch.Se
 		return sreconcile.ResultRequeue, nil
 	}
 
+	// Record the latest version available upstream, also when the
+	// artifact is up-to-date
+	obj.Status.LatestUpstreamVersion = b.LatestVersion
+
 	// Set the ArtifactInStorageCondition if there's no drift.
 	defer func() {
 		if obj.Status.ObservedChartName == b.Name && obj.GetArtifact().HasRevision(b.Version) {
 			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+			conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+			obj.Status.PendingArtifact = nil
 			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, reasonForBuild(b), b.Summary())
 		}
 	}()
@@ -848,6 +978,9 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, _ *patch.Se
 
 	// Return early if the build path equals the current artifact path
 	if curArtifact := obj.GetArtifact(); curArtifact != nil && r.Storage.LocalPath(*curArtifact) == b.Path {
+		if err := r.reconcileRenderedArtifact(obj, b); err != nil {
+			return sreconcile.ResultEmpty, err
+		}
 		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason, "artifact up-to-date with remote revision: '%s'", artifact.Revision)
 		return sreconcile.ResultSuccess, nil
 	}
@@ -855,6 +988,13 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, _ *patch.Se
 	// Garbage collect chart build once persisted to storage
 	defer os.Remove(b.Path)
 
+	// Hold the new revision while the schedule is closed
+	if held, err := holdRevision(obj, obj.Spec.Schedule, obj.GetArtifact(), artifact.Revision, time.Now()); err != nil {
+		return sreconcile.ResultEmpty, err
+	} else if held {
+		return sreconcile.ResultSuccess, nil
+	}
+
 	// Ensure artifact directory exists and acquire lock
 	if err := r.Storage.MkdirAll(artifact); err != nil {
 		e := serror.NewGeneric(
@@ -885,6 +1025,18 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, _ *patch.Se
 		return sreconcile.ResultEmpty, e
 	}
 
+	// Copy the rendered manifests next to the packaged chart
+	if err = r.reconcileRenderedArtifact(obj, b); err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
+	// Keep serving the current artifact until the new revision is approved
+	if awaitArtifactApproval(obj, obj.Spec.Approval, r.Storage, obj.GetArtifact(), &artifact) {
+		obj.Status.PendingArtifact = artifact.DeepCopy()
+		return sreconcile.ResultSuccess, nil
+	}
+	obj.Status.PendingArtifact = nil
+
 	// Record it on the object
 	obj.Status.Artifact = artifact.DeepCopy()
 	obj.Status.ObservedChartName = b.Name
@@ -907,36 +1059,91 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, _ *patch.Se
 	return sreconcile.ResultSuccess, nil
 }
 
+// reconcileRenderedArtifact copies the rendered manifests of the given build
+// to the Storage, and records the result as the RenderedArtifact on the
+// object. If the build was not rendered, the RenderedArtifact is removed
+// from the object.
+func (r *HelmChartReconciler) reconcileRenderedArtifact(obj *sourcev1.HelmChart, b *chart.Build) error {
+	if b.RenderedPath == "" {
+		obj.Status.RenderedArtifact = nil
+		return nil
+	}
+	defer os.Remove(b.RenderedPath)
+
+	artifact := r.Storage.NewArtifactFor(obj.Kind, obj.GetObjectMeta(), b.Version, fmt.Sprintf("%s-%s.yaml", b.Name, b.Version))
+	if err := r.Storage.MkdirAll(artifact); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to create artifact directory: %w", err),
+			sourcev1.DirCreationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	unlock, err := r.Storage.Lock(artifact)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to acquire lock for rendered artifact: %w", err),
+			sourcev1.AcquireLockFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	defer unlock()
+
+	if err = r.Storage.CopyFromPath(&artifact, b.RenderedPath); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("unable to copy rendered Helm chart to storage: %w", err),
+			sourcev1.ArchiveOperationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	obj.Status.RenderedArtifact = artifact.DeepCopy()
+	return nil
+}
+
 // getSource returns the v1beta1.Source for the given object, or an error describing why the source could not be
 // returned.
 func (r *HelmChartReconciler) getSource(ctx context.Context, obj *sourcev1.HelmChart) (sourcev1.Source, error) {
+	return getHelmChartSource(ctx, r.Client, obj.GetNamespace(), obj.Spec.SourceRef)
+}
+
+// getHelmChartSource returns the Source object for the given
+// v1.LocalHelmChartSourceReference in the given namespace.
+func getHelmChartSource(ctx context.Context, c client.Reader, namespace string, ref sourcev1.LocalHelmChartSourceReference) (sourcev1.Source, error) {
 	namespacedName := types.NamespacedName{
-		Namespace: obj.GetNamespace(),
-		Name:      obj.Spec.SourceRef.Name,
+		Namespace: namespace,
+		Name:      ref.Name,
 	}
 	var s sourcev1.Source
-	switch obj.Spec.SourceRef.Kind {
+	switch ref.Kind {
 	case sourcev1.HelmRepositoryKind:
 		var repo sourcev1.HelmRepository
-		if err := r.Client.Get(ctx, namespacedName, &repo); err != nil {
+		if err := c.Get(ctx, namespacedName, &repo); err != nil {
 			return nil, err
 		}
 		s = &repo
 	case sourcev1.GitRepositoryKind:
 		var repo sourcev1.GitRepository
-		if err := r.Client.Get(ctx, namespacedName, &repo); err != nil {
+		if err := c.Get(ctx, namespacedName, &repo); err != nil {
 			return nil, err
 		}
 		s = &repo
 	case sourcev1beta2.BucketKind:
 		var bucket sourcev1beta2.Bucket
-		if err := r.Client.Get(ctx, namespacedName, &bucket); err != nil {
+		if err := c.Get(ctx, namespacedName, &bucket); err != nil {
 			return nil, err
 		}
 		s = &bucket
+	case sourcev1beta2.OCIRepositoryKind:
+		var repo sourcev1beta2.OCIRepository
+		if err := c.Get(ctx, namespacedName, &repo); err != nil {
+			return nil, err
+		}
+		s = &repo
 	default:
-		return nil, fmt.Errorf("unsupported source kind '%s', must be one of: %v", obj.Spec.SourceRef.Kind, []string{
-			sourcev1.HelmRepositoryKind, sourcev1.GitRepositoryKind, sourcev1beta2.BucketKind})
+		return nil, fmt.Errorf("unsupported source kind '%s', must be one of: %v", ref.Kind, []string{
+			sourcev1.HelmRepositoryKind, sourcev1.GitRepositoryKind, sourcev1beta2.BucketKind, sourcev1beta2.OCIRepositoryKind})
 	}
 	return s, nil
 }
@@ -975,10 +1182,19 @@ func (r *HelmChartReconciler) garbageCollect(ctx context.Context, obj *sourcev1.
 				"garbage collected artifacts for deleted resource")
 		}
 		obj.Status.Artifact = nil
+		obj.Status.PendingArtifact = nil
+		obj.Status.RenderedArtifact = nil
 		return nil
 	}
 	if obj.GetArtifact() != nil {
-		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5)
+		var keep []sourcev1.Artifact
+		if rendered := obj.GetRenderedArtifact(); rendered != nil {
+			keep = append(keep, *rendered)
+		}
+		if pending := obj.Status.PendingArtifact; pending != nil {
+			keep = append(keep, *pending)
+		}
+		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5, keep...)
 		if err != nil {
 			return serror.NewGeneric(
 				fmt.Errorf("garbage collection of artifacts failed: %w", err),
@@ -995,26 +1211,38 @@ func (r *HelmChartReconciler) garbageCollect(ctx context.Context, obj *sourcev1.
 }
 
 // namespacedChartRepositoryCallback returns a chart.GetChartDownloaderCallback scoped to the given namespace.
-// The returned callback returns a repository.Downloader configured with the retrieved v1beta1.HelmRepository,
-// or a shim with defaults if no object could be found.
+// The returned callback returns a repository.Downloader configured with the matching dependency credentials,
+// the retrieved v1beta1.HelmRepository, or a shim with defaults if no object could be found.
+// The dependency credentials are read with the given secrets client, and the credentials of a retrieved
+// HelmRepository as its own ServiceAccount in multi-tenant lockdown mode.
 // The callback returns an object with a state, so the caller has to do the necessary cleanup.
-func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Context, name, namespace string) chart.GetChartDownloaderCallback {
+func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Context, secrets client.Client, name, namespace string,
+	credentials []sourcev1.HelmChartDependencyCredentials) chart.GetChartDownloaderCallback {
 	return func(url string) (repository.Downloader, error) {
 		normalizedURL, err := repository.NormalizeURL(url)
 		if err != nil {
 			return nil, err
 		}
-		obj, err := r.resolveDependencyRepository(ctx, url, namespace)
-		if err != nil {
-			// Return Kubernetes client errors, but ignore others
-			if apierrs.ReasonForError(err) != metav1.StatusReasonUnknown {
-				return nil, err
-			}
-			obj = &sourcev1.HelmRepository{
-				Spec: sourcev1.HelmRepositorySpec{
-					URL:     url,
-					Timeout: &metav1.Duration{Duration: 60 * time.Second},
-				},
+		repoSecrets := secrets
+		obj := dependencyRepositoryFromCredentials(normalizedURL, namespace, credentials)
+		if obj == nil {
+			obj, err = r.resolveDependencyRepository(ctx, url, namespace)
+			if err != nil {
+				// Return Kubernetes client errors, but ignore others
+				if apierrs.ReasonForError(err) != metav1.StatusReasonUnknown {
+					return nil, err
+				}
+				obj = &sourcev1.HelmRepository{
+					Spec: sourcev1.HelmRepositorySpec{
+						URL:     url,
+						Timeout: &metav1.Duration{Duration: 60 * time.Second},
+					},
+				}
+			} else if r.Impersonator.Lockdown() {
+				repoSecrets, err = r.Impersonator.ClientFor(namespace, obj.Spec.ServiceAccountName)
+				if err != nil {
+					return nil, err
+				}
 			}
 		}
 
@@ -1022,7 +1250,7 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 		ctxTimeout, cancel := context.WithTimeout(ctx, obj.GetTimeout())
 		defer cancel()
 
-		clientOpts, certsTmpDir, err := getter.GetClientOpts(ctxTimeout, r.Client, obj, normalizedURL)
+		clientOpts, certsTmpDir, err := getter.GetClientOpts(ctxTimeout, repoSecrets, obj, normalizedURL)
 		if err != nil && !errors.Is(err, getter.ErrDeprecatedTLSConfig) {
 			return nil, err
 		}
@@ -1038,11 +1266,28 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 			var errs []error
 			// Tell the chart repository to use the OCI client with the configured getter
 			getterOpts = append(getterOpts, helmgetter.WithRegistryClient(registryClient))
-			ociChartRepo, err := repository.NewOCIChartRepository(normalizedURL, repository.WithOCIGetter(r.Getters),
+			chartRepoOpts := []repository.OCIChartRepositoryOption{
+				repository.WithOCIGetter(r.Getters),
 				repository.WithOCIGetterOptions(getterOpts),
 				repository.WithOCIRegistryClient(registryClient),
 				repository.WithCertificatesStore(certsTmpDir),
-				repository.WithCredentialsFile(credentialsFile))
+				repository.WithCredentialsFile(credentialsFile),
+			}
+			// Resolve the digests of the chart tags with the same credentials,
+			// as the ChartCache stores the OCI charts by manifest digest
+			remoteOpts := append(remoteAuthOptions(*clientOpts), remote.WithContext(ctx))
+			if clientOpts.TlsConfig != nil {
+				tr := remote.DefaultTransport.(*http.Transport).Clone()
+				tr.TLSClientConfig = clientOpts.TlsConfig
+				remoteOpts = append(remoteOpts, remote.WithTransport(r.HostLimiter.RoundTripper(tr)))
+			} else if r.HostLimiter != nil {
+				remoteOpts = append(remoteOpts, remote.WithTransport(r.HostLimiter.RoundTripper(remote.DefaultTransport)))
+			}
+			chartRepoOpts = append(chartRepoOpts, repository.WithRemoteOptions(remoteOpts...))
+			if obj.Spec.Insecure {
+				chartRepoOpts = append(chartRepoOpts, repository.WithInsecureHTTP())
+			}
+			ociChartRepo, err := repository.NewOCIChartRepository(normalizedURL, chartRepoOpts...)
 			if err != nil {
 				errs = append(errs, fmt.Errorf("failed to create OCI chart repository: %w", err))
 				// clean up the credentialsFile
@@ -1068,7 +1313,7 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 
 			chartRepo = ociChartRepo
 		} else {
-			httpChartRepo, err := repository.NewChartRepository(normalizedURL, "", r.Getters, clientOpts.TlsConfig, getterOpts...)
+			httpChartRepo, err := repository.NewChartRepository(normalizedURL, "", clientOpts.Getters(r.Getters), clientOpts.TlsConfig, getterOpts...)
 			if err != nil {
 				return nil, err
 			}
@@ -1076,27 +1321,188 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 			if artifact := obj.GetArtifact(); artifact != nil {
 				httpChartRepo.Path = r.Storage.LocalPath(*artifact)
 
-				// Attempt to load the index from the cache.
-				if r.Cache != nil {
-					if index, ok := r.Cache.Get(artifact.Path); ok {
-						r.IncCacheEvents(cache.CacheEventTypeHit, name, namespace)
-						r.Cache.SetExpiration(artifact.Path, r.TTL)
-						httpChartRepo.Index = index.(*helmrepo.IndexFile)
-					} else {
-						r.IncCacheEvents(cache.CacheEventTypeMiss, name, namespace)
-						if err := httpChartRepo.LoadFromPath(); err != nil {
-							return nil, err
-						}
-						r.Cache.Set(artifact.Path, httpChartRepo.Index, r.TTL)
-					}
-				}
+				// Only load the entries of the dependencies, and attempt to
+				// load them from the cache, or the remote index if they are
+				// not referenced by a HelmChart. The entries loaded from the
+				// remote index are cached while it is of the same revision as
+				// the Artifact.
+				httpChartRepo.PartialIndex = true
+				httpChartRepo.FallbackToRemote = obj.Spec.Proxy == nil
+				httpChartRepo.IndexCache = r.helmIndexCacheFor(artifact.Path, name, namespace)
+				httpChartRepo.Revision = digest.Digest(artifact.Revision)
 			}
 
-			chartRepo = httpChartRepo
+			chartRepo = repository.NewFailoverDownloader(httpChartRepo, r.mirrorChartRepositories(ctx, repoSecrets, obj)...)
+		}
+
+		return repository.NewCachingDownloader(chartRepo, chartCacheScope(obj, normalizedURL), r.ChartCache,
+			r.chartCacheEventRecorder(name, namespace)), nil
+	}
+}
+
+// mirrorChartRepositories returns the repository.ChartRepository objects for
+// the mirrors of the given v1.HelmRepository, of which the credentials are
+// read with the given secrets client. Mirrors which can not be configured are
+// skipped.
+func (r *HelmChartReconciler) mirrorChartRepositories(ctx context.Context, secrets client.Client,
+	obj *sourcev1.HelmRepository) []*repository.ChartRepository {
+	var mirrors []*repository.ChartRepository
+	for _, m := range obj.Spec.Mirrors {
+		mirrorRepo, err := newMirrorChartRepository(ctx, secrets, r.Getters, obj, m)
+		if err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "skipping Helm repository mirror", "url", m.URL)
+			continue
+		}
+		mirrors = append(mirrors, mirrorRepo)
+	}
+	return mirrors
+}
+
+// helmIndexCacheFor returns a repository.IndexCache storing the chart
+// entries of the index of the Helm repository Artifact with the given path in
+// the Cache, or nil if caching is disabled. The cache events are recorded for
+// the object with the given name and namespace.
+func (r *HelmChartReconciler) helmIndexCacheFor(artifactPath, name, namespace string) repository.IndexCache {
+	if r.Cache == nil {
+		return nil
+	}
+	return &helmIndexCache{
+		reconciler:   r,
+		artifactPath: artifactPath,
+		name:         name,
+		namespace:    namespace,
+	}
+}
+
+// helmIndexCache is a repository.IndexCache backed by the Cache of a
+// HelmChartReconciler.
+type helmIndexCache struct {
+	reconciler   *HelmChartReconciler
+	artifactPath string
+	name         string
+	namespace    string
+}
+
+// key returns the cache key for the given chart name.
+// The cache keys have to be safe in multi-tenancy environments, as
+// otherwise it could be used as a vector to bypass the repository's
+// authentication. Using the Artifact.Path is safe as the path is in
+// the format of: /<repository-name>/<chart-name>/<filename>.
+func (c *helmIndexCache) key(chart string) string {
+	return c.artifactPath + "#" + chart
+}
+
+// Get returns the compact index of the given chart from the Cache, and
+// extends its TTL.
+func (c *helmIndexCache) Get(chart string) ([]byte, bool) {
+	r := c.reconciler
+	key := c.key(chart)
+	if index, ok := r.Cache.Get(key); ok {
+		r.IncCacheEvents(cache.CacheEventTypeHit, c.name, c.namespace)
+		r.Cache.SetExpiration(key, r.TTL)
+		return index.([]byte), true
+	}
+	r.IncCacheEvents(cache.CacheEventTypeMiss, c.name, c.namespace)
+	return nil, false
+}
+
+// Set stores the compact index of the given chart in the Cache, bounded by
+// its size in bytes. The index is not stored if the Cache is full.
+func (c *helmIndexCache) Set(chart string, index []byte) {
+	r := c.reconciler
+	_ = r.Cache.SetWithSize(c.key(chart), index, int64(len(index)), r.TTL)
+}
+
+// chartCacheScope returns the scope of the charts downloaded from the given
+// v1.HelmRepository at url in the ChartCache. The charts of a HelmRepository
+// object are scoped to the object, and those of a shim configured with
+// dependency credentials to the namespace and Secrets of the credentials.
+// Only the charts of a shim without credentials are shared by URL.
+func chartCacheScope(repo *sourcev1.HelmRepository, url string) string {
+	switch {
+	case repo.Name != "":
+		return fmt.Sprintf("%s/%s|%s", repo.Namespace, repo.Name, url)
+	case repo.Spec.SecretRef != nil || repo.Spec.CertSecretRef != nil:
+		var secretName, certSecretName string
+		if repo.Spec.SecretRef != nil {
+			secretName = repo.Spec.SecretRef.Name
+		}
+		if repo.Spec.CertSecretRef != nil {
+			certSecretName = repo.Spec.CertSecretRef.Name
+		}
+		return fmt.Sprintf("%s/%s/%s|%s", repo.Namespace, secretName, certSecretName, url)
+	default:
+		return url
+	}
+}
+
+// chartCacheEventRecorder returns a function recording the ChartCache events
+// for the object with the given name and namespace, or nil if no
+// CacheRecorder is configured.
+func (r *HelmChartReconciler) chartCacheEventRecorder(name, namespace string) func(event string) {
+	if r.CacheRecorder == nil {
+		return nil
+	}
+	return func(event string) {
+		r.IncCacheEvents(event, name, namespace)
+	}
+}
+
+// dependencyRepositoryFromCredentials returns a shim v1.HelmRepository for the
+// given normalized URL, configured with the references of the dependency
+// credentials with the longest matching URL prefix. It returns nil if none
+// of the credentials match.
+func dependencyRepositoryFromCredentials(url, namespace string, credentials []sourcev1.HelmChartDependencyCredentials) *sourcev1.HelmRepository {
+	var match *sourcev1.HelmChartDependencyCredentials
+	for i, c := range credentials {
+		if !matchesURLPrefix(url, c.URLPrefix) {
+			continue
 		}
+		if match == nil || len(c.URLPrefix) > len(match.URLPrefix) {
+			match = &credentials[i]
+		}
+	}
+	if match == nil {
+		return nil
+	}
 
-		return chartRepo, nil
+	obj := &sourcev1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+		},
+		Spec: sourcev1.HelmRepositorySpec{
+			URL:           url,
+			SecretRef:     match.SecretRef,
+			CertSecretRef: match.CertSecretRef,
+			Timeout:       &metav1.Duration{Duration: 60 * time.Second},
+		},
+	}
+	if helmreg.IsOCI(url) {
+		obj.Spec.Type = sourcev1.HelmRepositoryTypeOCI
 	}
+	return obj
+}
+
+// matchesURLPrefix returns true if the given URL has the same scheme and host
+// as the prefix, and its path starts with the path of the prefix on a '/'
+// boundary.
+func matchesURLPrefix(u, prefix string) bool {
+	uu, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	pu, err := url.Parse(prefix)
+	if err != nil {
+		return false
+	}
+	if !strings.EqualFold(uu.Scheme, pu.Scheme) || !strings.EqualFold(uu.Host, pu.Host) {
+		return false
+	}
+	prefixPath := strings.TrimSuffix(pu.Path, "/")
+	if prefixPath == "" {
+		return true
+	}
+	return uu.Path == prefixPath || strings.HasPrefix(uu.Path, prefixPath+"/")
 }
 
 func (r *HelmChartReconciler) resolveDependencyRepository(ctx context.Context, url string, namespace string) (*sourcev1.HelmRepository, error) {
@@ -1225,6 +1631,36 @@ func (r *HelmChartReconciler) requestsForBucketChange(ctx context.Context, o cli
 	return reqs
 }
 
+func (r *HelmChartReconciler) requestsForOCIRepositoryChange(ctx context.Context, o client.Object) []reconcile.Request {
+	repo, ok := o.(*sourcev1beta2.OCIRepository)
+	if !ok {
+		ctrl.LoggerFrom(ctx).Error(fmt.Errorf("expected an OCIRepository, got %T", o),
+			"failed to get reconcile requests for OCIRepository change")
+		return nil
+	}
+
+	// If we do not have an artifact, we have no requests to make
+	if repo.GetArtifact() == nil {
+		return nil
+	}
+
+	var list sourcev1.HelmChartList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		sourcev1.SourceIndexKey: fmt.Sprintf("%s/%s", sourcev1beta2.OCIRepositoryKind, repo.Name),
+	}); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list HelmCharts for OCIRepository change")
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for i, v := range list.Items {
+		if !repo.GetArtifact().HasRevision(v.Status.ObservedSourceArtifactRevision) {
+			reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+		}
+	}
+	return reqs
+}
+
 // eventLogf records events, and logs at the same time.
 //
 // This log is different from the debug log in the EventRecorder, in the sense
@@ -1277,7 +1713,7 @@ func observeChartBuild(ctx context.Context, sp *patch.SerialPatcher, pOpts []pat
 		}
 
 		switch buildErr.Reason {
-		case chart.ErrChartMetadataPatch, chart.ErrValuesFilesMerge, chart.ErrDependencyBuild, chart.ErrChartPackage:
+		case chart.ErrChartMetadataPatch, chart.ErrValuesFilesMerge, chart.ErrDependencyBuild, chart.ErrChartPackage, chart.ErrChartRender:
 			conditions.Delete(obj, sourcev1.FetchFailedCondition)
 			conditions.MarkTrue(obj, sourcev1.BuildFailedCondition, buildErr.Reason.Reason, buildErr.Error())
 		case chart.ErrChartVerification:
@@ -1292,6 +1728,35 @@ func observeChartBuild(ctx context.Context, sp *patch.SerialPatcher, pOpts []pat
 	}
 }
 
+// renderOptionsForObj returns the chart.RenderOptions for the render test
+// configured on the object, or nil.
+func renderOptionsForObj(obj *sourcev1.HelmChart) *chart.RenderOptions {
+	if obj.Spec.RenderTest == nil {
+		return nil
+	}
+	return &chart.RenderOptions{
+		KubeVersion: obj.Spec.RenderTest.KubeVersion,
+		APIVersions: obj.Spec.RenderTest.APIVersions,
+	}
+}
+
+// versionPolicyFor returns the repository.VersionPolicy for the given
+// v1.HelmChartVersionPolicy, or nil if no version policy is configured.
+func versionPolicyFor(policy *sourcev1.HelmChartVersionPolicy) (*repository.VersionPolicy, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	var soakTime time.Duration
+	if policy.SoakTime != nil {
+		soakTime = policy.SoakTime.Duration
+	}
+	p, err := repository.NewVersionPolicy(policy.ExcludePrerelease, soakTime, policy.Allow, policy.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version policy: %w", err)
+	}
+	return p, nil
+}
+
 func reasonForBuild(build *chart.Build) string {
 	if !build.Complete() {
 		return ""
@@ -1321,16 +1786,20 @@ func chartRepoConfigErrorReturn(err error, obj *sourcev1.HelmChart) (sreconcile.
 	}
 }
 
-// makeVerifiers returns a list of verifiers for the given chart.
-func (r *HelmChartReconciler) makeVerifiers(ctx context.Context, obj *sourcev1.HelmChart, clientOpts getter.ClientOpts) ([]soci.Verifier, error) {
-	var verifiers []soci.Verifier
-	verifyOpts := []remote.Option{}
-
+// remoteAuthOptions returns the remote.Option list to authenticate against an
+// OCI registry with the given getter.ClientOpts.
+func remoteAuthOptions(clientOpts getter.ClientOpts) []remote.Option {
 	if clientOpts.Authenticator != nil {
-		verifyOpts = append(verifyOpts, remote.WithAuth(clientOpts.Authenticator))
-	} else {
-		verifyOpts = append(verifyOpts, remote.WithAuthFromKeychain(clientOpts.Keychain))
+		return []remote.Option{remote.WithAuth(clientOpts.Authenticator)}
 	}
+	return []remote.Option{remote.WithAuthFromKeychain(clientOpts.Keychain)}
+}
+
+// makeVerifiers returns a list of verifiers for the given chart.
+func (r *HelmChartReconciler) makeVerifiers(ctx context.Context, secrets client.Reader, obj *sourcev1.HelmChart,
+	clientOpts getter.ClientOpts) ([]soci.Verifier, error) {
+	var verifiers []soci.Verifier
+	verifyOpts := remoteAuthOptions(clientOpts)
 
 	switch obj.Spec.Verify.Provider {
 	case "cosign":
@@ -1346,7 +1815,7 @@ func (r *HelmChartReconciler) makeVerifiers(ctx context.Context, obj *sourcev1.H
 				Name:      secretRef.Name,
 			}
 
-			pubSecret, err := r.retrieveSecret(ctx, verifySecret)
+			pubSecret, err := r.retrieveSecret(ctx, secrets, verifySecret)
 			if err != nil {
 				return nil, err
 			}
@@ -1397,7 +1866,7 @@ func (r *HelmChartReconciler) makeVerifiers(ctx context.Context, obj *sourcev1.H
 			Name:      secretRef.Name,
 		}
 
-		pubSecret, err := r.retrieveSecret(ctx, verifySecret)
+		pubSecret, err := r.retrieveSecret(ctx, secrets, verifySecret)
 		if err != nil {
 			return nil, err
 		}
@@ -1449,11 +1918,11 @@ func (r *HelmChartReconciler) makeVerifiers(ctx context.Context, obj *sourcev1.H
 
 // retrieveSecret retrieves a secret from the specified namespace with the given secret name.
 // It returns the retrieved secret and any error encountered during the retrieval process.
-func (r *HelmChartReconciler) retrieveSecret(ctx context.Context, verifySecret types.NamespacedName) (corev1.Secret, error) {
+func (r *HelmChartReconciler) retrieveSecret(ctx context.Context, c client.Reader, verifySecret types.NamespacedName) (corev1.Secret, error) {
 
 	var pubSecret corev1.Secret
 
-	if err := r.Get(ctx, verifySecret, &pubSecret); err != nil {
+	if err := c.Get(ctx, verifySecret, &pubSecret); err != nil {
 		return corev1.Secret{}, err
 	}
 	return pubSecret, nil
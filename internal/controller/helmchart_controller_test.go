@@ -68,6 +68,7 @@ import (
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/fluxcd/source-controller/internal/cache"
 	serror "github.com/fluxcd/source-controller/internal/error"
 	"github.com/fluxcd/source-controller/internal/helm/chart"
 	"github.com/fluxcd/source-controller/internal/helm/chart/secureloader"
@@ -183,11 +184,11 @@ func TestHelmChartReconciler_Reconcile(t *testing.T) {
 					return obj.Status.LastHandledReconcileAt == "now"
 				}, timeout).Should(BeTrue())
 
-				// Check if the cache contains the index.
+				// Check if the cache contains the index entries of the chart.
 				repoKey := client.ObjectKey{Name: repository.Name, Namespace: repository.Namespace}
 				err = testEnv.Get(ctx, repoKey, repository)
 				g.Expect(err).ToNot(HaveOccurred())
-				_, found := testCache.Get(repository.GetArtifact().Path)
+				_, found := testCache.Get(repository.GetArtifact().Path + "#" + obj.Spec.Chart)
 				g.Expect(found).To(BeTrue())
 
 				g.Expect(testEnv.Delete(ctx, obj)).To(Succeed())
@@ -1417,6 +1418,11 @@ func TestHelmChartReconciler_buildFromTarballArtifact(t *testing.T) {
 		Path:     "mock.tgz",
 	}
 	g.Expect(storage.Archive(chartsArtifact, "testdata/charts", nil)).To(Succeed())
+	ociArtifact := &sourcev1.Artifact{
+		Revision: "latest@sha256:3b5f2b4a2c1e5d7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f",
+		Path:     "oci.tgz",
+	}
+	g.Expect(storage.Archive(ociArtifact, "testdata/charts", nil)).To(Succeed())
 	yamlArtifact := &sourcev1.Artifact{
 		Revision: "9876abcd",
 		Path:     "values.yaml",
@@ -1478,6 +1484,25 @@ func TestHelmChartReconciler_buildFromTarballArtifact(t *testing.T) {
 				g.Expect(os.Remove(build.Path)).To(Succeed())
 			},
 		},
+		{
+			name:   "ReconcileStrategyRevision sets VersionMetadata from OCIRepository digest",
+			source: *ociArtifact.DeepCopy(),
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Spec.Chart = "testdata/charts/helmchart"
+				obj.Spec.SourceRef.Kind = sourcev1beta2.OCIRepositoryKind
+				obj.Spec.ReconcileStrategy = sourcev1.ReconcileStrategyRevision
+			},
+			want: sreconcile.ResultSuccess,
+			assertFunc: func(g *WithT, build chart.Build) {
+				g.Expect(build.Name).To(Equal("helmchart"))
+				g.Expect(build.Version).To(Equal("0.1.0+3b5f2b4a2c1e"))
+				g.Expect(build.ResolvedDependencies).To(Equal(0))
+				g.Expect(build.Path).To(BeARegularFile())
+			},
+			cleanFunc: func(g *WithT, build *chart.Build) {
+				g.Expect(os.Remove(build.Path)).To(Succeed())
+			},
+		},
 		{
 			name:   "ValuesFiles sets Generation as VersionMetadata",
 			source: *chartsArtifact.DeepCopy(),
@@ -1668,6 +1693,49 @@ func TestHelmChartReconciler_reconcileArtifact(t *testing.T) {
 				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, sourcev1.ChartPullSucceededReason, "pulled 'helmchart' chart with version '0.1.0'"),
 			},
 		},
+		{
+			name: "Copying rendered manifests to storage from build sets RenderedArtifact",
+			build: func() *chart.Build {
+				b := mockChartBuild("helmchart", "0.1.0", "testdata/charts/helmchart-0.1.0.tgz", nil)
+				f, err := os.CreateTemp("", "helmchart-rendered-*.yaml")
+				if err == nil {
+					_, _ = f.WriteString("---\n# Source: helmchart/templates/service.yaml\n")
+					_ = f.Close()
+					b.RenderedPath = f.Name()
+				}
+				return b
+			}(),
+			afterFunc: func(t *WithT, obj *sourcev1.HelmChart) {
+				t.Expect(obj.GetArtifact()).ToNot(BeNil())
+				t.Expect(obj.GetRenderedArtifact()).ToNot(BeNil())
+				t.Expect(obj.GetRenderedArtifact().Path).To(HaveSuffix("helmchart-0.1.0.yaml"))
+				t.Expect(obj.GetRenderedArtifact().Revision).To(Equal("0.1.0"))
+				t.Expect(testStorage.ArtifactExist(*obj.GetRenderedArtifact())).To(BeTrue())
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, sourcev1.ChartPullSucceededReason, "pulled 'helmchart' chart with version '0.1.0'"),
+			},
+		},
+		{
+			name: "Up-to-date chart build records LatestUpstreamVersion",
+			build: &chart.Build{
+				Name:          "helmchart",
+				Version:       "0.1.0",
+				Path:          filepath.Join(testStorage.BasePath, "testdata/charts/helmchart-0.1.0.tgz"),
+				LatestVersion: "0.2.0",
+			},
+			beforeFunc: func(obj *sourcev1.HelmChart) {
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Path: "testdata/charts/helmchart-0.1.0.tgz",
+				}
+				obj.Status.LatestUpstreamVersion = "0.1.0"
+			},
+			want: sreconcile.ResultSuccess,
+			afterFunc: func(t *WithT, obj *sourcev1.HelmChart) {
+				t.Expect(obj.Status.LatestUpstreamVersion).To(Equal("0.2.0"))
+			},
+		},
 		{
 			name: "Up-to-date chart build does not persist artifact to storage",
 			build: &chart.Build{
@@ -1865,6 +1933,16 @@ func TestHelmChartReconciler_getSource(t *testing.T) {
 				Namespace: "foo",
 			},
 		},
+		&sourcev1beta2.OCIRepository{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       sourcev1beta2.OCIRepositoryKind,
+				APIVersion: sourcev1beta2.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ocirepository",
+				Namespace: "foo",
+			},
+		},
 	}
 
 	clientBuilder := fakeclient.NewClientBuilder().
@@ -1927,6 +2005,21 @@ func TestHelmChartReconciler_getSource(t *testing.T) {
 			},
 			want: mocks[2].(sourcev1.Source),
 		},
+		{
+			name: "Get OCIRepository source for reference",
+			obj: &sourcev1.HelmChart{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: mocks[3].GetNamespace(),
+				},
+				Spec: sourcev1.HelmChartSpec{
+					SourceRef: sourcev1.LocalHelmChartSourceReference{
+						Name: mocks[3].GetName(),
+						Kind: mocks[3].GetObjectKind().GroupVersionKind().Kind,
+					},
+				},
+			},
+			want: mocks[3].(sourcev1.Source),
+		},
 		{
 			name: "Error on client error",
 			obj: &sourcev1.HelmChart{
@@ -1973,6 +2066,146 @@ func TestHelmChartReconciler_getSource(t *testing.T) {
 	}
 }
 
+func Test_dependencyRepositoryFromCredentials(t *testing.T) {
+	credentials := []sourcev1.HelmChartDependencyCredentials{
+		{
+			URLPrefix: "https://charts.example.com/",
+			SecretRef: &meta.LocalObjectReference{Name: "example"},
+		},
+		{
+			URLPrefix:     "https://charts.example.com/private/",
+			SecretRef:     &meta.LocalObjectReference{Name: "private"},
+			CertSecretRef: &meta.LocalObjectReference{Name: "private-tls"},
+		},
+		{
+			URLPrefix: "oci://ghcr.io/example",
+			SecretRef: &meta.LocalObjectReference{Name: "ghcr"},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		url               string
+		wantSecretRef     string
+		wantCertSecretRef string
+		wantType          string
+		wantNil           bool
+	}{
+		{
+			name:          "matches prefix",
+			url:           "https://charts.example.com/stable/",
+			wantSecretRef: "example",
+		},
+		{
+			name:              "longest prefix wins",
+			url:               "https://charts.example.com/private/",
+			wantSecretRef:     "private",
+			wantCertSecretRef: "private-tls",
+		},
+		{
+			name:          "matches OCI prefix",
+			url:           "oci://ghcr.io/example/charts",
+			wantSecretRef: "ghcr",
+			wantType:      sourcev1.HelmRepositoryTypeOCI,
+		},
+		{
+			name:    "no match",
+			url:     "https://other.example.com/",
+			wantNil: true,
+		},
+		{
+			name:    "look-alike host",
+			url:     "https://charts.example.com.evil.io/private/",
+			wantNil: true,
+		},
+		{
+			name:    "different port",
+			url:     "https://charts.example.com:8443/",
+			wantNil: true,
+		},
+		{
+			name:    "different scheme",
+			url:     "http://charts.example.com/",
+			wantNil: true,
+		},
+		{
+			name:          "sibling path",
+			url:           "https://charts.example.com/private-other/",
+			wantSecretRef: "example",
+		},
+		{
+			name:    "sibling OCI path",
+			url:     "oci://ghcr.io/example-other/charts",
+			wantNil: true,
+		},
+		{
+			name:          "exact OCI path",
+			url:           "oci://ghcr.io/example",
+			wantSecretRef: "ghcr",
+			wantType:      sourcev1.HelmRepositoryTypeOCI,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got := dependencyRepositoryFromCredentials(tt.url, "default", credentials)
+			if tt.wantNil {
+				g.Expect(got).To(BeNil())
+				return
+			}
+			g.Expect(got).ToNot(BeNil())
+			g.Expect(got.Namespace).To(Equal("default"))
+			g.Expect(got.Spec.URL).To(Equal(tt.url))
+			g.Expect(got.Spec.Type).To(Equal(tt.wantType))
+			g.Expect(got.Spec.SecretRef.Name).To(Equal(tt.wantSecretRef))
+			if tt.wantCertSecretRef != "" {
+				g.Expect(got.Spec.CertSecretRef.Name).To(Equal(tt.wantCertSecretRef))
+			} else {
+				g.Expect(got.Spec.CertSecretRef).To(BeNil())
+			}
+		})
+	}
+}
+
+func Test_chartCacheScope(t *testing.T) {
+	tests := []struct {
+		name string
+		repo *sourcev1.HelmRepository
+		want string
+	}{
+		{
+			name: "HelmRepository object",
+			repo: &sourcev1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "private", Namespace: "tenant-a"},
+			},
+			want: "tenant-a/private|https://example.com/",
+		},
+		{
+			name: "dependency credentials",
+			repo: &sourcev1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-a"},
+				Spec: sourcev1.HelmRepositorySpec{
+					SecretRef:     &meta.LocalObjectReference{Name: "auth"},
+					CertSecretRef: &meta.LocalObjectReference{Name: "tls"},
+				},
+			},
+			want: "tenant-a/auth/tls|https://example.com/",
+		},
+		{
+			name: "no credentials",
+			repo: &sourcev1.HelmRepository{},
+			want: "https://example.com/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(chartCacheScope(tt.repo, "https://example.com/")).To(Equal(tt.want))
+		})
+	}
+}
+
 func TestHelmChartReconciler_reconcileDelete(t *testing.T) {
 	g := NewWithT(t)
 
@@ -3429,6 +3662,38 @@ func TestHelmChartReconciler_reconcileSourceFromOCI_verifySignatureCosign(t *tes
 	}
 }
 
+func TestHelmChartReconciler_helmIndexCacheFor(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &HelmChartReconciler{
+		CacheRecorder: cache.MustMakeMetrics(),
+		TTL:           time.Minute,
+	}
+	g.Expect(r.helmIndexCacheFor("helmrepository/default/foo/index.yaml", "foo", "default")).To(BeNil())
+
+	r.Cache = cache.NewWithMaxBytes(10, 0)
+	c := r.helmIndexCacheFor("helmrepository/default/foo/index.yaml", "foo", "default")
+	g.Expect(c).ToNot(BeNil())
+
+	_, ok := c.Get("podinfo")
+	g.Expect(ok).To(BeFalse())
+
+	c.Set("podinfo", []byte("{}"))
+	b, ok := c.Get("podinfo")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(b).To(Equal([]byte("{}")))
+	g.Expect(r.Cache.Size()).To(Equal(int64(2)))
+
+	// Entries exceeding the size of the cache are not stored.
+	c.Set("nginx", []byte("{\"entries\":{}}"))
+	_, ok = c.Get("nginx")
+	g.Expect(ok).To(BeFalse())
+
+	// Entries are scoped to the repository artifact.
+	_, ok = r.helmIndexCacheFor("helmrepository/default/bar/index.yaml", "bar", "default").Get("podinfo")
+	g.Expect(ok).To(BeFalse())
+}
+
 // extractChartMeta is used to extract a chart metadata from a byte array
 func extractChartMeta(chartData []byte) (*hchart.Metadata, error) {
 	ch, err := loader.LoadArchive(bytes.NewReader(chartData))
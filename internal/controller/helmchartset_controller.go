@@ -0,0 +1,730 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	kuberecorder "k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	helper "github.com/fluxcd/pkg/runtime/controller"
+	"github.com/fluxcd/pkg/runtime/jitter"
+	"github.com/fluxcd/pkg/runtime/patch"
+	"github.com/fluxcd/pkg/runtime/predicates"
+	rreconcile "github.com/fluxcd/pkg/runtime/reconcile"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/helm/repository"
+	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
+)
+
+// helmChartSetReadyCondition contains all the conditions information
+// needed for HelmChartSet Ready status conditions summary calculation.
+var helmChartSetReadyCondition = summarize.Conditions{
+	Target: meta.ReadyCondition,
+	Owned: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.RevisionHeldCondition,
+		sourcev1.PendingApprovalCondition,
+		sourcev1.FetchFailedCondition,
+		sourcev1beta2.HelmChartsGeneratedCondition,
+		meta.ReadyCondition,
+		meta.ReconcilingCondition,
+		meta.StalledCondition,
+	},
+	Summarize: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.FetchFailedCondition,
+		sourcev1beta2.HelmChartsGeneratedCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+	NegativePolarity: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.FetchFailedCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+}
+
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=helmchartsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=helmchartsets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=helmchartsets/finalizers,verbs=get;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// HelmChartSetReconciler reconciles a v1beta2.HelmChartSet object, by
+// generating a v1.HelmChart object for every chart discovered in its Source.
+type HelmChartSetReconciler struct {
+	client.Client
+	kuberecorder.EventRecorder
+	helper.Metrics
+
+	Storage        *Storage
+	ControllerName string
+
+	requeueDependency time.Duration
+
+	patchOptions []patch.Option
+}
+
+type HelmChartSetReconcilerOptions struct {
+	DependencyRequeueInterval time.Duration
+	RateLimiter               ratelimiter.RateLimiter
+}
+
+// helmChartSetReconcileFunc is the function type for all the
+// v1beta2.HelmChartSet (sub)reconcile functions. The type implementations
+// are grouped and executed serially to perform the complete reconcile of the
+// object.
+type helmChartSetReconcileFunc func(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.HelmChartSet, charts *[]string) (sreconcile.Result, error)
+
+func (r *HelmChartSetReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	return r.SetupWithManagerAndOptions(ctx, mgr, HelmChartSetReconcilerOptions{})
+}
+
+func (r *HelmChartSetReconciler) SetupWithManagerAndOptions(ctx context.Context, mgr ctrl.Manager, opts HelmChartSetReconcilerOptions) error {
+	r.patchOptions = getPatchOptions(helmChartSetReadyCondition.Owned, r.ControllerName)
+	r.requeueDependency = opts.DependencyRequeueInterval
+
+	if err := mgr.GetCache().IndexField(ctx, &sourcev1beta2.HelmChartSet{}, sourcev1beta2.SourceIndexKey,
+		r.indexHelmChartSetBySource); err != nil {
+		return fmt.Errorf("failed setting index fields: %w", err)
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&sourcev1beta2.HelmChartSet{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+				intpredicates.ApprovedRevisionPredicate{}),
+		)).
+		Owns(&sourcev1.HelmChart{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&sourcev1.HelmRepository{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSourceChange),
+			builder.WithPredicates(SourceRevisionChangePredicate{}),
+		).
+		Watches(
+			&sourcev1.GitRepository{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSourceChange),
+			builder.WithPredicates(SourceRevisionChangePredicate{}),
+		).
+		Watches(
+			&sourcev1beta2.Bucket{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSourceChange),
+			builder.WithPredicates(SourceRevisionChangePredicate{}),
+		).
+		Watches(
+			&sourcev1beta2.OCIRepository{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSourceChange),
+			builder.WithPredicates(SourceRevisionChangePredicate{}),
+		).
+		WithOptions(controller.Options{
+			RateLimiter: opts.RateLimiter,
+		}).
+		Complete(r)
+}
+
+func (r *HelmChartSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
+	start := time.Now()
+	log := ctrl.LoggerFrom(ctx)
+
+	// Fetch the HelmChartSet
+	obj := &sourcev1beta2.HelmChartSet{}
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Initialize the patch helper with the current version of the object.
+	serialPatcher := patch.NewSerialPatcher(obj, r.Client)
+
+	// recResult stores the abstracted reconcile result.
+	var recResult sreconcile.Result
+
+	// Always attempt to patch the object after each reconciliation.
+	// NOTE: The final runtime result and error are set in this block.
+	defer func() {
+		summarizeHelper := summarize.NewHelper(r.EventRecorder, serialPatcher)
+		summarizeOpts := []summarize.Option{
+			summarize.WithConditions(helmChartSetReadyCondition),
+			summarize.WithBiPolarityConditionTypes(sourcev1beta2.HelmChartsGeneratedCondition),
+			summarize.WithReconcileResult(recResult),
+			summarize.WithReconcileError(retErr),
+			summarize.WithIgnoreNotFound(),
+			summarize.WithProcessors(
+				summarize.ErrorActionHandler,
+				summarize.RecordReconcileReq,
+			),
+			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
+				RequeueAfter: holdRequeueAfter(obj, obj.Spec.Schedule,
+					jitter.JitteredIntervalDuration(obj.GetRequeueAfter()), time.Now()),
+			}),
+			summarize.WithPatchFieldOwner(r.ControllerName),
+		}
+		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
+
+		// Always record suspend, readiness and duration metrics.
+		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
+		r.Metrics.RecordReadiness(ctx, obj)
+		r.Metrics.RecordDuration(ctx, obj, start)
+	}()
+
+	// Examine if the object is under deletion. The generated HelmChart
+	// objects are garbage collected by Kubernetes through their owner
+	// reference.
+	if !obj.ObjectMeta.DeletionTimestamp.IsZero() {
+		recResult = sreconcile.ResultEmpty
+		return
+	}
+
+	// Return if the object is suspended.
+	if obj.Spec.Suspend {
+		log.Info("Reconciliation is suspended for this object")
+		recResult, retErr = sreconcile.ResultEmpty, nil
+		return
+	}
+
+	// Wait for the dependencies of the object to be ready.
+	if err := checkSourceDependencies(ctx, r.Client, obj, obj.Spec.DependsOn, r.requeueDependency); err != nil {
+		recResult, retErr = sreconcile.ResultEmpty, err
+		return
+	}
+
+	// Reconcile actual object
+	reconcilers := []helmChartSetReconcileFunc{
+		r.reconcileSource,
+		r.reconcileCharts,
+	}
+	recResult, retErr = r.reconcile(ctx, serialPatcher, obj, reconcilers)
+	return
+}
+
+// reconcile iterates through the helmChartSetReconcileFunc tasks for the
+// object. It returns early on the first call that returns
+// reconcile.ResultRequeue, or produces an error.
+func (r *HelmChartSetReconciler) reconcile(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.HelmChartSet, reconcilers []helmChartSetReconcileFunc) (sreconcile.Result, error) {
+	rreconcile.ProgressiveStatus(false, obj, meta.ProgressingReason, "reconciliation in progress")
+
+	var reconcileAtVal string
+	if v, ok := meta.ReconcileAnnotationValue(obj.GetAnnotations()); ok {
+		reconcileAtVal = v
+	}
+
+	// Persist reconciling if generation differs or reconciliation is requested.
+	switch {
+	case obj.Generation != obj.Status.ObservedGeneration:
+		rreconcile.ProgressiveStatus(false, obj, meta.ProgressingReason,
+			"processing object: new generation %d -> %d", obj.Status.ObservedGeneration, obj.Generation)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	case reconcileAtVal != obj.Status.GetLastHandledReconcileRequest():
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	}
+
+	// Run the sub-reconcilers and build the result of reconciliation.
+	var (
+		charts []string
+		res    sreconcile.Result
+		resErr error
+	)
+	for _, rec := range reconcilers {
+		recResult, err := rec(ctx, sp, obj, &charts)
+		// Exit immediately on ResultRequeue.
+		if recResult == sreconcile.ResultRequeue {
+			return sreconcile.ResultRequeue, nil
+		}
+		// If an error is received, prioritize the returned results because an
+		// error also means immediate requeue.
+		if err != nil {
+			resErr = err
+			res = recResult
+			break
+		}
+		// Prioritize requeue request in the result.
+		res = sreconcile.LowestRequeuingResult(res, recResult)
+	}
+	return res, resErr
+}
+
+// reconcileSource discovers the charts matching the pattern of the object
+// in the Artifact of its Source, and writes their names or paths to charts.
+//
+// For a v1.HelmRepository, the charts are discovered in the repository index.
+// For any other Source, the charts are the directories with a Chart.yaml file
+// in the Artifact tarball.
+// If the Source does not have an Artifact, v1.FetchFailedCondition is marked
+// True and the object is requeued.
+func (r *HelmChartSetReconciler) reconcileSource(ctx context.Context, _ *patch.SerialPatcher, obj *sourcev1beta2.HelmChartSet, charts *[]string) (sreconcile.Result, error) {
+	if _, err := path.Match(obj.Spec.Pattern, ""); err != nil {
+		e := serror.NewStalling(
+			fmt.Errorf("invalid pattern '%s': %w", obj.Spec.Pattern, err),
+			"InvalidPattern",
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	// Retrieve the source
+	s, err := getHelmChartSource(ctx, r.Client, obj.GetNamespace(), obj.Spec.SourceRef)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to get source: %w", err),
+			"SourceUnavailable",
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+
+		// Return Kubernetes client errors, but ignore others which can only be
+		// solved by a change in generation
+		if apierrs.ReasonForError(err) == metav1.StatusReasonUnknown {
+			return sreconcile.ResultEmpty, serror.NewStalling(
+				fmt.Errorf("failed to get source: %w", err),
+				"UnsupportedSourceKind",
+			)
+		}
+		return sreconcile.ResultEmpty, e
+	}
+
+	if helmRepo, ok := s.(*sourcev1.HelmRepository); ok && helmRepo.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
+		e := serror.NewStalling(
+			fmt.Errorf("charts can not be discovered in HelmRepository '%s' of type '%s'", helmRepo.Name, sourcev1.HelmRepositoryTypeOCI),
+			"UnsupportedSourceKind",
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	// Assert source has an artifact
+	artifact := s.GetArtifact()
+	if artifact == nil || !r.Storage.ArtifactExist(*artifact) {
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, "NoSourceArtifact",
+			"no artifact available for %s source '%s'", obj.Spec.SourceRef.Kind, obj.Spec.SourceRef.Name)
+		r.Eventf(obj, eventv1.EventTypeTrace, "NoSourceArtifact",
+			"no artifact available for %s source '%s'", obj.Spec.SourceRef.Kind, obj.Spec.SourceRef.Name)
+		return sreconcile.ResultRequeue, nil
+	}
+
+	// Keep the current charts while the schedule holds the new revision
+	var current *sourcev1.Artifact
+	if obj.Status.ObservedSourceArtifactRevision != "" {
+		current = &sourcev1.Artifact{Revision: obj.Status.ObservedSourceArtifactRevision}
+	}
+	if held, err := holdRevision(obj, obj.Spec.Schedule, current, artifact.Revision, time.Now()); err != nil {
+		return sreconcile.ResultEmpty, err
+	} else if held {
+		for _, c := range obj.Status.Charts {
+			*charts = append(*charts, c.Chart)
+		}
+		conditions.Delete(obj, sourcev1.FetchFailedCondition)
+		return sreconcile.ResultSuccess, nil
+	}
+
+	f, err := os.Open(r.Storage.LocalPath(*artifact))
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to open source artifact: %w", err),
+			sourcev1.ReadOperationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	defer f.Close()
+
+	if _, ok := s.(*sourcev1.HelmRepository); ok {
+		*charts, err = discoverIndexCharts(f, obj.Spec.Pattern)
+	} else {
+		*charts, err = discoverArtifactCharts(f, obj.Spec.Pattern)
+	}
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to discover charts in %s source '%s': %w", obj.Spec.SourceRef.Kind, obj.Spec.SourceRef.Name, err),
+			sourcev1.ReadOperationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	// Keep the current charts until a new revision which changes them is
+	// approved
+	if added, removed := helmChartSetDiff(obj.Status.Charts, *charts); added == 0 && removed == 0 {
+		conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+	} else if awaitApproval(obj, obj.Spec.Approval, current, artifact, func() string {
+		return fmt.Sprintf("%d chart(s) added and %d removed", added, removed)
+	}) {
+		*charts = (*charts)[:0]
+		for _, c := range obj.Status.Charts {
+			*charts = append(*charts, c.Chart)
+		}
+		conditions.Delete(obj, sourcev1.FetchFailedCondition)
+		return sreconcile.ResultSuccess, nil
+	}
+
+	obj.Status.ObservedSourceArtifactRevision = artifact.Revision
+	conditions.Delete(obj, sourcev1.FetchFailedCondition)
+	return sreconcile.ResultSuccess, nil
+}
+
+// helmChartSetDiff returns the number of charts added and removed in the
+// discovered charts compared to the current entries.
+func helmChartSetDiff(entries []sourcev1beta2.HelmChartSetEntry, charts []string) (added, removed int) {
+	current := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		current[e.Chart] = true
+	}
+	for _, c := range charts {
+		if !current[c] {
+			added++
+		}
+		delete(current, c)
+	}
+	return added, len(current)
+}
+
+// reconcileCharts creates or updates a v1.HelmChart object for every chart
+// in charts, and deletes the HelmChart objects generated by the object for
+// charts which are no longer discovered.
+//
+// HelmChart objects with a conflicting name which are not controlled by the
+// object are left untouched, and result in an error.
+// On success, sourcev1beta2.HelmChartsGeneratedCondition is marked True and
+// the generated HelmChart objects are recorded in the Status of the object.
+func (r *HelmChartSetReconciler) reconcileCharts(ctx context.Context, _ *patch.SerialPatcher, obj *sourcev1beta2.HelmChartSet, charts *[]string) (sreconcile.Result, error) {
+	var (
+		entries          []sourcev1beta2.HelmChartSetEntry
+		created, updated int
+		errs             []error
+		chartNames       = helmChartSetChartNames(obj.GetName(), *charts, obj.Status.Charts)
+		names            = make(map[string]bool, len(chartNames))
+	)
+	for _, name := range chartNames {
+		names[name] = true
+	}
+	for _, c := range *charts {
+		name := chartNames[c]
+
+		hc := &sourcev1.HelmChart{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: obj.GetNamespace(),
+			},
+		}
+		op, err := controllerutil.CreateOrUpdate(ctx, r.Client, hc, func() error {
+			if hc.ResourceVersion != "" && !metav1.IsControlledBy(hc, obj) {
+				return fmt.Errorf("HelmChart '%s' already exists and is not controlled by the HelmChartSet", name)
+			}
+			mutateHelmChartSetChart(obj, hc, c)
+			return controllerutil.SetControllerReference(obj, hc, r.Client.Scheme())
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to generate HelmChart for chart '%s': %w", c, err))
+			continue
+		}
+		switch op {
+		case controllerutil.OperationResultCreated:
+			created++
+		case controllerutil.OperationResultUpdated:
+			updated++
+		}
+		entries = append(entries, sourcev1beta2.HelmChartSetEntry{Name: name, Chart: c})
+	}
+
+	// Delete the HelmCharts of charts which are no longer discovered
+	var deleted int
+	var list sourcev1.HelmChartList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace()),
+		client.MatchingLabels{sourcev1beta2.HelmChartSetNameLabel: helmChartSetLabelValue(obj.GetName())}); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list generated HelmCharts: %w", err))
+	}
+	for i := range list.Items {
+		hc := &list.Items[i]
+		if names[hc.Name] || !metav1.IsControlledBy(hc, obj) {
+			continue
+		}
+		if err := r.Delete(ctx, hc); client.IgnoreNotFound(err) != nil {
+			errs = append(errs, fmt.Errorf("failed to delete HelmChart '%s': %w", hc.Name, err))
+			continue
+		}
+		deleted++
+	}
+
+	obj.Status.Charts = entries
+	if created+updated+deleted > 0 {
+		r.Eventf(obj, corev1.EventTypeNormal, sourcev1beta2.GenerationSucceededReason,
+			"created %d, updated %d and deleted %d HelmChart(s) for revision '%s'",
+			created, updated, deleted, obj.Status.ObservedSourceArtifactRevision)
+	}
+
+	if len(errs) > 0 {
+		e := serror.NewGeneric(errors.Join(errs...), sourcev1beta2.GenerationFailedReason)
+		conditions.MarkFalse(obj, sourcev1beta2.HelmChartsGeneratedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	conditions.MarkTrue(obj, sourcev1beta2.HelmChartsGeneratedCondition, sourcev1beta2.GenerationSucceededReason,
+		"generated %d HelmChart(s) for revision '%s'", len(entries), obj.Status.ObservedSourceArtifactRevision)
+	return sreconcile.ResultSuccess, nil
+}
+
+// mutateHelmChartSetChart sets the labels, annotations and spec of the given
+// v1.HelmChart to the template of the v1beta2.HelmChartSet for the given chart.
+// Defaults of the HelmChart API are set explicitly, to prevent an update of
+// every HelmChart on every reconciliation.
+func mutateHelmChartSetChart(obj *sourcev1beta2.HelmChartSet, hc *sourcev1.HelmChart, chart string) {
+	tpl := obj.Spec.Template
+
+	if hc.Labels == nil {
+		hc.Labels = make(map[string]string, len(tpl.Metadata.Labels)+1)
+	}
+	for k, v := range tpl.Metadata.Labels {
+		hc.Labels[k] = v
+	}
+	hc.Labels[sourcev1beta2.HelmChartSetNameLabel] = helmChartSetLabelValue(obj.GetName())
+	if len(tpl.Metadata.Annotations) > 0 && hc.Annotations == nil {
+		hc.Annotations = make(map[string]string, len(tpl.Metadata.Annotations))
+	}
+	for k, v := range tpl.Metadata.Annotations {
+		hc.Annotations[k] = v
+	}
+
+	hc.Spec.Chart = chart
+	hc.Spec.SourceRef = obj.Spec.SourceRef
+	hc.Spec.Version = tpl.Spec.Version
+	if hc.Spec.Version == "" {
+		hc.Spec.Version = "*"
+	}
+	hc.Spec.Interval = obj.Spec.Interval
+	if tpl.Spec.Interval != nil {
+		hc.Spec.Interval = *tpl.Spec.Interval
+	}
+	hc.Spec.ReconcileStrategy = tpl.Spec.ReconcileStrategy
+	if hc.Spec.ReconcileStrategy == "" {
+		hc.Spec.ReconcileStrategy = sourcev1.ReconcileStrategyChartVersion
+	}
+	hc.Spec.DependencyMode = tpl.Spec.DependencyMode
+	hc.Spec.VersionPolicy = tpl.Spec.VersionPolicy.DeepCopy()
+	hc.Spec.ServiceAccountName = tpl.Spec.ServiceAccountName
+}
+
+// helmChartSetChartNames returns the names of the v1.HelmChart objects
+// generated by the HelmChartSet with the given name for the given charts,
+// indexed by chart. The name recorded for a chart in the given entries is
+// reused, so that the HelmChart of a chart is not renamed when other charts
+// are added or removed. Otherwise, the name is derived from the chart, see
+// helmChartSetChartName, and hashed if another chart has the same base name.
+func helmChartSetChartNames(setName string, charts []string, entries []sourcev1beta2.HelmChartSetEntry) map[string]string {
+	var (
+		names      = make(map[string]string, len(charts))
+		taken      = make(map[string]bool, len(charts))
+		discovered = make(map[string]bool, len(charts))
+		bases      = make(map[string]int, len(charts))
+	)
+	for _, c := range charts {
+		discovered[c] = true
+		bases[helmChartSetChartName(setName, c, false)]++
+	}
+	for _, e := range entries {
+		if discovered[e.Chart] && !taken[e.Name] {
+			names[e.Chart] = e.Name
+			taken[e.Name] = true
+		}
+	}
+	for _, c := range charts {
+		if _, ok := names[c]; ok {
+			continue
+		}
+		name := helmChartSetChartName(setName, c, false)
+		if bases[name] > 1 || taken[name] {
+			name = helmChartSetChartName(setName, c, true)
+		}
+		names[c] = name
+		taken[name] = true
+	}
+	return names
+}
+
+// helmChartSetChartName returns the name of the v1.HelmChart generated by
+// the HelmChartSet with the given name for the given chart, which is the name
+// of the HelmChartSet followed by the base name of the chart. If hash is true,
+// or the name is not a valid object name, a hash of the chart is appended
+// to it.
+func helmChartSetChartName(setName, chart string, hash bool) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, setName+"-"+path.Base(chart))
+	if !hash && len(validation.IsDNS1123Subdomain(name)) == 0 {
+		return name
+	}
+	return truncateWithHash(name, chart, validation.DNS1123SubdomainMaxLength)
+}
+
+// helmChartSetLabelValue returns the value of the
+// sourcev1beta2.HelmChartSetNameLabel for the HelmChartSet with the given
+// name. Names which exceed the maximum length of a label value are truncated,
+// and a hash of the name is appended.
+func helmChartSetLabelValue(setName string) string {
+	if len(setName) <= validation.LabelValueMaxLength {
+		return setName
+	}
+	return truncateWithHash(setName, setName, validation.LabelValueMaxLength)
+}
+
+// truncateWithHash returns the given name truncated to fit the given
+// maximum length with a suffix of a hash of s appended to it.
+func truncateWithHash(name, s string, max int) string {
+	sum := sha256.Sum256([]byte(s))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	if max -= len(suffix); len(name) > max {
+		name = name[:max]
+	}
+	return strings.TrimRight(name, "-.") + suffix
+}
+
+// discoverIndexCharts returns the sorted names of the charts in the Helm
+// repository index read from r which match the given pattern.
+func discoverIndexCharts(r io.Reader, pattern string) ([]string, error) {
+	var charts []string
+	_, err := repository.StreamIndex(r, func(name string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}, func(name string, _ repo.ChartVersions) error {
+		charts = append(charts, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(charts)
+	return charts, nil
+}
+
+// discoverArtifactCharts returns the sorted relative paths of the
+// directories with a Chart.yaml file in the gzipped tarball read from r,
+// which match the given pattern.
+func discoverArtifactCharts(r io.Reader, pattern string) ([]string, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+
+	var charts []string
+	tr := tar.NewReader(gzr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg || path.Base(hdr.Name) != "Chart.yaml" {
+			continue
+		}
+		dir := path.Dir(path.Clean(strings.TrimPrefix(hdr.Name, "./")))
+		if ok, _ := path.Match(pattern, dir); ok {
+			charts = append(charts, "./"+dir)
+		}
+	}
+	sort.Strings(charts)
+	return charts, nil
+}
+
+func (r *HelmChartSetReconciler) indexHelmChartSetBySource(o client.Object) []string {
+	hcs, ok := o.(*sourcev1beta2.HelmChartSet)
+	if !ok {
+		panic(fmt.Sprintf("Expected a HelmChartSet, got %T", o))
+	}
+	return []string{fmt.Sprintf("%s/%s", hcs.Spec.SourceRef.Kind, hcs.Spec.SourceRef.Name)}
+}
+
+func (r *HelmChartSetReconciler) requestsForSourceChange(ctx context.Context, o client.Object) []reconcile.Request {
+	var kind string
+	switch o.(type) {
+	case *sourcev1.HelmRepository:
+		kind = sourcev1.HelmRepositoryKind
+	case *sourcev1.GitRepository:
+		kind = sourcev1.GitRepositoryKind
+	case *sourcev1beta2.Bucket:
+		kind = sourcev1beta2.BucketKind
+	case *sourcev1beta2.OCIRepository:
+		kind = sourcev1beta2.OCIRepositoryKind
+	default:
+		ctrl.LoggerFrom(ctx).Error(fmt.Errorf("expected a Source, got %T", o), "failed to get requests for Source change")
+		return nil
+	}
+
+	s, ok := o.(sourcev1.Source)
+	// If we do not have an artifact, we have no requests to make
+	if !ok || s.GetArtifact() == nil {
+		return nil
+	}
+
+	var list sourcev1beta2.HelmChartSetList
+	if err := r.List(ctx, &list, client.InNamespace(o.GetNamespace()), client.MatchingFields{
+		sourcev1beta2.SourceIndexKey: fmt.Sprintf("%s/%s", kind, o.GetName()),
+	}); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list HelmChartSets for Source change")
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for i, v := range list.Items {
+		if !s.GetArtifact().HasRevision(v.Status.ObservedSourceArtifactRevision) {
+			reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+		}
+	}
+	return reqs
+}
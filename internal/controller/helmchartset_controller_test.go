@@ -0,0 +1,356 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+)
+
+func TestHelmChartSetReconciler_reconcileSource(t *testing.T) {
+	g := NewWithT(t)
+
+	storage, err := NewStorage(t.TempDir(), "example.com", retentionTTL, retentionRecords)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	chartsArtifact := &sourcev1.Artifact{
+		Revision: "main@sha1:abcdefg12345678",
+		Path:     "charts.tgz",
+	}
+	g.Expect(storage.Archive(chartsArtifact, "testdata/charts", nil)).To(Succeed())
+
+	index := repo.NewIndexFile()
+	for _, name := range []string{"podinfo", "podinfo-extra", "nginx"} {
+		g.Expect(index.MustAdd(&helmchart.Metadata{APIVersion: "v2", Name: name, Version: "1.0.0"},
+			name+"-1.0.0.tgz", "https://example.com", "")).To(Succeed())
+	}
+	indexPath := filepath.Join(t.TempDir(), "index.yaml")
+	g.Expect(index.WriteFile(indexPath, 0o600)).To(Succeed())
+	indexArtifact := &sourcev1.Artifact{
+		Revision: "sha256:abcdefg12345678",
+		Path:     "index.yaml",
+	}
+	g.Expect(storage.CopyFromPath(indexArtifact, indexPath)).To(Succeed())
+
+	tests := []struct {
+		name       string
+		sourceRef  sourcev1.LocalHelmChartSourceReference
+		pattern    string
+		want       sreconcile.Result
+		wantErr    string
+		wantCharts []string
+	}{
+		{
+			name:       "discovers charts in artifact",
+			sourceRef:  sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.GitRepositoryKind, Name: "charts"},
+			pattern:    "testdata/charts/*",
+			want:       sreconcile.ResultSuccess,
+			wantCharts: []string{"./testdata/charts/helmchart", "./testdata/charts/helmchartwithdeps"},
+		},
+		{
+			name:       "discovers charts matching pattern in artifact",
+			sourceRef:  sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.GitRepositoryKind, Name: "charts"},
+			pattern:    "testdata/*/*deps",
+			want:       sreconcile.ResultSuccess,
+			wantCharts: []string{"./testdata/charts/helmchartwithdeps"},
+		},
+		{
+			name:       "discovers charts matching pattern in index",
+			sourceRef:  sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "index"},
+			pattern:    "podinfo*",
+			want:       sreconcile.ResultSuccess,
+			wantCharts: []string{"podinfo", "podinfo-extra"},
+		},
+		{
+			name:      "requeues source without artifact",
+			sourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.GitRepositoryKind, Name: "pending"},
+			pattern:   "*",
+			want:      sreconcile.ResultRequeue,
+		},
+		{
+			name:      "stalls on OCI HelmRepository",
+			sourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "oci"},
+			pattern:   "*",
+			wantErr:   "charts can not be discovered",
+		},
+		{
+			name:      "stalls on invalid pattern",
+			sourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.GitRepositoryKind, Name: "charts"},
+			pattern:   "[",
+			wantErr:   "invalid pattern",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			clientBuilder := fakeclient.NewClientBuilder().
+				WithScheme(testEnv.GetScheme()).
+				WithObjects(
+					&sourcev1.GitRepository{
+						ObjectMeta: metav1.ObjectMeta{Name: "charts", Namespace: "default"},
+						Status:     sourcev1.GitRepositoryStatus{Artifact: chartsArtifact},
+					},
+					&sourcev1.GitRepository{
+						ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default"},
+					},
+					&sourcev1.HelmRepository{
+						ObjectMeta: metav1.ObjectMeta{Name: "index", Namespace: "default"},
+						Status:     sourcev1.HelmRepositoryStatus{Artifact: indexArtifact},
+					},
+					&sourcev1.HelmRepository{
+						ObjectMeta: metav1.ObjectMeta{Name: "oci", Namespace: "default"},
+						Spec:       sourcev1.HelmRepositorySpec{Type: sourcev1.HelmRepositoryTypeOCI},
+					},
+				)
+
+			r := &HelmChartSetReconciler{
+				Client:        clientBuilder.Build(),
+				EventRecorder: record.NewFakeRecorder(32),
+				Storage:       storage,
+			}
+
+			obj := &sourcev1beta2.HelmChartSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "set", Namespace: "default"},
+				Spec: sourcev1beta2.HelmChartSetSpec{
+					SourceRef: tt.sourceRef,
+					Pattern:   tt.pattern,
+				},
+			}
+
+			var charts []string
+			got, err := r.reconcileSource(context.TODO(), nil, obj, &charts)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(conditions.IsTrue(obj, sourcev1.FetchFailedCondition)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(charts).To(Equal(tt.wantCharts))
+			if tt.want == sreconcile.ResultRequeue {
+				g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal("NoSourceArtifact"))
+			}
+		})
+	}
+}
+
+func TestHelmChartSetReconciler_reconcileCharts(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &sourcev1beta2.HelmChartSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "set",
+			Namespace: "default",
+			UID:       "7d6c7e1f-4a4e-4c4e-9a4b-1c8e6f1e2d3c",
+		},
+		Spec: sourcev1beta2.HelmChartSetSpec{
+			SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.GitRepositoryKind, Name: "charts"},
+			Pattern:   "charts/*",
+			Interval:  metav1.Duration{Duration: time.Minute},
+			Template: sourcev1beta2.HelmChartSetTemplate{
+				Metadata: sourcev1beta2.HelmChartSetTemplateMetadata{
+					Labels: map[string]string{"team": "platform"},
+				},
+				Spec: sourcev1beta2.HelmChartSetTemplateSpec{
+					ReconcileStrategy: sourcev1.ReconcileStrategyRevision,
+				},
+			},
+		},
+		Status: sourcev1beta2.HelmChartSetStatus{
+			ObservedSourceArtifactRevision: "main@sha1:abcdefg12345678",
+		},
+	}
+
+	c := fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build()
+	r := &HelmChartSetReconciler{
+		Client:        c,
+		EventRecorder: record.NewFakeRecorder(32),
+	}
+
+	// A HelmChart not controlled by the set with the name of a chart.
+	g.Expect(c.Create(context.TODO(), &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Name: "set-conflict", Namespace: "default"},
+	})).To(Succeed())
+
+	charts := []string{"./charts/app", "./charts/api"}
+	got, err := r.reconcileCharts(context.TODO(), nil, obj, &charts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+	g.Expect(conditions.IsTrue(obj, sourcev1beta2.HelmChartsGeneratedCondition)).To(BeTrue())
+	g.Expect(obj.Status.Charts).To(Equal([]sourcev1beta2.HelmChartSetEntry{
+		{Name: "set-app", Chart: "./charts/app"},
+		{Name: "set-api", Chart: "./charts/api"},
+	}))
+
+	var hc sourcev1.HelmChart
+	g.Expect(c.Get(context.TODO(), client.ObjectKey{Name: "set-app", Namespace: "default"}, &hc)).To(Succeed())
+	g.Expect(metav1.IsControlledBy(&hc, obj)).To(BeTrue())
+	g.Expect(hc.Labels).To(HaveKeyWithValue(sourcev1beta2.HelmChartSetNameLabel, "set"))
+	g.Expect(hc.Labels).To(HaveKeyWithValue("team", "platform"))
+	g.Expect(hc.Spec.Chart).To(Equal("./charts/app"))
+	g.Expect(hc.Spec.SourceRef).To(Equal(obj.Spec.SourceRef))
+	g.Expect(hc.Spec.Version).To(Equal("*"))
+	g.Expect(hc.Spec.Interval).To(Equal(obj.Spec.Interval))
+	g.Expect(hc.Spec.ReconcileStrategy).To(Equal(sourcev1.ReconcileStrategyRevision))
+
+	// Removing a chart deletes its HelmChart, and a conflicting name fails.
+	charts = []string{"./charts/app", "./charts/conflict"}
+	_, err = r.reconcileCharts(context.TODO(), nil, obj, &charts)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("HelmChart 'set-conflict' already exists"))
+	g.Expect(conditions.IsFalse(obj, sourcev1beta2.HelmChartsGeneratedCondition)).To(BeTrue())
+	g.Expect(obj.Status.Charts).To(Equal([]sourcev1beta2.HelmChartSetEntry{
+		{Name: "set-app", Chart: "./charts/app"},
+	}))
+
+	var list sourcev1.HelmChartList
+	g.Expect(c.List(context.TODO(), &list)).To(Succeed())
+	var names []string
+	for _, item := range list.Items {
+		names = append(names, item.Name)
+	}
+	g.Expect(names).To(ConsistOf("set-app", "set-conflict"))
+}
+
+func Test_helmChartSetChartNames(t *testing.T) {
+	hash := func(chart string) string {
+		sum := sha256.Sum256([]byte(chart))
+		return hex.EncodeToString(sum[:])[:8]
+	}
+
+	tests := []struct {
+		name    string
+		setName string
+		charts  []string
+		entries []sourcev1beta2.HelmChartSetEntry
+		want    map[string]string
+	}{
+		{
+			name:    "base name of chart path",
+			setName: "set",
+			charts:  []string{"./charts/podinfo"},
+			want:    map[string]string{"./charts/podinfo": "set-podinfo"},
+		},
+		{
+			name:    "invalid characters",
+			setName: "set",
+			charts:  []string{"./charts/Pod_Info"},
+			want:    map[string]string{"./charts/Pod_Info": "set-pod-info"},
+		},
+		{
+			name:    "same base name",
+			setName: "set",
+			charts:  []string{"./a/nginx", "./b/nginx", "./c/redis"},
+			want: map[string]string{
+				"./a/nginx": "set-nginx-" + hash("./a/nginx"),
+				"./b/nginx": "set-nginx-" + hash("./b/nginx"),
+				"./c/redis": "set-redis",
+			},
+		},
+		{
+			name:    "same base name in other order",
+			setName: "set",
+			charts:  []string{"./b/nginx", "./a/nginx"},
+			want: map[string]string{
+				"./a/nginx": "set-nginx-" + hash("./a/nginx"),
+				"./b/nginx": "set-nginx-" + hash("./b/nginx"),
+			},
+		},
+		{
+			name:    "recorded name is reused",
+			setName: "set",
+			charts:  []string{"./b/nginx"},
+			entries: []sourcev1beta2.HelmChartSetEntry{
+				{Name: "set-nginx-" + hash("./a/nginx"), Chart: "./a/nginx"},
+				{Name: "set-nginx-" + hash("./b/nginx"), Chart: "./b/nginx"},
+			},
+			want: map[string]string{"./b/nginx": "set-nginx-" + hash("./b/nginx")},
+		},
+		{
+			name:    "recorded name is not reused by other chart",
+			setName: "set",
+			charts:  []string{"./b/nginx"},
+			entries: []sourcev1beta2.HelmChartSetEntry{
+				{Name: "set-nginx", Chart: "./a/nginx"},
+			},
+			want: map[string]string{"./b/nginx": "set-nginx"},
+		},
+		{
+			name:    "name taken by recorded chart",
+			setName: "set",
+			charts:  []string{"./a/nginx", "./b/nginx-x"},
+			entries: []sourcev1beta2.HelmChartSetEntry{
+				{Name: "set-nginx-x", Chart: "./a/nginx"},
+			},
+			want: map[string]string{
+				"./a/nginx":   "set-nginx-x",
+				"./b/nginx-x": "set-nginx-x-" + hash("./b/nginx-x"),
+			},
+		},
+		{
+			name:    "too long name",
+			setName: strings.Repeat("a", 250),
+			charts:  []string{"podinfo"},
+			want:    map[string]string{"podinfo": strings.Repeat("a", 244) + "-" + hash("podinfo")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got := helmChartSetChartNames(tt.setName, tt.charts, tt.entries)
+			g.Expect(got).To(Equal(tt.want))
+			for _, name := range got {
+				g.Expect(validation.IsDNS1123Subdomain(name)).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func Test_helmChartSetLabelValue(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(helmChartSetLabelValue("set")).To(Equal("set"))
+
+	long := strings.Repeat("a", 60) + "." + strings.Repeat("b", 60)
+	got := helmChartSetLabelValue(long)
+	g.Expect(validation.IsValidLabelValue(got)).To(BeEmpty())
+	g.Expect(got).To(HavePrefix(strings.Repeat("a", 54)))
+	g.Expect(got).ToNot(Equal(helmChartSetLabelValue(strings.Repeat("a", 60) + "." + strings.Repeat("c", 60))))
+}
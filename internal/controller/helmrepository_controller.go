@@ -21,7 +21,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
+	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -29,15 +33,23 @@ import (
 	"github.com/opencontainers/go-digest"
 	helmgetter "helm.sh/helm/v3/pkg/getter"
 	helmreg "helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	kuberecorder "k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
 
 	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
 	"github.com/fluxcd/pkg/apis/meta"
@@ -49,11 +61,14 @@ import (
 	rreconcile "github.com/fluxcd/pkg/runtime/reconcile"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/fluxcd/source-controller/internal/cache"
 	intdigest "github.com/fluxcd/source-controller/internal/digest"
 	serror "github.com/fluxcd/source-controller/internal/error"
 	"github.com/fluxcd/source-controller/internal/helm/getter"
 	"github.com/fluxcd/source-controller/internal/helm/repository"
+	"github.com/fluxcd/source-controller/internal/hostlimit"
+	"github.com/fluxcd/source-controller/internal/impersonation"
 	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
@@ -64,6 +79,9 @@ import (
 var helmRepositoryReadyCondition = summarize.Conditions{
 	Target: meta.ReadyCondition,
 	Owned: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.RevisionHeldCondition,
+		sourcev1.PendingApprovalCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.ArtifactOutdatedCondition,
@@ -73,6 +91,7 @@ var helmRepositoryReadyCondition = summarize.Conditions{
 		meta.StalledCondition,
 	},
 	Summarize: []string{
+		sourcev1.DependencyNotReadyCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.ArtifactOutdatedCondition,
@@ -81,6 +100,7 @@ var helmRepositoryReadyCondition = summarize.Conditions{
 		meta.ReconcilingCondition,
 	},
 	NegativePolarity: []string{
+		sourcev1.DependencyNotReadyCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.ArtifactOutdatedCondition,
@@ -89,6 +109,16 @@ var helmRepositoryReadyCondition = summarize.Conditions{
 	},
 }
 
+// helmRepositoryIndexChartsKey is the key of the Artifact metadata which
+// records the names and patterns of the charts kept in the index of a
+// v1.HelmRepository Artifact.
+const helmRepositoryIndexChartsKey = "source.toolkit.fluxcd.io/charts"
+
+// helmRepositoryIndexHostnameKey is the key of the Artifact metadata which
+// records the Storage hostname of the chart URLs in the index of a
+// v1.HelmRepository Artifact in proxy mode.
+const helmRepositoryIndexHostnameKey = "source.toolkit.fluxcd.io/storage-hostname"
+
 // helmRepositoryFailConditions contains the conditions that represent a
 // failure.
 var helmRepositoryFailConditions = []string{
@@ -111,15 +141,31 @@ type HelmRepositoryReconciler struct {
 	Storage        *Storage
 	ControllerName string
 
-	Cache *cache.Cache
-	TTL   time.Duration
+	// RegistryClientGenerator is used to probe the registry of OCI
+	// HelmRepositories.
+	RegistryClientGenerator RegistryClientGeneratorFunc
+	// TagCache is used to share the tags listed while probing the registry
+	// of OCI HelmRepositories with the HelmChartReconciler.
+	TagCache *cache.TagCache
+
+	// HostLimiter limits the concurrency and rate of remote operations per
+	// host. It is shared between reconcilers, and disabled when nil.
+	HostLimiter *hostlimit.Limiter
+
+	// Impersonator impersonates the ServiceAccount of the object to read its
+	// Secrets in multi-tenant lockdown mode. It is disabled when nil.
+	Impersonator *impersonation.Impersonator
+
 	*cache.CacheRecorder
 
+	requeueDependency time.Duration
+
 	patchOptions []patch.Option
 }
 
 type HelmRepositoryReconcilerOptions struct {
-	RateLimiter ratelimiter.RateLimiter
+	DependencyRequeueInterval time.Duration
+	RateLimiter               ratelimiter.RateLimiter
 }
 
 // helmRepositoryReconcileFunc is the function type for all the
@@ -134,14 +180,25 @@ func (r *HelmRepositoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 func (r *HelmRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts HelmRepositoryReconcilerOptions) error {
 	r.patchOptions = getPatchOptions(helmRepositoryReadyCondition.Owned, r.ControllerName)
+	r.requeueDependency = opts.DependencyRequeueInterval
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&sourcev1.HelmRepository{}).
-		WithEventFilter(
+		For(&sourcev1.HelmRepository{}, builder.WithPredicates(
 			predicate.And(
 				intpredicates.HelmRepositoryOCIMigrationPredicate{},
-				predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
+				predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+					intpredicates.ApprovedRevisionPredicate{}),
 			),
+		)).
+		Watches(
+			&sourcev1.HelmChart{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForChartReferenceChange),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&sourcev1beta2.HelmChartSet{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForChartReferenceChange),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
 		).
 		WithOptions(controller.Options{
 			RateLimiter: opts.RateLimiter,
@@ -162,8 +219,12 @@ func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	// Initialize the patch helper with the current version of the object.
 	serialPatcher := patch.NewSerialPatcher(obj, r.Client)
 
-	// If it's of type OCI, migrate the object to static.
+	// If it's of type OCI, probe the registry if enabled, or else migrate
+	// the object to static.
 	if obj.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
+		if obj.Spec.Probe {
+			return r.reconcileOCI(ctx, serialPatcher, obj)
+		}
 		return r.migrationToStatic(ctx, serialPatcher, obj)
 	}
 
@@ -184,7 +245,8 @@ func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 				summarize.RecordReconcileReq,
 			),
 			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
-				RequeueAfter: jitter.JitteredIntervalDuration(obj.GetRequeueAfter()),
+				RequeueAfter: holdRequeueAfter(obj, obj.Spec.Schedule,
+					jitter.JitteredIntervalDuration(obj.GetRequeueAfter()), time.Now()),
 			}),
 			summarize.WithPatchFieldOwner(r.ControllerName),
 		}
@@ -219,6 +281,12 @@ func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return
 	}
 
+	// Wait for the dependencies of the object to be ready.
+	if err := checkSourceDependencies(ctx, r.Client, obj, obj.Spec.DependsOn, r.requeueDependency); err != nil {
+		recResult, retErr = sreconcile.ResultEmpty, err
+		return
+	}
+
 	// Reconcile actual object
 	reconcilers := []helmRepositoryReconcileFunc{
 		r.reconcileStorage,
@@ -380,6 +448,9 @@ func (r *HelmRepositoryReconciler) reconcileStorage(ctx context.Context, sp *pat
 	// TODO(hidde): we may want to send out an event only if we notice the URL has changed
 	r.Storage.SetArtifactURL(obj.GetArtifact())
 	obj.Status.URL = r.Storage.SetHostname(obj.Status.URL)
+	for i := range obj.Status.ChartArtifacts {
+		r.Storage.SetArtifactURL(&obj.Status.ChartArtifacts[i])
+	}
 
 	return sreconcile.ResultSuccess, nil
 }
@@ -416,7 +487,12 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 		return sreconcile.ResultEmpty, e
 	}
 
-	clientOpts, _, err := getter.GetClientOpts(ctx, r.Client, obj, normalizedURL)
+	secrets, err := secretClient(obj, r.Client, r.Impersonator, obj.Spec.ServiceAccountName)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
+	clientOpts, _, err := getter.GetClientOpts(ctx, secrets, obj, normalizedURL)
 	if err != nil {
 		if errors.Is(err, getter.ErrDeprecatedTLSConfig) {
 			ctrl.LoggerFrom(ctx).
@@ -432,7 +508,7 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 	}
 
 	// Construct Helm chart repository with options and download index
-	newChartRepo, err := repository.NewChartRepository(obj.Spec.URL, "", r.Getters, clientOpts.TlsConfig, clientOpts.GetterOpts...)
+	newChartRepo, err := repository.NewChartRepository(obj.Spec.URL, "", clientOpts.Getters(r.Getters), clientOpts.TlsConfig, clientOpts.GetterOpts...)
 	if err != nil {
 		switch err.(type) {
 		case *url.Error:
@@ -452,20 +528,54 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 		}
 	}
 
-	// Fetch the repository index from remote.
-	if err := newChartRepo.CacheIndex(); err != nil {
-		e := serror.NewGeneric(
-			fmt.Errorf("failed to fetch Helm repository index: %w", err),
-			meta.FailedReason,
-		)
-		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
-		// Coin flip on transient or persistent error, return error and hope for the best
-		return sreconcile.ResultEmpty, e
+	// Fetch the repository index from remote, falling back to the mirrors
+	// in order. Hosts of which the circuit breaker is open are skipped.
+	err = r.cacheIndex(obj, newChartRepo, obj.Spec.URL)
+	if hostLimited(err) {
+		return sreconcile.ResultEmpty, err
+	}
+	if err != nil {
+		errs := []error{err}
+		newChartRepo = nil
+		for _, m := range obj.Spec.Mirrors {
+			mirrorRepo, err := newMirrorChartRepository(ctx, secrets, r.Getters, obj, m)
+			if err == nil {
+				err = r.cacheIndex(obj, mirrorRepo, m.URL)
+			}
+			if hostLimited(err) {
+				return sreconcile.ResultEmpty, err
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("mirror '%s': %w", m.URL, err))
+				continue
+			}
+			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "MirrorFallback",
+				"fetched Helm repository index from mirror '%s'", m.URL)
+			newChartRepo = mirrorRepo
+			break
+		}
+		if newChartRepo == nil {
+			// Requeue once a probe may be attempted if the circuit breakers
+			// of all hosts are open.
+			if allHostsUnavailable(errs) {
+				return sreconcile.ResultEmpty, errs[0]
+			}
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to fetch Helm repository index: %w", kerrors.NewAggregate(errs)),
+				meta.FailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			// Coin flip on transient or persistent error, return error and hope for the best
+			return sreconcile.ResultEmpty, e
+		}
 	}
 	*chartRepo = *newChartRepo
 
 	// Early comparison to current Artifact.
-	if curArtifact := obj.GetArtifact(); curArtifact != nil {
+	// In proxy mode, or after it was disabled, the Artifact also depends on
+	// the spec of the object, and the comparison is skipped when it changed.
+	proxied := obj.Spec.Proxy != nil || len(obj.Status.ChartArtifacts) > 0
+	if curArtifact := obj.GetArtifact(); curArtifact != nil && (!proxied || obj.Generation == obj.Status.ObservedGeneration) {
 		curRev := digest.Digest(curArtifact.Revision)
 		if curRev.Validate() == nil {
 			// Short-circuit based on the fetched index being an exact match to the
@@ -478,8 +588,9 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 		}
 	}
 
-	// Load the cached repository index to ensure it passes validation.
-	if err := chartRepo.LoadFromPath(); err != nil {
+	// Stream the cached repository index to ensure it passes validation,
+	// without loading it into memory.
+	if err := chartRepo.ValidateIndex(); err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("failed to load Helm repository from index YAML: %w", err),
 			sourcev1.IndexationFailedReason,
@@ -521,6 +632,23 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 	return sreconcile.ResultSuccess, nil
 }
 
+// cacheIndex caches the index of the given ChartRepository as a remote
+// operation on the host of the given URL, and records the result with the
+// circuit breaker of the host. It returns the error of reserveHost without
+// fetching the index if the operation may not start yet, or if the circuit
+// breaker of the host is open.
+func (r *HelmRepositoryReconciler) cacheIndex(obj *sourcev1.HelmRepository, chartRepo *repository.ChartRepository, u string) error {
+	res, err := reserveHost(obj, r.HostLimiter, u)
+	if err != nil {
+		return err
+	}
+	defer res.Release()
+
+	err = chartRepo.CacheIndex()
+	recordHostResult(r.EventRecorder, obj, res, err)
+	return err
+}
+
 // reconcileArtifact archives a new Artifact to the Storage, if the current
 // (Status) data on the object does not match the given.
 //
@@ -534,7 +662,10 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 	// Set the ArtifactInStorageCondition if there's no drift.
 	defer func() {
 		if obj.GetArtifact().HasRevision(artifact.Revision) {
+			obj.Status.ActiveURL = chartRepo.URL
 			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+			conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+			obj.Status.PendingArtifact = nil
 			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, meta.SucceededReason,
 				"stored artifact: revision '%s'", artifact.Revision)
 		}
@@ -543,13 +674,37 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 		}
 	}()
 
-	if obj.GetArtifact().HasRevision(artifact.Revision) && obj.GetArtifact().HasDigest(artifact.Digest) {
-		// Extend TTL of the Index in the cache (if present).
-		if r.Cache != nil {
-			r.Cache.SetExpiration(artifact.Path, r.TTL)
+	// Hold the new revision while the schedule is closed
+	if held, err := holdRevision(obj, obj.Spec.Schedule, obj.GetArtifact(), artifact.Revision, time.Now()); err != nil {
+		return sreconcile.ResultEmpty, err
+	} else if held {
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// Outside of proxy mode, only the entries of the charts referenced by
+	// HelmCharts and HelmChartSets are kept in the stored index.
+	var charts []string
+	if obj.Spec.Proxy == nil {
+		var err error
+		if charts, err = r.referencedCharts(ctx, obj); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, meta.FailedReason)
 		}
+	}
+	chartsMetadata := strings.Join(charts, ",")
 
+	// In proxy mode, the index is regenerated when the Storage hostname of the
+	// mirrored chart URLs changed.
+	upToDate := hasIndexCharts(obj.GetArtifact(), chartsMetadata)
+	if obj.Spec.Proxy != nil {
+		upToDate = hasIndexHostname(obj.GetArtifact(), r.Storage.Hostname)
+	}
+	if obj.GetArtifact().HasRevision(artifact.Revision) && obj.GetArtifact().HasDigest(artifact.Digest) && upToDate {
 		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason, "artifact up-to-date with remote revision: '%s'", artifact.Revision)
+		// Only (re)summarize the index if the summary has been enabled or
+		// disabled since the Artifact was recorded.
+		if obj.Spec.Summary != (obj.Status.Summary != nil) {
+			r.summarizeArtifact(ctx, obj, chartRepo)
+		}
 		return sreconcile.ResultSuccess, nil
 	}
 
@@ -573,17 +728,59 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 	}
 	defer unlock()
 
-	// Save artifact to storage in JSON format.
-	b, err := chartRepo.ToJSON()
-	if err != nil {
-		e := serror.NewGeneric(
-			fmt.Errorf("unable to get JSON index from chart repo: %w", err),
-			sourcev1.ArchiveOperationFailedReason,
-		)
-		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
-		return sreconcile.ResultEmpty, e
+	// In proxy mode, mirror the selected charts and save the index
+	// referencing them to storage.
+	if obj.Spec.Proxy != nil {
+		res, err := reserveHost(obj, r.HostLimiter, obj.Spec.URL)
+		if err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+		defer res.Release()
+
+		index, chartArtifacts, err := r.mirrorCharts(ctx, obj, chartRepo)
+		recordHostResult(r.EventRecorder, obj, res, err)
+		if err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+		b, err := yaml.Marshal(index)
+		if err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("unable to marshal index of mirrored charts: %w", err),
+				sourcev1.ArchiveOperationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		if artifact.Metadata == nil {
+			artifact.Metadata = map[string]string{}
+		}
+		artifact.Metadata[helmRepositoryIndexHostnameKey] = r.Storage.Hostname
+		if err = r.Storage.Copy(artifact, bytes.NewReader(b)); err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("unable to save artifact to storage: %w", err),
+				sourcev1.ArchiveOperationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		return r.recordArtifact(ctx, obj, artifact, chartArtifacts, chartRepo)
 	}
-	if err = r.Storage.Copy(artifact, bytes.NewBuffer(b)); err != nil {
+
+	// Save artifact to storage in JSON format, converting the index one
+	// chart at a time, and only keeping the entries of the referenced charts.
+	if artifact.Metadata == nil {
+		artifact.Metadata = map[string]string{}
+	}
+	artifact.Metadata[helmRepositoryIndexChartsKey] = chartsMetadata
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(chartRepo.WriteJSON(pw, func(name string) bool {
+			return matchesChartPatterns(charts, name)
+		}))
+	}()
+	err = r.Storage.Copy(artifact, pr)
+	pr.Close()
+	if err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("unable to save artifact to storage: %w", err),
 			sourcev1.ArchiveOperationFailedReason,
@@ -592,19 +789,25 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 		return sreconcile.ResultEmpty, e
 	}
 
-	// Record it on the object.
-	obj.Status.Artifact = artifact.DeepCopy()
+	return r.recordArtifact(ctx, obj, artifact, nil, chartRepo)
+}
 
-	// Cache the index if it was successfully retrieved.
-	if r.Cache != nil && chartRepo.Index != nil {
-		// The cache keys have to be safe in multi-tenancy environments, as
-		// otherwise it could be used as a vector to bypass the repository's
-		// authentication. Using the Artifact.Path is safe as the path is in
-		// the format of: /<repository-name>/<chart-name>/<filename>.
-		if err := r.Cache.Set(artifact.Path, chartRepo.Index, r.TTL); err != nil {
-			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.CacheOperationFailedReason, "failed to cache index: %s", err)
-		}
+// recordArtifact records the given Artifact and chart Artifacts on the
+// object, and updates the index symlink in the Storage to point to it. If the
+// new revision awaits approval, it is recorded as the pending Artifact
+// instead.
+func (r *HelmRepositoryReconciler) recordArtifact(ctx context.Context, obj *sourcev1.HelmRepository, artifact *sourcev1.Artifact,
+	chartArtifacts []sourcev1.Artifact, chartRepo *repository.ChartRepository) (sreconcile.Result, error) {
+	// Keep serving the current artifact until the new revision is approved
+	if awaitArtifactApproval(obj, obj.Spec.Approval, r.Storage, obj.GetArtifact(), artifact) {
+		obj.Status.PendingArtifact = artifact.DeepCopy()
+		return sreconcile.ResultSuccess, nil
 	}
+	obj.Status.PendingArtifact = nil
+
+	// Record it on the object.
+	obj.Status.Artifact = artifact.DeepCopy()
+	obj.Status.ChartArtifacts = chartArtifacts
 
 	// Update index symlink.
 	indexURL, err := r.Storage.Symlink(*artifact, "index.yaml")
@@ -616,9 +819,271 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 		obj.Status.URL = indexURL
 	}
 	conditions.Delete(obj, sourcev1.StorageOperationFailedCondition)
+	r.summarizeArtifact(ctx, obj, chartRepo)
 	return sreconcile.ResultSuccess, nil
 }
 
+// summarizeArtifact sets the summary of the charts in the index of the
+// Artifact of the object in its Status, if enabled by the spec.
+// As the stored index only contains the entries of the referenced charts
+// outside of proxy mode, the fetched index of the given ChartRepository is
+// summarized instead in that case.
+// As the summary is informational, a failure to summarize the index is
+// recorded as an event instead of failing the reconciliation.
+func (r *HelmRepositoryReconciler) summarizeArtifact(ctx context.Context, obj *sourcev1.HelmRepository,
+	chartRepo *repository.ChartRepository) {
+	obj.Status.Summary = nil
+	if !obj.Spec.Summary || obj.GetArtifact() == nil {
+		return
+	}
+
+	indexPath := r.Storage.LocalPath(*obj.GetArtifact())
+	if obj.Spec.Proxy == nil {
+		indexPath = chartRepo.Path
+	}
+	f, err := os.Open(indexPath)
+	if err != nil {
+		r.eventLogf(ctx, obj, corev1.EventTypeWarning, sourcev1.IndexationFailedReason,
+			"failed to summarize index: %s", err)
+		return
+	}
+	defer f.Close()
+
+	charts, err := repository.SummarizeIndex(f)
+	if err != nil {
+		r.eventLogf(ctx, obj, corev1.EventTypeWarning, sourcev1.IndexationFailedReason,
+			"failed to summarize index: %s", err)
+		return
+	}
+
+	summary := &sourcev1.HelmRepositorySummary{
+		ChartCount: len(charts),
+	}
+	for _, c := range charts[:min(len(charts), sourcev1.MaxHelmRepositorySummaryCharts)] {
+		summary.Charts = append(summary.Charts, sourcev1.HelmRepositoryChartSummary{
+			Name:          c.Name,
+			LatestVersion: c.LatestVersion,
+			Versions:      c.Versions,
+		})
+	}
+	obj.Status.Summary = summary
+}
+
+// referencedCharts returns the sorted names of the charts referenced by the
+// HelmCharts of the given v1.HelmRepository, and the patterns of the charts
+// discovered by its HelmChartSets.
+func (r *HelmRepositoryReconciler) referencedCharts(ctx context.Context, obj *sourcev1.HelmRepository) ([]string, error) {
+	sourceKey := fmt.Sprintf("%s/%s", sourcev1.HelmRepositoryKind, obj.Name)
+
+	var charts sourcev1.HelmChartList
+	if err := r.List(ctx, &charts, client.InNamespace(obj.Namespace),
+		client.MatchingFields{sourcev1.SourceIndexKey: sourceKey}); err != nil {
+		return nil, fmt.Errorf("failed to list HelmCharts: %w", err)
+	}
+	var sets sourcev1beta2.HelmChartSetList
+	if err := r.List(ctx, &sets, client.InNamespace(obj.Namespace),
+		client.MatchingFields{sourcev1beta2.SourceIndexKey: sourceKey}); err != nil {
+		return nil, fmt.Errorf("failed to list HelmChartSets: %w", err)
+	}
+
+	seen := map[string]struct{}{}
+	for _, hc := range charts.Items {
+		seen[hc.Spec.Chart] = struct{}{}
+	}
+	for _, hcs := range sets.Items {
+		seen[hcs.Spec.Pattern] = struct{}{}
+	}
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// hasIndexCharts returns true if the given Artifact records the given charts
+// as kept in its index.
+func hasIndexCharts(artifact *sourcev1.Artifact, charts string) bool {
+	if artifact == nil {
+		return false
+	}
+	v, ok := artifact.Metadata[helmRepositoryIndexChartsKey]
+	return ok && v == charts
+}
+
+// hasIndexHostname returns true if the given Artifact records the given
+// Storage hostname as used for the chart URLs in its index.
+func hasIndexHostname(artifact *sourcev1.Artifact, hostname string) bool {
+	if artifact == nil {
+		return false
+	}
+	v, ok := artifact.Metadata[helmRepositoryIndexHostnameKey]
+	return ok && v == hostname
+}
+
+// matchesChartPatterns returns true if the given chart name equals or matches
+// any of the given patterns.
+func matchesChartPatterns(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok || p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// requestsForChartReferenceChange returns the reconcile request for the
+// v1.HelmRepository a HelmChart or HelmChartSet refers to, to update the
+// charts kept in the index of its Artifact.
+func (r *HelmRepositoryReconciler) requestsForChartReferenceChange(ctx context.Context, o client.Object) []reconcile.Request {
+	var sourceRef sourcev1.LocalHelmChartSourceReference
+	switch obj := o.(type) {
+	case *sourcev1.HelmChart:
+		sourceRef = obj.Spec.SourceRef
+	case *sourcev1beta2.HelmChartSet:
+		sourceRef = obj.Spec.SourceRef
+	default:
+		ctrl.LoggerFrom(ctx).Error(fmt.Errorf("expected a HelmChart or HelmChartSet, got %T", o),
+			"failed to get reconcile requests for chart reference change")
+		return nil
+	}
+	if sourceRef.Kind != sourcev1.HelmRepositoryKind {
+		return nil
+	}
+
+	key := types.NamespacedName{Namespace: o.GetNamespace(), Name: sourceRef.Name}
+	repo := &sourcev1.HelmRepository{}
+	if err := r.Get(ctx, key, repo); err != nil {
+		return nil
+	}
+	// Only the index of a static HelmRepository without proxy is filtered.
+	if repo.Spec.Type == sourcev1.HelmRepositoryTypeOCI || repo.Spec.Proxy != nil {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: key}}
+}
+
+// mirrorCharts copies the chart versions selected by the proxy configuration
+// of the object from the repository to the Storage, and returns an index of
+// the mirrored chart versions with their URLs pointing to the Storage,
+// together with the Artifacts of the chart tarballs.
+// Chart tarballs which are already in the Storage from a previous
+// reconciliation are not downloaded again.
+func (r *HelmRepositoryReconciler) mirrorCharts(ctx context.Context, obj *sourcev1.HelmRepository,
+	chartRepo *repository.ChartRepository) (*repo.IndexFile, []sourcev1.Artifact, error) {
+	existing := make(map[string]sourcev1.Artifact, len(obj.Status.ChartArtifacts))
+	for _, a := range obj.Status.ChartArtifacts {
+		existing[a.Path] = a
+	}
+
+	index := repo.NewIndexFile()
+	chartRepo.PartialIndex = true
+	var chartArtifacts []sourcev1.Artifact
+	for _, c := range obj.Spec.Proxy.Charts {
+		policy, err := versionPolicyFor(c.VersionPolicy)
+		if err != nil {
+			e := serror.NewStalling(
+				fmt.Errorf("invalid proxy configuration for chart '%s': %w", c.Name, err),
+				sourcev1.ChartMirrorFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return nil, nil, e
+		}
+		chartRepo.VersionPolicy = policy
+
+		cvs, err := chartRepo.GetChartVersions(c.Name, c.Version, c.GetMaxVersions())
+		if err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to select versions of chart '%s' to mirror: %w", c.Name, err),
+				sourcev1.ChartMirrorFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return nil, nil, e
+		}
+
+		for _, cv := range cvs {
+			chartArtifact, err := r.mirrorChartVersion(ctx, obj, chartRepo, cv, existing)
+			if err != nil {
+				return nil, nil, err
+			}
+			chartArtifacts = append(chartArtifacts, chartArtifact)
+
+			mirrored := *cv
+			mirrored.URLs = []string{chartArtifact.URL}
+			index.Entries[cv.Name] = append(index.Entries[cv.Name], &mirrored)
+		}
+	}
+
+	// Use the generation timestamp of the upstream index, to ensure the
+	// index only changes when the mirrored charts do.
+	if chartRepo.Index != nil {
+		index.Generated = chartRepo.Index.Generated
+	}
+	index.SortEntries()
+	return index, chartArtifacts, nil
+}
+
+// mirrorChartVersion copies the tarball of the given chart version from the
+// repository to the Storage, unless it is present in the given existing
+// Artifacts, and returns its Artifact.
+func (r *HelmRepositoryReconciler) mirrorChartVersion(ctx context.Context, obj *sourcev1.HelmRepository,
+	chartRepo *repository.ChartRepository, cv *repo.ChartVersion, existing map[string]sourcev1.Artifact) (sourcev1.Artifact, error) {
+	fileName := fmt.Sprintf("%s-%s.tgz", cv.Name, cv.Version)
+	if strings.ContainsAny(fileName, "/\\") {
+		e := serror.NewGeneric(
+			fmt.Errorf("invalid file name '%s' for chart '%s' version '%s'", fileName, cv.Name, cv.Version),
+			sourcev1.ChartMirrorFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sourcev1.Artifact{}, e
+	}
+
+	// The revision includes the upstream digest (if known), to detect charts
+	// which were republished with the same version.
+	revision := cv.Version
+	if cv.Digest != "" {
+		revision = fmt.Sprintf("%s@sha256:%s", cv.Version, strings.TrimPrefix(cv.Digest, "sha256:"))
+	}
+	chartArtifact := r.Storage.NewArtifactFor(obj.Kind, obj.GetObjectMeta(), revision, fileName)
+	if a, ok := existing[chartArtifact.Path]; ok && a.Revision == revision && r.Storage.ArtifactExist(a) {
+		r.Storage.SetArtifactURL(&a)
+		return a, nil
+	}
+
+	res, err := chartRepo.DownloadChart(cv)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to download chart '%s' version '%s': %w", cv.Name, cv.Version, err),
+			sourcev1.ChartMirrorFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sourcev1.Artifact{}, e
+	}
+	if cv.Digest != "" {
+		if sum := digest.SHA256.FromBytes(res.Bytes()).Encoded(); sum != strings.TrimPrefix(cv.Digest, "sha256:") {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to verify chart '%s' version '%s': digest mismatch: expected '%s', got '%s'",
+					cv.Name, cv.Version, cv.Digest, sum),
+				sourcev1.ChartMirrorFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sourcev1.Artifact{}, e
+		}
+	}
+
+	if err = r.Storage.Copy(&chartArtifact, res); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("unable to save chart '%s' version '%s' to storage: %w", cv.Name, cv.Version, err),
+			sourcev1.ArchiveOperationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sourcev1.Artifact{}, e
+	}
+	r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "ChartMirrored",
+		"mirrored chart '%s' version '%s'", cv.Name, cv.Version)
+	return chartArtifact, nil
+}
+
 // reconcileDelete handles the deletion of the object.
 // It first garbage collects all Artifacts for the object from the Storage.
 // Removing the finalizer from the object if successful.
@@ -663,13 +1128,20 @@ func (r *HelmRepositoryReconciler) garbageCollect(ctx context.Context, obj *sour
 		}
 		// Clean status sub-resource
 		obj.Status.Artifact = nil
+		obj.Status.PendingArtifact = nil
+		obj.Status.ChartArtifacts = nil
+		obj.Status.ActiveURL = ""
 		obj.Status.URL = ""
 		// Remove any stale conditions.
 		obj.Status.Conditions = nil
 		return nil
 	}
 	if obj.GetArtifact() != nil {
-		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5)
+		keep := obj.Status.ChartArtifacts
+		if pending := obj.Status.PendingArtifact; pending != nil {
+			keep = append(keep, *pending)
+		}
+		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5, keep...)
 		if err != nil {
 			return serror.NewGeneric(
 				fmt.Errorf("garbage collection of artifacts failed: %w", err),
@@ -685,6 +1157,38 @@ func (r *HelmRepositoryReconciler) garbageCollect(ctx context.Context, obj *sour
 	return nil
 }
 
+// newMirrorChartRepository returns a repository.ChartRepository for the given
+// mirror of the v1.HelmRepository, configured with the credentials of the
+// mirror.
+func newMirrorChartRepository(ctx context.Context, c client.Client, getters helmgetter.Providers,
+	obj *sourcev1.HelmRepository, mirror sourcev1.HelmRepositoryMirror) (*repository.ChartRepository, error) {
+	normalizedURL, err := repository.NormalizeURL(mirror.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Helm repository mirror URL: %w", err)
+	}
+
+	// Shim v1.HelmRepository for the mirror, to construct the client
+	// options from its credentials.
+	mirrorObj := &sourcev1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      obj.Name,
+			Namespace: obj.Namespace,
+		},
+		Spec: sourcev1.HelmRepositorySpec{
+			URL:             normalizedURL,
+			SecretRef:       mirror.SecretRef,
+			CertSecretRef:   mirror.CertSecretRef,
+			PassCredentials: obj.Spec.PassCredentials,
+			Timeout:         obj.Spec.Timeout,
+		},
+	}
+	clientOpts, _, err := getter.GetClientOpts(ctx, c, mirrorObj, normalizedURL)
+	if err != nil && !errors.Is(err, getter.ErrDeprecatedTLSConfig) {
+		return nil, err
+	}
+	return repository.NewChartRepository(normalizedURL, "", clientOpts.Getters(getters), clientOpts.TlsConfig, clientOpts.GetterOpts...)
+}
+
 // eventLogf records events, and logs at the same time.
 //
 // This log is different from the debug log in the EventRecorder, in the sense
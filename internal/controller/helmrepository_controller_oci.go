@@ -0,0 +1,321 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	helmreg "helm.sh/helm/v3/pkg/registry"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/fluxcd/pkg/runtime/jitter"
+	"github.com/fluxcd/pkg/runtime/patch"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/fluxcd/source-controller/internal/cache"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/helm/getter"
+	"github.com/fluxcd/source-controller/internal/helm/repository"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
+)
+
+// helmRepositoryOCIReadyCondition contains the information required to
+// summarize a v1.HelmRepository of type OCI which is probed.
+var helmRepositoryOCIReadyCondition = summarize.Conditions{
+	Target: meta.ReadyCondition,
+	Owned: []string{
+		sourcev1.FetchFailedCondition,
+		meta.ReadyCondition,
+		meta.ReconcilingCondition,
+		meta.StalledCondition,
+	},
+	Summarize: []string{
+		sourcev1.FetchFailedCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+	NegativePolarity: []string{
+		sourcev1.FetchFailedCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+}
+
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=helmcharts,verbs=get;list;watch
+
+// reconcileOCI reconciles a v1.HelmRepository of type OCI with probing
+// enabled. Unlike a static OCI HelmRepository, the object has a status
+// reflecting the result of the last probe of the registry.
+func (r *HelmRepositoryReconciler) reconcileOCI(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1.HelmRepository) (result ctrl.Result, retErr error) {
+	start := time.Now()
+	log := ctrl.LoggerFrom(ctx)
+
+	// recResult stores the abstracted reconcile result.
+	var recResult sreconcile.Result
+
+	// Always attempt to patch the object after each reconciliation.
+	// NOTE: The final runtime result and error are set in this block.
+	defer func() {
+		summarizeHelper := summarize.NewHelper(r.EventRecorder, sp)
+		summarizeOpts := []summarize.Option{
+			summarize.WithConditions(helmRepositoryOCIReadyCondition),
+			summarize.WithReconcileResult(recResult),
+			summarize.WithReconcileError(retErr),
+			summarize.WithIgnoreNotFound(),
+			summarize.WithProcessors(
+				summarize.ErrorActionHandler,
+				summarize.RecordReconcileReq,
+			),
+			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
+				RequeueAfter: jitter.JitteredIntervalDuration(obj.GetRequeueAfter()),
+			}),
+			summarize.WithPatchFieldOwner(r.ControllerName),
+		}
+		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
+
+		// Always record suspend, readiness and duration metrics.
+		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
+		r.Metrics.RecordReadiness(ctx, obj)
+		r.Metrics.RecordDuration(ctx, obj, start)
+	}()
+
+	// Remove any Artifact and the finalizer left by a reconciliation of the
+	// object before it was of type OCI, as probing does not produce an
+	// Artifact.
+	if controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
+		if recResult, retErr = r.reconcileDelete(ctx, obj); retErr != nil {
+			return
+		}
+		controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+		obj.Status.Artifact = nil
+		obj.Status.URL = ""
+		obj.Status.ActiveURL = ""
+		obj.Status.ChartArtifacts = nil
+		obj.Status.Summary = nil
+	}
+
+	// Examine if the object is under deletion.
+	if !obj.ObjectMeta.DeletionTimestamp.IsZero() {
+		recResult = sreconcile.ResultEmpty
+		return
+	}
+
+	// Return if the object is suspended.
+	if obj.Spec.Suspend {
+		log.Info("reconciliation is suspended for this object")
+		recResult, retErr = sreconcile.ResultEmpty, nil
+		return
+	}
+
+	reconcilers := []helmRepositoryReconcileFunc{
+		r.reconcileOCIProbe,
+	}
+	recResult, retErr = r.reconcile(ctx, sp, obj, reconcilers)
+	return
+}
+
+// reconcileOCIProbe probes the registry of a v1.HelmRepository of type OCI,
+// by logging in to the registry if credentials are configured, and listing
+// the tags of the charts referenced by v1.HelmChart objects. The listed tags
+// are stored in the TagCache, to be shared with the HelmChartReconciler.
+//
+// On success, it marks the object as Ready. If the registry can not be
+// probed, it records v1.FetchFailedCondition=True and returns an error.
+func (r *HelmRepositoryReconciler) reconcileOCIProbe(ctx context.Context, _ *patch.SerialPatcher,
+	obj *sourcev1.HelmRepository, _ *sourcev1.Artifact, _ *repository.ChartRepository) (sreconcile.Result, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, obj.GetTimeout())
+	defer cancel()
+
+	normalizedURL, err := repository.NormalizeURL(obj.Spec.URL)
+	if err == nil && !helmreg.IsOCI(normalizedURL) {
+		err = fmt.Errorf("URL scheme must be '%s'", helmreg.OCIScheme)
+	}
+	if err != nil {
+		e := serror.NewStalling(
+			fmt.Errorf("invalid OCI registry URL '%s': %w", obj.Spec.URL, err),
+			sourcev1.URLInvalidReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	secrets, err := secretClient(obj, r.Client, r.Impersonator, obj.Spec.ServiceAccountName)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
+	clientOpts, certsTmpDir, err := getter.GetClientOpts(ctxTimeout, secrets, obj, normalizedURL)
+	if err != nil && !errors.Is(err, getter.ErrDeprecatedTLSConfig) {
+		e := serror.NewGeneric(
+			err,
+			sourcev1.AuthenticationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	if certsTmpDir != "" {
+		defer func() {
+			if err := os.RemoveAll(certsTmpDir); err != nil {
+				r.eventLogf(ctx, obj, corev1.EventTypeWarning, meta.FailedReason,
+					"failed to delete temporary certificates directory: %s", err)
+			}
+		}()
+	}
+
+	registryClient, credentialsFile, err := r.RegistryClientGenerator(clientOpts.TlsConfig, clientOpts.MustLoginToRegistry(), obj.Spec.Insecure)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to construct Helm client: %w", err),
+			meta.FailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	if credentialsFile != "" {
+		defer func() {
+			if err := os.Remove(credentialsFile); err != nil {
+				r.eventLogf(ctx, obj, corev1.EventTypeWarning, meta.FailedReason,
+					"failed to delete temporary credentials file: %s", err)
+			}
+		}()
+	}
+
+	ociChartRepo, err := repository.NewOCIChartRepository(normalizedURL,
+		repository.WithOCIRegistryClient(registryClient),
+		repository.WithTagCache(helmTagCacheFor(r.TagCache, obj)),
+	)
+	if err != nil {
+		e := serror.NewStalling(
+			fmt.Errorf("invalid OCI registry URL '%s': %w", obj.Spec.URL, err),
+			sourcev1.URLInvalidReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	if clientOpts.MustLoginToRegistry() {
+		if err = ociChartRepo.Login(clientOpts.RegLoginOpts...); err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to login to OCI registry: %w", err),
+				sourcev1.AuthenticationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+	}
+
+	charts, err := r.chartsForHelmRepository(ctx, obj)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to list HelmCharts referencing the repository: %w", err),
+			sourcev1.ProbeFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	for _, name := range charts {
+		if _, err = ociChartRepo.ListTags(name); err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to list tags of chart '%s': %w", name, err),
+				sourcev1.ProbeFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+	}
+
+	conditions.Delete(obj, sourcev1.FetchFailedCondition)
+	conditions.MarkTrue(obj, meta.ReadyCondition, meta.SucceededReason,
+		"probed registry: listed tags of %d chart(s)", len(charts))
+	return sreconcile.ResultSuccess, nil
+}
+
+// chartsForHelmRepository returns the sorted names of the charts of the
+// v1.HelmChart objects referencing the given v1.HelmRepository.
+// It relies on the v1.SourceIndexKey field index of the HelmChartReconciler.
+func (r *HelmRepositoryReconciler) chartsForHelmRepository(ctx context.Context, obj *sourcev1.HelmRepository) ([]string, error) {
+	var list sourcev1.HelmChartList
+	if err := r.List(ctx, &list, client.InNamespace(obj.Namespace), client.MatchingFields{
+		sourcev1.SourceIndexKey: fmt.Sprintf("%s/%s", sourcev1.HelmRepositoryKind, obj.Name),
+	}); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(list.Items))
+	var charts []string
+	for _, hc := range list.Items {
+		if _, ok := seen[hc.Spec.Chart]; ok || hc.Spec.Chart == "" {
+			continue
+		}
+		seen[hc.Spec.Chart] = struct{}{}
+		charts = append(charts, hc.Spec.Chart)
+	}
+	sort.Strings(charts)
+	return charts, nil
+}
+
+// helmTagCacheFor returns a repository.TagCache storing the tags of the
+// charts of the given v1.HelmRepository in the given cache.TagCache, or nil
+// if caching is disabled.
+func helmTagCacheFor(c *cache.TagCache, obj *sourcev1.HelmRepository) repository.TagCache {
+	if c == nil {
+		return nil
+	}
+	return &helmTagCache{
+		cache:     c,
+		name:      obj.Name,
+		namespace: obj.Namespace,
+	}
+}
+
+// helmTagCache is a repository.TagCache scoped to a v1.HelmRepository.
+type helmTagCache struct {
+	cache     *cache.TagCache
+	name      string
+	namespace string
+}
+
+// key returns the cache key for the given chart reference.
+// The cache keys have to be safe in multi-tenancy environments, as
+// otherwise the tags of a private chart could be listed without the
+// credentials of the repository. As a HelmChart can only reference a
+// HelmRepository in its own namespace, scoping the key to the
+// HelmRepository is safe.
+func (c *helmTagCache) key(ref string) string {
+	return fmt.Sprintf("%s/%s/%s#%s", sourcev1.HelmRepositoryKind, c.namespace, c.name, ref)
+}
+
+// Get returns the tags of the given chart reference from the cache.
+func (c *helmTagCache) Get(ref string) ([]string, bool) {
+	return c.cache.Get(c.key(ref))
+}
+
+// Set stores the tags of the given chart reference in the cache.
+func (c *helmTagCache) Set(ref string, tags []string) {
+	c.cache.Set(c.key(ref), tags)
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/fluxcd/pkg/runtime/patch"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/fluxcd/source-controller/internal/cache"
+	"github.com/fluxcd/source-controller/internal/helm/registry"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+)
+
+func TestHelmRepositoryReconciler_reconcileOCIProbe(t *testing.T) {
+	g := NewWithT(t)
+
+	server, err := setupRegistryServer(ctx, t.TempDir(), registryOptions{
+		withBasicAuth: true,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	chartData, err := os.ReadFile("testdata/charts/helmchart-0.1.0.tgz")
+	g.Expect(err).ToNot(HaveOccurred())
+	metadata, err := loadTestChartToOCI(chartData, server, "", "", "")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	tests := []struct {
+		name             string
+		url              string
+		password         string
+		charts           []string
+		want             sreconcile.Result
+		wantErr          bool
+		wantTags         map[string][]string
+		assertConditions []metav1.Condition
+	}{
+		{
+			name:     "lists the tags of the referenced charts",
+			password: testRegistryPassword,
+			charts:   []string{metadata.Name, metadata.Name},
+			want:     sreconcile.ResultSuccess,
+			wantTags: map[string][]string{
+				metadata.Name: {metadata.Version},
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReadyCondition, meta.SucceededReason, "probed registry: listed tags of 1 chart(s)"),
+			},
+		},
+		{
+			name:     "logs in without referenced charts",
+			password: testRegistryPassword,
+			want:     sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReadyCondition, meta.SucceededReason, "probed registry: listed tags of 0 chart(s)"),
+			},
+		},
+		{
+			name:     "invalid credentials",
+			password: "wrong",
+			want:     sreconcile.ResultEmpty,
+			wantErr:  true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.AuthenticationFailedReason, "failed to login to OCI registry"),
+			},
+		},
+		{
+			name:     "referenced chart does not exist",
+			password: testRegistryPassword,
+			charts:   []string{"missing"},
+			want:     sreconcile.ResultEmpty,
+			wantErr:  true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.ProbeFailedReason, "failed to list tags of chart 'missing'"),
+			},
+		},
+		{
+			name:    "invalid URL",
+			url:     "https://example.com/charts",
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.URLInvalidReason, "URL scheme must be 'oci'"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "probe",
+					Namespace: "default",
+				},
+				Spec: sourcev1.HelmRepositorySpec{
+					URL:       fmt.Sprintf("oci://%s/testrepo", server.registryHost),
+					Type:      sourcev1.HelmRepositoryTypeOCI,
+					Probe:     true,
+					Insecure:  true,
+					Timeout:   &metav1.Duration{Duration: timeout},
+					SecretRef: &meta.LocalObjectReference{Name: "auth"},
+				},
+			}
+			if tt.url != "" {
+				obj.Spec.URL = tt.url
+			}
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "auth",
+					Namespace: "default",
+				},
+				Data: map[string][]byte{
+					"username": []byte(testRegistryUsername),
+					"password": []byte(tt.password),
+				},
+			}
+			objs := []client.Object{secret}
+			for i, c := range tt.charts {
+				objs = append(objs, &sourcev1.HelmChart{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("chart-%d", i),
+						Namespace: "default",
+					},
+					Spec: sourcev1.HelmChartSpec{
+						Chart: c,
+						SourceRef: sourcev1.LocalHelmChartSourceReference{
+							Kind: sourcev1.HelmRepositoryKind,
+							Name: obj.Name,
+						},
+					},
+				})
+			}
+
+			tagCache := cache.NewTagCache(10, time.Minute, 0)
+			r := &HelmRepositoryReconciler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithObjects(objs...).
+					WithIndex(&sourcev1.HelmChart{}, sourcev1.SourceIndexKey, (&HelmChartReconciler{}).indexHelmChartBySource).
+					Build(),
+				EventRecorder:           record.NewFakeRecorder(32),
+				RegistryClientGenerator: registry.ClientGenerator,
+				TagCache:                tagCache,
+				patchOptions:            getPatchOptions(helmRepositoryOCIReadyCondition.Owned, "sc"),
+			}
+
+			sp := patch.NewSerialPatcher(obj, r.Client)
+			got, err := r.reconcileOCIProbe(context.TODO(), sp, obj, nil, nil)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+
+			g.Expect(tagCache.ItemCount()).To(Equal(len(tt.wantTags)))
+			tc := helmTagCacheFor(tagCache, obj)
+			for name, wantTags := range tt.wantTags {
+				tags, ok := tc.Get(obj.Spec.URL + "/" + name)
+				g.Expect(ok).To(BeTrue())
+				g.Expect(tags).To(Equal(wantTags))
+			}
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_reconcileOCI_removesArtifact(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &sourcev1.HelmRepository{
+		TypeMeta: metav1.TypeMeta{
+			Kind: sourcev1.HelmRepositoryKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "probe",
+			Namespace:  "default",
+			Generation: 1,
+			Finalizers: []string{sourcev1.SourceFinalizer},
+		},
+		Spec: sourcev1.HelmRepositorySpec{
+			URL:     "oci://example.com/charts",
+			Type:    sourcev1.HelmRepositoryTypeOCI,
+			Probe:   true,
+			Suspend: true,
+		},
+		Status: sourcev1.HelmRepositoryStatus{
+			URL: "http://example.com/index.yaml",
+		},
+	}
+	artifact := testStorage.NewArtifactFor(obj.Kind, obj, "revision", "index.yaml")
+	g.Expect(testStorage.MkdirAll(artifact)).To(Succeed())
+	g.Expect(os.WriteFile(testStorage.LocalPath(artifact), []byte("index"), 0o640)).To(Succeed())
+	obj.Status.Artifact = &artifact
+
+	r := &HelmRepositoryReconciler{
+		Client: fakeclient.NewClientBuilder().
+			WithScheme(testEnv.GetScheme()).
+			WithObjects(obj).
+			WithStatusSubresource(&sourcev1.HelmRepository{}).
+			Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Metrics:       testMetricsH,
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(helmRepositoryOCIReadyCondition.Owned, "sc"),
+	}
+
+	sp := patch.NewSerialPatcher(obj, r.Client)
+	_, err := r.reconcileOCI(context.TODO(), sp, obj)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(obj.Finalizers).To(BeEmpty())
+	g.Expect(obj.Status.Artifact).To(BeNil())
+	g.Expect(obj.Status.URL).To(BeEmpty())
+	g.Expect(testStorage.ArtifactExist(artifact)).To(BeFalse())
+}
@@ -32,15 +32,16 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/opencontainers/go-digest"
 	helmgetter "helm.sh/helm/v3/pkg/getter"
-	"helm.sh/helm/v3/pkg/repo"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	kstatus "github.com/fluxcd/cli-utils/pkg/kstatus/status"
 	"github.com/fluxcd/pkg/apis/meta"
@@ -50,10 +51,12 @@ import (
 	"github.com/fluxcd/pkg/runtime/patch"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
-	"github.com/fluxcd/source-controller/internal/cache"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
 	intdigest "github.com/fluxcd/source-controller/internal/digest"
+	serror "github.com/fluxcd/source-controller/internal/error"
 	"github.com/fluxcd/source-controller/internal/helm/getter"
 	"github.com/fluxcd/source-controller/internal/helm/repository"
+	"github.com/fluxcd/source-controller/internal/hostlimit"
 	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
@@ -473,7 +476,7 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 			},
 			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
 				t.Expect(chartRepo.Path).ToNot(BeEmpty())
-				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(chartRepo.Index).To(BeNil())
 				t.Expect(artifact.Revision).ToNot(BeEmpty())
 			},
 		},
@@ -503,7 +506,7 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 			},
 			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
 				t.Expect(chartRepo.Path).ToNot(BeEmpty())
-				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(chartRepo.Index).To(BeNil())
 				t.Expect(artifact.Revision).ToNot(BeEmpty())
 			},
 		},
@@ -535,7 +538,7 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 			},
 			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
 				t.Expect(chartRepo.Path).ToNot(BeEmpty())
-				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(chartRepo.Index).To(BeNil())
 				t.Expect(artifact.Revision).ToNot(BeEmpty())
 			},
 		},
@@ -549,7 +552,7 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 			},
 			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
 				t.Expect(chartRepo.Path).ToNot(BeEmpty())
-				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(chartRepo.Index).To(BeNil())
 				t.Expect(artifact.Revision).ToNot(BeEmpty())
 			},
 		},
@@ -579,7 +582,7 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 			},
 			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
 				t.Expect(chartRepo.Path).ToNot(BeEmpty())
-				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(chartRepo.Index).To(BeNil())
 				t.Expect(artifact.Revision).ToNot(BeEmpty())
 			},
 		},
@@ -611,7 +614,7 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 			},
 			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
 				t.Expect(chartRepo.Path).ToNot(BeEmpty())
-				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(chartRepo.Index).To(BeNil())
 				t.Expect(artifact.Revision).ToNot(BeEmpty())
 			},
 		},
@@ -785,7 +788,7 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 			},
 			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
 				t.Expect(chartRepo.Path).ToNot(BeEmpty())
-				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(chartRepo.Index).To(BeNil())
 
 				t.Expect(artifact.Path).To(Not(BeEmpty()))
 				t.Expect(artifact.Revision).ToNot(Equal(obj.Status.Artifact.Revision))
@@ -955,12 +958,140 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 	}
 }
 
+func TestHelmRepositoryReconciler_reconcileSource_mirrors(t *testing.T) {
+	server, err := helmtestserver.NewTempHelmServer()
+	NewWithT(t).Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(server.Root())
+	NewWithT(t).Expect(server.PackageChart("testdata/charts/helmchart")).To(Succeed())
+	NewWithT(t).Expect(server.GenerateIndex()).To(Succeed())
+	server.Start()
+	defer server.Stop()
+
+	tests := []struct {
+		name          string
+		url           string
+		mirrors       []sourcev1.HelmRepositoryMirror
+		openHosts     []string
+		wantErr       string
+		wantWaiting   bool
+		wantActiveURL string
+	}{
+		{
+			name: "falls back to the first available mirror",
+			mirrors: []sourcev1.HelmRepositoryMirror{
+				{URL: "http://127.0.0.1:1/unavailable"},
+				{URL: server.URL()},
+			},
+			wantActiveURL: server.URL() + "/",
+		},
+		{
+			name: "fails when all mirrors are unavailable",
+			mirrors: []sourcev1.HelmRepositoryMirror{
+				{URL: "http://127.0.0.1:1/unavailable"},
+				{URL: server.URL(), SecretRef: &meta.LocalObjectReference{Name: "missing"}},
+			},
+			wantErr: "mirror 'http://127.0.0.1:1/unavailable'",
+		},
+		{
+			name:      "skips the primary host of which the circuit breaker is open",
+			url:       "http://localhost:1/primary",
+			openHosts: []string{"localhost"},
+			mirrors: []sourcev1.HelmRepositoryMirror{
+				{URL: server.URL()},
+			},
+			wantActiveURL: server.URL() + "/",
+		},
+		{
+			name:      "requeues when the circuit breakers of all hosts are open",
+			url:       "http://localhost:1/primary",
+			openHosts: []string{"localhost", "127.0.0.1"},
+			mirrors: []sourcev1.HelmRepositoryMirror{
+				{URL: server.URL()},
+			},
+			wantErr:     "host 'localhost' is unavailable",
+			wantWaiting: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.HelmRepository{
+				TypeMeta: metav1.TypeMeta{
+					Kind: sourcev1.HelmRepositoryKind,
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "mirrors",
+					Generation: 1,
+					Namespace:  "default",
+				},
+				Spec: sourcev1.HelmRepositorySpec{
+					URL:     "http://127.0.0.1:1/primary",
+					Mirrors: tt.mirrors,
+				},
+			}
+			if tt.url != "" {
+				obj.Spec.URL = tt.url
+			}
+
+			var l *hostlimit.Limiter
+			if len(tt.openHosts) > 0 {
+				l = hostlimit.New(hostlimit.Options{FailureThreshold: 1, MinBreakerBackoff: time.Minute}, nil)
+			}
+			for _, host := range tt.openHosts {
+				res, _, err := l.Reserve(host)
+				g.Expect(err).ToNot(HaveOccurred())
+				res.Done(errors.New("dial tcp: connection refused"))
+				res.Release()
+			}
+
+			r := &HelmRepositoryReconciler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithObjects(obj).
+					WithStatusSubresource(&sourcev1.HelmRepository{}).
+					WithIndex(&sourcev1.HelmChart{}, sourcev1.SourceIndexKey,
+						(&HelmChartReconciler{}).indexHelmChartBySource).
+					WithIndex(&sourcev1beta2.HelmChartSet{}, sourcev1beta2.SourceIndexKey,
+						(&HelmChartSetReconciler{}).indexHelmChartSetBySource).
+					Build(),
+				EventRecorder: record.NewFakeRecorder(32),
+				Getters:       testGetters,
+				Storage:       testStorage,
+				HostLimiter:   l,
+				patchOptions:  getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
+			}
+
+			var chartRepo repository.ChartRepository
+			var artifact sourcev1.Artifact
+			sp := patch.NewSerialPatcher(obj, r.Client)
+			reconcilers := []helmRepositoryReconcileFunc{r.reconcileSource, r.reconcileArtifact}
+			for _, rec := range reconcilers {
+				_, err = rec(context.TODO(), sp, obj, &artifact, &chartRepo)
+				if err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				var waitErr *serror.Waiting
+				g.Expect(errors.As(err, &waitErr)).To(Equal(tt.wantWaiting))
+				g.Expect(conditions.IsTrue(obj, sourcev1.FetchFailedCondition)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(obj.Status.ActiveURL).To(Equal(tt.wantActiveURL))
+			g.Expect(obj.GetArtifact()).ToNot(BeNil())
+		})
+	}
+}
+
 func TestHelmRepositoryReconciler_reconcileArtifact(t *testing.T) {
 	tests := []struct {
 		name             string
-		cache            *cache.Cache
 		beforeFunc       func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository)
-		afterFunc        func(t *WithT, obj *sourcev1.HelmRepository, cache *cache.Cache)
+		afterFunc        func(t *WithT, obj *sourcev1.HelmRepository)
 		want             sreconcile.Result
 		wantErr          bool
 		assertConditions []metav1.Condition
@@ -971,44 +1102,112 @@ func TestHelmRepositoryReconciler_reconcileArtifact(t *testing.T) {
 				obj.Spec.Interval = metav1.Duration{Duration: interval}
 			},
 			want: sreconcile.ResultSuccess,
-			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, cache *cache.Cache) {
+			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository) {
 				localPath := testStorage.LocalPath(*obj.GetArtifact())
 				b, err := os.ReadFile(localPath)
 				t.Expect(err).To(Not(HaveOccurred()))
 				t.Expect(json.Valid(b)).To(BeTrue())
+
+				i, err := repository.IndexFromBytes(b)
+				t.Expect(err).To(Not(HaveOccurred()))
+				t.Expect(i.Entries).To(HaveKey("helmchart"))
+				t.Expect(i.Entries).ToNot(HaveKey("unreferenced"))
+				t.Expect(obj.Status.Artifact.Metadata).To(HaveKeyWithValue(helmRepositoryIndexChartsKey, "helmchart"))
 			},
 			assertConditions: []metav1.Condition{
 				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'existing'"),
 			},
 		},
 		{
-			name:  "Archiving (loaded) artifact to storage adds to cache",
-			cache: cache.New(10, time.Minute),
+			name: "Up-to-date artifact should not update status",
 			beforeFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository) {
-				index.Index = &repo.IndexFile{
-					APIVersion: "v1",
-					Generated:  time.Now(),
-				}
 				obj.Spec.Interval = metav1.Duration{Duration: interval}
+				obj.Status.Artifact = artifact.DeepCopy()
+				obj.Status.Artifact.Metadata = map[string]string{helmRepositoryIndexChartsKey: "helmchart"}
+			},
+			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository) {
+				t.Expect(obj.Status.URL).To(BeEmpty())
 			},
 			want: sreconcile.ResultSuccess,
-			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, cache *cache.Cache) {
-				i, ok := cache.Get(obj.GetArtifact().Path)
-				t.Expect(ok).To(BeTrue())
-				t.Expect(i).To(BeAssignableToTypeOf(&repo.IndexFile{}))
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'existing'"),
 			},
+		},
+		{
+			name: "Enabled summary is set from the fetched index",
+			beforeFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository) {
+				obj.Spec.Interval = metav1.Duration{Duration: interval}
+				obj.Spec.Summary = true
+			},
+			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository) {
+				t.Expect(obj.Status.Summary).To(Equal(&sourcev1.HelmRepositorySummary{
+					ChartCount: 2,
+					Charts: []sourcev1.HelmRepositoryChartSummary{
+						{Name: "helmchart", LatestVersion: "0.1.0", Versions: 1},
+						{Name: "unreferenced", LatestVersion: "1.0.0", Versions: 1},
+					},
+				}))
+			},
+			want: sreconcile.ResultSuccess,
 			assertConditions: []metav1.Condition{
 				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'existing'"),
 			},
 		},
 		{
-			name: "Up-to-date artifact should not update status",
+			name: "Up-to-date artifact is summarized from the fetched index when summary is enabled",
 			beforeFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository) {
 				obj.Spec.Interval = metav1.Duration{Duration: interval}
+				obj.Spec.Summary = true
 				obj.Status.Artifact = artifact.DeepCopy()
+				obj.Status.Artifact.Metadata = map[string]string{helmRepositoryIndexChartsKey: "helmchart"}
+
+				t.Expect(os.WriteFile(index.Path,
+					[]byte(`{"apiVersion":"v1","entries":{"foo":[{"name":"foo","version":"1.0.0"},{"name":"foo","version":"1.1.0"}]}}`), 0o640)).To(Succeed())
 			},
-			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, _ *cache.Cache) {
+			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository) {
 				t.Expect(obj.Status.URL).To(BeEmpty())
+				t.Expect(obj.Status.Summary).To(Equal(&sourcev1.HelmRepositorySummary{
+					ChartCount: 1,
+					Charts: []sourcev1.HelmRepositoryChartSummary{
+						{Name: "foo", LatestVersion: "1.1.0", Versions: 2},
+					},
+				}))
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'existing'"),
+			},
+		},
+		{
+			name: "Up-to-date artifact summary is removed when summary is disabled",
+			beforeFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository) {
+				obj.Spec.Interval = metav1.Duration{Duration: interval}
+				obj.Status.Artifact = artifact.DeepCopy()
+				obj.Status.Artifact.Metadata = map[string]string{helmRepositoryIndexChartsKey: "helmchart"}
+				obj.Status.Summary = &sourcev1.HelmRepositorySummary{ChartCount: 1}
+			},
+			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository) {
+				t.Expect(obj.Status.Summary).To(BeNil())
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'existing'"),
+			},
+		},
+		{
+			name: "Up-to-date artifact is rewritten when the referenced charts change",
+			beforeFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository) {
+				obj.Spec.Interval = metav1.Duration{Duration: interval}
+				obj.Status.Artifact = artifact.DeepCopy()
+				obj.Status.Artifact.Metadata = map[string]string{helmRepositoryIndexChartsKey: "other"}
+			},
+			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository) {
+				t.Expect(obj.Status.URL).ToNot(BeEmpty())
+				t.Expect(obj.Status.Artifact.Metadata).To(HaveKeyWithValue(helmRepositoryIndexChartsKey, "helmchart"))
+
+				i, err := repository.IndexFromFile(testStorage.LocalPath(*obj.GetArtifact()))
+				t.Expect(err).ToNot(HaveOccurred())
+				t.Expect(i.Entries).To(HaveKey("helmchart"))
 			},
 			want: sreconcile.ResultSuccess,
 			assertConditions: []metav1.Condition{
@@ -1031,7 +1230,7 @@ func TestHelmRepositoryReconciler_reconcileArtifact(t *testing.T) {
 			beforeFunc: func(t *WithT, obj *sourcev1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository) {
 				obj.Spec.Interval = metav1.Duration{Duration: interval}
 			},
-			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository, _ *cache.Cache) {
+			afterFunc: func(t *WithT, obj *sourcev1.HelmRepository) {
 				localPath := testStorage.LocalPath(*obj.GetArtifact())
 				symlinkPath := filepath.Join(filepath.Dir(localPath), "index.yaml")
 				targetFile, err := os.Readlink(symlinkPath)
@@ -1053,11 +1252,23 @@ func TestHelmRepositoryReconciler_reconcileArtifact(t *testing.T) {
 				Client: fakeclient.NewClientBuilder().
 					WithScheme(testEnv.GetScheme()).
 					WithStatusSubresource(&sourcev1.HelmRepository{}).
+					WithIndex(&sourcev1.HelmChart{}, sourcev1.SourceIndexKey,
+						(&HelmChartReconciler{}).indexHelmChartBySource).
+					WithIndex(&sourcev1beta2.HelmChartSet{}, sourcev1beta2.SourceIndexKey,
+						(&HelmChartSetReconciler{}).indexHelmChartSetBySource).
+					WithObjects(&sourcev1.HelmChart{
+						ObjectMeta: metav1.ObjectMeta{Name: "helmchart", Namespace: "default"},
+						Spec: sourcev1.HelmChartSpec{
+							Chart: "helmchart",
+							SourceRef: sourcev1.LocalHelmChartSourceReference{
+								Kind: sourcev1.HelmRepositoryKind,
+								Name: "test-repository",
+							},
+						},
+					}).
 					Build(),
 				EventRecorder: record.NewFakeRecorder(32),
 				Storage:       testStorage,
-				Cache:         tt.cache,
-				TTL:           1 * time.Minute,
 				patchOptions:  getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
 			}
 
@@ -1066,9 +1277,9 @@ func TestHelmRepositoryReconciler_reconcileArtifact(t *testing.T) {
 					Kind: sourcev1.HelmRepositoryKind,
 				},
 				ObjectMeta: metav1.ObjectMeta{
-					GenerateName: "test-bucket-",
-					Generation:   1,
-					Namespace:    "default",
+					Name:       "test-repository",
+					Generation: 1,
+					Namespace:  "default",
 				},
 				Spec: sourcev1.HelmRepositorySpec{
 					Timeout: &metav1.Duration{Duration: timeout},
@@ -1076,9 +1287,23 @@ func TestHelmRepositoryReconciler_reconcileArtifact(t *testing.T) {
 				},
 			}
 
-			chartRepo, err := repository.NewChartRepository(obj.Spec.URL, "", testGetters, nil)
+			indexPath := filepath.Join(t.TempDir(), "index.yaml")
+			g.Expect(os.WriteFile(indexPath, []byte(`apiVersion: v1
+entries:
+  helmchart:
+  - name: helmchart
+    version: 0.1.0
+    urls:
+    - https://example.com/helmchart-0.1.0.tgz
+  unreferenced:
+  - name: unreferenced
+    version: 1.0.0
+    urls:
+    - https://example.com/unreferenced-1.0.0.tgz
+`), 0o640)).To(Succeed())
+
+			chartRepo, err := repository.NewChartRepository(obj.Spec.URL, indexPath, testGetters, nil)
 			g.Expect(err).ToNot(HaveOccurred())
-			chartRepo.Index = &repo.IndexFile{}
 
 			artifact := testStorage.NewArtifactFor(obj.Kind, obj, "existing", "foo.tar.gz")
 			// Digest of the index file calculated by the ChartRepository.
@@ -1101,12 +1326,221 @@ func TestHelmRepositoryReconciler_reconcileArtifact(t *testing.T) {
 			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
 
 			if tt.afterFunc != nil {
-				tt.afterFunc(g, obj, tt.cache)
+				tt.afterFunc(g, obj)
 			}
 		})
 	}
 }
 
+func TestHelmRepositoryReconciler_requestsForChartReferenceChange(t *testing.T) {
+	repos := []client.Object{
+		&sourcev1.HelmRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: "static", Namespace: "default"},
+		},
+		&sourcev1.HelmRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: "oci", Namespace: "default"},
+			Spec:       sourcev1.HelmRepositorySpec{Type: sourcev1.HelmRepositoryTypeOCI},
+		},
+		&sourcev1.HelmRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: "proxy", Namespace: "default"},
+			Spec:       sourcev1.HelmRepositorySpec{Proxy: &sourcev1.HelmRepositoryProxy{}},
+		},
+	}
+	r := &HelmRepositoryReconciler{
+		Client: fakeclient.NewClientBuilder().
+			WithScheme(testEnv.GetScheme()).
+			WithObjects(repos...).
+			Build(),
+	}
+
+	tests := []struct {
+		name string
+		obj  client.Object
+		want []reconcile.Request
+	}{
+		{
+			name: "HelmChart referencing a static HelmRepository",
+			obj: &sourcev1.HelmChart{
+				ObjectMeta: metav1.ObjectMeta{Name: "chart", Namespace: "default"},
+				Spec: sourcev1.HelmChartSpec{
+					SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "static"},
+				},
+			},
+			want: []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "static"}}},
+		},
+		{
+			name: "HelmChartSet referencing a static HelmRepository",
+			obj: &sourcev1beta2.HelmChartSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "set", Namespace: "default"},
+				Spec: sourcev1beta2.HelmChartSetSpec{
+					SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "static"},
+				},
+			},
+			want: []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "static"}}},
+		},
+		{
+			name: "HelmChart referencing an OCI HelmRepository",
+			obj: &sourcev1.HelmChart{
+				ObjectMeta: metav1.ObjectMeta{Name: "chart", Namespace: "default"},
+				Spec: sourcev1.HelmChartSpec{
+					SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "oci"},
+				},
+			},
+		},
+		{
+			name: "HelmChart referencing a proxy HelmRepository",
+			obj: &sourcev1.HelmChart{
+				ObjectMeta: metav1.ObjectMeta{Name: "chart", Namespace: "default"},
+				Spec: sourcev1.HelmChartSpec{
+					SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "proxy"},
+				},
+			},
+		},
+		{
+			name: "HelmChart referencing a GitRepository",
+			obj: &sourcev1.HelmChart{
+				ObjectMeta: metav1.ObjectMeta{Name: "chart", Namespace: "default"},
+				Spec: sourcev1.HelmChartSpec{
+					SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.GitRepositoryKind, Name: "static"},
+				},
+			},
+		},
+		{
+			name: "HelmChart referencing a missing HelmRepository",
+			obj: &sourcev1.HelmChart{
+				ObjectMeta: metav1.ObjectMeta{Name: "chart", Namespace: "default"},
+				Spec: sourcev1.HelmChartSpec{
+					SourceRef: sourcev1.LocalHelmChartSourceReference{Kind: sourcev1.HelmRepositoryKind, Name: "missing"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(r.requestsForChartReferenceChange(context.TODO(), tt.obj)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_reconcileArtifact_proxy(t *testing.T) {
+	g := NewWithT(t)
+
+	server, err := helmtestserver.NewTempHelmServer()
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(server.Root())
+
+	for _, v := range []string{"0.1.0", "0.2.0", "0.3.0-rc.1"} {
+		g.Expect(server.PackageChartWithVersion("testdata/charts/helmchart", v)).To(Succeed())
+	}
+	g.Expect(server.GenerateIndex()).To(Succeed())
+	server.Start()
+
+	r := &HelmRepositoryReconciler{
+		Client: fakeclient.NewClientBuilder().
+			WithScheme(testEnv.GetScheme()).
+			WithStatusSubresource(&sourcev1.HelmRepository{}).
+			Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Getters:       testGetters,
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	obj := &sourcev1.HelmRepository{
+		TypeMeta: metav1.TypeMeta{
+			Kind: sourcev1.HelmRepositoryKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "proxy",
+			Generation: 1,
+			Namespace:  "default",
+		},
+		Spec: sourcev1.HelmRepositorySpec{
+			URL: server.URL(),
+			Proxy: &sourcev1.HelmRepositoryProxy{
+				Charts: []sourcev1.HelmRepositoryProxyChart{
+					{
+						Name:          "helmchart",
+						MaxVersions:   3,
+						VersionPolicy: &sourcev1.HelmChartVersionPolicy{ExcludePrerelease: true},
+						Version:       ">=0.0.0-0",
+					},
+				},
+			},
+		},
+	}
+
+	reconcileArtifact := func() {
+		chartRepo, err := repository.NewChartRepository(obj.Spec.URL, "", testGetters, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(chartRepo.CacheIndex()).To(Succeed())
+		// Chart tarballs which are already mirrored are not downloaded again.
+		if obj.GetArtifact() != nil {
+			server.Stop()
+		}
+
+		artifact := testStorage.NewArtifactFor(obj.Kind, obj, "sha256:foo", "index-foo.yaml")
+		sp := patch.NewSerialPatcher(obj, r.Client)
+		got, err := r.reconcileArtifact(context.TODO(), sp, obj, &artifact, chartRepo)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+	}
+
+	reconcileArtifact()
+	g.Expect(obj.Status.ChartArtifacts).To(HaveLen(2))
+	first := obj.Status.ChartArtifacts
+	reconcileArtifact()
+	g.Expect(obj.Status.ChartArtifacts).To(Equal(first))
+
+	index, err := repository.IndexFromFile(testStorage.LocalPath(*obj.GetArtifact()))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(index.Entries).To(HaveKey("helmchart"))
+	cvs := index.Entries["helmchart"]
+	g.Expect(cvs).To(HaveLen(2))
+	for i, cv := range cvs {
+		chartArtifact := obj.Status.ChartArtifacts[i]
+		g.Expect(cv.URLs).To(Equal([]string{chartArtifact.URL}))
+		g.Expect(chartArtifact.Revision).To(Equal(cv.Version + "@sha256:" + cv.Digest))
+		g.Expect(testStorage.ArtifactExist(chartArtifact)).To(BeTrue())
+	}
+	g.Expect(cvs[0].Version).To(Equal("0.2.0"))
+	g.Expect(cvs[1].Version).To(Equal("0.1.0"))
+	g.Expect(obj.Status.Artifact.Metadata).To(HaveKeyWithValue(helmRepositoryIndexHostnameKey, testStorage.Hostname))
+
+	// The index is regenerated when the Storage hostname changes, as it
+	// contains the URLs of the mirrored charts.
+	storage := *testStorage
+	storage.Hostname = "new-hostname"
+	r.Storage = &storage
+	defer func() { r.Storage = testStorage }()
+	sp := patch.NewSerialPatcher(obj, r.Client)
+	got, err := r.reconcileStorage(context.TODO(), sp, obj, &sourcev1.Artifact{}, &repository.ChartRepository{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+
+	chartRepo, err := repository.NewChartRepository(obj.Spec.URL, filepath.Join(server.Root(), "index.yaml"), testGetters, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	artifact := obj.GetArtifact().DeepCopy()
+	got, err = r.reconcileArtifact(context.TODO(), sp, obj, artifact, chartRepo)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+	g.Expect(obj.Status.Artifact.Metadata).To(HaveKeyWithValue(helmRepositoryIndexHostnameKey, "new-hostname"))
+
+	index, err = repository.IndexFromFile(storage.LocalPath(*obj.GetArtifact()))
+	g.Expect(err).ToNot(HaveOccurred())
+	for i, cv := range index.Entries["helmchart"] {
+		g.Expect(cv.URLs).To(Equal([]string{obj.Status.ChartArtifacts[i].URL}))
+		g.Expect(cv.URLs[0]).To(ContainSubstring("new-hostname"))
+	}
+
+	// Mirrored chart tarballs are not garbage collected.
+	g.Expect(r.garbageCollect(context.TODO(), obj)).To(Succeed())
+	for _, chartArtifact := range obj.Status.ChartArtifacts {
+		g.Expect(testStorage.ArtifactExist(chartArtifact)).To(BeTrue())
+	}
+}
+
 func TestHelmRepositoryReconciler_reconcileSubRecs(t *testing.T) {
 	// Helper to build simple helmRepositoryReconcileFunc with result and error.
 	buildReconcileFuncs := func(r sreconcile.Result, e error) helmRepositoryReconcileFunc {
@@ -1647,73 +2081,6 @@ func TestHelmRepositoryReconciler_ReconcileSpecUpdatePredicateFilter(t *testing.
 	}, timeout).Should(BeTrue())
 }
 
-func TestHelmRepositoryReconciler_InMemoryCaching(t *testing.T) {
-	g := NewWithT(t)
-	testCache.Clear()
-
-	testServer, err := helmtestserver.NewTempHelmServer()
-	g.Expect(err).NotTo(HaveOccurred())
-	defer os.RemoveAll(testServer.Root())
-
-	g.Expect(testServer.PackageChartWithVersion("testdata/charts/helmchart", "0.1.0")).To(Succeed())
-	g.Expect(testServer.GenerateIndex()).To(Succeed())
-
-	testServer.Start()
-	defer testServer.Stop()
-
-	ns, err := testEnv.CreateNamespace(ctx, "helmrepository")
-	g.Expect(err).ToNot(HaveOccurred())
-	defer func() { g.Expect(testEnv.Delete(ctx, ns)).To(Succeed()) }()
-
-	helmRepo := &sourcev1.HelmRepository{
-		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: "helmrepository-",
-			Namespace:    ns.Name,
-		},
-		Spec: sourcev1.HelmRepositorySpec{
-			URL: testServer.URL(),
-		},
-	}
-	g.Expect(testEnv.CreateAndWait(ctx, helmRepo)).To(Succeed())
-
-	key := client.ObjectKey{Name: helmRepo.Name, Namespace: helmRepo.Namespace}
-	// Wait for finalizer to be set
-	g.Eventually(func() bool {
-		if err := testEnv.Get(ctx, key, helmRepo); err != nil {
-			return false
-		}
-		return len(helmRepo.Finalizers) > 0
-	}, timeout).Should(BeTrue())
-
-	// Wait for HelmRepository to be Ready
-	g.Eventually(func() bool {
-		if err := testEnv.Get(ctx, key, helmRepo); err != nil {
-			return false
-		}
-		if !conditions.IsReady(helmRepo) || helmRepo.Status.Artifact == nil {
-			return false
-		}
-		readyCondition := conditions.Get(helmRepo, meta.ReadyCondition)
-		return helmRepo.Generation == readyCondition.ObservedGeneration &&
-			helmRepo.Generation == helmRepo.Status.ObservedGeneration
-	}, timeout).Should(BeTrue())
-
-	err = testEnv.Get(ctx, key, helmRepo)
-	g.Expect(err).ToNot(HaveOccurred())
-	_, cacheHit := testCache.Get(helmRepo.GetArtifact().Path)
-	g.Expect(cacheHit).To(BeTrue())
-
-	g.Expect(testEnv.Delete(ctx, helmRepo)).To(Succeed())
-
-	// Wait for HelmRepository to be deleted
-	g.Eventually(func() bool {
-		if err := testEnv.Get(ctx, key, helmRepo); err != nil {
-			return apierrors.IsNotFound(err)
-		}
-		return false
-	}, timeout).Should(BeTrue())
-}
-
 func TestHelmRepositoryReconciler_ociMigration(t *testing.T) {
 	g := NewWithT(t)
 
@@ -0,0 +1,1049 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kuberecorder "k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+
+	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	helper "github.com/fluxcd/pkg/runtime/controller"
+	"github.com/fluxcd/pkg/runtime/jitter"
+	"github.com/fluxcd/pkg/runtime/patch"
+	"github.com/fluxcd/pkg/runtime/predicates"
+	rreconcile "github.com/fluxcd/pkg/runtime/reconcile"
+	"github.com/fluxcd/pkg/sourceignore"
+	"github.com/fluxcd/pkg/tar"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	httpv1 "github.com/fluxcd/source-controller/api/v1beta2"
+	intdigest "github.com/fluxcd/source-controller/internal/digest"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/fs"
+	"github.com/fluxcd/source-controller/internal/impersonation"
+	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
+	"github.com/fluxcd/source-controller/internal/tls"
+)
+
+// httpSourceReadyCondition contains the information required to summarize a
+// v1beta2.HTTPSource Ready Condition.
+var httpSourceReadyCondition = summarize.Conditions{
+	Target: meta.ReadyCondition,
+	Owned: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.RevisionHeldCondition,
+		sourcev1.PendingApprovalCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1.FetchFailedCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		sourcev1.ArtifactInStorageCondition,
+		meta.ReadyCondition,
+		meta.ReconcilingCondition,
+		meta.StalledCondition,
+	},
+	Summarize: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1.FetchFailedCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		sourcev1.ArtifactInStorageCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+	NegativePolarity: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1.FetchFailedCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+}
+
+// httpSourceFailConditions contains the conditions that represent a failure.
+var httpSourceFailConditions = []string{
+	sourcev1.FetchFailedCondition,
+	sourcev1.StorageOperationFailedCondition,
+}
+
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=httpsources,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=httpsources/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=httpsources/finalizers,verbs=get;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// HTTPSourceReconciler reconciles a v1beta2.HTTPSource object.
+type HTTPSourceReconciler struct {
+	client.Client
+	kuberecorder.EventRecorder
+	helper.Metrics
+
+	Storage        *Storage
+	ControllerName string
+
+	// Impersonator impersonates the ServiceAccount of the object to read its
+	// Secrets in multi-tenant lockdown mode. It is disabled when nil.
+	Impersonator *impersonation.Impersonator
+
+	// MaxFileSize is the max allowed size in bytes of a fetched file, and
+	// of the files extracted from it. DefaultHTTPSourceMaxFileSize is used
+	// when it is not set.
+	MaxFileSize int64
+
+	requeueDependency time.Duration
+
+	patchOptions []patch.Option
+}
+
+// DefaultHTTPSourceMaxFileSize is the default max allowed size in bytes of
+// the file fetched for a v1beta2.HTTPSource, and of the files extracted from
+// it.
+const DefaultHTTPSourceMaxFileSize int64 = tar.DefaultMaxUntarSize
+
+type HTTPSourceReconcilerOptions struct {
+	DependencyRequeueInterval time.Duration
+	RateLimiter               ratelimiter.RateLimiter
+}
+
+// httpSourceFetch holds the observations about the file fetched for a
+// v1beta2.HTTPSource.
+type httpSourceFetch struct {
+	// Revision is the digest of the fetched file.
+	Revision string
+	// ETag is the ETag response header of the fetched file.
+	ETag string
+	// LastModified is the Last-Modified response header of the fetched file.
+	LastModified string
+}
+
+// httpSourceReconcileFunc is the function type for all the v1beta2.HTTPSource
+// (sub)reconcile functions. The type implementations are grouped and
+// executed serially to perform the complete reconcile of the object.
+type httpSourceReconcileFunc func(ctx context.Context, sp *patch.SerialPatcher, obj *httpv1.HTTPSource, fetch *httpSourceFetch, dir string) (sreconcile.Result, error)
+
+func (r *HTTPSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return r.SetupWithManagerAndOptions(mgr, HTTPSourceReconcilerOptions{})
+}
+
+func (r *HTTPSourceReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts HTTPSourceReconcilerOptions) error {
+	r.patchOptions = getPatchOptions(httpSourceReadyCondition.Owned, r.ControllerName)
+	r.requeueDependency = opts.DependencyRequeueInterval
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&httpv1.HTTPSource{}).
+		WithEventFilter(predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+			intpredicates.ApprovedRevisionPredicate{})).
+		WithOptions(controller.Options{
+			RateLimiter: opts.RateLimiter,
+		}).
+		Complete(r)
+}
+
+func (r *HTTPSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
+	start := time.Now()
+	log := ctrl.LoggerFrom(ctx)
+
+	// Fetch the HTTPSource
+	obj := &httpv1.HTTPSource{}
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Initialize the patch helper with the current version of the object.
+	serialPatcher := patch.NewSerialPatcher(obj, r.Client)
+
+	// recResult stores the abstracted reconcile result.
+	var recResult sreconcile.Result
+
+	// Always attempt to patch the object and status after each reconciliation
+	// NOTE: The final runtime result and error are set in this block.
+	defer func() {
+		summarizeHelper := summarize.NewHelper(r.EventRecorder, serialPatcher)
+		summarizeOpts := []summarize.Option{
+			summarize.WithConditions(httpSourceReadyCondition),
+			summarize.WithReconcileResult(recResult),
+			summarize.WithReconcileError(retErr),
+			summarize.WithIgnoreNotFound(),
+			summarize.WithProcessors(
+				summarize.ErrorActionHandler,
+				summarize.RecordReconcileReq,
+			),
+			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
+				RequeueAfter: holdRequeueAfter(obj, obj.Spec.Schedule,
+					jitter.JitteredIntervalDuration(obj.GetRequeueAfter()), time.Now()),
+			}),
+			summarize.WithPatchFieldOwner(r.ControllerName),
+		}
+		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
+
+		// Always record suspend, readiness and duration metrics.
+		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
+		r.Metrics.RecordReadiness(ctx, obj)
+		r.Metrics.RecordDuration(ctx, obj, start)
+	}()
+
+	// Examine if the object is under deletion.
+	if !obj.ObjectMeta.DeletionTimestamp.IsZero() {
+		recResult, retErr = r.reconcileDelete(ctx, obj)
+		return
+	}
+
+	// Add finalizer first if not exist to avoid the race condition between init
+	// and delete.
+	// Note: Finalizers in general can only be added when the deletionTimestamp
+	// is not set.
+	if !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
+		controllerutil.AddFinalizer(obj, sourcev1.SourceFinalizer)
+		recResult = sreconcile.ResultRequeue
+		return
+	}
+
+	// Return if the object is suspended.
+	if obj.Spec.Suspend {
+		log.Info("reconciliation is suspended for this object")
+		recResult, retErr = sreconcile.ResultEmpty, nil
+		return
+	}
+
+	// Wait for the dependencies of the object to be ready.
+	if err := checkSourceDependencies(ctx, r.Client, obj, obj.Spec.DependsOn, r.requeueDependency); err != nil {
+		recResult, retErr = sreconcile.ResultEmpty, err
+		return
+	}
+
+	// Reconcile actual object
+	reconcilers := []httpSourceReconcileFunc{
+		r.reconcileStorage,
+		r.reconcileSource,
+		r.reconcileArtifact,
+	}
+	recResult, retErr = r.reconcile(ctx, serialPatcher, obj, reconcilers)
+	return
+}
+
+// reconcile iterates through the httpSourceReconcileFunc tasks for the
+// object. It returns early on the first call that returns
+// reconcile.ResultRequeue, or produces an error.
+func (r *HTTPSourceReconciler) reconcile(ctx context.Context, sp *patch.SerialPatcher, obj *httpv1.HTTPSource, reconcilers []httpSourceReconcileFunc) (sreconcile.Result, error) {
+	oldObj := obj.DeepCopy()
+
+	rreconcile.ProgressiveStatus(false, obj, meta.ProgressingReason, "reconciliation in progress")
+
+	var recAtVal string
+	if v, ok := meta.ReconcileAnnotationValue(obj.GetAnnotations()); ok {
+		recAtVal = v
+	}
+
+	// Persist reconciling if generation differs or reconciliation is requested.
+	switch {
+	case obj.Generation != obj.Status.ObservedGeneration:
+		rreconcile.ProgressiveStatus(false, obj, meta.ProgressingReason,
+			"processing object: new generation %d -> %d", obj.Status.ObservedGeneration, obj.Generation)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	case recAtVal != obj.Status.GetLastHandledReconcileRequest():
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	}
+
+	// Create temp working dir
+	tmpDir, err := os.MkdirTemp("", fmt.Sprintf("%s-%s-%s-", obj.Kind, obj.Namespace, obj.Name))
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to create temporary working directory: %w", err),
+			sourcev1.DirCreationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	defer func() {
+		if err = os.RemoveAll(tmpDir); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to remove temporary working directory")
+		}
+	}()
+	conditions.Delete(obj, sourcev1.StorageOperationFailedCondition)
+
+	// Run the sub-reconcilers and build the result of reconciliation.
+	var (
+		res    sreconcile.Result
+		resErr error
+		fetch  = &httpSourceFetch{}
+	)
+
+	for _, rec := range reconcilers {
+		recResult, err := rec(ctx, sp, obj, fetch, tmpDir)
+		// Exit immediately on ResultRequeue.
+		if recResult == sreconcile.ResultRequeue {
+			return sreconcile.ResultRequeue, nil
+		}
+		// If an error is received, prioritize the returned results because an
+		// error also means immediate requeue.
+		if err != nil {
+			resErr = err
+			res = recResult
+			break
+		}
+		// Prioritize requeue request in the result.
+		res = sreconcile.LowestRequeuingResult(res, recResult)
+	}
+
+	r.notify(ctx, oldObj, obj, res, resErr)
+
+	return res, resErr
+}
+
+// notify emits notification related to the reconciliation.
+func (r *HTTPSourceReconciler) notify(ctx context.Context, oldObj, newObj *httpv1.HTTPSource, res sreconcile.Result, resErr error) {
+	// Notify successful reconciliation for new artifact and recovery from any
+	// failure.
+	if resErr == nil && res == sreconcile.ResultSuccess && newObj.Status.Artifact != nil {
+		annotations := map[string]string{
+			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaRevisionKey): newObj.Status.Artifact.Revision,
+			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaDigestKey):   newObj.Status.Artifact.Digest,
+		}
+
+		fileName, _ := httpSourceFileName(newObj.Spec.URL)
+		message := fmt.Sprintf("stored artifact for '%s' with revision '%s'", fileName, newObj.Status.Artifact.Revision)
+
+		// Notify on new artifact and failure recovery.
+		if !oldObj.GetArtifact().HasDigest(newObj.GetArtifact().Digest) {
+			r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
+				"NewArtifact", message)
+			ctrl.LoggerFrom(ctx).Info(message)
+		} else {
+			if sreconcile.FailureRecovery(oldObj, newObj, httpSourceFailConditions) {
+				r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
+					meta.SucceededReason, message)
+				ctrl.LoggerFrom(ctx).Info(message)
+			}
+		}
+	}
+}
+
+// reconcileStorage ensures the current state of the storage matches the
+// desired and previously observed state.
+//
+// The garbage collection is executed based on the flag configured settings and
+// may remove files that are beyond their TTL or the maximum number of files
+// to survive a collection cycle.
+// If the Artifact in the Status of the object disappeared from the Storage,
+// it is removed from the object.
+// If the object does not have an Artifact in its Status, a Reconciling
+// condition is added.
+// The hostname of any URL in the Status of the object are updated, to ensure
+// they match the Storage server hostname of current runtime.
+func (r *HTTPSourceReconciler) reconcileStorage(ctx context.Context, sp *patch.SerialPatcher, obj *httpv1.HTTPSource, _ *httpSourceFetch, _ string) (sreconcile.Result, error) {
+	// Garbage collect previous advertised artifact(s) from storage
+	_ = r.garbageCollect(ctx, obj)
+
+	var artifactMissing bool
+	if artifact := obj.GetArtifact(); artifact != nil {
+		// Determine if the advertised artifact is still in storage
+		if !r.Storage.ArtifactExist(*artifact) {
+			artifactMissing = true
+		}
+
+		// If the artifact is in storage, verify if the advertised digest still
+		// matches the actual artifact
+		if !artifactMissing {
+			if err := r.Storage.VerifyArtifact(*artifact); err != nil {
+				r.Eventf(obj, corev1.EventTypeWarning, "ArtifactVerificationFailed", "failed to verify integrity of artifact: %s", err.Error())
+
+				if err = r.Storage.Remove(*artifact); err != nil {
+					return sreconcile.ResultEmpty, fmt.Errorf("failed to remove artifact after digest mismatch: %w", err)
+				}
+
+				artifactMissing = true
+			}
+		}
+
+		// If the artifact is missing, remove it from the object
+		if artifactMissing {
+			obj.Status.Artifact = nil
+			obj.Status.URL = ""
+		}
+	}
+
+	// Record that we do not have an artifact
+	if obj.GetArtifact() == nil {
+		msg := "building artifact"
+		if artifactMissing {
+			msg += ": disappeared from storage"
+		}
+		rreconcile.ProgressiveStatus(true, obj, meta.ProgressingReason, msg)
+		conditions.Delete(obj, sourcev1.ArtifactInStorageCondition)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// Always update URLs to ensure hostname is up-to-date
+	r.Storage.SetArtifactURL(obj.GetArtifact())
+	obj.Status.URL = r.Storage.SetHostname(obj.Status.URL)
+
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileSource fetches the file from the URL of the object into dir, and
+// records its observations on the given httpSourceFetch.
+//
+// When the object has an up-to-date Artifact, a conditional request is made
+// using the observed ETag and Last-Modified headers. If the server responds
+// with "304 Not Modified", the revision of the current Artifact is recorded
+// and the file is not fetched.
+// When a Checksum is defined, the fetched file is verified against it. If
+// this fails, it records v1beta2.FetchFailedCondition=True on the object and
+// returns early.
+// If the revision of the fetched file differs from the current Artifact, it
+// records v1.ArtifactOutdatedCondition=True on the object.
+func (r *HTTPSourceReconciler) reconcileSource(ctx context.Context, sp *patch.SerialPatcher, obj *httpv1.HTTPSource, fetch *httpSourceFetch, dir string) (sreconcile.Result, error) {
+	fileName, err := httpSourceFileName(obj.Spec.URL)
+	if err != nil {
+		e := serror.NewStalling(err, sourcev1.URLInvalidReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	var expected digest.Digest
+	if c := obj.Spec.Checksum; c != nil {
+		if (c.Digest == "") == (c.URL == "") {
+			e := serror.NewStalling(errors.New("exactly one of checksum digest or URL must be set"),
+				httpv1.ChecksumVerificationFailedReason)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		if c.Digest != "" {
+			if expected, err = digest.Parse(c.Digest); err != nil {
+				e := serror.NewStalling(fmt.Errorf("invalid checksum digest '%s': %w", c.Digest, err),
+					httpv1.ChecksumVerificationFailedReason)
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+				return sreconcile.ResultEmpty, e
+			}
+		}
+	}
+
+	secrets, err := secretClient(obj, r.Client, r.Impersonator, obj.Spec.ServiceAccountName)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
+	hc, err := r.httpClient(ctx, secrets, obj)
+	if err != nil {
+		e := serror.NewGeneric(err, sourcev1.AuthenticationFailedReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+		// Return error as the world as observed may change
+		return sreconcile.ResultEmpty, e
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, obj.Spec.Timeout.Duration)
+	defer cancel()
+
+	// Only make a conditional request if the current Artifact was produced
+	// for the current spec, as the Artifact must otherwise be rebuilt.
+	header := http.Header{}
+	if obj.GetArtifact() != nil && obj.Status.ObservedGeneration == obj.Generation {
+		if obj.Status.ObservedETag != "" {
+			header.Set("If-None-Match", obj.Status.ObservedETag)
+		}
+		if obj.Status.ObservedLastModified != "" {
+			header.Set("If-Modified-Since", obj.Status.ObservedLastModified)
+		}
+	}
+
+	resp, err := hc.get(ctxTimeout, obj.Spec.URL, header)
+	if err != nil {
+		e := serror.NewGeneric(err, httpv1.HTTPOperationFailedReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	defer resp.Body.Close()
+
+	fetch.ETag = resp.Header.Get("ETag")
+	fetch.LastModified = resp.Header.Get("Last-Modified")
+
+	if resp.StatusCode == http.StatusNotModified && len(header) > 0 {
+		fetch.Revision = obj.GetArtifact().Revision
+		if fetch.ETag == "" {
+			fetch.ETag = obj.Status.ObservedETag
+		}
+		if fetch.LastModified == "" {
+			fetch.LastModified = obj.Status.ObservedLastModified
+		}
+		conditions.Delete(obj, sourcev1.FetchFailedCondition)
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// Write the file to a temporary location while calculating its digests.
+	f, err := os.CreateTemp("", "httpsource-*")
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to create temporary file: %w", err),
+			meta.FailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	defer os.Remove(f.Name())
+
+	canonical := intdigest.Canonical.Digester()
+	writers := []io.Writer{f, canonical.Hash()}
+	verifier := canonical
+	if expected != "" && expected.Algorithm() != intdigest.Canonical {
+		verifier = expected.Algorithm().Digester()
+		writers = append(writers, verifier.Hash())
+	}
+	maxSize := r.MaxFileSize
+	if maxSize <= 0 {
+		maxSize = DefaultHTTPSourceMaxFileSize
+	}
+	n, err := io.Copy(io.MultiWriter(writers...), io.LimitReader(resp.Body, maxSize+1))
+	if err == nil && n > maxSize {
+		err = fmt.Errorf("file exceeds the max size of %d bytes", maxSize)
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to fetch '%s': %w", fileName, serror.SanitizeError(err)),
+			httpv1.HTTPOperationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	// Verify the file against the checksum.
+	if c := obj.Spec.Checksum; c != nil {
+		if c.URL != "" {
+			expected, err = r.fetchChecksum(ctxTimeout, hc, c.URL, fileName)
+			if err != nil {
+				e := serror.NewGeneric(err, httpv1.ChecksumVerificationFailedReason)
+				conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+				return sreconcile.ResultEmpty, e
+			}
+			if expected.Algorithm() != verifier.Digest().Algorithm() {
+				// The algorithm of a checksum file is only known after
+				// fetching it, calculate the digest from the file instead.
+				if verifier, err = digestFile(expected.Algorithm(), f.Name()); err != nil {
+					e := serror.NewGeneric(err, sourcev1.ReadOperationFailedReason)
+					conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Error())
+					return sreconcile.ResultEmpty, e
+				}
+			}
+		}
+		if got := verifier.Digest(); got != expected {
+			e := serror.NewGeneric(
+				fmt.Errorf("checksum mismatch for '%s': expected '%s', got '%s'", fileName, expected, got),
+				httpv1.ChecksumVerificationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+			return sreconcile.ResultEmpty, e
+		}
+	}
+
+	// Extract or move the file into the directory for the Artifact.
+	if obj.Spec.Extract {
+		tf, err := os.Open(f.Name())
+		if err != nil {
+			e := serror.NewGeneric(err, sourcev1.ReadOperationFailedReason)
+			conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		err = tar.Untar(tf, dir, tar.WithMaxUntarSize(int(maxSize)), tar.WithSkipSymlinks())
+		tf.Close()
+		if err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to extract '%s': %w", fileName, err),
+				httpv1.HTTPOperationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+			return sreconcile.ResultEmpty, e
+		}
+	} else if err = fs.RenameWithFallback(f.Name(), filepath.Join(dir, fileName)); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to move '%s' to working directory: %w", fileName, err),
+			meta.FailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	fetch.Revision = canonical.Digest().String()
+
+	// Mark observations about the revision on the object
+	if !obj.GetArtifact().HasRevision(fetch.Revision) {
+		message := fmt.Sprintf("new upstream revision '%s'", fetch.Revision)
+		if obj.GetArtifact() != nil {
+			conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", message)
+		}
+		rreconcile.ProgressiveStatus(true, obj, meta.ProgressingReason, "building artifact: %s", message)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	}
+
+	conditions.Delete(obj, sourcev1.FetchFailedCondition)
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileArtifact archives a new Artifact to the Storage, if the current
+// (Status) data on the object does not match the given.
+//
+// The inspection of the given data to the object is differed, ensuring any
+// stale observations like v1beta2.ArtifactOutdatedCondition are removed.
+// If the given Artifact does not differ from the object's current, it returns
+// early.
+// On a successful archive, the Artifact in the Status of the object is set,
+// and the symlink in the Storage is updated to its path.
+func (r *HTTPSourceReconciler) reconcileArtifact(ctx context.Context, sp *patch.SerialPatcher, obj *httpv1.HTTPSource, fetch *httpSourceFetch, dir string) (sreconcile.Result, error) {
+	revision := digest.Digest(fetch.Revision)
+
+	// Create artifact
+	artifact := r.Storage.NewArtifactFor(obj.Kind, obj, fetch.Revision, fmt.Sprintf("%s.tar.gz", revision.Encoded()))
+
+	// Set the ArtifactInStorageCondition if there's no drift.
+	defer func() {
+		if obj.GetArtifact().HasRevision(artifact.Revision) && !httpSourceContentConfigChanged(obj) {
+			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+			conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+			obj.Status.PendingArtifact = nil
+			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, meta.SucceededReason,
+				"stored artifact: revision '%s'", artifact.Revision)
+		}
+	}()
+
+	// Hold the new revision while the schedule is closed
+	if held, err := holdRevision(obj, obj.Spec.Schedule, obj.GetArtifact(), artifact.Revision, time.Now()); err != nil {
+		return sreconcile.ResultEmpty, err
+	} else if held {
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// The artifact is up-to-date
+	if obj.GetArtifact().HasRevision(artifact.Revision) && !httpSourceContentConfigChanged(obj) {
+		obj.Status.ObservedETag = fetch.ETag
+		obj.Status.ObservedLastModified = fetch.LastModified
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason, "artifact up-to-date with remote revision: '%s'", artifact.Revision)
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// Ensure target path exists and is a directory
+	if f, err := os.Stat(dir); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to stat source path: %w", err),
+			sourcev1.StatOperationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	} else if !f.IsDir() {
+		e := serror.NewGeneric(
+			fmt.Errorf("source path '%s' is not a directory", dir),
+			sourcev1.InvalidPathReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	// Ensure artifact directory exists and acquire lock
+	if err := r.Storage.MkdirAll(artifact); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to create artifact directory: %w", err),
+			sourcev1.DirCreationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	unlock, err := r.Storage.Lock(artifact)
+	if err != nil {
+		return sreconcile.ResultEmpty, serror.NewGeneric(
+			fmt.Errorf("failed to acquire lock for artifact: %w", err),
+			meta.FailedReason,
+		)
+	}
+	defer unlock()
+
+	// Load ignore rules for archiving extracted files.
+	var filter ArchiveFileFilter
+	if obj.Spec.Extract {
+		ignoreDomain := strings.Split(dir, string(filepath.Separator))
+		ps, err := sourceignore.LoadIgnorePatterns(dir, ignoreDomain)
+		if err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(
+				fmt.Errorf("failed to load source ignore patterns from archive: %w", err),
+				"SourceIgnoreError",
+			)
+		}
+		if obj.Spec.Ignore != nil {
+			ps = append(ps, sourceignore.ReadPatterns(strings.NewReader(*obj.Spec.Ignore), ignoreDomain)...)
+		}
+		filter = SourceIgnoreFilter(ps, ignoreDomain)
+	}
+
+	// Archive directory to storage
+	if err := r.Storage.Archive(&artifact, dir, filter); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("unable to archive artifact to storage: %s", err),
+			sourcev1.ArchiveOperationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	// Keep serving the current artifact until the new revision is approved
+	if awaitArtifactApproval(obj, obj.Spec.Approval, r.Storage, obj.GetArtifact(), &artifact) {
+		obj.Status.PendingArtifact = artifact.DeepCopy()
+		return sreconcile.ResultSuccess, nil
+	}
+	obj.Status.PendingArtifact = nil
+
+	// Record it on the object
+	obj.Status.Artifact = artifact.DeepCopy()
+	obj.Status.ObservedETag = fetch.ETag
+	obj.Status.ObservedLastModified = fetch.LastModified
+	obj.Status.ObservedExtract = obj.Spec.Extract
+	obj.Status.ObservedIgnore = obj.Spec.Ignore
+
+	// Update symlink on a "best effort" basis
+	url, err := r.Storage.Symlink(artifact, "latest.tar.gz")
+	if err != nil {
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.SymlinkUpdateFailedReason,
+			"failed to update status URL symlink: %s", err)
+	}
+	if url != "" {
+		obj.Status.URL = url
+	}
+	conditions.Delete(obj, sourcev1.StorageOperationFailedCondition)
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileDelete handles the deletion of the object.
+// It first garbage collects all Artifacts for the object from the Storage.
+// Removing the finalizer from the object if successful.
+func (r *HTTPSourceReconciler) reconcileDelete(ctx context.Context, obj *httpv1.HTTPSource) (sreconcile.Result, error) {
+	// Garbage collect the resource's artifacts
+	if err := r.garbageCollect(ctx, obj); err != nil {
+		// Return the error so we retry the failed garbage collection
+		return sreconcile.ResultEmpty, err
+	}
+
+	// Remove our finalizer from the list
+	controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+
+	// Stop reconciliation as the object is being deleted
+	return sreconcile.ResultEmpty, nil
+}
+
+// garbageCollect performs a garbage collection for the given object.
+//
+// It removes all but the current Artifact from the Storage, unless the
+// deletion timestamp on the object is set. Which will result in the
+// removal of all Artifacts for the objects.
+func (r *HTTPSourceReconciler) garbageCollect(ctx context.Context, obj *httpv1.HTTPSource) error {
+	if !obj.DeletionTimestamp.IsZero() {
+		if deleted, err := r.Storage.RemoveAll(r.Storage.NewArtifactFor(obj.Kind, obj.GetObjectMeta(), "", "*")); err != nil {
+			return serror.NewGeneric(
+				fmt.Errorf("garbage collection for deleted resource failed: %s", err),
+				"GarbageCollectionFailed",
+			)
+		} else if deleted != "" {
+			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "GarbageCollectionSucceeded",
+				"garbage collected artifacts for deleted resource")
+		}
+		obj.Status.Artifact = nil
+		obj.Status.PendingArtifact = nil
+		return nil
+	}
+	if obj.GetArtifact() != nil {
+		var keep []sourcev1.Artifact
+		if pending := obj.Status.PendingArtifact; pending != nil {
+			keep = append(keep, *pending)
+		}
+		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5, keep...)
+		if err != nil {
+			return serror.NewGeneric(
+				fmt.Errorf("garbage collection of artifacts failed: %w", err),
+				"GarbageCollectionFailed",
+			)
+		}
+		if len(delFiles) > 0 {
+			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "GarbageCollectionSucceeded",
+				fmt.Sprintf("garbage collected %d artifacts", len(delFiles)))
+			return nil
+		}
+	}
+	return nil
+}
+
+// httpSourceClient fetches files for a v1beta2.HTTPSource, authenticating
+// the requests with the configured credentials.
+type httpSourceClient struct {
+	client      *http.Client
+	username    string
+	password    string
+	bearerToken string
+}
+
+// get makes a GET request for the given URL with the given headers. It
+// returns an error if the response status code is not successful, or is not
+// "304 Not Modified".
+func (c *httpSourceClient) get(ctx context.Context, u string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, serror.SanitizeError(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	switch {
+	case c.bearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	case c.username != "" || c.password != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch '%s': %w", u, serror.SanitizeError(err))
+	}
+	if resp.StatusCode != http.StatusNotModified && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		resp.Body.Close()
+		return nil, serror.SanitizeError(fmt.Errorf("failed to fetch '%s': %s", u, resp.Status))
+	}
+	return resp, nil
+}
+
+// httpClient returns an httpSourceClient configured with the credentials,
+// TLS and proxy configuration of the object.
+func (r *HTTPSourceReconciler) httpClient(ctx context.Context, secrets client.Reader, obj *httpv1.HTTPSource) (*httpSourceClient, error) {
+	c := &httpSourceClient{}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	secret, err := r.getSecret(ctx, secrets, obj.Spec.SecretRef, obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	if secret != nil {
+		c.username = string(secret.Data["username"])
+		c.password = string(secret.Data["password"])
+		c.bearerToken = string(secret.Data["bearerToken"])
+		if c.username == "" && c.password == "" && c.bearerToken == "" {
+			return nil, fmt.Errorf("invalid '%s' secret data: required fields 'username' and 'password', or 'bearerToken'", secret.Name)
+		}
+	}
+
+	certSecret, err := r.getSecret(ctx, secrets, obj.Spec.CertSecretRef, obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	if certSecret != nil {
+		tlsConfig, _, err := tls.KubeTLSClientConfigFromSecret(*certSecret, obj.Spec.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create TLS config: %w", err)
+		}
+		if tlsConfig == nil {
+			return nil, fmt.Errorf("certificate secret does not contain any TLS configuration")
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	proxySecret, err := r.getSecret(ctx, secrets, obj.Spec.ProxySecretRef, obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	if proxySecret != nil {
+		address, ok := proxySecret.Data["address"]
+		if !ok {
+			return nil, fmt.Errorf("invalid proxy secret '%s/%s': key 'address' is missing", proxySecret.Namespace, proxySecret.Name)
+		}
+		proxyURL, err := url.Parse(string(address))
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy secret '%s/%s': failed to parse address: %w", proxySecret.Namespace, proxySecret.Name, err)
+		}
+		if username, ok := proxySecret.Data["username"]; ok {
+			proxyURL.User = url.UserPassword(string(username), string(proxySecret.Data["password"]))
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	c.client = &http.Client{Transport: transport}
+	return c, nil
+}
+
+// fetchChecksum fetches the checksum file from the given URL, and returns the
+// checksum for the file with the given name.
+func (r *HTTPSourceReconciler) fetchChecksum(ctx context.Context, c *httpSourceClient, u, fileName string) (digest.Digest, error) {
+	resp, err := c.get(ctx, u, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch checksum file: %w", err)
+	}
+	defer resp.Body.Close()
+	return checksumFromFile(resp.Body, fileName)
+}
+
+// checksumFromFile returns the checksum for the file with the given name from
+// a checksum file in the format of the sha256sum, sha384sum and sha512sum
+// utilities. A checksum file with a single checksum without file name is
+// accepted for any file name. The algorithm is determined by the length of
+// the checksum.
+func checksumFromFile(r io.Reader, fileName string) (digest.Digest, error) {
+	var found, single string
+	var lines int
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		lines++
+		if len(fields) == 1 {
+			single = fields[0]
+			continue
+		}
+		name := strings.TrimPrefix(fields[1], "*")
+		if name == fileName || path.Base(name) == fileName {
+			found = fields[0]
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read checksum file: %w", err)
+	}
+	if found == "" {
+		if single == "" || lines > 1 {
+			return "", fmt.Errorf("no checksum found for '%s' in checksum file", fileName)
+		}
+		found = single
+	}
+
+	var algo digest.Algorithm
+	switch len(found) {
+	case digest.SHA256.Size() * 2:
+		algo = digest.SHA256
+	case digest.SHA384.Size() * 2:
+		algo = digest.SHA384
+	case digest.SHA512.Size() * 2:
+		algo = digest.SHA512
+	default:
+		return "", fmt.Errorf("unsupported checksum '%s' for '%s' in checksum file", found, fileName)
+	}
+	d := digest.NewDigestFromEncoded(algo, strings.ToLower(found))
+	if err := d.Validate(); err != nil {
+		return "", fmt.Errorf("invalid checksum for '%s' in checksum file: %w", fileName, err)
+	}
+	return d, nil
+}
+
+// digestFile returns a digester with the digest of the file at the given path
+// calculated using the given algorithm.
+func digestFile(algo digest.Algorithm, p string) (digest.Digester, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d := algo.Digester()
+	if _, err = io.Copy(d.Hash(), f); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// httpSourceFileName returns the last element of the path of the given URL,
+// used as the file name of the fetched file.
+func httpSourceFileName(u string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", serror.SanitizeError(fmt.Errorf("failed to parse URL '%s': %w", u, err))
+	}
+	name := path.Base(parsed.Path)
+	if name == "" || name == "." || name == "/" {
+		return "", serror.SanitizeError(fmt.Errorf("URL '%s' does not contain a file name", u))
+	}
+	return name, nil
+}
+
+// httpSourceContentConfigChanged returns true if the spec of the object
+// produces different Artifact content for the same revision than the observed
+// spec.
+func httpSourceContentConfigChanged(obj *httpv1.HTTPSource) bool {
+	if obj.Spec.Extract != obj.Status.ObservedExtract {
+		return true
+	}
+	return obj.Spec.Extract && !ptr.Equal(obj.Spec.Ignore, obj.Status.ObservedIgnore)
+}
+
+// getSecret attempts to fetch a Secret reference if specified. It returns any client error.
+func (r *HTTPSourceReconciler) getSecret(ctx context.Context, c client.Reader, secretRef *meta.LocalObjectReference,
+	namespace string) (*corev1.Secret, error) {
+	if secretRef == nil {
+		return nil, nil
+	}
+	secretName := types.NamespacedName{
+		Namespace: namespace,
+		Name:      secretRef.Name,
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, secretName, secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret '%s': %w", secretName.String(), err)
+	}
+	return secret, nil
+}
+
+// eventLogf records events, and logs at the same time.
+//
+// This log is different from the debug log in the EventRecorder, in the sense
+// that this is a simple log. While the debug log contains complete details
+// about the event.
+func (r *HTTPSourceReconciler) eventLogf(ctx context.Context, obj runtime.Object, eventType string, reason string, messageFmt string, args ...interface{}) {
+	msg := fmt.Sprintf(messageFmt, args...)
+	// Log and emit event.
+	if eventType == corev1.EventTypeWarning {
+		ctrl.LoggerFrom(ctx).Error(errors.New(reason), msg)
+	} else {
+		ctrl.LoggerFrom(ctx).Info(msg)
+	}
+	r.Eventf(obj, eventType, reason, msg)
+}
@@ -0,0 +1,584 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/fluxcd/pkg/runtime/patch"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	httpv1 "github.com/fluxcd/source-controller/api/v1beta2"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+)
+
+func TestHTTPSourceReconciler_reconcileSource(t *testing.T) {
+	fileData := []byte("apiVersion: v1\nkind: Namespace\n")
+	fileDigest := digest.FromBytes(fileData)
+	fileSHA512 := fmt.Sprintf("%x", sha512.Sum512(fileData))
+
+	var archive bytes.Buffer
+	gw := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gw)
+	for name, data := range map[string][]byte{
+		"manifests/namespace.yaml": fileData,
+		"README.md":                []byte("readme"),
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// An archive of which the extracted file is much larger than the archive.
+	var largeArchive bytes.Buffer
+	gw = gzip.NewWriter(&largeArchive)
+	tw = tar.NewWriter(gw)
+	if err := tw.WriteHeader(&tar.Header{Name: "large.txt", Mode: 0o644, Size: 1 << 16}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(make([]byte, 1<<16)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); ok && (u != "user" || p != "pass") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/namespace.yaml":
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			_, _ = w.Write(fileData)
+		case "/private/namespace.yaml":
+			if _, _, ok := r.BasicAuth(); !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write(fileData)
+		case "/archive.tar.gz":
+			_, _ = w.Write(archive.Bytes())
+		case "/large.tar.gz":
+			_, _ = w.Write(largeArchive.Bytes())
+		case "/checksums.txt":
+			_, _ = fmt.Fprintf(w, "%x  archive.tar.gz\n%s  namespace.yaml\n", sha256.Sum256(archive.Bytes()), fileDigest.Encoded())
+		case "/namespace.yaml.sha512":
+			_, _ = fmt.Fprintln(w, fileSHA512)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name             string
+		path             string
+		secret           *corev1.Secret
+		beforeFunc       func(obj *httpv1.HTTPSource)
+		maxFileSize      int64
+		want             sreconcile.Result
+		wantErr          bool
+		wantRevision     string
+		wantFiles        []string
+		assertConditions []metav1.Condition
+	}{
+		{
+			name:         "fetches file",
+			path:         "/namespace.yaml",
+			want:         sreconcile.ResultSuccess,
+			wantRevision: fileDigest.String(),
+			wantFiles:    []string{"namespace.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new upstream revision '%s'", fileDigest),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new upstream revision '%s'", fileDigest),
+			},
+		},
+		{
+			name: "makes conditional request for up-to-date artifact",
+			path: "/namespace.yaml",
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "sha256:existing"}
+				obj.Status.ObservedETag = `"v1"`
+				obj.Status.ObservedGeneration = obj.Generation
+			},
+			want:         sreconcile.ResultSuccess,
+			wantRevision: "sha256:existing",
+		},
+		{
+			name: "ignores observed ETag for new generation",
+			path: "/namespace.yaml",
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "sha256:existing"}
+				obj.Status.ObservedETag = `"v1"`
+				obj.Status.ObservedGeneration = obj.Generation - 1
+			},
+			want:         sreconcile.ResultSuccess,
+			wantRevision: fileDigest.String(),
+			wantFiles:    []string{"namespace.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new upstream revision '%s'", fileDigest),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new upstream revision '%s'", fileDigest),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new upstream revision '%s'", fileDigest),
+			},
+		},
+		{
+			name: "verifies checksum digest",
+			path: "/namespace.yaml",
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Spec.Checksum = &httpv1.HTTPSourceChecksum{Digest: fileDigest.String()}
+			},
+			want:         sreconcile.ResultSuccess,
+			wantRevision: fileDigest.String(),
+			wantFiles:    []string{"namespace.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new upstream revision '%s'", fileDigest),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new upstream revision '%s'", fileDigest),
+			},
+		},
+		{
+			name: "checksum digest mismatch",
+			path: "/namespace.yaml",
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Spec.Checksum = &httpv1.HTTPSourceChecksum{Digest: digest.FromString("other").String()}
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, httpv1.ChecksumVerificationFailedReason, "checksum mismatch for 'namespace.yaml'"),
+			},
+		},
+		{
+			name: "verifies checksum from checksum file",
+			path: "/archive.tar.gz",
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Spec.Checksum = &httpv1.HTTPSourceChecksum{URL: server.URL + "/checksums.txt"}
+				obj.Spec.Extract = true
+			},
+			want:         sreconcile.ResultSuccess,
+			wantRevision: digest.FromBytes(archive.Bytes()).String(),
+			wantFiles:    []string{"README.md", "manifests/namespace.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new upstream revision"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new upstream revision"),
+			},
+		},
+		{
+			name: "verifies non-canonical checksum from checksum file",
+			path: "/namespace.yaml",
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Spec.Checksum = &httpv1.HTTPSourceChecksum{URL: server.URL + "/namespace.yaml.sha512"}
+			},
+			want:         sreconcile.ResultSuccess,
+			wantRevision: fileDigest.String(),
+			wantFiles:    []string{"namespace.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new upstream revision '%s'", fileDigest),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new upstream revision '%s'", fileDigest),
+			},
+		},
+		{
+			name: "checksum file not found",
+			path: "/namespace.yaml",
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Spec.Checksum = &httpv1.HTTPSourceChecksum{URL: server.URL + "/missing.txt"}
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, httpv1.ChecksumVerificationFailedReason, "failed to fetch checksum file"),
+			},
+		},
+		{
+			name: "invalid checksum spec",
+			path: "/namespace.yaml",
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Spec.Checksum = &httpv1.HTTPSourceChecksum{}
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, httpv1.ChecksumVerificationFailedReason, "exactly one of checksum digest or URL must be set"),
+			},
+		},
+		{
+			name: "authenticates with basic auth",
+			path: "/private/namespace.yaml",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "auth", Namespace: "default"},
+				Data: map[string][]byte{
+					"username": []byte("user"),
+					"password": []byte("pass"),
+				},
+			},
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Spec.SecretRef = &meta.LocalObjectReference{Name: "auth"}
+			},
+			want:         sreconcile.ResultSuccess,
+			wantRevision: fileDigest.String(),
+			wantFiles:    []string{"namespace.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new upstream revision '%s'", fileDigest),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new upstream revision '%s'", fileDigest),
+			},
+		},
+		{
+			name: "missing secret",
+			path: "/private/namespace.yaml",
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Spec.SecretRef = &meta.LocalObjectReference{Name: "auth"}
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.AuthenticationFailedReason, "failed to get secret 'default/auth'"),
+			},
+		},
+		{
+			name:    "unauthorized",
+			path:    "/private/namespace.yaml",
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, httpv1.HTTPOperationFailedReason, "401 Unauthorized"),
+			},
+		},
+		{
+			name:    "not found",
+			path:    "/missing.yaml",
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, httpv1.HTTPOperationFailedReason, "404 Not Found"),
+			},
+		},
+		{
+			name: "extracts invalid archive",
+			path: "/namespace.yaml",
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Spec.Extract = true
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, httpv1.HTTPOperationFailedReason, "failed to extract 'namespace.yaml'"),
+			},
+		},
+		{
+			name:        "file exceeds max size",
+			path:        "/namespace.yaml",
+			maxFileSize: 10,
+			want:        sreconcile.ResultEmpty,
+			wantErr:     true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, httpv1.HTTPOperationFailedReason, "file exceeds the max size of 10 bytes"),
+			},
+		},
+		{
+			name: "extracted files exceed max size",
+			path: "/large.tar.gz",
+			beforeFunc: func(obj *httpv1.HTTPSource) {
+				obj.Spec.Extract = true
+			},
+			maxFileSize: 1 << 15,
+			want:        sreconcile.ResultEmpty,
+			wantErr:     true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, httpv1.HTTPOperationFailedReason, "bigger than max archive size"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &httpv1.HTTPSource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test-httpsource",
+					Namespace:  "default",
+					Generation: 2,
+				},
+				Spec: httpv1.HTTPSourceSpec{
+					URL:     server.URL + tt.path,
+					Timeout: &metav1.Duration{Duration: timeout},
+				},
+			}
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			objs := []client.Object{obj}
+			if tt.secret != nil {
+				objs = append(objs, tt.secret)
+			}
+			r := &HTTPSourceReconciler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithObjects(objs...).
+					WithStatusSubresource(&httpv1.HTTPSource{}).
+					Build(),
+				EventRecorder: record.NewFakeRecorder(32),
+				Storage:       testStorage,
+				MaxFileSize:   tt.maxFileSize,
+				patchOptions:  getPatchOptions(httpSourceReadyCondition.Owned, "sc"),
+			}
+
+			dir := t.TempDir()
+			fetch := &httpSourceFetch{}
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			got, err := r.reconcileSource(context.TODO(), sp, obj, fetch, dir)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(fetch.Revision).To(Equal(tt.wantRevision))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+
+			var files []string
+			g.Expect(filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(dir, p)
+				files = append(files, filepath.ToSlash(rel))
+				return err
+			})).To(Succeed())
+			g.Expect(files).To(Equal(tt.wantFiles))
+		})
+	}
+}
+
+func TestHTTPSourceReconciler_reconcileArtifact(t *testing.T) {
+	tests := []struct {
+		name             string
+		beforeFunc       func(t *WithT, obj *httpv1.HTTPSource, fetch *httpSourceFetch, dir string)
+		afterFunc        func(t *WithT, obj *httpv1.HTTPSource)
+		want             sreconcile.Result
+		wantErr          bool
+		assertConditions []metav1.Condition
+	}{
+		{
+			name: "Archiving artifact to storage makes ArtifactInStorage=True",
+			beforeFunc: func(t *WithT, obj *httpv1.HTTPSource, fetch *httpSourceFetch, dir string) {
+				t.Expect(os.WriteFile(filepath.Join(dir, "namespace.yaml"), []byte("kind: Namespace"), 0o640)).To(Succeed())
+				fetch.ETag = `"v1"`
+				conditions.MarkReconciling(obj, meta.ProgressingReason, "foo")
+				conditions.MarkUnknown(obj, meta.ReadyCondition, meta.ProgressingReason, "foo")
+			},
+			afterFunc: func(t *WithT, obj *httpv1.HTTPSource) {
+				t.Expect(obj.GetArtifact()).ToNot(BeNil())
+				t.Expect(obj.GetArtifact().Revision).To(Equal(digest.FromString("revision").String()))
+				t.Expect(obj.Status.URL).ToNot(BeEmpty())
+				t.Expect(obj.Status.ObservedETag).To(Equal(`"v1"`))
+				t.Expect(obj.Status.ObservedExtract).To(BeFalse())
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'sha256:"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "foo"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "foo"),
+			},
+		},
+		{
+			name: "Up-to-date artifact records observed headers",
+			beforeFunc: func(t *WithT, obj *httpv1.HTTPSource, fetch *httpSourceFetch, dir string) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: fetch.Revision}
+				fetch.ETag = `"v2"`
+				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", "foo")
+			},
+			afterFunc: func(t *WithT, obj *httpv1.HTTPSource) {
+				t.Expect(obj.Status.URL).To(BeEmpty())
+				t.Expect(obj.Status.ObservedETag).To(Equal(`"v2"`))
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'sha256:"),
+			},
+		},
+		{
+			name: "Changed extract setting rebuilds artifact",
+			beforeFunc: func(t *WithT, obj *httpv1.HTTPSource, fetch *httpSourceFetch, dir string) {
+				t.Expect(os.WriteFile(filepath.Join(dir, "namespace.yaml"), []byte("kind: Namespace"), 0o640)).To(Succeed())
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: fetch.Revision}
+				obj.Spec.Extract = true
+			},
+			afterFunc: func(t *WithT, obj *httpv1.HTTPSource) {
+				t.Expect(obj.Status.URL).ToNot(BeEmpty())
+				t.Expect(obj.Status.ObservedExtract).To(BeTrue())
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'sha256:"),
+			},
+		},
+		{
+			name: "Dir path deleted",
+			beforeFunc: func(t *WithT, obj *httpv1.HTTPSource, fetch *httpSourceFetch, dir string) {
+				t.Expect(os.RemoveAll(dir)).ToNot(HaveOccurred())
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.StorageOperationFailedCondition, sourcev1.StatOperationFailedReason, "failed to stat source path"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &HTTPSourceReconciler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithStatusSubresource(&httpv1.HTTPSource{}).
+					Build(),
+				EventRecorder: record.NewFakeRecorder(32),
+				Storage:       testStorage,
+				patchOptions:  getPatchOptions(httpSourceReadyCondition.Owned, "sc"),
+			}
+
+			obj := &httpv1.HTTPSource{
+				TypeMeta: metav1.TypeMeta{
+					Kind: httpv1.HTTPSourceKind,
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-httpsource-",
+					Generation:   1,
+					Namespace:    "default",
+				},
+				Spec: httpv1.HTTPSourceSpec{
+					Timeout: &metav1.Duration{Duration: timeout},
+				},
+			}
+
+			tmpDir := t.TempDir()
+			fetch := &httpSourceFetch{Revision: digest.FromString("revision").String()}
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(g, obj, fetch, tmpDir)
+			}
+
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			got, err := r.reconcileArtifact(context.TODO(), sp, obj, fetch, tmpDir)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+
+			if tt.afterFunc != nil {
+				tt.afterFunc(g, obj)
+			}
+		})
+	}
+}
+
+func Test_checksumFromFile(t *testing.T) {
+	sum256 := digest.FromString("file")
+	sum512 := digest.SHA512.FromString("file")
+
+	tests := []struct {
+		name     string
+		data     string
+		fileName string
+		want     digest.Digest
+		wantErr  string
+	}{
+		{
+			name:     "sha256sum format",
+			data:     fmt.Sprintf("%s  other.tar.gz\n%s  file.tar.gz\n", digest.FromString("other").Encoded(), sum256.Encoded()),
+			fileName: "file.tar.gz",
+			want:     sum256,
+		},
+		{
+			name:     "binary mode and path",
+			data:     fmt.Sprintf("# checksums\n%s *dist/file.tar.gz\n", sum512.Encoded()),
+			fileName: "file.tar.gz",
+			want:     sum512,
+		},
+		{
+			name:     "single checksum",
+			data:     strings.ToUpper(sum256.Encoded()) + "\n",
+			fileName: "file.tar.gz",
+			want:     sum256,
+		},
+		{
+			name:     "no checksum for file",
+			data:     fmt.Sprintf("%s  other.tar.gz\n", sum256.Encoded()),
+			fileName: "file.tar.gz",
+			wantErr:  "no checksum found for 'file.tar.gz'",
+		},
+		{
+			name:     "unsupported checksum",
+			data:     "abc  file.tar.gz\n",
+			fileName: "file.tar.gz",
+			wantErr:  "unsupported checksum 'abc'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := checksumFromFile(strings.NewReader(tt.data), tt.fileName)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
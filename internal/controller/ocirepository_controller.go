@@ -70,9 +70,12 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	ociv1 "github.com/fluxcd/source-controller/api/v1beta2"
 	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/hostlimit"
+	"github.com/fluxcd/source-controller/internal/impersonation"
 	soci "github.com/fluxcd/source-controller/internal/oci"
 	scosign "github.com/fluxcd/source-controller/internal/oci/cosign"
 	"github.com/fluxcd/source-controller/internal/oci/notation"
+	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
 	"github.com/fluxcd/source-controller/internal/tls"
@@ -84,6 +87,9 @@ import (
 var ociRepositoryReadyCondition = summarize.Conditions{
 	Target: meta.ReadyCondition,
 	Owned: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.RevisionHeldCondition,
+		sourcev1.PendingApprovalCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.ArtifactOutdatedCondition,
@@ -94,6 +100,7 @@ var ociRepositoryReadyCondition = summarize.Conditions{
 		meta.StalledCondition,
 	},
 	Summarize: []string{
+		sourcev1.DependencyNotReadyCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.ArtifactOutdatedCondition,
@@ -103,6 +110,7 @@ var ociRepositoryReadyCondition = summarize.Conditions{
 		meta.ReconcilingCondition,
 	},
 	NegativePolarity: []string{
+		sourcev1.DependencyNotReadyCondition,
 		sourcev1.StorageOperationFailedCondition,
 		sourcev1.FetchFailedCondition,
 		sourcev1.ArtifactOutdatedCondition,
@@ -138,8 +146,17 @@ type OCIRepositoryReconciler struct {
 	helper.Metrics
 	kuberecorder.EventRecorder
 
-	Storage           *Storage
-	ControllerName    string
+	Storage        *Storage
+	ControllerName string
+
+	// HostLimiter limits the concurrency and rate of remote operations per
+	// host. It is shared between reconcilers, and disabled when nil.
+	HostLimiter *hostlimit.Limiter
+
+	// Impersonator impersonates the ServiceAccount of the object to read its
+	// Secrets in multi-tenant lockdown mode. It is disabled when nil.
+	Impersonator *impersonation.Impersonator
+
 	requeueDependency time.Duration
 
 	patchOptions []patch.Option
@@ -162,7 +179,8 @@ func (r *OCIRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, o
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ociv1.OCIRepository{}, builder.WithPredicates(
-			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+				intpredicates.ApprovedRevisionPredicate{}),
 		)).
 		WithOptions(controller.Options{
 			RateLimiter: opts.RateLimiter,
@@ -206,7 +224,8 @@ func (r *OCIRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				summarize.RecordReconcileReq,
 			),
 			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
-				RequeueAfter: jitter.JitteredIntervalDuration(obj.GetRequeueAfter()),
+				RequeueAfter: holdRequeueAfter(obj, obj.Spec.Schedule,
+					jitter.JitteredIntervalDuration(obj.GetRequeueAfter()), time.Now()),
 			}),
 			summarize.WithPatchFieldOwner(r.ControllerName),
 		}
@@ -241,6 +260,12 @@ func (r *OCIRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return
 	}
 
+	// Wait for the dependencies of the object to be ready.
+	if err := checkSourceDependencies(ctx, r.Client, obj, obj.Spec.DependsOn, r.requeueDependency); err != nil {
+		recResult, retErr = sreconcile.ResultEmpty, err
+		return
+	}
+
 	// Reconcile actual object
 	reconcilers := []ociRepositoryReconcileFunc{
 		r.reconcileStorage,
@@ -342,8 +367,13 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 		conditions.Delete(obj, sourcev1.SourceVerifiedCondition)
 	}
 
+	secrets, err := secretClient(obj, r.Client, r.Impersonator, obj.Spec.ServiceAccountName)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
 	// Generate the registry credential keychain either from static credentials or using cloud OIDC
-	keychain, err := r.keychain(ctx, obj)
+	keychain, err := r.keychain(ctx, secrets, obj)
 	if err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("failed to get credential: %w", err),
@@ -367,7 +397,7 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 	}
 
 	// Generate the transport for remote operations
-	transport, err := r.transport(ctx, obj)
+	transport, err := r.transport(ctx, secrets, obj)
 	if err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("failed to generate transport for '%s': %w", obj.Spec.URL, err),
@@ -377,11 +407,19 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 		return sreconcile.ResultEmpty, e
 	}
 
-	opts := makeRemoteOptions(ctx, transport, keychain, auth)
+	// Limit the remote operations on the registry host
+	res, err := reserveHost(obj, r.HostLimiter, obj.Spec.URL)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+	defer res.Release()
+
+	opts := makeRemoteOptions(ctx, r.HostLimiter.RoundTripper(transport), keychain, auth)
 
 	// Determine which artifact revision to pull
 	ref, err := r.getArtifactRef(obj, opts)
 	if err != nil {
+		recordHostResult(r.EventRecorder, obj, res, err)
 		if _, ok := err.(invalidOCIURLError); ok {
 			e := serror.NewStalling(
 				fmt.Errorf("URL validation failed for '%s': %w", obj.Spec.URL, err),
@@ -400,6 +438,7 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 	// Get the upstream revision from the artifact digest
 	// TODO: getRevision resolves the digest, which may change before image is fetched, so it should probaly update ref
 	revision, err := r.getRevision(ref, opts)
+	recordHostResult(r.EventRecorder, obj, res, err)
 	if err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("failed to determine artifact digest: %w", err),
@@ -437,7 +476,7 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 		conditions.GetObservedGeneration(obj, sourcev1.SourceVerifiedCondition) != obj.Generation ||
 		conditions.IsFalse(obj, sourcev1.SourceVerifiedCondition) {
 
-		result, err := r.verifySignature(ctx, obj, ref, keychain, auth, opts...)
+		result, err := r.verifySignature(ctx, secrets, obj, ref, keychain, auth, opts...)
 		if err != nil {
 			provider := obj.Spec.Verify.Provider
 			if obj.Spec.Verify.SecretRef == nil && obj.Spec.Verify.Provider == "cosign" {
@@ -623,7 +662,7 @@ func (r *OCIRepositoryReconciler) digestFromRevision(revision string) string {
 // If not, when using cosign it falls back to a keyless approach for verification.
 // When notation is used, a trust policy is required to verify the image.
 // The verification result is returned as a VerificationResult and any error encountered.
-func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, obj *ociv1.OCIRepository, ref name.Reference, keychain authn.Keychain, auth authn.Authenticator, opt ...remote.Option) (soci.VerificationResult, error) {
+func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, secrets client.Reader, obj *ociv1.OCIRepository, ref name.Reference, keychain authn.Keychain, auth authn.Authenticator, opt ...remote.Option) (soci.VerificationResult, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, obj.Spec.Timeout.Duration)
 	defer cancel()
 
@@ -642,7 +681,7 @@ func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, obj *ociv
 				Name:      secretRef.Name,
 			}
 
-			pubSecret, err := r.retrieveSecret(ctxTimeout, verifySecret)
+			pubSecret, err := r.retrieveSecret(ctxTimeout, secrets, verifySecret)
 			if err != nil {
 				return soci.VerificationResultFailed, err
 			}
@@ -716,7 +755,7 @@ func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, obj *ociv
 			Name:      secretRef.Name,
 		}
 
-		pubSecret, err := r.retrieveSecret(ctxTimeout, verifySecret)
+		pubSecret, err := r.retrieveSecret(ctxTimeout, secrets, verifySecret)
 		if err != nil {
 			return soci.VerificationResultFailed, err
 		}
@@ -777,10 +816,10 @@ func (r *OCIRepositoryReconciler) verifySignature(ctx context.Context, obj *ociv
 
 // retrieveSecret retrieves a secret from the specified namespace with the given secret name.
 // It returns the retrieved secret and any error encountered during the retrieval process.
-func (r *OCIRepositoryReconciler) retrieveSecret(ctx context.Context, verifySecret types.NamespacedName) (corev1.Secret, error) {
+func (r *OCIRepositoryReconciler) retrieveSecret(ctx context.Context, c client.Reader, verifySecret types.NamespacedName) (corev1.Secret, error) {
 	var pubSecret corev1.Secret
 
-	if err := r.Get(ctx, verifySecret, &pubSecret); err != nil {
+	if err := c.Get(ctx, verifySecret, &pubSecret); err != nil {
 		return corev1.Secret{}, err
 	}
 	return pubSecret, nil
@@ -875,8 +914,9 @@ func (r *OCIRepositoryReconciler) getTagBySemver(repo name.Repository, exp strin
 
 // keychain generates the credential keychain based on the resource
 // configuration. If no auth is specified a default keychain with
-// anonymous access is returned
-func (r *OCIRepositoryReconciler) keychain(ctx context.Context, obj *ociv1.OCIRepository) (authn.Keychain, error) {
+// anonymous access is returned. The pull secrets are read with the given
+// secrets client.
+func (r *OCIRepositoryReconciler) keychain(ctx context.Context, secrets client.Reader, obj *ociv1.OCIRepository) (authn.Keychain, error) {
 	pullSecretNames := sets.NewString()
 
 	// lookup auth secret
@@ -906,7 +946,7 @@ func (r *OCIRepositoryReconciler) keychain(ctx context.Context, obj *ociv1.OCIRe
 	imagePullSecrets := make([]corev1.Secret, len(pullSecretNames))
 	for i, imagePullSecretName := range pullSecretNames.List() {
 		imagePullSecret := corev1.Secret{}
-		err := r.Get(ctx, types.NamespacedName{Namespace: obj.Namespace, Name: imagePullSecretName}, &imagePullSecret)
+		err := secrets.Get(ctx, types.NamespacedName{Namespace: obj.Namespace, Name: imagePullSecretName}, &imagePullSecret)
 		if err != nil {
 			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.AuthenticationFailedReason,
 				"auth secret '%s' not found", imagePullSecretName)
@@ -920,7 +960,7 @@ func (r *OCIRepositoryReconciler) keychain(ctx context.Context, obj *ociv1.OCIRe
 
 // transport clones the default transport from remote and when a certSecretRef is specified,
 // the returned transport will include the TLS client and/or CA certificates.
-func (r *OCIRepositoryReconciler) transport(ctx context.Context, obj *ociv1.OCIRepository) (*http.Transport, error) {
+func (r *OCIRepositoryReconciler) transport(ctx context.Context, secrets client.Reader, obj *ociv1.OCIRepository) (*http.Transport, error) {
 	transport := remote.DefaultTransport.(*http.Transport).Clone()
 
 	if obj.Spec.CertSecretRef == nil || obj.Spec.CertSecretRef.Name == "" {
@@ -937,7 +977,7 @@ func (r *OCIRepositoryReconciler) transport(ctx context.Context, obj *ociv1.OCIR
 		Name:      obj.Spec.CertSecretRef.Name,
 	}
 	var certSecret corev1.Secret
-	if err := r.Get(ctx, certSecretName, &certSecret); err != nil {
+	if err := secrets.Get(ctx, certSecretName, &certSecret); err != nil {
 		return nil, err
 	}
 
@@ -1045,11 +1085,20 @@ func (r *OCIRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 	defer func() {
 		if obj.GetArtifact().HasRevision(artifact.Revision) && !ociContentConfigChanged(obj) {
 			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+			conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+			obj.Status.PendingArtifact = nil
 			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, meta.SucceededReason,
 				"stored artifact for digest '%s'", artifact.Revision)
 		}
 	}()
 
+	// Hold the new revision while the schedule is closed
+	if held, err := holdRevision(obj, obj.Spec.Schedule, obj.GetArtifact(), artifact.Revision, time.Now()); err != nil {
+		return sreconcile.ResultEmpty, err
+	} else if held {
+		return sreconcile.ResultSuccess, nil
+	}
+
 	// The artifact is up-to-date
 	if obj.GetArtifact().HasRevision(artifact.Revision) && !ociContentConfigChanged(obj) {
 		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason,
@@ -1126,6 +1175,15 @@ func (r *OCIRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 		}
 	}
 
+	// Keep serving the current artifact until the new revision is approved
+	pending := artifact.DeepCopy()
+	pending.Metadata = metadata.Metadata
+	if awaitArtifactApproval(obj, obj.Spec.Approval, r.Storage, obj.GetArtifact(), pending) {
+		obj.Status.PendingArtifact = pending
+		return sreconcile.ResultSuccess, nil
+	}
+	obj.Status.PendingArtifact = nil
+
 	// Record the observations on the object.
 	obj.Status.Artifact = artifact.DeepCopy()
 	obj.Status.Artifact.Metadata = metadata.Metadata
@@ -1180,10 +1238,15 @@ func (r *OCIRepositoryReconciler) garbageCollect(ctx context.Context, obj *ociv1
 				"garbage collected artifacts for deleted resource")
 		}
 		obj.Status.Artifact = nil
+		obj.Status.PendingArtifact = nil
 		return nil
 	}
 	if obj.GetArtifact() != nil {
-		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5)
+		var keep []sourcev1.Artifact
+		if pending := obj.Status.PendingArtifact; pending != nil {
+			keep = append(keep, *pending)
+		}
+		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5, keep...)
 		if err != nil {
 			return serror.NewGeneric(
 				fmt.Errorf("garbage collection of artifacts failed: %w", err),
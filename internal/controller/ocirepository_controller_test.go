@@ -1445,7 +1445,7 @@ func TestOCIRepository_reconcileSource_verifyOCISourceSignatureNotation(t *testi
 				obj.Spec.Reference.Digest = podinfoVersions[tt.reference.Tag].digest.String()
 			}
 
-			keychain, err := r.keychain(ctx, obj)
+			keychain, err := r.keychain(ctx, r.Client, obj)
 			if err != nil {
 				g.Expect(err).ToNot(HaveOccurred())
 			}
@@ -1775,7 +1775,7 @@ func TestOCIRepository_reconcileSource_verifyOCISourceTrustPolicyNotation(t *tes
 				obj.Spec.Reference.Digest = podinfoVersions[tt.reference.Tag].digest.String()
 			}
 
-			keychain, err := r.keychain(ctx, obj)
+			keychain, err := r.keychain(ctx, r.Client, obj)
 			if err != nil {
 				g.Expect(err).ToNot(HaveOccurred())
 			}
@@ -2064,7 +2064,7 @@ func TestOCIRepository_reconcileSource_verifyOCISourceSignatureCosign(t *testing
 			podinfoVersions, err := pushMultiplePodinfoImages(server.registryHost, tt.insecure, tt.reference.Tag)
 			g.Expect(err).ToNot(HaveOccurred())
 
-			keychain, err := r.keychain(ctx, obj)
+			keychain, err := r.keychain(ctx, r.Client, obj)
 			if err != nil {
 				g.Expect(err).ToNot(HaveOccurred())
 			}
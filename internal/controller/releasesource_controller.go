@@ -0,0 +1,906 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kuberecorder "k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+
+	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	helper "github.com/fluxcd/pkg/runtime/controller"
+	"github.com/fluxcd/pkg/runtime/jitter"
+	"github.com/fluxcd/pkg/runtime/patch"
+	"github.com/fluxcd/pkg/runtime/predicates"
+	rreconcile "github.com/fluxcd/pkg/runtime/reconcile"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	intdigest "github.com/fluxcd/source-controller/internal/digest"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/impersonation"
+	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
+	"github.com/fluxcd/source-controller/internal/release"
+	"github.com/fluxcd/source-controller/internal/tls"
+)
+
+// releaseSourceReadyCondition contains the information required to summarize
+// a v1beta2.ReleaseSource Ready Condition.
+var releaseSourceReadyCondition = summarize.Conditions{
+	Target: meta.ReadyCondition,
+	Owned: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.RevisionHeldCondition,
+		sourcev1.PendingApprovalCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1.FetchFailedCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		sourcev1.ArtifactInStorageCondition,
+		sourcev1.SourceVerifiedCondition,
+		meta.ReadyCondition,
+		meta.ReconcilingCondition,
+		meta.StalledCondition,
+	},
+	Summarize: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1.FetchFailedCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		sourcev1.ArtifactInStorageCondition,
+		sourcev1.SourceVerifiedCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+	NegativePolarity: []string{
+		sourcev1.DependencyNotReadyCondition,
+		sourcev1.StorageOperationFailedCondition,
+		sourcev1.FetchFailedCondition,
+		sourcev1.ArtifactOutdatedCondition,
+		meta.StalledCondition,
+		meta.ReconcilingCondition,
+	},
+}
+
+// releaseSourceFailConditions contains the conditions that represent a
+// failure.
+var releaseSourceFailConditions = []string{
+	sourcev1.FetchFailedCondition,
+	sourcev1.StorageOperationFailedCondition,
+}
+
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=releasesources,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=releasesources/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=releasesources/finalizers,verbs=get;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// ReleaseSourceReconciler reconciles a v1beta2.ReleaseSource object.
+type ReleaseSourceReconciler struct {
+	client.Client
+	kuberecorder.EventRecorder
+	helper.Metrics
+
+	Storage        *Storage
+	ControllerName string
+
+	// Impersonator impersonates the ServiceAccount of the object to read its
+	// Secrets in multi-tenant lockdown mode. It is disabled when nil.
+	Impersonator *impersonation.Impersonator
+
+	// MaxAssetSize is the max allowed size in bytes of a downloaded asset.
+	// release.DefaultMaxDownloadSize is used when it is not set.
+	MaxAssetSize int64
+
+	requeueDependency time.Duration
+
+	patchOptions []patch.Option
+}
+
+type ReleaseSourceReconcilerOptions struct {
+	DependencyRequeueInterval time.Duration
+	RateLimiter               ratelimiter.RateLimiter
+}
+
+// releaseSourceFetch holds the observations about the release fetched for a
+// v1beta2.ReleaseSource.
+type releaseSourceFetch struct {
+	// Revision is the tag of the release and the digest of the assets, in
+	// the format '<tag>@<digest>'.
+	Revision string
+	// Assets are the names of the fetched assets.
+	Assets []string
+}
+
+// releaseSourceReconcileFunc is the function type for all the
+// v1beta2.ReleaseSource (sub)reconcile functions. The type implementations
+// are grouped and executed serially to perform the complete reconcile of the
+// object.
+type releaseSourceReconcileFunc func(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.ReleaseSource, fetch *releaseSourceFetch, dir string) (sreconcile.Result, error)
+
+func (r *ReleaseSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return r.SetupWithManagerAndOptions(mgr, ReleaseSourceReconcilerOptions{})
+}
+
+func (r *ReleaseSourceReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts ReleaseSourceReconcilerOptions) error {
+	r.patchOptions = getPatchOptions(releaseSourceReadyCondition.Owned, r.ControllerName)
+	r.requeueDependency = opts.DependencyRequeueInterval
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&sourcev1beta2.ReleaseSource{}).
+		WithEventFilter(predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{},
+			intpredicates.ApprovedRevisionPredicate{})).
+		WithOptions(controller.Options{
+			RateLimiter: opts.RateLimiter,
+		}).
+		Complete(r)
+}
+
+func (r *ReleaseSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
+	start := time.Now()
+	log := ctrl.LoggerFrom(ctx)
+
+	// Fetch the ReleaseSource
+	obj := &sourcev1beta2.ReleaseSource{}
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Initialize the patch helper with the current version of the object.
+	serialPatcher := patch.NewSerialPatcher(obj, r.Client)
+
+	// recResult stores the abstracted reconcile result.
+	var recResult sreconcile.Result
+
+	// Always attempt to patch the object and status after each reconciliation
+	// NOTE: The final runtime result and error are set in this block.
+	defer func() {
+		summarizeHelper := summarize.NewHelper(r.EventRecorder, serialPatcher)
+		summarizeOpts := []summarize.Option{
+			summarize.WithConditions(releaseSourceReadyCondition),
+			summarize.WithBiPolarityConditionTypes(sourcev1.SourceVerifiedCondition),
+			summarize.WithReconcileResult(recResult),
+			summarize.WithReconcileError(retErr),
+			summarize.WithIgnoreNotFound(),
+			summarize.WithProcessors(
+				summarize.ErrorActionHandler,
+				summarize.RecordReconcileReq,
+			),
+			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
+				RequeueAfter: holdRequeueAfter(obj, obj.Spec.Schedule,
+					jitter.JitteredIntervalDuration(obj.GetRequeueAfter()), time.Now()),
+			}),
+			summarize.WithPatchFieldOwner(r.ControllerName),
+		}
+		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
+
+		// Always record suspend, readiness and duration metrics.
+		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
+		r.Metrics.RecordReadiness(ctx, obj)
+		r.Metrics.RecordDuration(ctx, obj, start)
+	}()
+
+	// Examine if the object is under deletion.
+	if !obj.ObjectMeta.DeletionTimestamp.IsZero() {
+		recResult, retErr = r.reconcileDelete(ctx, obj)
+		return
+	}
+
+	// Add finalizer first if not exist to avoid the race condition between init
+	// and delete.
+	// Note: Finalizers in general can only be added when the deletionTimestamp
+	// is not set.
+	if !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
+		controllerutil.AddFinalizer(obj, sourcev1.SourceFinalizer)
+		recResult = sreconcile.ResultRequeue
+		return
+	}
+
+	// Return if the object is suspended.
+	if obj.Spec.Suspend {
+		log.Info("reconciliation is suspended for this object")
+		recResult, retErr = sreconcile.ResultEmpty, nil
+		return
+	}
+
+	// Wait for the dependencies of the object to be ready.
+	if err := checkSourceDependencies(ctx, r.Client, obj, obj.Spec.DependsOn, r.requeueDependency); err != nil {
+		recResult, retErr = sreconcile.ResultEmpty, err
+		return
+	}
+
+	// Reconcile actual object
+	reconcilers := []releaseSourceReconcileFunc{
+		r.reconcileStorage,
+		r.reconcileSource,
+		r.reconcileArtifact,
+	}
+	recResult, retErr = r.reconcile(ctx, serialPatcher, obj, reconcilers)
+	return
+}
+
+// reconcile iterates through the releaseSourceReconcileFunc tasks for the
+// object. It returns early on the first call that returns
+// reconcile.ResultRequeue, or produces an error.
+func (r *ReleaseSourceReconciler) reconcile(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.ReleaseSource, reconcilers []releaseSourceReconcileFunc) (sreconcile.Result, error) {
+	oldObj := obj.DeepCopy()
+
+	rreconcile.ProgressiveStatus(false, obj, meta.ProgressingReason, "reconciliation in progress")
+
+	var recAtVal string
+	if v, ok := meta.ReconcileAnnotationValue(obj.GetAnnotations()); ok {
+		recAtVal = v
+	}
+
+	// Persist reconciling if generation differs or reconciliation is requested.
+	switch {
+	case obj.Generation != obj.Status.ObservedGeneration:
+		rreconcile.ProgressiveStatus(false, obj, meta.ProgressingReason,
+			"processing object: new generation %d -> %d", obj.Status.ObservedGeneration, obj.Generation)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	case recAtVal != obj.Status.GetLastHandledReconcileRequest():
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	}
+
+	// Create temp working dir
+	tmpDir, err := os.MkdirTemp("", fmt.Sprintf("%s-%s-%s-", obj.Kind, obj.Namespace, obj.Name))
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to create temporary working directory: %w", err),
+			sourcev1.DirCreationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	defer func() {
+		if err = os.RemoveAll(tmpDir); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to remove temporary working directory")
+		}
+	}()
+	conditions.Delete(obj, sourcev1.StorageOperationFailedCondition)
+
+	// Run the sub-reconcilers and build the result of reconciliation.
+	var (
+		res    sreconcile.Result
+		resErr error
+		fetch  = &releaseSourceFetch{}
+	)
+
+	for _, rec := range reconcilers {
+		recResult, err := rec(ctx, sp, obj, fetch, tmpDir)
+		// Exit immediately on ResultRequeue.
+		if recResult == sreconcile.ResultRequeue {
+			return sreconcile.ResultRequeue, nil
+		}
+		// If an error is received, prioritize the returned results because an
+		// error also means immediate requeue.
+		if err != nil {
+			resErr = err
+			res = recResult
+			break
+		}
+		// Prioritize requeue request in the result.
+		res = sreconcile.LowestRequeuingResult(res, recResult)
+	}
+
+	r.notify(ctx, oldObj, obj, res, resErr)
+
+	return res, resErr
+}
+
+// notify emits notification related to the reconciliation.
+func (r *ReleaseSourceReconciler) notify(ctx context.Context, oldObj, newObj *sourcev1beta2.ReleaseSource, res sreconcile.Result, resErr error) {
+	// Notify successful reconciliation for new artifact and recovery from any
+	// failure.
+	if resErr == nil && res == sreconcile.ResultSuccess && newObj.Status.Artifact != nil {
+		annotations := map[string]string{
+			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaRevisionKey): newObj.Status.Artifact.Revision,
+			fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, eventv1.MetaDigestKey):   newObj.Status.Artifact.Digest,
+		}
+
+		message := fmt.Sprintf("stored artifact with %d asset(s) for revision '%s'", len(newObj.Status.ObservedAssets), newObj.Status.Artifact.Revision)
+
+		// Notify on new artifact and failure recovery.
+		if !oldObj.GetArtifact().HasDigest(newObj.GetArtifact().Digest) {
+			r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
+				"NewArtifact", message)
+			ctrl.LoggerFrom(ctx).Info(message)
+		} else {
+			if sreconcile.FailureRecovery(oldObj, newObj, releaseSourceFailConditions) {
+				r.AnnotatedEventf(newObj, annotations, corev1.EventTypeNormal,
+					meta.SucceededReason, message)
+				ctrl.LoggerFrom(ctx).Info(message)
+			}
+		}
+	}
+}
+
+// reconcileStorage ensures the current state of the storage matches the
+// desired and previously observed state.
+//
+// The garbage collection is executed based on the flag configured settings and
+// may remove files that are beyond their TTL or the maximum number of files
+// to survive a collection cycle.
+// If the Artifact in the Status of the object disappeared from the Storage,
+// it is removed from the object.
+// If the object does not have an Artifact in its Status, a Reconciling
+// condition is added.
+// The hostname of any URL in the Status of the object are updated, to ensure
+// they match the Storage server hostname of current runtime.
+func (r *ReleaseSourceReconciler) reconcileStorage(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.ReleaseSource, _ *releaseSourceFetch, _ string) (sreconcile.Result, error) {
+	// Garbage collect previous advertised artifact(s) from storage
+	_ = r.garbageCollect(ctx, obj)
+
+	var artifactMissing bool
+	if artifact := obj.GetArtifact(); artifact != nil {
+		// Determine if the advertised artifact is still in storage
+		if !r.Storage.ArtifactExist(*artifact) {
+			artifactMissing = true
+		}
+
+		// If the artifact is in storage, verify if the advertised digest still
+		// matches the actual artifact
+		if !artifactMissing {
+			if err := r.Storage.VerifyArtifact(*artifact); err != nil {
+				r.Eventf(obj, corev1.EventTypeWarning, "ArtifactVerificationFailed", "failed to verify integrity of artifact: %s", err.Error())
+
+				if err = r.Storage.Remove(*artifact); err != nil {
+					return sreconcile.ResultEmpty, fmt.Errorf("failed to remove artifact after digest mismatch: %w", err)
+				}
+
+				artifactMissing = true
+			}
+		}
+
+		// If the artifact is missing, remove it from the object
+		if artifactMissing {
+			obj.Status.Artifact = nil
+			obj.Status.URL = ""
+		}
+	}
+
+	// Record that we do not have an artifact
+	if obj.GetArtifact() == nil {
+		msg := "building artifact"
+		if artifactMissing {
+			msg += ": disappeared from storage"
+		}
+		rreconcile.ProgressiveStatus(true, obj, meta.ProgressingReason, msg)
+		conditions.Delete(obj, sourcev1.ArtifactInStorageCondition)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// Always update URLs to ensure hostname is up-to-date
+	r.Storage.SetArtifactURL(obj.GetArtifact())
+	obj.Status.URL = r.Storage.SetHostname(obj.Status.URL)
+
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileSource selects the latest release of the repository within the
+// semver range of the object, downloads its matching assets into dir, and
+// records its observations on the given releaseSourceFetch.
+//
+// When the object has an Artifact for the selected release produced for the
+// current generation, the assets are not downloaded again, as the assets of
+// a release are assumed to be immutable.
+// When Verify is defined, the assets are verified against the checksums (and
+// signature) shipped with the release. The result is recorded as
+// v1.SourceVerifiedCondition on the object.
+// If the revision of the downloaded assets differs from the current Artifact,
+// it records v1.ArtifactOutdatedCondition=True on the object.
+func (r *ReleaseSourceReconciler) reconcileSource(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.ReleaseSource, fetch *releaseSourceFetch, dir string) (sreconcile.Result, error) {
+	secrets, err := secretClient(obj, r.Client, r.Impersonator, obj.Spec.ServiceAccountName)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
+	c, err := r.releaseClient(ctx, secrets, obj)
+	if err != nil {
+		e := serror.NewGeneric(err, sourcev1.AuthenticationFailedReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+		// Return error as the world as observed may change
+		return sreconcile.ResultEmpty, e
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, obj.Spec.Timeout.Duration)
+	defer cancel()
+
+	releases, err := c.ListReleases(ctxTimeout)
+	if err != nil {
+		e := serror.NewGeneric(serror.SanitizeError(err), sourcev1beta2.ReleaseOperationFailedReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	rel, err := release.LatestBySemver(releases, obj.Spec.SemVer, obj.Spec.SemverFilter, obj.Spec.Prereleases)
+	if err != nil {
+		e := serror.NewGeneric(err, sourcev1beta2.ReleaseOperationFailedReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	// Skip the download if the Artifact was produced from the release for
+	// the current spec.
+	if artifact := obj.GetArtifact(); artifact != nil && obj.Status.ObservedGeneration == obj.Generation &&
+		releaseSourceTag(artifact.Revision) == rel.Tag {
+		fetch.Revision = artifact.Revision
+		fetch.Assets = obj.Status.ObservedAssets
+		conditions.Delete(obj, sourcev1.FetchFailedCondition)
+		return sreconcile.ResultSuccess, nil
+	}
+
+	assets, err := rel.MatchAssets(obj.Spec.Assets)
+	if err != nil {
+		e := serror.NewGeneric(err, sourcev1beta2.ReleaseOperationFailedReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	fetch.Assets = make([]string, 0, len(assets))
+	for _, a := range assets {
+		if err := downloadReleaseAsset(ctxTimeout, c, a, filepath.Join(dir, a.Name)); err != nil {
+			e := serror.NewGeneric(serror.SanitizeError(err), sourcev1beta2.ReleaseOperationFailedReason)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		fetch.Assets = append(fetch.Assets, a.Name)
+	}
+
+	// Verify the assets, if configured.
+	if obj.Spec.Verify == nil {
+		conditions.Delete(obj, sourcev1.SourceVerifiedCondition)
+	} else {
+		msg, e := r.verifyAssets(ctxTimeout, secrets, c, obj, rel, fetch.Assets, dir)
+		if e != nil {
+			conditions.MarkFalse(obj, sourcev1.SourceVerifiedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		conditions.MarkTrue(obj, sourcev1.SourceVerifiedCondition, meta.SucceededReason, msg)
+	}
+
+	d, err := releaseSourceDigest(dir, fetch.Assets)
+	if err != nil {
+		e := serror.NewGeneric(err, sourcev1.ReadOperationFailedReason)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	fetch.Revision = fmt.Sprintf("%s@%s", rel.Tag, d)
+
+	// Mark observations about the revision on the object
+	if !obj.GetArtifact().HasRevision(fetch.Revision) {
+		message := fmt.Sprintf("new release revision '%s'", fetch.Revision)
+		if obj.GetArtifact() != nil {
+			conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", message)
+		}
+		rreconcile.ProgressiveStatus(true, obj, meta.ProgressingReason, "building artifact: %s", message)
+		if err := sp.Patch(ctx, obj, r.patchOptions...); err != nil {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, sourcev1.PatchOperationFailedReason)
+		}
+	}
+
+	conditions.Delete(obj, sourcev1.FetchFailedCondition)
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileArtifact archives a new Artifact to the Storage, if the current
+// (Status) data on the object does not match the given.
+//
+// The inspection of the given data to the object is differed, ensuring any
+// stale observations like v1beta2.ArtifactOutdatedCondition are removed.
+// If the given Artifact does not differ from the object's current, it returns
+// early.
+// On a successful archive, the Artifact in the Status of the object is set,
+// and the symlink in the Storage is updated to its path.
+func (r *ReleaseSourceReconciler) reconcileArtifact(ctx context.Context, sp *patch.SerialPatcher, obj *sourcev1beta2.ReleaseSource, fetch *releaseSourceFetch, dir string) (sreconcile.Result, error) {
+	revision := digest.Digest(strings.TrimPrefix(fetch.Revision, releaseSourceTag(fetch.Revision)+"@"))
+
+	// Create artifact
+	artifact := r.Storage.NewArtifactFor(obj.Kind, obj, fetch.Revision, fmt.Sprintf("%s.tar.gz", revision.Encoded()))
+
+	// Set the ArtifactInStorageCondition if there's no drift.
+	defer func() {
+		if obj.GetArtifact().HasRevision(artifact.Revision) {
+			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+			conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+			obj.Status.PendingArtifact = nil
+			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, meta.SucceededReason,
+				"stored artifact for revision '%s'", artifact.Revision)
+		}
+	}()
+
+	// Hold the new revision while the schedule is closed
+	if held, err := holdRevision(obj, obj.Spec.Schedule, obj.GetArtifact(), artifact.Revision, time.Now()); err != nil {
+		return sreconcile.ResultEmpty, err
+	} else if held {
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// The artifact is up-to-date
+	if obj.GetArtifact().HasRevision(artifact.Revision) {
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason, "artifact up-to-date with remote revision: '%s'", artifact.Revision)
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// Ensure artifact directory exists and acquire lock
+	if err := r.Storage.MkdirAll(artifact); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to create artifact directory: %w", err),
+			sourcev1.DirCreationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	unlock, err := r.Storage.Lock(artifact)
+	if err != nil {
+		return sreconcile.ResultEmpty, serror.NewGeneric(
+			fmt.Errorf("failed to acquire lock for artifact: %w", err),
+			meta.FailedReason,
+		)
+	}
+	defer unlock()
+
+	// Archive directory to storage
+	if err := r.Storage.Archive(&artifact, dir, nil); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("unable to archive artifact to storage: %s", err),
+			sourcev1.ArchiveOperationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+
+	// Keep serving the current artifact until the new revision is approved
+	if awaitArtifactApproval(obj, obj.Spec.Approval, r.Storage, obj.GetArtifact(), &artifact) {
+		obj.Status.PendingArtifact = artifact.DeepCopy()
+		return sreconcile.ResultSuccess, nil
+	}
+	obj.Status.PendingArtifact = nil
+
+	// Record it on the object
+	obj.Status.Artifact = artifact.DeepCopy()
+	obj.Status.ObservedAssets = fetch.Assets
+
+	// Update symlink on a "best effort" basis
+	url, err := r.Storage.Symlink(artifact, "latest.tar.gz")
+	if err != nil {
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.SymlinkUpdateFailedReason,
+			"failed to update status URL symlink: %s", err)
+	}
+	if url != "" {
+		obj.Status.URL = url
+	}
+	conditions.Delete(obj, sourcev1.StorageOperationFailedCondition)
+	return sreconcile.ResultSuccess, nil
+}
+
+// reconcileDelete handles the deletion of the object.
+// It first garbage collects all Artifacts for the object from the Storage.
+// Removing the finalizer from the object if successful.
+func (r *ReleaseSourceReconciler) reconcileDelete(ctx context.Context, obj *sourcev1beta2.ReleaseSource) (sreconcile.Result, error) {
+	// Garbage collect the resource's artifacts
+	if err := r.garbageCollect(ctx, obj); err != nil {
+		// Return the error so we retry the failed garbage collection
+		return sreconcile.ResultEmpty, err
+	}
+
+	// Remove our finalizer from the list
+	controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+
+	// Stop reconciliation as the object is being deleted
+	return sreconcile.ResultEmpty, nil
+}
+
+// garbageCollect performs a garbage collection for the given object.
+//
+// It removes all but the current Artifact from the Storage, unless the
+// deletion timestamp on the object is set. Which will result in the
+// removal of all Artifacts for the objects.
+func (r *ReleaseSourceReconciler) garbageCollect(ctx context.Context, obj *sourcev1beta2.ReleaseSource) error {
+	if !obj.DeletionTimestamp.IsZero() {
+		if deleted, err := r.Storage.RemoveAll(r.Storage.NewArtifactFor(obj.Kind, obj.GetObjectMeta(), "", "*")); err != nil {
+			return serror.NewGeneric(
+				fmt.Errorf("garbage collection for deleted resource failed: %s", err),
+				"GarbageCollectionFailed",
+			)
+		} else if deleted != "" {
+			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "GarbageCollectionSucceeded",
+				"garbage collected artifacts for deleted resource")
+		}
+		obj.Status.Artifact = nil
+		obj.Status.PendingArtifact = nil
+		return nil
+	}
+	if obj.GetArtifact() != nil {
+		var keep []sourcev1.Artifact
+		if pending := obj.Status.PendingArtifact; pending != nil {
+			keep = append(keep, *pending)
+		}
+		delFiles, err := r.Storage.GarbageCollect(ctx, *obj.GetArtifact(), time.Second*5, keep...)
+		if err != nil {
+			return serror.NewGeneric(
+				fmt.Errorf("garbage collection of artifacts failed: %w", err),
+				"GarbageCollectionFailed",
+			)
+		}
+		if len(delFiles) > 0 {
+			r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "GarbageCollectionSucceeded",
+				fmt.Sprintf("garbage collected %d artifacts", len(delFiles)))
+			return nil
+		}
+	}
+	return nil
+}
+
+// releaseClient returns a release.Client configured with the API URL, token
+// and TLS configuration of the object.
+func (r *ReleaseSourceReconciler) releaseClient(ctx context.Context, secrets client.Reader, obj *sourcev1beta2.ReleaseSource) (*release.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	opts := []release.Option{release.WithMaxDownloadSize(r.MaxAssetSize)}
+
+	secret, err := r.getSecret(ctx, secrets, obj.Spec.SecretRef, obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	if secret != nil {
+		token := string(secret.Data["token"])
+		if token == "" {
+			return nil, fmt.Errorf("invalid '%s' secret data: required field 'token'", secret.Name)
+		}
+		opts = append(opts, release.WithToken(token))
+	}
+
+	certSecret, err := r.getSecret(ctx, secrets, obj.Spec.CertSecretRef, obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	if certSecret != nil {
+		tlsConfig, _, err := tls.KubeTLSClientConfigFromSecret(*certSecret, obj.Spec.APIURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create TLS config: %w", err)
+		}
+		if tlsConfig == nil {
+			return nil, fmt.Errorf("certificate secret does not contain any TLS configuration")
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	opts = append(opts, release.WithHTTPClient(&http.Client{Transport: transport}))
+	return release.NewClient(obj.Spec.Provider, obj.Spec.APIURL, obj.Spec.Repository, opts...)
+}
+
+// verifyAssets verifies the downloaded assets in dir against the checksum
+// asset of the release. When a SecretRef is configured, the signature of the
+// checksum asset is verified first. It returns a message describing the
+// verification on success.
+func (r *ReleaseSourceReconciler) verifyAssets(ctx context.Context, secrets client.Reader, c *release.Client, obj *sourcev1beta2.ReleaseSource,
+	rel *release.Release, assets []string, dir string) (string, *serror.Generic) {
+	verify := obj.Spec.Verify
+
+	checksumAsset, ok := rel.Asset(verify.ChecksumAsset)
+	if !ok {
+		return "", serror.NewGeneric(
+			fmt.Errorf("release '%s' has no checksum asset '%s'", rel.Tag, verify.ChecksumAsset),
+			sourcev1beta2.ChecksumVerificationFailedReason,
+		)
+	}
+	var checksums bytes.Buffer
+	if err := c.Download(ctx, checksumAsset, &checksums); err != nil {
+		return "", serror.NewGeneric(serror.SanitizeError(err), sourcev1beta2.ChecksumVerificationFailedReason)
+	}
+
+	var signed bool
+	if verify.SecretRef != nil {
+		secret, err := r.getSecret(ctx, secrets, verify.SecretRef, obj.GetNamespace())
+		if err != nil {
+			return "", serror.NewGeneric(err, sourcev1.VerificationError)
+		}
+		signatureAsset, ok := rel.Asset(verify.GetSignatureAsset())
+		if !ok {
+			return "", serror.NewGeneric(
+				fmt.Errorf("release '%s' has no signature asset '%s'", rel.Tag, verify.GetSignatureAsset()),
+				sourcev1.VerificationError,
+			)
+		}
+		var sig bytes.Buffer
+		if err := c.Download(ctx, signatureAsset, &sig); err != nil {
+			return "", serror.NewGeneric(serror.SanitizeError(err), sourcev1.VerificationError)
+		}
+		if err := verifyBlobSignature(secret, checksums.Bytes(), sig.Bytes()); err != nil {
+			return "", serror.NewGeneric(
+				fmt.Errorf("failed to verify signature of '%s': %w", checksumAsset.Name, err),
+				sourcev1.VerificationError,
+			)
+		}
+		signed = true
+	}
+
+	var verified int
+	for _, name := range assets {
+		// The checksum and signature assets can not contain their own
+		// checksum, they are verified by the signature.
+		if name == verify.ChecksumAsset || name == verify.GetSignatureAsset() {
+			continue
+		}
+		expected, err := checksumFromFile(bytes.NewReader(checksums.Bytes()), name)
+		if err != nil {
+			return "", serror.NewGeneric(err, sourcev1beta2.ChecksumVerificationFailedReason)
+		}
+		got, err := digestFile(expected.Algorithm(), filepath.Join(dir, name))
+		if err != nil {
+			return "", serror.NewGeneric(err, sourcev1.ReadOperationFailedReason)
+		}
+		if got.Digest() != expected {
+			return "", serror.NewGeneric(
+				fmt.Errorf("checksum mismatch for '%s': expected '%s', got '%s'", name, expected, got.Digest()),
+				sourcev1beta2.ChecksumVerificationFailedReason,
+			)
+		}
+		verified++
+	}
+
+	msg := fmt.Sprintf("verified checksums of %d asset(s) of release '%s'", verified, rel.Tag)
+	if signed {
+		msg = fmt.Sprintf("verified signature and checksums of %d asset(s) of release '%s'", verified, rel.Tag)
+	}
+	return msg, nil
+}
+
+// verifyBlobSignature verifies the Cosign signature of the given data with
+// the public keys in the given Secret. The signature may be base64 encoded,
+// as produced by 'cosign sign-blob'.
+func verifyBlobSignature(secret *corev1.Secret, data, sig []byte) error {
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err == nil {
+		sig = decoded
+	}
+
+	var keys int
+	for k, pem := range secret.Data {
+		// search for public keys in the secret
+		if !strings.HasSuffix(k, ".pub") {
+			continue
+		}
+		keys++
+		pubKey, err := cryptoutils.UnmarshalPEMToPublicKey(pem)
+		if err != nil {
+			return fmt.Errorf("failed to parse public key '%s': %w", k, err)
+		}
+		verifier, err := signature.LoadVerifier(pubKey, crypto.SHA256)
+		if err != nil {
+			return fmt.Errorf("failed to load public key '%s': %w", k, err)
+		}
+		if err = verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data)); err == nil {
+			return nil
+		}
+	}
+	if keys == 0 {
+		return fmt.Errorf("no public keys found in secret '%s'", secret.Name)
+	}
+	return errors.New("no matching signatures were found")
+}
+
+// downloadReleaseAsset downloads the given asset to the file at path p.
+func downloadReleaseAsset(ctx context.Context, c *release.Client, a release.Asset, p string) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	err = c.Download(ctx, a, f)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+// releaseSourceDigest returns the digest of the names and contents of the
+// assets with the given names in dir.
+func releaseSourceDigest(dir string, assets []string) (digest.Digest, error) {
+	names := append([]string(nil), assets...)
+	sort.Strings(names)
+
+	digester := intdigest.Canonical.Digester()
+	for _, name := range names {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return "", err
+		}
+		fi, err := f.Stat()
+		if err == nil {
+			_, _ = fmt.Fprintf(digester.Hash(), "%s\x00%d\x00", name, fi.Size())
+			_, err = io.Copy(digester.Hash(), f)
+		}
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to calculate digest of '%s': %w", name, err)
+		}
+	}
+	return digester.Digest(), nil
+}
+
+// releaseSourceTag returns the release tag of the given revision in the
+// format '<tag>@<digest>'.
+func releaseSourceTag(revision string) string {
+	if i := strings.LastIndex(revision, "@"); i >= 0 {
+		return revision[:i]
+	}
+	return revision
+}
+
+// getSecret attempts to fetch a Secret reference if specified. It returns any client error.
+func (r *ReleaseSourceReconciler) getSecret(ctx context.Context, c client.Reader, secretRef *meta.LocalObjectReference,
+	namespace string) (*corev1.Secret, error) {
+	if secretRef == nil {
+		return nil, nil
+	}
+	secretName := types.NamespacedName{
+		Namespace: namespace,
+		Name:      secretRef.Name,
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, secretName, secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret '%s': %w", secretName.String(), err)
+	}
+	return secret, nil
+}
+
+// eventLogf records events, and logs at the same time.
+//
+// This log is different from the debug log in the EventRecorder, in the sense
+// that this is a simple log. While the debug log contains complete details
+// about the event.
+func (r *ReleaseSourceReconciler) eventLogf(ctx context.Context, obj runtime.Object, eventType string, reason string, messageFmt string, args ...interface{}) {
+	msg := fmt.Sprintf(messageFmt, args...)
+	// Log and emit event.
+	if eventType == corev1.EventTypeWarning {
+		ctrl.LoggerFrom(ctx).Error(errors.New(reason), msg)
+	} else {
+		ctrl.LoggerFrom(ctx).Info(msg)
+	}
+	r.Eventf(obj, eventType, reason, msg)
+}
@@ -0,0 +1,480 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/fluxcd/pkg/runtime/patch"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
+)
+
+func TestReleaseSourceReconciler_reconcileSource(t *testing.T) {
+	install := []byte("apiVersion: v1\nkind: Namespace\n")
+	checksums := []byte(fmt.Sprintf("%x  install.yaml\n", sha256.Sum256(install)))
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, err := cryptoutils.MarshalPublicKeyToPEM(privKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(checksums)
+	sig, err := ecdsa.SignASN1(rand.Reader, privKey, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assets := map[string][]byte{
+		"install.yaml":      install,
+		"checksums.txt":     checksums,
+		"checksums.txt.sig": []byte(base64.StdEncoding.EncodeToString(sig)),
+		"bad-checksums.txt": []byte(fmt.Sprintf("%x  install.yaml\n", sha256.Sum256([]byte("other")))),
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/owner/repo/releases":
+			asset := func(name string) map[string]string {
+				return map[string]string{"name": name, "url": server.URL + "/assets/" + name}
+			}
+			_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"tag_name": "v1.1.0-rc.1", "prerelease": true, "assets": []interface{}{asset("install.yaml")}},
+				{"tag_name": "v1.0.0", "assets": []interface{}{
+					asset("install.yaml"), asset("checksums.txt"), asset("checksums.txt.sig"), asset("bad-checksums.txt"),
+				}},
+			})
+		case strings.HasPrefix(r.URL.Path, "/assets/"):
+			data, ok := assets[strings.TrimPrefix(r.URL.Path, "/assets/")]
+			if !ok || r.Header.Get("Accept") != "application/octet-stream" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	installRevision := func() string {
+		d, err := releaseSourceDigest(writeReleaseAssets(t, map[string][]byte{"install.yaml": install}), []string{"install.yaml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "v1.0.0@" + d.String()
+	}()
+
+	tests := []struct {
+		name             string
+		secret           *corev1.Secret
+		beforeFunc       func(obj *sourcev1beta2.ReleaseSource)
+		want             sreconcile.Result
+		wantErr          bool
+		wantRevision     string
+		wantFiles        []string
+		assertConditions []metav1.Condition
+	}{
+		{
+			name:         "downloads assets of latest release",
+			want:         sreconcile.ResultSuccess,
+			wantRevision: installRevision,
+			wantFiles:    []string{"install.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new release revision '%s'", installRevision),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new release revision '%s'", installRevision),
+			},
+		},
+		{
+			name: "includes pre-releases",
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Spec.Prereleases = true
+				obj.Spec.SemVer = ">=1.0.0-0"
+			},
+			want:      sreconcile.ResultSuccess,
+			wantFiles: []string{"install.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new release revision 'v1.1.0-rc.1@sha256:"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new release revision 'v1.1.0-rc.1@sha256:"),
+			},
+		},
+		{
+			name: "skips download for up-to-date artifact",
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "v1.0.0@sha256:existing"}
+				obj.Status.ObservedAssets = []string{"install.yaml"}
+				obj.Status.ObservedGeneration = obj.Generation
+			},
+			want:         sreconcile.ResultSuccess,
+			wantRevision: "v1.0.0@sha256:existing",
+		},
+		{
+			name: "new generation downloads assets",
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: "v1.0.0@sha256:existing"}
+				obj.Status.ObservedGeneration = obj.Generation - 1
+			},
+			want:         sreconcile.ResultSuccess,
+			wantRevision: installRevision,
+			wantFiles:    []string{"install.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new release revision '%s'", installRevision),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new release revision '%s'", installRevision),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new release revision '%s'", installRevision),
+			},
+		},
+		{
+			name: "verifies checksums",
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Spec.Assets = []string{"install.yaml", "checksums.txt"}
+				obj.Spec.Verify = &sourcev1beta2.ReleaseSourceVerification{ChecksumAsset: "checksums.txt"}
+			},
+			want:      sreconcile.ResultSuccess,
+			wantFiles: []string{"checksums.txt", "install.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new release revision 'v1.0.0@sha256:"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new release revision 'v1.0.0@sha256:"),
+				*conditions.TrueCondition(sourcev1.SourceVerifiedCondition, meta.SucceededReason, "verified checksums of 1 asset(s) of release 'v1.0.0'"),
+			},
+		},
+		{
+			name: "verifies signature and checksums",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "cosign-keys", Namespace: "default"},
+				Data:       map[string][]byte{"cosign.pub": pubKey},
+			},
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Spec.Verify = &sourcev1beta2.ReleaseSourceVerification{
+					ChecksumAsset: "checksums.txt",
+					SecretRef:     &meta.LocalObjectReference{Name: "cosign-keys"},
+				}
+			},
+			want:         sreconcile.ResultSuccess,
+			wantRevision: installRevision,
+			wantFiles:    []string{"install.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new release revision '%s'", installRevision),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new release revision '%s'", installRevision),
+				*conditions.TrueCondition(sourcev1.SourceVerifiedCondition, meta.SucceededReason, "verified signature and checksums of 1 asset(s) of release 'v1.0.0'"),
+			},
+		},
+		{
+			name: "checksum mismatch",
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Spec.Verify = &sourcev1beta2.ReleaseSourceVerification{ChecksumAsset: "bad-checksums.txt"}
+			},
+			want:      sreconcile.ResultEmpty,
+			wantErr:   true,
+			wantFiles: []string{"install.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1beta2.ChecksumVerificationFailedReason, "checksum mismatch for 'install.yaml'"),
+			},
+		},
+		{
+			name: "signature mismatch",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "cosign-keys", Namespace: "default"},
+				Data:       map[string][]byte{"cosign.pub": pubKey},
+			},
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Spec.Verify = &sourcev1beta2.ReleaseSourceVerification{
+					ChecksumAsset:  "bad-checksums.txt",
+					SignatureAsset: "checksums.txt.sig",
+					SecretRef:      &meta.LocalObjectReference{Name: "cosign-keys"},
+				}
+			},
+			want:      sreconcile.ResultEmpty,
+			wantErr:   true,
+			wantFiles: []string{"install.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1.VerificationError, "failed to verify signature of 'bad-checksums.txt': no matching signatures were found"),
+			},
+		},
+		{
+			name: "missing checksum asset",
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Spec.Verify = &sourcev1beta2.ReleaseSourceVerification{ChecksumAsset: "SHA256SUMS"}
+			},
+			want:      sreconcile.ResultEmpty,
+			wantErr:   true,
+			wantFiles: []string{"install.yaml"},
+			assertConditions: []metav1.Condition{
+				*conditions.FalseCondition(sourcev1.SourceVerifiedCondition, sourcev1beta2.ChecksumVerificationFailedReason, "release 'v1.0.0' has no checksum asset 'SHA256SUMS'"),
+			},
+		},
+		{
+			name: "no asset matches pattern",
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Spec.Assets = []string{"*.zip"}
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1beta2.ReleaseOperationFailedReason, "no asset of release 'v1.0.0' matches '*.zip'"),
+			},
+		},
+		{
+			name: "no release matches semver",
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Spec.SemVer = ">=2.0.0"
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1beta2.ReleaseOperationFailedReason, "no release found matching semver '>=2.0.0'"),
+			},
+		},
+		{
+			name: "repository not found",
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Spec.Repository = "owner/missing"
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1beta2.ReleaseOperationFailedReason, "404 Not Found"),
+			},
+		},
+		{
+			name: "missing secret",
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Spec.SecretRef = &meta.LocalObjectReference{Name: "token"}
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.AuthenticationFailedReason, "failed to get secret 'default/token'"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1beta2.ReleaseSource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test-releasesource",
+					Namespace:  "default",
+					Generation: 2,
+				},
+				Spec: sourcev1beta2.ReleaseSourceSpec{
+					Provider:   sourcev1beta2.GitHubReleaseProvider,
+					Repository: "owner/repo",
+					APIURL:     server.URL,
+					Assets:     []string{"install.yaml"},
+					Timeout:    &metav1.Duration{Duration: timeout},
+				},
+			}
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			objs := []client.Object{obj}
+			if tt.secret != nil {
+				objs = append(objs, tt.secret)
+			}
+			r := &ReleaseSourceReconciler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithObjects(objs...).
+					WithStatusSubresource(&sourcev1beta2.ReleaseSource{}).
+					Build(),
+				EventRecorder: record.NewFakeRecorder(32),
+				Storage:       testStorage,
+				patchOptions:  getPatchOptions(releaseSourceReadyCondition.Owned, "sc"),
+			}
+
+			dir := t.TempDir()
+			fetch := &releaseSourceFetch{}
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			got, err := r.reconcileSource(context.TODO(), sp, obj, fetch, dir)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			g.Expect(got).To(Equal(tt.want))
+			if tt.wantRevision != "" {
+				g.Expect(fetch.Revision).To(Equal(tt.wantRevision))
+			}
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+
+			var files []string
+			entries, err := os.ReadDir(dir)
+			g.Expect(err).ToNot(HaveOccurred())
+			for _, e := range entries {
+				files = append(files, e.Name())
+			}
+			g.Expect(files).To(Equal(tt.wantFiles))
+		})
+	}
+}
+
+func TestReleaseSourceReconciler_reconcileArtifact(t *testing.T) {
+	revision := "v1.0.0@" + digest.FromString("assets").String()
+
+	tests := []struct {
+		name             string
+		beforeFunc       func(obj *sourcev1beta2.ReleaseSource)
+		afterFunc        func(t *WithT, obj *sourcev1beta2.ReleaseSource)
+		want             sreconcile.Result
+		assertConditions []metav1.Condition
+	}{
+		{
+			name: "Archiving artifact to storage makes ArtifactInStorage=True",
+			afterFunc: func(t *WithT, obj *sourcev1beta2.ReleaseSource) {
+				t.Expect(obj.GetArtifact()).ToNot(BeNil())
+				t.Expect(obj.GetArtifact().Revision).To(Equal(revision))
+				t.Expect(obj.GetArtifact().Path).To(HaveSuffix(digest.FromString("assets").Encoded() + ".tar.gz"))
+				t.Expect(obj.Status.ObservedAssets).To(Equal([]string{"install.yaml"}))
+				t.Expect(obj.Status.URL).ToNot(BeEmpty())
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for revision '%s'", revision),
+			},
+		},
+		{
+			name: "Up-to-date artifact is not rebuilt",
+			beforeFunc: func(obj *sourcev1beta2.ReleaseSource) {
+				obj.Status.Artifact = &sourcev1.Artifact{Revision: revision}
+				conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", "foo")
+			},
+			afterFunc: func(t *WithT, obj *sourcev1beta2.ReleaseSource) {
+				t.Expect(obj.Status.URL).To(BeEmpty())
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact for revision '%s'", revision),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &ReleaseSourceReconciler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithStatusSubresource(&sourcev1beta2.ReleaseSource{}).
+					Build(),
+				EventRecorder: record.NewFakeRecorder(32),
+				Storage:       testStorage,
+				patchOptions:  getPatchOptions(releaseSourceReadyCondition.Owned, "sc"),
+			}
+
+			obj := &sourcev1beta2.ReleaseSource{
+				TypeMeta: metav1.TypeMeta{
+					Kind: sourcev1beta2.ReleaseSourceKind,
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-releasesource-",
+					Generation:   1,
+					Namespace:    "default",
+				},
+			}
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			dir := writeReleaseAssets(t, map[string][]byte{"install.yaml": []byte("kind: Namespace")})
+			fetch := &releaseSourceFetch{Revision: revision, Assets: []string{"install.yaml"}}
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			got, err := r.reconcileArtifact(context.TODO(), sp, obj, fetch, dir)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+
+			if tt.afterFunc != nil {
+				tt.afterFunc(g, obj)
+			}
+		})
+	}
+}
+
+func Test_releaseSourceDigest(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := writeReleaseAssets(t, map[string][]byte{
+		"a.yaml": []byte("a"),
+		"b.yaml": []byte("b"),
+	})
+
+	d, err := releaseSourceDigest(dir, []string{"b.yaml", "a.yaml"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(d.Validate()).To(Succeed())
+
+	// The order of the assets does not matter.
+	g.Expect(releaseSourceDigest(dir, []string{"a.yaml", "b.yaml"})).To(Equal(d))
+
+	// The digest changes with the selected assets.
+	g.Expect(releaseSourceDigest(dir, []string{"a.yaml"})).ToNot(Equal(d))
+
+	_, err = releaseSourceDigest(dir, []string{"missing.yaml"})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_releaseSourceTag(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(releaseSourceTag("v1.0.0@sha256:abc")).To(Equal("v1.0.0"))
+	g.Expect(releaseSourceTag("app@v1.0.0@sha256:abc")).To(Equal("app@v1.0.0"))
+	g.Expect(releaseSourceTag("v1.0.0")).To(Equal("v1.0.0"))
+}
+
+// writeReleaseAssets writes the given assets to a temporary directory, and
+// returns its path.
+func writeReleaseAssets(t *testing.T, assets map[string][]byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range assets {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+// approvalSetter is an object which can be approved with the
+// sourcev1.ApprovedRevisionAnnotation.
+type approvalSetter interface {
+	conditions.Setter
+	GetAnnotations() map[string]string
+}
+
+// awaitApproval returns true if the pending Artifact may not replace the
+// current Artifact, because the approval of new revisions is required and
+// the sourcev1.ApprovedRevisionAnnotation of the object does not carry the
+// revision or digest of the pending Artifact. The first Artifact of an
+// object never awaits approval.
+//
+// When the pending Artifact awaits approval, the PendingApproval condition
+// is set to True with the revision, digest and the summary returned by
+// diff, and the ArtifactOutdated condition is removed as the current
+// Artifact keeps being served. Otherwise, the PendingApproval condition is
+// removed.
+func awaitApproval(obj approvalSetter, approval *sourcev1.RevisionApproval, current, pending *sourcev1.Artifact,
+	diff func() string) bool {
+	if !approval.IsRequired() || current == nil || pending == nil || current.HasRevision(pending.Revision) {
+		conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+		return false
+	}
+
+	if v := obj.GetAnnotations()[sourcev1.ApprovedRevisionAnnotation]; v != "" &&
+		(pending.HasRevision(v) || (pending.Digest != "" && pending.HasDigest(v))) {
+		conditions.Delete(obj, sourcev1.PendingApprovalCondition)
+		return false
+	}
+
+	msg := fmt.Sprintf("new revision '%s' awaits approval", pending.Revision)
+	if pending.Digest != "" {
+		msg = fmt.Sprintf("new revision '%s' with digest '%s' awaits approval", pending.Revision, pending.Digest)
+	}
+	if summary := diff(); summary != "" {
+		msg += ": " + summary
+	}
+	conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+	conditions.MarkTrue(obj, sourcev1.PendingApprovalCondition, sourcev1.AwaitingApprovalReason, msg)
+	return true
+}
+
+// awaitArtifactApproval calls awaitApproval with a summary of the changes
+// between the files of the current and pending Artifact in storage.
+func awaitArtifactApproval(obj approvalSetter, approval *sourcev1.RevisionApproval, storage *Storage,
+	current, pending *sourcev1.Artifact) bool {
+	return awaitApproval(obj, approval, current, pending, func() string {
+		return artifactDiffSummary(storage, current, pending)
+	})
+}
+
+// artifactDiffSummary returns a summary of the files added, removed and
+// modified in the pending Artifact compared to the current Artifact. If
+// either of them is not a gzipped tarball, or can not be read, it falls
+// back to a summary of the change in size.
+func artifactDiffSummary(storage *Storage, current, pending *sourcev1.Artifact) string {
+	fallback := fmt.Sprintf("size changed from %d to %d bytes", artifactSize(current), artifactSize(pending))
+
+	oldFiles, err := tarballFileDigests(storage.LocalPath(*current))
+	if err != nil {
+		return fallback
+	}
+	newFiles, err := tarballFileDigests(storage.LocalPath(*pending))
+	if err != nil {
+		return fallback
+	}
+
+	var added, removed, modified int
+	for name, d := range newFiles {
+		oldDigest, ok := oldFiles[name]
+		switch {
+		case !ok:
+			added++
+		case oldDigest != d:
+			modified++
+		}
+	}
+	for name := range oldFiles {
+		if _, ok := newFiles[name]; !ok {
+			removed++
+		}
+	}
+	return fmt.Sprintf("%d file(s) added, %d removed and %d modified", added, removed, modified)
+}
+
+// tarballFileDigests returns the SHA-256 digests of the regular files in the
+// gzipped tarball at path, by file name.
+func tarballFileDigests(path string) (map[string]string, error) {
+	if !strings.HasSuffix(path, ".tar.gz") && !strings.HasSuffix(path, ".tgz") {
+		return nil, fmt.Errorf("'%s' is not a gzipped tarball", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+
+	files := make(map[string]string)
+	tr := tar.NewReader(gzr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, tr); err != nil {
+			return nil, err
+		}
+		files[strings.TrimPrefix(hdr.Name, "./")] = fmt.Sprintf("%x", h.Sum(nil))
+	}
+	return files, nil
+}
+
+func artifactSize(a *sourcev1.Artifact) int64 {
+	if a.Size == nil {
+		return 0
+	}
+	return *a.Size
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func Test_awaitApproval(t *testing.T) {
+	required := &sourcev1.RevisionApproval{Required: true}
+	pending := &sourcev1.Artifact{Revision: "sha256:new", Digest: "sha256:digest"}
+
+	tests := []struct {
+		name             string
+		approval         *sourcev1.RevisionApproval
+		current          *sourcev1.Artifact
+		approved         string
+		wantAwait        bool
+		assertConditions []metav1.Condition
+	}{
+		{
+			name:      "No approval",
+			current:   &sourcev1.Artifact{Revision: "sha256:old"},
+			wantAwait: false,
+		},
+		{
+			name:      "Approval not required",
+			approval:  &sourcev1.RevisionApproval{},
+			current:   &sourcev1.Artifact{Revision: "sha256:old"},
+			wantAwait: false,
+		},
+		{
+			name:      "First artifact does not await approval",
+			approval:  required,
+			wantAwait: false,
+		},
+		{
+			name:      "Current revision does not await approval",
+			approval:  required,
+			current:   &sourcev1.Artifact{Revision: "sha256:new"},
+			wantAwait: false,
+		},
+		{
+			name:      "New revision approved by revision",
+			approval:  required,
+			current:   &sourcev1.Artifact{Revision: "sha256:old"},
+			approved:  "sha256:new",
+			wantAwait: false,
+		},
+		{
+			name:      "New revision approved by digest",
+			approval:  required,
+			current:   &sourcev1.Artifact{Revision: "sha256:old"},
+			approved:  "sha256:digest",
+			wantAwait: false,
+		},
+		{
+			name:      "New revision makes PendingApproval=True",
+			approval:  required,
+			current:   &sourcev1.Artifact{Revision: "sha256:old"},
+			wantAwait: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.PendingApprovalCondition, sourcev1.AwaitingApprovalReason,
+					"new revision 'sha256:new' with digest 'sha256:digest' awaits approval: 1 file(s) changed"),
+			},
+		},
+		{
+			name:      "Other approved revision makes PendingApproval=True",
+			approval:  required,
+			current:   &sourcev1.Artifact{Revision: "sha256:old"},
+			approved:  "sha256:old",
+			wantAwait: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.PendingApprovalCondition, sourcev1.AwaitingApprovalReason,
+					"new revision 'sha256:new' with digest 'sha256:digest' awaits approval: 1 file(s) changed"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1beta2.HTTPSource{}
+			if tt.approved != "" {
+				obj.SetAnnotations(map[string]string{sourcev1.ApprovedRevisionAnnotation: tt.approved})
+			}
+			conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", "new revision")
+			conditions.MarkTrue(obj, sourcev1.PendingApprovalCondition, sourcev1.AwaitingApprovalReason, "previous")
+
+			await := awaitApproval(obj, tt.approval, tt.current, pending, func() string {
+				return "1 file(s) changed"
+			})
+			g.Expect(await).To(Equal(tt.wantAwait))
+
+			if tt.wantAwait {
+				g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+				return
+			}
+			g.Expect(conditions.Has(obj, sourcev1.PendingApprovalCondition)).To(BeFalse())
+		})
+	}
+}
+
+func Test_artifactDiffSummary(t *testing.T) {
+	g := NewWithT(t)
+
+	storage, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	archive := func(revision string, files map[string]string) *sourcev1.Artifact {
+		dir := t.TempDir()
+		for name, content := range files {
+			g.Expect(os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o700)).To(Succeed())
+			g.Expect(os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)).To(Succeed())
+		}
+		artifact := storage.NewArtifactFor(sourcev1beta2.HTTPSourceKind, &metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			revision, revision+".tar.gz")
+		g.Expect(storage.MkdirAll(artifact)).To(Succeed())
+		g.Expect(storage.Archive(&artifact, dir, nil)).To(Succeed())
+		return &artifact
+	}
+
+	current := archive("old", map[string]string{
+		"README.md":        "readme",
+		"deploy/app.yaml":  "replicas: 1",
+		"deploy/conf.yaml": "debug: false",
+	})
+	pending := archive("new", map[string]string{
+		"README.md":       "readme",
+		"deploy/app.yaml": "replicas: 2",
+		"deploy/svc.yaml": "port: 80",
+		"deploy/ing.yaml": "host: example.com",
+	})
+	g.Expect(artifactDiffSummary(storage, current, pending)).To(Equal("2 file(s) added, 1 removed and 1 modified"))
+
+	index := storage.NewArtifactFor(sourcev1.HelmRepositoryKind, &metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		"new", "index-new.yaml")
+	size := int64(42)
+	index.Size = &size
+	g.Expect(artifactDiffSummary(storage, current, &index)).To(MatchRegexp(`^size changed from \d+ to 42 bytes$`))
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/fluxcd/pkg/version"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	serror "github.com/fluxcd/source-controller/internal/error"
+)
+
+// checkSourceDependencies confirms the given dependencies of the object are
+// Ready, and their Artifacts satisfy the constraints of the dependency.
+//
+// If a dependency does not exist, is not Ready for its current generation,
+// or does not satisfy the constraints, the DependencyNotReady condition is
+// set to True and a Waiting error is returned to requeue the object after
+// the given interval. On success, the DependencyNotReady condition is
+// removed.
+func checkSourceDependencies(ctx context.Context, c client.Reader, obj conditions.Setter,
+	dependencies []sourcev1.SourceDependency, requeueAfter time.Duration) error {
+	for _, dep := range dependencies {
+		if err := checkSourceDependency(ctx, c, obj.GetNamespace(), dep); err != nil {
+			err.RequeueAfter = requeueAfter
+			conditions.MarkTrue(obj, sourcev1.DependencyNotReadyCondition, err.Reason, err.Err.Error())
+			return err
+		}
+	}
+	conditions.Delete(obj, sourcev1.DependencyNotReadyCondition)
+	return nil
+}
+
+// checkSourceDependency returns a Waiting error if the given dependency in
+// the namespace is not Ready, or does not satisfy its constraints.
+func checkSourceDependency(ctx context.Context, c client.Reader, namespace string, dep sourcev1.SourceDependency) *serror.Waiting {
+	obj, err := getSourceDependency(ctx, c, namespace, dep)
+	if err != nil {
+		return serror.NewWaiting(
+			fmt.Errorf("could not get %s dependency '%s': %w", dep.Kind, dep.Name, err),
+			"NotFound",
+		)
+	}
+
+	if !conditions.IsReady(obj) ||
+		conditions.GetObservedGeneration(obj, meta.ReadyCondition) != obj.GetGeneration() {
+		return serror.NewWaiting(
+			fmt.Errorf("%s dependency '%s' is not ready", dep.Kind, dep.Name),
+			meta.DependencyNotReadyReason,
+		)
+	}
+
+	if !dep.HasArtifactConstraints() {
+		return nil
+	}
+	var artifact *sourcev1.Artifact
+	if s, ok := obj.(sourcev1.Source); ok {
+		artifact = s.GetArtifact()
+	}
+	if artifact == nil {
+		return serror.NewWaiting(
+			fmt.Errorf("no artifact available for %s dependency '%s'", dep.Kind, dep.Name),
+			sourcev1.DependencyNotSatisfiedReason,
+		)
+	}
+	if err := matchDependencyArtifact(dep, artifact); err != nil {
+		return serror.NewWaiting(
+			fmt.Errorf("%s dependency '%s' does not satisfy constraints: %w", dep.Kind, dep.Name, err),
+			sourcev1.DependencyNotSatisfiedReason,
+		)
+	}
+	return nil
+}
+
+// matchDependencyArtifact returns an error if the Artifact does not satisfy
+// the SemVer and MatchMetadata constraints of the dependency.
+func matchDependencyArtifact(dep sourcev1.SourceDependency, artifact *sourcev1.Artifact) error {
+	if dep.SemVer != "" {
+		constraint, err := semver.NewConstraint(dep.SemVer)
+		if err != nil {
+			return fmt.Errorf("semver '%s' parse error: %w", dep.SemVer, err)
+		}
+		name, _, _ := strings.Cut(artifact.Revision, "@")
+		v, err := version.ParseVersion(name)
+		if err != nil {
+			return fmt.Errorf("revision '%s' is not a valid semver", artifact.Revision)
+		}
+		if !constraint.Check(v) {
+			return fmt.Errorf("revision '%s' is not within semver range '%s'", artifact.Revision, dep.SemVer)
+		}
+	}
+	for k, v := range dep.MatchMetadata {
+		if got, ok := artifact.Metadata[k]; !ok || got != v {
+			return fmt.Errorf("artifact metadata '%s' does not match '%s'", k, v)
+		}
+	}
+	return nil
+}
+
+// getSourceDependency returns the object of the given dependency in the
+// namespace.
+func getSourceDependency(ctx context.Context, c client.Reader, namespace string, dep sourcev1.SourceDependency) (conditions.Getter, error) {
+	var obj conditions.Getter
+	switch dep.Kind {
+	case sourcev1.GitRepositoryKind:
+		obj = &sourcev1.GitRepository{}
+	case sourcev1.HelmRepositoryKind:
+		obj = &sourcev1.HelmRepository{}
+	case sourcev1.HelmChartKind:
+		obj = &sourcev1.HelmChart{}
+	case sourcev1beta2.BucketKind:
+		obj = &sourcev1beta2.Bucket{}
+	case sourcev1beta2.OCIRepositoryKind:
+		obj = &sourcev1beta2.OCIRepository{}
+	case sourcev1beta2.HelmChartSetKind:
+		obj = &sourcev1beta2.HelmChartSet{}
+	case sourcev1beta2.HTTPSourceKind:
+		obj = &sourcev1beta2.HTTPSource{}
+	case sourcev1beta2.CompositeSourceKind:
+		obj = &sourcev1beta2.CompositeSource{}
+	case sourcev1beta2.ConfigSourceKind:
+		obj = &sourcev1beta2.ConfigSource{}
+	case sourcev1beta2.ReleaseSourceKind:
+		obj = &sourcev1beta2.ReleaseSource{}
+	default:
+		return nil, fmt.Errorf("unsupported source kind '%s'", dep.Kind)
+	}
+	namespacedName := types.NamespacedName{
+		Namespace: namespace,
+		Name:      dep.Name,
+	}
+	if err := c.Get(ctx, namespacedName, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
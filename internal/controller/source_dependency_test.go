@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	serror "github.com/fluxcd/source-controller/internal/error"
+)
+
+func Test_checkSourceDependencies(t *testing.T) {
+	readyChart := &sourcev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Name: "chart", Namespace: "default", Generation: 2},
+		Status: sourcev1.HelmChartStatus{
+			Conditions: []metav1.Condition{
+				{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, Reason: meta.SucceededReason, ObservedGeneration: 2},
+			},
+			Artifact: &sourcev1.Artifact{
+				Revision: "1.2.3",
+				Metadata: map[string]string{"channel": "stable"},
+			},
+		},
+	}
+	readyHelmChartSet := &sourcev1beta2.HelmChartSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "set", Namespace: "default", Generation: 1},
+		Status: sourcev1beta2.HelmChartSetStatus{
+			Conditions: []metav1.Condition{
+				{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, Reason: meta.SucceededReason, ObservedGeneration: 1},
+			},
+		},
+	}
+	staleRepository := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "default", Generation: 3},
+		Status: sourcev1.GitRepositoryStatus{
+			Conditions: []metav1.Condition{
+				{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, Reason: meta.SucceededReason, ObservedGeneration: 2},
+			},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		dependencies     []sourcev1.SourceDependency
+		wantErr          bool
+		assertConditions []metav1.Condition
+	}{
+		{
+			name: "Ready dependencies remove DependencyNotReady",
+			dependencies: []sourcev1.SourceDependency{
+				{Kind: sourcev1.HelmChartKind, Name: "chart"},
+				{Kind: sourcev1beta2.HelmChartSetKind, Name: "set"},
+			},
+		},
+		{
+			name: "Missing dependency makes DependencyNotReady=True",
+			dependencies: []sourcev1.SourceDependency{
+				{Kind: sourcev1beta2.BucketKind, Name: "missing"},
+			},
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.DependencyNotReadyCondition, "NotFound", "could not get Bucket dependency 'missing'"),
+			},
+		},
+		{
+			name: "Dependency not Ready for its generation makes DependencyNotReady=True",
+			dependencies: []sourcev1.SourceDependency{
+				{Kind: sourcev1.HelmChartKind, Name: "chart"},
+				{Kind: sourcev1.GitRepositoryKind, Name: "git"},
+			},
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.DependencyNotReadyCondition, meta.DependencyNotReadyReason, "GitRepository dependency 'git' is not ready"),
+			},
+		},
+		{
+			name: "Revision within semver range and matching metadata",
+			dependencies: []sourcev1.SourceDependency{
+				{Kind: sourcev1.HelmChartKind, Name: "chart", SemVer: "1.x", MatchMetadata: map[string]string{"channel": "stable"}},
+			},
+		},
+		{
+			name: "Revision outside semver range makes DependencyNotReady=True",
+			dependencies: []sourcev1.SourceDependency{
+				{Kind: sourcev1.HelmChartKind, Name: "chart", SemVer: ">=2.0.0"},
+			},
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.DependencyNotReadyCondition, sourcev1.DependencyNotSatisfiedReason, "revision '1.2.3' is not within semver range '>=2.0.0'"),
+			},
+		},
+		{
+			name: "Mismatching metadata makes DependencyNotReady=True",
+			dependencies: []sourcev1.SourceDependency{
+				{Kind: sourcev1.HelmChartKind, Name: "chart", MatchMetadata: map[string]string{"channel": "edge"}},
+			},
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.DependencyNotReadyCondition, sourcev1.DependencyNotSatisfiedReason, "artifact metadata 'channel' does not match 'edge'"),
+			},
+		},
+		{
+			name: "Constraints on dependency without artifact makes DependencyNotReady=True",
+			dependencies: []sourcev1.SourceDependency{
+				{Kind: sourcev1beta2.HelmChartSetKind, Name: "set", SemVer: "*"},
+			},
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.DependencyNotReadyCondition, sourcev1.DependencyNotSatisfiedReason, "no artifact available for HelmChartSet dependency 'set'"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fakeclient.NewClientBuilder().
+				WithScheme(testEnv.GetScheme()).
+				WithObjects([]client.Object{readyChart, readyHelmChartSet, staleRepository}...).
+				Build()
+
+			obj := &sourcev1beta2.HTTPSource{
+				ObjectMeta: metav1.ObjectMeta{Name: "dependent", Namespace: "default"},
+			}
+			conditions.MarkTrue(obj, sourcev1.DependencyNotReadyCondition, "NotFound", "previous failure")
+
+			err := checkSourceDependencies(context.TODO(), c, obj, tt.dependencies, 5*time.Second)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			if tt.wantErr {
+				var e *serror.Waiting
+				g.Expect(err).To(BeAssignableToTypeOf(e))
+				g.Expect(err.(*serror.Waiting).RequeueAfter).To(Equal(5 * time.Second))
+			}
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+		})
+	}
+}
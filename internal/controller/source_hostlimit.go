@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"helm.sh/helm/v3/pkg/getter"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kuberecorder "k8s.io/client-go/tools/record"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/helm/repository"
+	"github.com/fluxcd/source-controller/internal/hostlimit"
+)
+
+// reserveHost reserves a remote operation on the host of the given address
+// with the limiter, and returns the Reservation to release once the
+// operation is done.
+//
+// If the operation may not start yet, it returns a Waiting error to requeue
+// the object after the delay, without emitting an event. If the circuit
+// breaker of the host is open, the FetchFailed condition is set to True and
+// a Waiting error is returned to requeue the object once a probe may be
+// attempted.
+func reserveHost(obj conditions.Setter, l *hostlimit.Limiter, address string) (*hostlimit.Reservation, error) {
+	host := hostlimit.Host(address)
+	res, delay, err := l.Reserve(host)
+	if err != nil {
+		e := serror.NewWaiting(err, sourcev1.HostUnavailableReason)
+		var unavailable *hostlimit.HostUnavailableError
+		if errors.As(err, &unavailable) {
+			e.RequeueAfter = unavailable.RetryAfter
+		}
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return nil, e
+	}
+	if delay > 0 {
+		e := serror.NewWaiting(
+			fmt.Errorf("remote operations on host '%s' are limited, retrying in %s", host, delay.Round(time.Second)),
+			sourcev1.HostLimitedReason,
+		)
+		e.RequeueAfter = delay
+		e.Event = serror.EventTypeNone
+		return nil, e
+	}
+	return res, nil
+}
+
+// hostLimited returns true if the given error of reserveHost indicates the
+// operation may not start yet due to the limits of the host.
+func hostLimited(err error) bool {
+	var e *serror.Waiting
+	return errors.As(err, &e) && e.Reason == sourcev1.HostLimitedReason
+}
+
+// allHostsUnavailable returns true if all the given errors indicate the
+// circuit breaker of the host is open.
+func allHostsUnavailable(errs []error) bool {
+	for _, err := range errs {
+		var unavailable *hostlimit.HostUnavailableError
+		if !errors.As(err, &unavailable) {
+			return false
+		}
+	}
+	return len(errs) > 0
+}
+
+// recordHostResult records the result of the remote operation of the
+// Reservation with the circuit breaker of the host, and emits an event for
+// the object if the circuit breaker opened or closed.
+func recordHostResult(recorder kuberecorder.EventRecorder, obj runtime.Object, res *hostlimit.Reservation, err error) {
+	from, to := res.Done(err)
+	if from == to {
+		return
+	}
+	switch to {
+	case hostlimit.StateOpen:
+		recorder.Eventf(obj, corev1.EventTypeWarning, sourcev1.HostUnavailableReason,
+			"circuit breaker for host '%s' opened: %s", res.Host(), err)
+	case hostlimit.StateClosed:
+		recorder.Eventf(obj, corev1.EventTypeNormal, sourcev1.HostAvailableReason,
+			"circuit breaker for host '%s' closed", res.Host())
+	}
+}
+
+// hostLimitedGetter is a getter.Getter which reserves a remote operation on
+// the host of every requested URL with the limiter, and records the result
+// with the circuit breaker of the host.
+type hostLimitedGetter struct {
+	getter.Getter
+
+	limiter  *hostlimit.Limiter
+	recorder kuberecorder.EventRecorder
+	obj      conditions.Setter
+}
+
+// Get reserves a remote operation on the host of the given URL, and gets
+// the URL using the underlying getter.Getter. It returns the error of
+// reserveHost without getting the URL if the operation may not start yet,
+// or if the circuit breaker of the host is open.
+func (g *hostLimitedGetter) Get(u string, opts ...getter.Option) (*bytes.Buffer, error) {
+	res, err := reserveHost(g.obj, g.limiter, u)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Release()
+
+	b, err := g.Getter.Get(u, opts...)
+	recordHostResult(g.recorder, g.obj, res, err)
+	return b, err
+}
+
+// hostLimitedRegistryClient is a repository.RegistryClient which reserves a
+// remote operation on the host of the repository with the limiter while
+// listing its tags, and records the result with the circuit breaker of the
+// host.
+type hostLimitedRegistryClient struct {
+	repository.RegistryClient
+
+	limiter  *hostlimit.Limiter
+	recorder kuberecorder.EventRecorder
+	obj      conditions.Setter
+}
+
+// Tags reserves a remote operation on the host of the given repository URL,
+// and lists the tags using the underlying repository.RegistryClient.
+func (c *hostLimitedRegistryClient) Tags(u string) ([]string, error) {
+	res, err := reserveHost(c.obj, c.limiter, u)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Release()
+
+	tags, err := c.RegistryClient.Tags(u)
+	recordHostResult(c.recorder, c.obj, res, err)
+	return tags, err
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/getter"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/helm/repository"
+	"github.com/fluxcd/source-controller/internal/hostlimit"
+)
+
+func Test_reserveHost(t *testing.T) {
+	t.Run("limited", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := hostlimit.New(hostlimit.Options{MaxConcurrent: 1}, nil)
+		obj := &sourcev1.GitRepository{}
+
+		res, err := reserveHost(obj, l, "https://github.com/fluxcd/flux2")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = reserveHost(obj, l, "ssh://git@github.com/fluxcd/source-controller")
+		var waitErr *serror.Waiting
+		g.Expect(errors.As(err, &waitErr)).To(BeTrue())
+		g.Expect(waitErr.Reason).To(Equal(sourcev1.HostLimitedReason))
+		g.Expect(waitErr.RequeueAfter).To(Equal(hostlimit.DefaultConcurrencyDelay))
+		g.Expect(waitErr.Event).To(Equal(serror.EventTypeNone))
+		g.Expect(conditions.Has(obj, sourcev1.FetchFailedCondition)).To(BeFalse())
+
+		res.Release()
+		_, err = reserveHost(obj, l, "ssh://git@github.com/fluxcd/source-controller")
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := hostlimit.New(hostlimit.Options{FailureThreshold: 1, MinBreakerBackoff: time.Minute}, nil)
+		obj := &sourcev1.GitRepository{}
+
+		res, err := reserveHost(obj, l, "https://github.com/fluxcd/flux2")
+		g.Expect(err).ToNot(HaveOccurred())
+		res.Done(errors.New("dial tcp: connection refused"))
+		res.Release()
+
+		_, err = reserveHost(obj, l, "https://github.com/fluxcd/flux2")
+		var waitErr *serror.Waiting
+		g.Expect(errors.As(err, &waitErr)).To(BeTrue())
+		g.Expect(waitErr.Reason).To(Equal(sourcev1.HostUnavailableReason))
+		g.Expect(waitErr.RequeueAfter).To(BeNumerically("~", time.Minute, time.Second))
+		g.Expect(conditions.IsTrue(obj, sourcev1.FetchFailedCondition)).To(BeTrue())
+		g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(sourcev1.HostUnavailableReason))
+	})
+
+	t.Run("nil limiter", func(t *testing.T) {
+		g := NewWithT(t)
+
+		res, err := reserveHost(&sourcev1.GitRepository{}, nil, "https://github.com/fluxcd/flux2")
+		g.Expect(err).ToNot(HaveOccurred())
+		res.Release()
+	})
+}
+
+func Test_recordHostResult(t *testing.T) {
+	g := NewWithT(t)
+
+	l := hostlimit.New(hostlimit.Options{FailureThreshold: 1, MinBreakerBackoff: time.Millisecond}, nil)
+	obj := &sourcev1.GitRepository{}
+	recorder := record.NewFakeRecorder(10)
+
+	res, err := reserveHost(obj, l, "https://github.com/fluxcd/flux2")
+	g.Expect(err).ToNot(HaveOccurred())
+	recordHostResult(recorder, obj, res, errors.New("dial tcp: connection refused"))
+	res.Release()
+	g.Expect(recorder.Events).To(Receive(Equal(
+		"Warning HostUnavailable circuit breaker for host 'github.com' opened: dial tcp: connection refused")))
+
+	g.Eventually(func() error {
+		res, err = reserveHost(obj, l, "https://github.com/fluxcd/flux2")
+		return err
+	}).Should(Succeed())
+	recordHostResult(recorder, obj, res, nil)
+	res.Release()
+	g.Expect(recorder.Events).To(Receive(Equal(
+		"Normal HostAvailable circuit breaker for host 'github.com' closed")))
+
+	// Results which do not change the state do not emit events.
+	res, err = reserveHost(obj, l, "https://github.com/fluxcd/flux2")
+	g.Expect(err).ToNot(HaveOccurred())
+	recordHostResult(recorder, obj, res, nil)
+	res.Release()
+	g.Expect(recorder.Events).ToNot(Receive())
+}
+
+type countingGetter struct {
+	urls []string
+	err  error
+}
+
+func (g *countingGetter) Get(u string, _ ...getter.Option) (*bytes.Buffer, error) {
+	g.urls = append(g.urls, u)
+	if g.err != nil {
+		return nil, g.err
+	}
+	return bytes.NewBufferString(u), nil
+}
+
+func Test_hostLimitedGetter(t *testing.T) {
+	g := NewWithT(t)
+
+	l := hostlimit.New(hostlimit.Options{MaxConcurrent: 1, FailureThreshold: 1, MinBreakerBackoff: time.Minute}, nil)
+	obj := &sourcev1.HelmChart{}
+	recorder := record.NewFakeRecorder(10)
+	inner := &countingGetter{}
+	hg := &hostLimitedGetter{Getter: inner, limiter: l, recorder: recorder, obj: obj}
+
+	// The reservation is released once the URL is fetched.
+	b, err := hg.Get("https://charts.example.com/index.yaml")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(b.String()).To(Equal("https://charts.example.com/index.yaml"))
+	_, err = hg.Get("https://charts.example.com/podinfo-6.0.0.tgz")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(inner.urls).To(HaveLen(2))
+
+	// The URL is not fetched while the host is limited.
+	res, err := reserveHost(obj, l, "https://charts.example.com")
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = hg.Get("https://charts.example.com/podinfo-6.0.0.tgz")
+	g.Expect(hostLimited(err)).To(BeTrue())
+	g.Expect(inner.urls).To(HaveLen(2))
+	res.Release()
+
+	// Failures are recorded with the circuit breaker of the host of the URL.
+	inner.err = errors.New("dial tcp: connection refused")
+	_, err = hg.Get("https://charts.example.com/podinfo-6.0.0.tgz")
+	g.Expect(err).To(MatchError(inner.err))
+	g.Expect(recorder.Events).To(Receive(ContainSubstring("circuit breaker for host 'charts.example.com' opened")))
+
+	_, err = hg.Get("https://charts.example.com/podinfo-6.0.0.tgz")
+	var waitErr *serror.Waiting
+	g.Expect(errors.As(err, &waitErr)).To(BeTrue())
+	g.Expect(waitErr.Reason).To(Equal(sourcev1.HostUnavailableReason))
+	g.Expect(inner.urls).To(HaveLen(3))
+
+	// Other hosts are not affected.
+	inner.err = nil
+	_, err = hg.Get("https://mirror.example.com/podinfo-6.0.0.tgz")
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+type fakeTagsClient struct {
+	repository.RegistryClient
+	calls int
+}
+
+func (c *fakeTagsClient) Tags(string) ([]string, error) {
+	c.calls++
+	return []string{"6.0.0"}, nil
+}
+
+func Test_hostLimitedRegistryClient(t *testing.T) {
+	g := NewWithT(t)
+
+	l := hostlimit.New(hostlimit.Options{MaxConcurrent: 1}, nil)
+	obj := &sourcev1.HelmChart{}
+	inner := &fakeTagsClient{}
+	c := &hostLimitedRegistryClient{RegistryClient: inner, limiter: l, recorder: record.NewFakeRecorder(10), obj: obj}
+
+	tags, err := c.Tags("ghcr.io/stefanprodan/charts/podinfo")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(tags).To(Equal([]string{"6.0.0"}))
+
+	res, err := reserveHost(obj, l, "oci://ghcr.io/stefanprodan/charts")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer res.Release()
+	_, err = c.Tags("ghcr.io/stefanprodan/charts/podinfo")
+	g.Expect(hostLimited(err)).To(BeTrue())
+	g.Expect(inner.calls).To(Equal(1))
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/impersonation"
+)
+
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=impersonate
+
+// secretClient returns the client to read the Secrets of the object with.
+// In multi-tenant lockdown mode, this is a client which impersonates the
+// given ServiceAccount of the object, or the default ServiceAccount if
+// empty. Otherwise, it is the given client of the controller.
+//
+// If no ServiceAccount can be impersonated, the FetchFailed condition is set
+// to True and a Stalling error is returned, as this requires a change of the
+// object or the controller configuration.
+func secretClient(obj conditions.Setter, c client.Client, i *impersonation.Impersonator,
+	serviceAccountName string) (client.Client, error) {
+	if !i.Lockdown() {
+		return c, nil
+	}
+
+	sc, err := i.ClientFor(obj.GetNamespace(), serviceAccountName)
+	if err != nil {
+		if errors.Is(err, impersonation.ErrServiceAccountRequired) {
+			e := serror.NewStalling(err, sourcev1.ServiceAccountRequiredReason)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return nil, e
+		}
+		e := serror.NewGeneric(err, sourcev1.ImpersonationFailedReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return nil, e
+	}
+	return sc, nil
+}
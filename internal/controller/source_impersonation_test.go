@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/impersonation"
+)
+
+func Test_secretClient(t *testing.T) {
+	c := fakeclient.NewClientBuilder().WithScheme(testEnv.GetScheme()).Build()
+	newImpersonator := func(opts impersonation.Options) *impersonation.Impersonator {
+		return impersonation.New(&rest.Config{Host: "https://127.0.0.1"}, testEnv.GetScheme(),
+			meta.NewDefaultRESTMapper(nil), opts)
+	}
+
+	tests := []struct {
+		name               string
+		impersonator       *impersonation.Impersonator
+		serviceAccountName string
+		wantImpersonated   bool
+		wantReason         string
+	}{
+		{
+			name:         "without impersonator",
+			impersonator: nil,
+		},
+		{
+			name:               "without lockdown",
+			impersonator:       newImpersonator(impersonation.Options{DefaultServiceAccount: "default"}),
+			serviceAccountName: "tenant",
+		},
+		{
+			name:               "lockdown with service account",
+			impersonator:       newImpersonator(impersonation.Options{Lockdown: true}),
+			serviceAccountName: "tenant",
+			wantImpersonated:   true,
+		},
+		{
+			name:             "lockdown with default service account",
+			impersonator:     newImpersonator(impersonation.Options{Lockdown: true, DefaultServiceAccount: "default"}),
+			wantImpersonated: true,
+		},
+		{
+			name:         "lockdown without service account",
+			impersonator: newImpersonator(impersonation.Options{Lockdown: true}),
+			wantReason:   sourcev1.ServiceAccountRequiredReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1.GitRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "tenant-ns"},
+			}
+			got, err := secretClient(obj, c, tt.impersonator, tt.serviceAccountName)
+
+			if tt.wantReason != "" {
+				var stallingErr *serror.Stalling
+				g.Expect(errors.As(err, &stallingErr)).To(BeTrue())
+				g.Expect(stallingErr.Reason).To(Equal(tt.wantReason))
+				g.Expect(conditions.IsTrue(obj, sourcev1.FetchFailedCondition)).To(BeTrue())
+				g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(tt.wantReason))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(conditions.Has(obj, sourcev1.FetchFailedCondition)).To(BeFalse())
+			if tt.wantImpersonated {
+				g.Expect(got).ToNot(BeIdenticalTo(c))
+			} else {
+				g.Expect(got).To(BeIdenticalTo(c))
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	"github.com/fluxcd/source-controller/internal/schedule"
+)
+
+// holdRevision returns true if no Artifact may be produced for the given
+// revision at the given time, because the schedule is closed. Only revisions
+// which differ from the current Artifact are held; the first Artifact of an
+// object is always produced.
+//
+// When the revision is held, the RevisionHeld condition is set to True with
+// the next time the schedule opens, and the ArtifactOutdated condition is
+// removed as the current Artifact keeps being served. Otherwise, the
+// RevisionHeld condition is removed. An invalid schedule results in a
+// Stalling error. While the revision is held, the object is requeued when the
+// schedule opens, see holdRequeueAfter.
+func holdRevision(obj conditions.Setter, s *sourcev1.ReconcileSchedule, current *sourcev1.Artifact,
+	revision string, now time.Time) (bool, error) {
+	if s == nil || current == nil || current.HasRevision(revision) {
+		conditions.Delete(obj, sourcev1.RevisionHeldCondition)
+		return false, nil
+	}
+
+	sched, err := newSchedule(s)
+	if err != nil {
+		return false, serror.NewStalling(
+			fmt.Errorf("invalid schedule: %w", err),
+			sourcev1.InvalidScheduleReason,
+		)
+	}
+	if sched.Open(now) {
+		conditions.Delete(obj, sourcev1.RevisionHeldCondition)
+		return false, nil
+	}
+
+	msg := fmt.Sprintf("new revision '%s' is held, the schedule does not open within the foreseeable future", revision)
+	if next, ok := sched.NextOpen(now); ok {
+		msg = fmt.Sprintf("new revision '%s' is held until the schedule opens at %s", revision, next.Format(time.RFC3339))
+	}
+	conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+	conditions.MarkTrue(obj, sourcev1.RevisionHeldCondition, sourcev1.ScheduleClosedReason, msg)
+	return true, nil
+}
+
+// holdRequeueAfter returns the period after which to requeue the object
+// with the given schedule and success interval. While a new revision is held,
+// it returns the time until the schedule opens if that is shorter than the
+// interval, so that the revision is not held for up to an interval longer
+// than required.
+func holdRequeueAfter(obj conditions.Getter, s *sourcev1.ReconcileSchedule, interval time.Duration, now time.Time) time.Duration {
+	if s == nil || !conditions.IsTrue(obj, sourcev1.RevisionHeldCondition) {
+		return interval
+	}
+	sched, err := newSchedule(s)
+	if err != nil {
+		return interval
+	}
+	if next, ok := sched.NextOpen(now); ok {
+		if d := next.Sub(now); d > 0 && d < interval {
+			return d
+		}
+	}
+	return interval
+}
+
+// newSchedule returns a schedule.Schedule for the given
+// v1.ReconcileSchedule.
+func newSchedule(s *sourcev1.ReconcileSchedule) (*schedule.Schedule, error) {
+	toWindows := func(in []sourcev1.ScheduleWindow) []schedule.Window {
+		out := make([]schedule.Window, 0, len(in))
+		for _, w := range in {
+			out = append(out, schedule.Window{Cron: w.Cron, Duration: w.Duration.Duration})
+		}
+		return out
+	}
+	return schedule.New(s.TimeZone, toWindows(s.Windows), toWindows(s.DenyWindows))
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func Test_holdRevision(t *testing.T) {
+	// 2024-01-03 is a Wednesday.
+	now := time.Date(2024, time.January, 3, 18, 0, 0, 0, time.UTC)
+	officeHours := &sourcev1.ReconcileSchedule{
+		Windows: []sourcev1.ScheduleWindow{
+			{Cron: "0 9 * * 1-5", Duration: metav1.Duration{Duration: 7 * time.Hour}},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		schedule         *sourcev1.ReconcileSchedule
+		current          *sourcev1.Artifact
+		now              time.Time
+		wantHeld         bool
+		wantErr          bool
+		assertConditions []metav1.Condition
+	}{
+		{
+			name:     "No schedule",
+			current:  &sourcev1.Artifact{Revision: "sha256:old"},
+			now:      now,
+			wantHeld: false,
+		},
+		{
+			name:     "First artifact is not held",
+			schedule: officeHours,
+			now:      now,
+			wantHeld: false,
+		},
+		{
+			name:     "Current revision is not held",
+			schedule: officeHours,
+			current:  &sourcev1.Artifact{Revision: "sha256:new"},
+			now:      now,
+			wantHeld: false,
+		},
+		{
+			name:     "New revision within window",
+			schedule: officeHours,
+			current:  &sourcev1.Artifact{Revision: "sha256:old"},
+			now:      now.Add(-4 * time.Hour),
+			wantHeld: false,
+		},
+		{
+			name:     "New revision outside window makes RevisionHeld=True",
+			schedule: officeHours,
+			current:  &sourcev1.Artifact{Revision: "sha256:old"},
+			now:      now,
+			wantHeld: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.RevisionHeldCondition, sourcev1.ScheduleClosedReason,
+					"new revision 'sha256:new' is held until the schedule opens at 2024-01-04T09:00:00Z"),
+			},
+		},
+		{
+			name: "Invalid schedule",
+			schedule: &sourcev1.ReconcileSchedule{
+				TimeZone: "Mars/Olympus_Mons",
+			},
+			current: &sourcev1.Artifact{Revision: "sha256:old"},
+			now:     now,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new revision"),
+				*conditions.TrueCondition(sourcev1.RevisionHeldCondition, sourcev1.ScheduleClosedReason, "previous"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1beta2.HTTPSource{}
+			conditions.MarkTrue(obj, sourcev1.ArtifactOutdatedCondition, "NewRevision", "new revision")
+			conditions.MarkTrue(obj, sourcev1.RevisionHeldCondition, sourcev1.ScheduleClosedReason, "previous")
+
+			held, err := holdRevision(obj, tt.schedule, tt.current, "sha256:new", tt.now)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			g.Expect(held).To(Equal(tt.wantHeld))
+
+			if tt.wantHeld || tt.wantErr {
+				g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+				return
+			}
+			g.Expect(conditions.Has(obj, sourcev1.RevisionHeldCondition)).To(BeFalse())
+		})
+	}
+}
+
+func Test_holdRequeueAfter(t *testing.T) {
+	// 2024-01-03 is a Wednesday.
+	now := time.Date(2024, time.January, 3, 18, 0, 0, 0, time.UTC)
+	officeHours := &sourcev1.ReconcileSchedule{
+		Windows: []sourcev1.ScheduleWindow{
+			{Cron: "0 9 * * 1-5", Duration: metav1.Duration{Duration: 7 * time.Hour}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		schedule *sourcev1.ReconcileSchedule
+		held     bool
+		interval time.Duration
+		want     time.Duration
+	}{
+		{
+			name:     "No schedule",
+			held:     true,
+			interval: 24 * time.Hour,
+			want:     24 * time.Hour,
+		},
+		{
+			name:     "Revision not held",
+			schedule: officeHours,
+			interval: 24 * time.Hour,
+			want:     24 * time.Hour,
+		},
+		{
+			name:     "Held revision requeues when the schedule opens",
+			schedule: officeHours,
+			held:     true,
+			interval: 24 * time.Hour,
+			want:     15 * time.Hour,
+		},
+		{
+			name:     "Held revision requeues at shorter interval",
+			schedule: officeHours,
+			held:     true,
+			interval: time.Hour,
+			want:     time.Hour,
+		},
+		{
+			name: "Invalid schedule",
+			schedule: &sourcev1.ReconcileSchedule{
+				TimeZone: "Mars/Olympus_Mons",
+			},
+			held:     true,
+			interval: 24 * time.Hour,
+			want:     24 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &sourcev1beta2.HTTPSource{}
+			if tt.held {
+				conditions.MarkTrue(obj, sourcev1.RevisionHeldCondition, sourcev1.ScheduleClosedReason, "held")
+			}
+			g.Expect(holdRequeueAfter(obj, tt.schedule, tt.interval, now)).To(Equal(tt.want))
+		})
+	}
+}
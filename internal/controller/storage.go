@@ -176,9 +176,15 @@ func (s Storage) RemoveAllButCurrent(artifact v1.Artifact) ([]string, error) {
 // 1. collect all artifact files with an expired ttl
 // 2. if we satisfy maxItemsToBeRetained, then return
 // 3. else, collect all artifact files till the latest n files remain, where n=maxItemsToBeRetained
-func (s Storage) getGarbageFiles(artifact v1.Artifact, totalCountLimit, maxItemsToBeRetained int, ttl time.Duration) (garbageFiles []string, _ error) {
+// Any additional artifacts to keep, which must reside in the same directory as
+// the given artifact, are never considered garbage.
+func (s Storage) getGarbageFiles(artifact v1.Artifact, totalCountLimit, maxItemsToBeRetained int, ttl time.Duration, keep ...v1.Artifact) (garbageFiles []string, _ error) {
 	localPath := s.LocalPath(artifact)
 	dir := filepath.Dir(localPath)
+	keepPaths := []string{localPath}
+	for _, k := range keep {
+		keepPaths = append(keepPaths, s.LocalPath(k))
+	}
 	artifactFilesWithCreatedTs := make(map[time.Time]string)
 	// sortedPaths contain all files sorted according to their created ts.
 	sortedPaths := []string{}
@@ -207,7 +213,7 @@ func (s Storage) getGarbageFiles(artifact v1.Artifact, totalCountLimit, maxItems
 		// we avoid all lock files, adding them at the end to the list of garbage files.
 		expired := diff > ttl
 		if !info.IsDir() && info.Mode()&os.ModeSymlink != os.ModeSymlink && filepath.Ext(path) != ".lock" {
-			if path != localPath && expired {
+			if !stringInSlice(path, keepPaths) && expired {
 				garbageFiles = append(garbageFiles, path)
 			}
 			totalArtifactFiles += 1
@@ -240,7 +246,7 @@ func (s Storage) getGarbageFiles(artifact v1.Artifact, totalCountLimit, maxItems
 	var collected int
 	noOfGarbageFiles := len(garbageFiles)
 	for _, path := range sortedPaths {
-		if path != localPath && filepath.Ext(path) != ".lock" && !stringInSlice(path, garbageFiles) {
+		if !stringInSlice(path, keepPaths) && filepath.Ext(path) != ".lock" && !stringInSlice(path, garbageFiles) {
 			// If we previously collected some garbage files with an expired ttl, then take that into account
 			// when checking whether we need to remove more files to satisfy the max no. of items allowed
 			// in the filesystem, along with the no. of files already removed in this loop.
@@ -262,8 +268,8 @@ func (s Storage) getGarbageFiles(artifact v1.Artifact, totalCountLimit, maxItems
 }
 
 // GarbageCollect removes all garbage files in the artifact dir according to the provided
-// retention options.
-func (s Storage) GarbageCollect(ctx context.Context, artifact v1.Artifact, timeout time.Duration) ([]string, error) {
+// retention options. Any additional artifacts to keep are excluded from collection.
+func (s Storage) GarbageCollect(ctx context.Context, artifact v1.Artifact, timeout time.Duration, keep ...v1.Artifact) ([]string, error) {
 	delFilesChan := make(chan []string)
 	errChan := make(chan error)
 	// Abort if it takes more than the provided timeout duration.
@@ -271,7 +277,7 @@ func (s Storage) GarbageCollect(ctx context.Context, artifact v1.Artifact, timeo
 	defer cancel()
 
 	go func() {
-		garbageFiles, err := s.getGarbageFiles(artifact, GarbageCountLimit, s.ArtifactRetentionRecords, s.ArtifactRetentionTTL)
+		garbageFiles, err := s.getGarbageFiles(artifact, GarbageCountLimit, s.ArtifactRetentionRecords, s.ArtifactRetentionTTL, keep...)
 		if err != nil {
 			errChan <- err
 			return
@@ -538,6 +538,7 @@ func TestStorage_getGarbageFiles(t *testing.T) {
 		ttl                  time.Duration
 		maxItemsToBeRetained int
 		totalCountLimit      int
+		keepPaths            []string
 		wantDeleted          []string
 	}{
 		{
@@ -658,6 +659,30 @@ func TestStorage_getGarbageFiles(t *testing.T) {
 				filepath.Join(artifactFolder, "artifact3.tar.gz"),
 			},
 		},
+		{
+			name: "delete files based on ttl and maxItemsToBeRetained, ignore files to keep",
+			artifactPaths: []string{
+				filepath.Join(artifactFolder, "artifact1.tar.gz"),
+				filepath.Join(artifactFolder, "artifact1.yaml"),
+				filepath.Join(artifactFolder, "artifact2.tar.gz"),
+				filepath.Join(artifactFolder, "artifact2.yaml"),
+				filepath.Join(artifactFolder, "artifact3.yaml"),
+				filepath.Join(artifactFolder, "artifact3.tar.gz"),
+			},
+			createPause:          time.Millisecond * 500,
+			ttl:                  time.Millisecond * 500,
+			totalCountLimit:      10,
+			maxItemsToBeRetained: 2,
+			keepPaths: []string{
+				filepath.Join(artifactFolder, "artifact3.yaml"),
+			},
+			wantDeleted: []string{
+				filepath.Join(artifactFolder, "artifact1.tar.gz"),
+				filepath.Join(artifactFolder, "artifact1.yaml"),
+				filepath.Join(artifactFolder, "artifact2.tar.gz"),
+				filepath.Join(artifactFolder, "artifact2.yaml"),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -679,7 +704,12 @@ func TestStorage_getGarbageFiles(t *testing.T) {
 				time.Sleep(tt.createPause)
 			}
 
-			deletedPaths, err := s.getGarbageFiles(artifact, tt.totalCountLimit, tt.maxItemsToBeRetained, tt.ttl)
+			var keep []sourcev1.Artifact
+			for _, p := range tt.keepPaths {
+				keep = append(keep, sourcev1.Artifact{Path: p})
+			}
+
+			deletedPaths, err := s.getGarbageFiles(artifact, tt.totalCountLimit, tt.maxItemsToBeRetained, tt.ttl, keep...)
 			g.Expect(err).ToNot(HaveOccurred(), "failed to collect garbage files")
 			g.Expect(len(tt.wantDeleted)).To(Equal(len(deletedPaths)))
 			for _, wantDeletedPath := range tt.wantDeleted {
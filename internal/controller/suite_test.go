@@ -57,6 +57,7 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/fluxcd/source-controller/internal/cache"
+	"github.com/fluxcd/source-controller/internal/helm/registry"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -350,14 +351,13 @@ func TestMain(m *testing.M) {
 	}
 
 	if err := (&HelmRepositoryReconciler{
-		Client:        testEnv,
-		EventRecorder: record.NewFakeRecorder(32),
-		Metrics:       testMetricsH,
-		Getters:       testGetters,
-		Storage:       testStorage,
-		Cache:         testCache,
-		TTL:           1 * time.Second,
-		CacheRecorder: cacheRecorder,
+		Client:                  testEnv,
+		EventRecorder:           record.NewFakeRecorder(32),
+		Metrics:                 testMetricsH,
+		Getters:                 testGetters,
+		Storage:                 testStorage,
+		CacheRecorder:           cacheRecorder,
+		RegistryClientGenerator: registry.ClientGenerator,
 	}).SetupWithManagerAndOptions(testEnv, HelmRepositoryReconcilerOptions{
 		RateLimiter: controller.GetDefaultRateLimiter(),
 	}); err != nil {
@@ -379,6 +379,61 @@ func TestMain(m *testing.M) {
 		panic(fmt.Sprintf("Failed to start HelmChartReconciler: %v", err))
 	}
 
+	if err := (&HelmChartSetReconciler{
+		Client:        testEnv,
+		EventRecorder: record.NewFakeRecorder(32),
+		Metrics:       testMetricsH,
+		Storage:       testStorage,
+	}).SetupWithManagerAndOptions(ctx, testEnv, HelmChartSetReconcilerOptions{
+		RateLimiter: controller.GetDefaultRateLimiter(),
+	}); err != nil {
+		panic(fmt.Sprintf("Failed to start HelmChartSetReconciler: %v", err))
+	}
+
+	if err := (&HTTPSourceReconciler{
+		Client:        testEnv,
+		EventRecorder: record.NewFakeRecorder(32),
+		Metrics:       testMetricsH,
+		Storage:       testStorage,
+	}).SetupWithManagerAndOptions(testEnv, HTTPSourceReconcilerOptions{
+		RateLimiter: controller.GetDefaultRateLimiter(),
+	}); err != nil {
+		panic(fmt.Sprintf("Failed to start HTTPSourceReconciler: %v", err))
+	}
+
+	if err := (&CompositeSourceReconciler{
+		Client:        testEnv,
+		EventRecorder: record.NewFakeRecorder(32),
+		Metrics:       testMetricsH,
+		Storage:       testStorage,
+	}).SetupWithManagerAndOptions(ctx, testEnv, CompositeSourceReconcilerOptions{
+		RateLimiter: controller.GetDefaultRateLimiter(),
+	}); err != nil {
+		panic(fmt.Sprintf("Failed to start CompositeSourceReconciler: %v", err))
+	}
+
+	if err := (&ConfigSourceReconciler{
+		Client:        testEnv,
+		EventRecorder: record.NewFakeRecorder(32),
+		Metrics:       testMetricsH,
+		Storage:       testStorage,
+	}).SetupWithManagerAndOptions(testEnv, ConfigSourceReconcilerOptions{
+		RateLimiter: controller.GetDefaultRateLimiter(),
+	}); err != nil {
+		panic(fmt.Sprintf("Failed to start ConfigSourceReconciler: %v", err))
+	}
+
+	if err := (&ReleaseSourceReconciler{
+		Client:        testEnv,
+		EventRecorder: record.NewFakeRecorder(32),
+		Metrics:       testMetricsH,
+		Storage:       testStorage,
+	}).SetupWithManagerAndOptions(testEnv, ReleaseSourceReconcilerOptions{
+		RateLimiter: controller.GetDefaultRateLimiter(),
+	}); err != nil {
+		panic(fmt.Sprintf("Failed to start ReleaseSourceReconciler: %v", err))
+	}
+
 	go func() {
 		fmt.Println("Starting the test environment")
 		if err := testEnv.Start(ctx); err != nil {
@@ -27,12 +27,21 @@ const (
 	// When enabled, it will cache both object types, resulting in increased memory usage
 	// and cluster-wide RBAC permissions (list and watch).
 	CacheSecretsAndConfigMaps = "CacheSecretsAndConfigMaps"
+
+	// ConfigSourceSecrets controls whether ConfigSources are allowed to select Secrets.
+	//
+	// When enabled, the data of the selected Secrets is included in the Artifact of the
+	// ConfigSource, which can be fetched by anyone with network access to the controller.
+	ConfigSourceSecrets = "ConfigSourceSecrets"
 )
 
 var features = map[string]bool{
 	// CacheSecretsAndConfigMaps
 	// opt-in from v0.34
 	CacheSecretsAndConfigMaps: false,
+	// ConfigSourceSecrets
+	// opt-in
+	ConfigSourceSecrets: false,
 }
 
 // FeatureGates contains a list of all supported feature gates and
@@ -17,12 +17,17 @@ limitations under the License.
 package chart
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	helmchart "helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
@@ -122,6 +127,9 @@ type BuildOptions struct {
 	Force bool
 	// Verifier can be set to the verification of the chart.
 	Verify bool
+	// Render can be set to render the templates of the built chart with
+	// the given RenderOptions, failing the build if this returns an error.
+	Render *RenderOptions
 }
 
 // GetValuesFiles returns BuildOptions.ValuesFiles, except if it equals
@@ -156,6 +164,13 @@ type Build struct {
 	// VerifiedResult indicates the results of verifying the chart.
 	// If no verification was performed, this field should be VerificationResultIgnored.
 	VerifiedResult oci.VerificationResult
+	// RenderedPath is the absolute path to the rendered manifests of the
+	// chart. It is only set when BuildOptions.Render was configured.
+	RenderedPath string
+	// LatestVersion is the latest stable version of the chart available in
+	// the remote repository, regardless of the version constraint and
+	// policy. It is only set by the remote builder, on a best effort basis.
+	LatestVersion string
 }
 
 // Summary returns a human-readable summary of the Build.
@@ -207,7 +222,34 @@ func (b *Build) String() string {
 	return b.Path
 }
 
+// renderBuild renders the chart at Build.Path if BuildOptions.Render is
+// set, recording the path of the result on the Build. When the render
+// fails, the Build is marked incomplete and any newly written chart is
+// removed.
+func renderBuild(b *Build, opts BuildOptions) error {
+	if opts.Render == nil || b == nil || b.Path == "" {
+		return nil
+	}
+	p, err := renderToPath(b.Path, *opts.Render)
+	if err != nil {
+		if b.Path != opts.CachedChart {
+			_ = os.Remove(b.Path)
+		}
+		b.Path = ""
+		return err
+	}
+	b.RenderedPath = p
+	return nil
+}
+
+// packageModTime is the modification time set on all files in a packaged
+// chart, to produce identical archives for identical charts.
+var packageModTime = time.Unix(0, 0)
+
 // packageToPath attempts to package the given chart to the out filepath.
+// The files and dependencies of the chart are sorted, and the modification
+// times in the archive are normalized, so that packaging the same chart
+// results in a byte-identical archive.
 func packageToPath(chart *helmchart.Chart, out string) error {
 	o, err := os.MkdirTemp("", "chart-build-*")
 	if err != nil {
@@ -215,12 +257,100 @@ func packageToPath(chart *helmchart.Chart, out string) error {
 	}
 	defer os.RemoveAll(o)
 
+	sortChart(chart)
 	p, err := chartutil.Save(chart, o)
 	if err != nil {
 		return fmt.Errorf("failed to package chart: %w", err)
 	}
-	if err = fs.RenameWithFallback(p, out); err != nil {
+	n := filepath.Join(o, "normalized.tgz")
+	if err = normalizeArchive(p, n); err != nil {
+		return fmt.Errorf("failed to package chart: %w", err)
+	}
+	if err = fs.RenameWithFallback(n, out); err != nil {
 		return fmt.Errorf("failed to write chart to file: %w", err)
 	}
 	return nil
 }
+
+// sortChart sorts the templates, files and dependencies of the chart and
+// its dependencies by name, as their order depends on the order in which
+// they were loaded or added.
+func sortChart(chart *helmchart.Chart) {
+	sortFiles := func(files []*helmchart.File) {
+		sort.SliceStable(files, func(i, j int) bool {
+			return files[i].Name < files[j].Name
+		})
+	}
+	sortFiles(chart.Templates)
+	sortFiles(chart.Files)
+
+	deps := chart.Dependencies()
+	sort.SliceStable(deps, func(i, j int) bool {
+		if deps[i].Name() != deps[j].Name() {
+			return deps[i].Name() < deps[j].Name()
+		}
+		return deps[i].Metadata.Version < deps[j].Metadata.Version
+	})
+	for _, dep := range deps {
+		sortChart(dep)
+	}
+	chart.SetDependencies(deps...)
+}
+
+// normalizeArchive copies the gzipped tar archive at src to dst, while
+// setting the modification time of all entries to packageModTime.
+func normalizeArchive(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	zr, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := f.Close(); err == nil {
+			err = cErr
+		}
+	}()
+
+	zw := gzip.NewWriter(f)
+	zw.Header.Extra = zr.Header.Extra
+	zw.Header.Comment = zr.Header.Comment
+	tw := tar.NewWriter(zw)
+
+	tr := tar.NewReader(zr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err = tw.WriteHeader(&tar.Header{
+			Typeflag: h.Typeflag,
+			Name:     h.Name,
+			Mode:     h.Mode,
+			Size:     h.Size,
+			ModTime:  packageModTime,
+		}); err != nil {
+			return err
+		}
+		if _, err = io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
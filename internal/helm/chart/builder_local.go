@@ -128,6 +128,9 @@ func (b *localChartBuilder) Build(ctx context.Context, ref Reference, p string,
 					}
 					result.Packaged = requiresPackaging
 
+					if err = renderBuild(result, opts); err != nil {
+						return result, err
+					}
 					return result, nil
 				}
 			}
@@ -141,6 +144,9 @@ func (b *localChartBuilder) Build(ctx context.Context, ref Reference, p string,
 			return result, &BuildError{Reason: ErrChartPull, Err: err}
 		}
 		result.Path = p
+		if err = renderBuild(result, opts); err != nil {
+			return result, err
+		}
 		return result, nil
 	}
 
@@ -191,6 +197,9 @@ func (b *localChartBuilder) Build(ctx context.Context, ref Reference, p string,
 	}
 	result.Path = p
 	result.Packaged = requiresPackaging
+	if err = renderBuild(result, opts); err != nil {
+		return result, err
+	}
 	return result, nil
 }
 
@@ -279,6 +279,47 @@ func TestLocalBuilder_Build_CachedChart(t *testing.T) {
 	g.Expect(cb.Path).To(Equal(targetPath2))
 }
 
+func TestLocalBuilder_Build_Render(t *testing.T) {
+	g := NewWithT(t)
+
+	workDir := t.TempDir()
+	testChartPath := "./../testdata/charts/helmchart"
+	g.Expect(copy.Copy(testChartPath, filepath.Join(workDir, "testdata", "charts", filepath.Base("helmchart")))).ToNot(HaveOccurred())
+
+	b := NewLocalBuilder(NewDependencyManager())
+	reference := LocalReference{WorkDir: workDir, Path: testChartPath}
+	tmpDir := t.TempDir()
+
+	// Build without render options.
+	targetPath := filepath.Join(tmpDir, "chart1.tgz")
+	cb, err := b.Build(context.TODO(), reference, targetPath, BuildOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cb.RenderedPath).To(BeEmpty())
+
+	// Build from cache with render options.
+	buildOpts := BuildOptions{
+		CachedChart: cb.Path,
+		Render:      &RenderOptions{KubeVersion: "v1.29.0"},
+	}
+	cb, err = b.Build(context.TODO(), reference, filepath.Join(tmpDir, "chart2.tgz"), buildOpts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cb.Path).To(Equal(targetPath))
+	g.Expect(cb.RenderedPath).To(BeARegularFile())
+	t.Cleanup(func() { _ = os.Remove(cb.RenderedPath) })
+
+	// Build with a render failure.
+	buildOpts = BuildOptions{
+		Force:  true,
+		Render: &RenderOptions{KubeVersion: "invalid"},
+	}
+	targetPath3 := filepath.Join(tmpDir, "chart3.tgz")
+	cb, err = b.Build(context.TODO(), reference, targetPath3, buildOpts)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err).To(MatchError(ErrChartRender))
+	g.Expect(cb.Complete()).To(BeFalse())
+	g.Expect(targetPath3).ToNot(BeAnExistingFile())
+}
+
 func Test_mergeFileValues(t *testing.T) {
 	tests := []struct {
 		name          string
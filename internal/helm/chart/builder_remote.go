@@ -80,6 +80,9 @@ func (b *remoteChartBuilder) Build(ctx context.Context, ref Reference, p string,
 		return nil, err
 	}
 	if res == nil {
+		if err = renderBuild(result, opts); err != nil {
+			return result, err
+		}
 		return result, nil
 	}
 
@@ -92,6 +95,9 @@ func (b *remoteChartBuilder) Build(ctx context.Context, ref Reference, p string,
 			return nil, &BuildError{Reason: ErrChartPull, Err: err}
 		}
 		result.Path = p
+		if err = renderBuild(result, opts); err != nil {
+			return result, err
+		}
 		return result, nil
 	}
 
@@ -122,6 +128,9 @@ func (b *remoteChartBuilder) Build(ctx context.Context, ref Reference, p string,
 	}
 	result.Path = p
 	result.Packaged = true
+	if err = renderBuild(result, opts); err != nil {
+		return result, err
+	}
 	return result, nil
 }
 
@@ -158,6 +167,12 @@ func (b *remoteChartBuilder) downloadFromRepository(ctx context.Context, remote
 
 	result.VerifiedResult = verifiedResult
 
+	// Record the latest version available in the repository, failing to
+	// determine it does not fail the build
+	if latest, err := remote.GetLatestChartVersion(remoteRef.Name); err == nil {
+		result.LatestVersion = latest.Version
+	}
+
 	if shouldReturn {
 		return nil, result, nil
 	}
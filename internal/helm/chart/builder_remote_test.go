@@ -211,6 +211,7 @@ entries:
 			g.Expect(err).ToNot(HaveOccurred())
 			g.Expect(cb.Packaged).To(Equal(tt.wantPackaged), "unexpected Build.Packaged value")
 			g.Expect(cb.Path).ToNot(BeEmpty(), "empty Build.Path")
+			g.Expect(cb.LatestVersion).To(Equal("6.17.4"), "unexpected Build.LatestVersion value")
 
 			// Load the resulting chart and verify the values.
 			resultChart, err := secureloader.LoadFile(cb.Path)
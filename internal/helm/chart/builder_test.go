@@ -17,13 +17,19 @@ limitations under the License.
 package chart
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	helmchart "helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
 
 	"github.com/fluxcd/source-controller/internal/helm/chart/secureloader"
@@ -257,6 +263,58 @@ func Test_packageToPath(t *testing.T) {
 	g.Expect(err).ToNot(HaveOccurred())
 }
 
+func Test_packageToPath_deterministic(t *testing.T) {
+	g := NewWithT(t)
+
+	reverse := func(files []*helmchart.File) {
+		for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+			files[i], files[j] = files[j], files[i]
+		}
+	}
+
+	var digests []string
+	for i := 0; i < 2; i++ {
+		chart, err := secureloader.LoadFile("../testdata/charts/helmchartwithdeps-v1-0.3.0.tgz")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(chart.Dependencies()).ToNot(BeEmpty())
+		if i > 0 {
+			// Simulate a different load and dependency resolution order.
+			reverse(chart.Templates)
+			reverse(chart.Files)
+			deps := chart.Dependencies()
+			chart.SetDependencies(append([]*helmchart.Chart{deps[len(deps)-1]}, deps[:len(deps)-1]...)...)
+		}
+
+		out := tmpFile("chart-0.3.0", ".tgz")
+		defer os.RemoveAll(out)
+		g.Expect(packageToPath(chart, out)).To(Succeed())
+
+		f, err := os.Open(out)
+		g.Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+		zr, err := gzip.NewReader(f)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(zr.Header.Comment).To(Equal("Helm"))
+		tr := tar.NewReader(zr)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(h.ModTime.Equal(packageModTime)).To(BeTrue(), h.Name)
+		}
+
+		b, err := os.ReadFile(out)
+		g.Expect(err).ToNot(HaveOccurred())
+		digests = append(digests, fmt.Sprintf("%x", sha256.Sum256(b)))
+
+		_, err = secureloader.LoadFile(out)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	g.Expect(digests[0]).To(Equal(digests[1]))
+}
+
 func tmpFile(prefix, suffix string) string {
 	randBytes := make([]byte, 16)
 	rand.Read(randBytes)
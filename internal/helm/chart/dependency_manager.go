@@ -18,10 +18,14 @@ package chart
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 
@@ -30,6 +34,7 @@ import (
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 	helmchart "helm.sh/helm/v3/pkg/chart"
+	helmreg "helm.sh/helm/v3/pkg/registry"
 	"k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/fluxcd/source-controller/internal/helm/chart/secureloader"
@@ -40,6 +45,24 @@ import (
 // URL or an error describing why it could not be returned.
 type GetChartDownloaderCallback func(url string) (repository.Downloader, error)
 
+// DependencyMode defines how a DependencyManager resolves the dependencies
+// of a chart.
+type DependencyMode string
+
+const (
+	// DependencyModeResolve resolves missing dependencies from their
+	// repository, using the Chart.lock versions if present.
+	DependencyModeResolve DependencyMode = "Resolve"
+	// DependencyModeLocked requires the chart to have a Chart.lock which is
+	// in sync with the chart dependencies, and all dependencies to match the
+	// locked version and (if available) digest.
+	DependencyModeLocked DependencyMode = "Locked"
+	// DependencyModeOffline only allows dependencies which are vendored in
+	// the charts/ directory or referenced by a local path, and does not
+	// make any network calls.
+	DependencyModeOffline DependencyMode = "Offline"
+)
+
 // DependencyManager manages dependencies for a Helm chart.
 type DependencyManager struct {
 	// downloaders contains a map of Downloader objects
@@ -56,6 +79,10 @@ type DependencyManager struct {
 	// Build. Defaults to 1 (non-concurrent).
 	concurrent int64
 
+	// mode is the DependencyMode used during Build.
+	// Defaults to DependencyModeResolve.
+	mode DependencyMode
+
 	// mu contains the lock for chart writes.
 	mu sync.Mutex
 }
@@ -83,6 +110,12 @@ func (o WithConcurrent) applyToDependencyManager(dm *DependencyManager) {
 	dm.concurrent = int64(o)
 }
 
+type WithDependencyMode DependencyMode
+
+func (o WithDependencyMode) applyToDependencyManager(dm *DependencyManager) {
+	dm.mode = DependencyMode(o)
+}
+
 // NewDependencyManager returns a new DependencyManager configured with the given
 // DependencyManagerOption list.
 func NewDependencyManager(opts ...DependencyManagerOption) *DependencyManager {
@@ -114,6 +147,11 @@ func (dm *DependencyManager) Build(ctx context.Context, ref Reference, chart *he
 		deps = chart.Dependencies()
 		reqs = chart.Metadata.Dependencies
 	)
+	if dm.mode == DependencyModeLocked {
+		if err := verifyLock(chart); err != nil {
+			return 0, err
+		}
+	}
 	// Lock file takes precedence
 	if lock := chart.Lock; lock != nil {
 		reqs = lock.Dependencies
@@ -125,6 +163,15 @@ func (dm *DependencyManager) Build(ctx context.Context, ref Reference, chart *he
 		return 0, nil
 	}
 
+	if dm.mode == DependencyModeOffline {
+		for name, dep := range missing {
+			if !isLocalDep(dep) {
+				return 0, fmt.Errorf("dependency '%s' from '%s' is not vendored in the chart and can not be resolved offline",
+					name, dep.Repository)
+			}
+		}
+	}
+
 	// Run the build for the missing dependencies
 	if err := dm.build(ctx, ref, chart, missing); err != nil {
 		return 0, err
@@ -245,10 +292,20 @@ func (dm *DependencyManager) addRemoteDependency(chart *chartWithLock, dep *helm
 	if err != nil {
 		return fmt.Errorf("chart download of version '%s' failed: %w", ver.Version, err)
 	}
+	if dm.mode == DependencyModeLocked && ver.Digest != "" && !helmreg.IsOCI(dep.Repository) {
+		sum := sha256.Sum256(res.Bytes())
+		if d := hex.EncodeToString(sum[:]); d != ver.Digest {
+			return fmt.Errorf("digest '%s' of chart version '%s' does not match repository index digest '%s'",
+				d, ver.Version, ver.Digest)
+		}
+	}
 	ch, err := secureloader.LoadArchive(res)
 	if err != nil {
 		return fmt.Errorf("failed to load downloaded archive of version '%s': %w", ver.Version, err)
 	}
+	if dm.mode == DependencyModeLocked && ch.Metadata.Version != dep.Version {
+		return fmt.Errorf("downloaded chart version '%s' does not match locked version '%s'", ch.Metadata.Version, dep.Version)
+	}
 
 	if dep.Alias != "" {
 		ch.Metadata.Name = dep.Alias
@@ -306,6 +363,94 @@ func (dm *DependencyManager) secureLocalChartPath(ref LocalReference, dep *helmc
 	return securejoin.SecureJoin(ref.WorkDir, filepath.Join(ref.Path, localUrl.Host, localUrl.Path))
 }
 
+// verifyLock confirms the chart.Chart has a lock which is in sync with the
+// dependencies of the chart, and that all vendored dependencies match the
+// locked version. It returns an error describing the mismatch otherwise.
+func verifyLock(chart *helmchart.Chart) error {
+	lock := chart.Lock
+	if lock == nil {
+		if len(chart.Metadata.Dependencies) == 0 {
+			return nil
+		}
+		return fmt.Errorf("chart '%s' has dependencies but no lock file", chart.Name())
+	}
+
+	reqs := resolveRepoNames(chart.Metadata.Dependencies, lock.Dependencies)
+	sum, err := hashReq(reqs, lock.Dependencies)
+	if err != nil {
+		return fmt.Errorf("failed to calculate lock digest: %w", err)
+	}
+	if sum != lock.Digest {
+		// The lock of a chart with apiVersion v1 may have been written by
+		// Helm 2, which calculated the digest differently.
+		// Ref: https://github.com/helm/helm/issues/7233
+		v2Sum, err := hashV2Req(reqs)
+		if err != nil || chart.Metadata.APIVersion != helmchart.APIVersionV1 || v2Sum != lock.Digest {
+			return fmt.Errorf("lock file of chart '%s' is out of sync with the dependencies: expected digest '%s', got '%s'",
+				chart.Name(), sum, lock.Digest)
+		}
+	}
+
+	// Index the locked versions by the name of the chart, as vendored
+	// dependencies are named after the chart and not after their alias.
+	locked := make(map[string][]string, len(lock.Dependencies))
+	for _, dep := range lock.Dependencies {
+		locked[dep.Name] = append(locked[dep.Name], dep.Version)
+	}
+	for _, existing := range chart.Dependencies() {
+		versions, ok := locked[existing.Name()]
+		if ok && !slices.Contains(versions, existing.Metadata.Version) {
+			return fmt.Errorf("vendored dependency '%s' version '%s' does not match locked version '%s'",
+				existing.Name(), existing.Metadata.Version, strings.Join(versions, "', '"))
+		}
+	}
+	return nil
+}
+
+// resolveRepoNames returns a copy of the given requirements, with the
+// repository names ('@<name>' or 'alias:<name>') replaced by the URL of the
+// dependency in the lock. This mirrors Helm, which replaces the repository
+// names with the URLs from its repository configuration before calculating
+// the lock digest.
+func resolveRepoNames(reqs, lock []*helmchart.Dependency) []*helmchart.Dependency {
+	resolved := make([]*helmchart.Dependency, 0, len(reqs))
+	for i, req := range reqs {
+		if !strings.HasPrefix(req.Repository, "@") && !strings.HasPrefix(req.Repository, "alias:") {
+			resolved = append(resolved, req)
+			continue
+		}
+		r := *req
+		// Helm locks the dependencies in the order of the requirements.
+		if i < len(lock) && lock[i].Name == req.Name {
+			r.Repository = lock[i].Repository
+		}
+		resolved = append(resolved, &r)
+	}
+	return resolved
+}
+
+// hashReq returns the digest of the given requirements and lock
+// dependencies, as calculated by Helm for the Chart.lock digest.
+func hashReq(req, lock []*helmchart.Dependency) (string, error) {
+	data, err := json.Marshal([2][]*helmchart.Dependency{req, lock})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// hashV2Req returns the digest of the given requirements, as calculated by
+// Helm 2 for the requirements.lock digest.
+func hashV2Req(req []*helmchart.Dependency) (string, error) {
+	data, err := json.Marshal(map[string][]*helmchart.Dependency{"dependencies": req})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
 // collectMissing returns a map with dependencies from reqs that are missing
 // from current, indexed by their alias or name. All dependencies of a chart
 // are present if len of returned map == 0.
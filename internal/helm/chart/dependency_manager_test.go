@@ -19,6 +19,8 @@ package chart
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/url"
@@ -29,6 +31,7 @@ import (
 
 	. "github.com/onsi/gomega"
 	helmchart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
 	helmgetter "helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/repo"
@@ -251,6 +254,177 @@ func build(t *testing.T, mockRepo repository.Downloader) {
 	}
 }
 
+func TestDependencyManager_Build_Mode(t *testing.T) {
+	g := NewWithT(t)
+
+	// Package a chart matching the locked grafana dependency
+	grafana, err := secureloader.Load("./../testdata/charts", "helmchart")
+	g.Expect(err).ToNot(HaveOccurred())
+	grafana.Metadata.Name = "grafana"
+	grafana.Metadata.Version = "6.17.4"
+	p, err := chartutil.Save(grafana, t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+	chartGrafana, err := os.ReadFile(p)
+	g.Expect(err).ToNot(HaveOccurred())
+	sum := sha256.Sum256(chartGrafana)
+
+	chartOther, err := os.ReadFile("./../testdata/charts/helmchart-0.1.0.tgz")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	mockRepo := func(res []byte, digest string) repository.Downloader {
+		return &repository.ChartRepository{
+			Client: &mockGetter{
+				Response: res,
+			},
+			Index: &repo.IndexFile{
+				Entries: map[string]repo.ChartVersions{
+					"grafana": {
+						&repo.ChartVersion{
+							Metadata: &helmchart.Metadata{
+								Name:    "grafana",
+								Version: "6.17.4",
+							},
+							URLs:   []string{"https://example.com/grafana.tgz"},
+							Digest: digest,
+						},
+					},
+				},
+			},
+			RWMutex: &sync.RWMutex{},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		mode       DependencyMode
+		chartFunc  func(c *helmchart.Chart)
+		downloader repository.Downloader
+		want       int
+		wantErr    string
+	}{
+		{
+			name:       "locked build with dependencies matching lock file",
+			path:       "helmchartwithdeps",
+			mode:       DependencyModeLocked,
+			downloader: mockRepo(chartGrafana, hex.EncodeToString(sum[:])),
+			want:       2,
+		},
+		{
+			name:       "locked build without lock file",
+			path:       "helmchartwithdeps",
+			mode:       DependencyModeLocked,
+			chartFunc:  func(c *helmchart.Chart) { c.Lock = nil },
+			downloader: mockRepo(chartGrafana, ""),
+			wantErr:    "chart 'helmchartwithdeps' has dependencies but no lock file",
+		},
+		{
+			name: "locked build with lock file out of sync",
+			path: "helmchartwithdeps",
+			mode: DependencyModeLocked,
+			chartFunc: func(c *helmchart.Chart) {
+				c.Metadata.Dependencies[2].Version = ">=6.0.0"
+			},
+			downloader: mockRepo(chartGrafana, ""),
+			wantErr:    "lock file of chart 'helmchartwithdeps' is out of sync with the dependencies",
+		},
+		{
+			name:       "locked build with digest mismatch",
+			path:       "helmchartwithdeps",
+			mode:       DependencyModeLocked,
+			downloader: mockRepo(chartGrafana, "invalid"),
+			wantErr:    "does not match repository index digest 'invalid'",
+		},
+		{
+			name:       "locked build with version mismatch",
+			path:       "helmchartwithdeps",
+			mode:       DependencyModeLocked,
+			downloader: mockRepo(chartOther, ""),
+			wantErr:    "downloaded chart version '0.1.0' does not match locked version '6.17.4'",
+		},
+		{
+			name:       "locked build with repository name in dependencies",
+			path:       "helmchartwithdeps-alias",
+			mode:       DependencyModeLocked,
+			downloader: mockRepo(chartGrafana, hex.EncodeToString(sum[:])),
+			want:       1,
+		},
+		{
+			name: "locked build with aliased vendored dependency version mismatch",
+			path: "helmchartwithdeps-alias",
+			mode: DependencyModeLocked,
+			chartFunc: func(c *helmchart.Chart) {
+				vendored := *grafana
+				md := *grafana.Metadata
+				md.Version = "6.0.0"
+				vendored.Metadata = &md
+				c.AddDependency(&vendored)
+			},
+			downloader: mockRepo(chartGrafana, hex.EncodeToString(sum[:])),
+			wantErr:    "vendored dependency 'grafana' version '6.0.0' does not match locked version '6.17.4'",
+		},
+		{
+			name:       "locked build with lock file written by Helm 2",
+			path:       "helmchartwithdeps-v1-lock",
+			mode:       DependencyModeLocked,
+			downloader: mockRepo(chartGrafana, hex.EncodeToString(sum[:])),
+			want:       1,
+		},
+		{
+			name: "locked build without dependencies",
+			path: "helmchart",
+			mode: DependencyModeLocked,
+			want: 0,
+		},
+		{
+			name:    "offline build with missing remote dependency",
+			path:    "helmchartwithdeps",
+			mode:    DependencyModeOffline,
+			wantErr: "dependency 'grafana' from 'https://grafana.github.io/helm-charts' is not vendored in the chart",
+		},
+		{
+			name: "offline build with vendored remote dependency",
+			path: "helmchartwithdeps",
+			mode: DependencyModeOffline,
+			chartFunc: func(c *helmchart.Chart) {
+				c.AddDependency(grafana)
+			},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			chart, err := secureloader.Load("./../testdata/charts", tt.path)
+			g.Expect(err).ToNot(HaveOccurred())
+			if tt.chartFunc != nil {
+				tt.chartFunc(chart)
+			}
+
+			dm := NewDependencyManager(
+				WithRepositories(map[string]repository.Downloader{
+					"https://grafana.github.io/helm-charts/": tt.downloader,
+				}),
+				WithDependencyMode(tt.mode),
+			)
+			absBaseDir, err := filepath.Abs("./../testdata/charts")
+			g.Expect(err).ToNot(HaveOccurred())
+			got, err := dm.Build(context.TODO(), LocalReference{WorkDir: absBaseDir, Path: tt.path}, chart)
+
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(got).To(BeZero())
+				return
+			}
+
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
 func TestDependencyManager_build(t *testing.T) {
 	tests := []struct {
 		name    string
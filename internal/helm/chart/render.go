@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chart
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+
+	"github.com/fluxcd/source-controller/internal/helm/chart/secureloader"
+)
+
+// RenderOptions configures the template render of a chart performed
+// after a successful build.
+type RenderOptions struct {
+	// ReleaseName is the name of the release the chart is rendered as.
+	// Defaults to the name of the chart.
+	ReleaseName string
+	// Namespace is the namespace the chart is rendered in.
+	// Defaults to "default".
+	Namespace string
+	// KubeVersion is the Kubernetes version used as capability during
+	// rendering. Defaults to chartutil.DefaultCapabilities when empty.
+	KubeVersion string
+	// APIVersions is a list of API versions added to the default set of
+	// capabilities during rendering.
+	APIVersions []string
+}
+
+// capabilities returns the chartutil.Capabilities for the RenderOptions,
+// or an error if the KubeVersion can not be parsed.
+func (o RenderOptions) capabilities() (*chartutil.Capabilities, error) {
+	caps := chartutil.DefaultCapabilities.Copy()
+	if o.KubeVersion != "" {
+		kv, err := chartutil.ParseKubeVersion(o.KubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kube version '%s': %w", o.KubeVersion, err)
+		}
+		caps.KubeVersion = *kv
+	}
+	if len(o.APIVersions) > 0 {
+		caps.APIVersions = append(caps.APIVersions, o.APIVersions...)
+	}
+	return caps, nil
+}
+
+// RenderChart renders the templates of the chart with its default values
+// and the given RenderOptions. It returns the manifests, sorted in install
+// order and separated by a "# Source" header, or an error.
+func RenderChart(chart *helmchart.Chart, opts RenderOptions) ([]byte, error) {
+	caps, err := opts.capabilities()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = chartutil.ProcessDependenciesWithMerge(chart, chart.Values); err != nil {
+		return nil, fmt.Errorf("failed to process chart dependencies: %w", err)
+	}
+	if kv := chart.Metadata.KubeVersion; kv != "" && !chartutil.IsCompatibleRange(kv, caps.KubeVersion.String()) {
+		return nil, fmt.Errorf("chart requires kubeVersion '%s' which is incompatible with Kubernetes %s",
+			kv, caps.KubeVersion.String())
+	}
+
+	releaseOpts := chartutil.ReleaseOptions{
+		Name:      opts.ReleaseName,
+		Namespace: opts.Namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	if releaseOpts.Name == "" {
+		releaseOpts.Name = chart.Name()
+	}
+	if releaseOpts.Namespace == "" {
+		releaseOpts.Namespace = "default"
+	}
+	values, err := chartutil.ToRenderValues(chart, chart.Values, releaseOpts, caps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose render values: %w", err)
+	}
+
+	files, err := engine.Render(chart, values)
+	if err != nil {
+		return nil, err
+	}
+	// Remove the NOTES.txt files, as they are not part of the manifests
+	for k := range files {
+		if strings.HasSuffix(k, "NOTES.txt") {
+			delete(files, k)
+		}
+	}
+
+	hooks, manifests, err := releaseutil.SortManifests(files, caps.APIVersions, releaseutil.InstallOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rendered manifests: %w", err)
+	}
+
+	var b strings.Builder
+	for _, m := range manifests {
+		fmt.Fprintf(&b, "---\n# Source: %s\n%s\n", m.Name, m.Content)
+	}
+	for _, h := range hooks {
+		fmt.Fprintf(&b, "---\n# Source: %s\n%s\n", h.Path, h.Manifest)
+	}
+	return []byte(b.String()), nil
+}
+
+// renderToPath loads the packaged chart from chartPath, renders it with
+// the given RenderOptions and writes the result to a new temporary file.
+// It returns the path to the written file, or a BuildError.
+func renderToPath(chartPath string, opts RenderOptions) (string, error) {
+	chart, err := secureloader.LoadFile(chartPath)
+	if err != nil {
+		err = fmt.Errorf("failed to load packaged chart for render: %w", err)
+		return "", &BuildError{Reason: ErrChartRender, Err: err}
+	}
+	b, err := RenderChart(chart, opts)
+	if err != nil {
+		err = fmt.Errorf("failed to render chart '%s': %w", chart.Name(), err)
+		return "", &BuildError{Reason: ErrChartRender, Err: err}
+	}
+
+	f, err := os.CreateTemp("", strings.TrimSuffix(path.Base(chartPath), ".tgz")+"-*.yaml")
+	if err != nil {
+		err = fmt.Errorf("failed to create temporary file for rendered chart: %w", err)
+		return "", &BuildError{Reason: ErrChartRender, Err: err}
+	}
+	if _, err = f.Write(b); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		err = fmt.Errorf("failed to write rendered chart: %w", err)
+		return "", &BuildError{Reason: ErrChartRender, Err: err}
+	}
+	if err = f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", &BuildError{Reason: ErrChartRender, Err: err}
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chart
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+
+	"github.com/fluxcd/source-controller/internal/helm/chart/secureloader"
+)
+
+func TestRenderChart(t *testing.T) {
+	capsChart := func(kubeVersion string) *helmchart.Chart {
+		return &helmchart.Chart{
+			Metadata: &helmchart.Metadata{
+				APIVersion:  helmchart.APIVersionV2,
+				Name:        "caps",
+				Version:     "0.1.0",
+				KubeVersion: kubeVersion,
+			},
+			Templates: []*helmchart.File{
+				{
+					Name: "templates/configmap.yaml",
+					Data: []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}
+data:
+  kubeVersion: {{ .Capabilities.KubeVersion.Version | quote }}
+  monitoring: {{ .Capabilities.APIVersions.Has "monitoring.coreos.com/v1" | quote }}
+`),
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		chart   func() *helmchart.Chart
+		opts    RenderOptions
+		want    []string
+		wantNot []string
+		wantErr string
+	}{
+		{
+			name: "renders chart with default capabilities",
+			chart: func() *helmchart.Chart {
+				c, err := secureloader.Load("./../testdata/charts", "helmchart")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return c
+			},
+			want: []string{
+				"# Source: helmchart/templates/deployment.yaml",
+				"kind: Deployment",
+				"# Source: helmchart/templates/tests/test-connection.yaml",
+			},
+			wantNot: []string{"NOTES.txt"},
+		},
+		{
+			name:  "renders chart with given capabilities",
+			chart: func() *helmchart.Chart { return capsChart("") },
+			opts: RenderOptions{
+				ReleaseName: "release",
+				KubeVersion: "v1.29.3",
+				APIVersions: []string{"monitoring.coreos.com/v1"},
+			},
+			want: []string{
+				"name: release",
+				`kubeVersion: "v1.29.3"`,
+				`monitoring: "true"`,
+			},
+		},
+		{
+			name:  "renders chart without additional API versions",
+			chart: func() *helmchart.Chart { return capsChart("") },
+			want: []string{
+				"name: caps",
+				`monitoring: "false"`,
+			},
+		},
+		{
+			name:    "invalid kube version",
+			chart:   func() *helmchart.Chart { return capsChart("") },
+			opts:    RenderOptions{KubeVersion: "invalid"},
+			wantErr: "invalid kube version 'invalid'",
+		},
+		{
+			name:    "incompatible kube version",
+			chart:   func() *helmchart.Chart { return capsChart(">=1.30.0-0") },
+			opts:    RenderOptions{KubeVersion: "v1.29.0"},
+			wantErr: "chart requires kubeVersion '>=1.30.0-0' which is incompatible with Kubernetes v1.29.0",
+		},
+		{
+			name: "template error",
+			chart: func() *helmchart.Chart {
+				c := capsChart("")
+				c.Templates = append(c.Templates, &helmchart.File{
+					Name: "templates/broken.yaml",
+					Data: []byte(`{{ required "value is required" .Values.missing }}`),
+				})
+				return c
+			},
+			wantErr: "value is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := RenderChart(tt.chart(), tt.opts)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(got).To(BeNil())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			for _, w := range tt.want {
+				g.Expect(string(got)).To(ContainSubstring(w))
+			}
+			for _, w := range tt.wantNot {
+				g.Expect(string(got)).ToNot(ContainSubstring(w))
+			}
+		})
+	}
+}
+
+func Test_renderToPath(t *testing.T) {
+	g := NewWithT(t)
+
+	p, err := renderToPath("./../testdata/charts/helmchart-0.1.0.tgz", RenderOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() { _ = os.Remove(p) })
+	g.Expect(p).To(BeARegularFile())
+	b, err := os.ReadFile(p)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(b)).To(ContainSubstring("kind: Deployment"))
+
+	p, err = renderToPath("./../testdata/charts/empty.tgz", RenderOptions{})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errors.Is(err, ErrChartRender)).To(BeTrue())
+	g.Expect(p).To(BeEmpty())
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	helmgetter "helm.sh/helm/v3/pkg/getter"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	bucketv1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/fluxcd/source-controller/pkg/gcp"
+	"github.com/fluxcd/source-controller/pkg/minio"
+)
+
+const (
+	// S3Scheme is the URL scheme of Helm repositories hosted in an Amazon
+	// S3 (compatible) bucket.
+	S3Scheme = "s3"
+	// GCSScheme is the URL scheme of Helm repositories hosted in a Google
+	// Cloud Storage bucket.
+	GCSScheme = "gs"
+
+	// defaultS3Endpoint is the endpoint of the S3 API used for s3:// URLs.
+	defaultS3Endpoint = "s3.amazonaws.com"
+)
+
+// BucketClient is the subset of the object storage bucket clients in pkg
+// used to fetch the index and chart tarballs of a Helm repository.
+type BucketClient interface {
+	// FGetObject gets the object from the provided object storage bucket, and
+	// writes it to targetPath.
+	FGetObject(ctx context.Context, bucketName, objectKey, targetPath string) (etag string, err error)
+	// ObjectIsNotFound returns true if the given error indicates an object
+	// could not be found.
+	ObjectIsNotFound(error) bool
+	// Close closes the client, if supported.
+	Close(context.Context)
+}
+
+// BucketGetter is a helmgetter.Getter for Helm repositories hosted in an
+// object storage bucket, with URLs of the form
+// '<scheme>://<bucket name>/<object key>'.
+type BucketGetter struct {
+	// newClient constructs the BucketClient for a Get. A client is
+	// constructed for every Get, and closed after it.
+	newClient func(ctx context.Context) (BucketClient, error)
+	// timeout is the timeout of a Get.
+	timeout time.Duration
+}
+
+// Get fetches the object at the given URL from the bucket. The options are
+// ignored, as the credentials of the getter are configured on construction.
+func (g *BucketGetter) Get(u string, _ ...helmgetter.Option) (*bytes.Buffer, error) {
+	bucketName, objectKey, err := parseBucketURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct bucket client: %w", err)
+	}
+	defer client.Close(ctx)
+
+	dir, err := os.MkdirTemp("", "helm-bucket-")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "object")
+	if _, err = client.FGetObject(ctx, bucketName, objectKey, path); err != nil {
+		if client.ObjectIsNotFound(err) {
+			return nil, fmt.Errorf("failed to fetch %s : object not found", u)
+		}
+		return nil, fmt.Errorf("failed to fetch %s : %w", u, err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(b), nil
+}
+
+// newBucketProvider returns a helmgetter.Provider for the bucket scheme of
+// the given URL, configured with the credentials from the given Secret and
+// TLS config. It returns nil if the URL is not a bucket URL.
+// Without a Secret, the S3 client uses the IAM credentials of the
+// controller if the provider of the HelmRepository is 'aws', and the GCS
+// client uses the Application Default Credentials if it is 'gcp'. Otherwise,
+// the bucket is accessed anonymously.
+func newBucketProvider(obj *sourcev1.HelmRepository, u string, secret *corev1.Secret, tlsConfig *tls.Config) (*helmgetter.Provider, error) {
+	var newClient func(ctx context.Context) (BucketClient, error)
+	scheme, _, _ := strings.Cut(u, "://")
+	switch scheme {
+	case S3Scheme:
+		if err := minio.ValidateSecret(secret); err != nil {
+			return nil, err
+		}
+		bucket := &bucketv1.Bucket{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+			},
+			Spec: bucketv1.BucketSpec{
+				Provider: bucketv1.GenericBucketProvider,
+				Endpoint: defaultS3Endpoint,
+			},
+		}
+		if allowsAmbientCredentials(obj, bucketv1.AmazonBucketProvider) {
+			bucket.Spec.Provider = bucketv1.AmazonBucketProvider
+		}
+		newClient = func(_ context.Context) (BucketClient, error) {
+			return minio.NewClient(bucket, secret, tlsConfig)
+		}
+	case GCSScheme:
+		if err := gcp.ValidateSecret(secret); err != nil {
+			return nil, err
+		}
+		newClient = func(ctx context.Context) (BucketClient, error) {
+			if secret == nil && !allowsAmbientCredentials(obj, bucketv1.GoogleBucketProvider) {
+				return gcp.NewAnonymousClient(ctx)
+			}
+			return gcp.NewClient(ctx, secret)
+		}
+	default:
+		return nil, nil
+	}
+
+	return &helmgetter.Provider{
+		Schemes: []string{scheme},
+		New: func(_ ...helmgetter.Option) (helmgetter.Getter, error) {
+			return &BucketGetter{newClient: newClient, timeout: obj.GetTimeout()}, nil
+		},
+	}, nil
+}
+
+// allowsAmbientCredentials returns true if the bucket client for the given
+// HelmRepository may use the credentials of the controller for the given
+// provider. This requires the provider to be set on a HelmRepository object,
+// so that the ad-hoc repositories of chart dependencies, which are unnamed
+// shims, never use them.
+func allowsAmbientCredentials(obj *sourcev1.HelmRepository, provider string) bool {
+	return obj.GetName() != "" && obj.Spec.Provider == provider
+}
+
+// parseBucketURL returns the bucket name and object key of the given
+// bucket URL.
+func parseBucketURL(u string) (string, string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", "", err
+	}
+	objectKey := strings.TrimPrefix(parsed.Path, "/")
+	if parsed.Host == "" || objectKey == "" {
+		return "", "", fmt.Errorf("invalid bucket URL '%s': expected '%s://<bucket name>/<object key>'", u, parsed.Scheme)
+	}
+	return parsed.Host, objectKey, nil
+}
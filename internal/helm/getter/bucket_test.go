@@ -0,0 +1,245 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	. "github.com/onsi/gomega"
+	helmgetter "helm.sh/helm/v3/pkg/getter"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	helmv1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+var errNotFound = errors.New("not found")
+
+// mockBucketClient is a BucketClient serving the objects of a single bucket.
+type mockBucketClient struct {
+	bucketName string
+	objects    map[string][]byte
+	closed     bool
+}
+
+func (c *mockBucketClient) FGetObject(_ context.Context, bucketName, objectKey, targetPath string) (string, error) {
+	if bucketName != c.bucketName {
+		return "", errors.New("bucket does not exist")
+	}
+	b, ok := c.objects[objectKey]
+	if !ok {
+		return "", errNotFound
+	}
+	return "etag", os.WriteFile(targetPath, b, 0o600)
+}
+
+func (c *mockBucketClient) ObjectIsNotFound(err error) bool {
+	return errors.Is(err, errNotFound)
+}
+
+func (c *mockBucketClient) Close(_ context.Context) {
+	c.closed = true
+}
+
+func TestBucketGetter_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "fetches object",
+			url:  "s3://charts/stable/index.yaml",
+			want: "index",
+		},
+		{
+			name:    "object not found",
+			url:     "s3://charts/stable/podinfo-1.0.0.tgz",
+			wantErr: "failed to fetch s3://charts/stable/podinfo-1.0.0.tgz : object not found",
+		},
+		{
+			name:    "bucket does not exist",
+			url:     "s3://other/stable/index.yaml",
+			wantErr: "bucket does not exist",
+		},
+		{
+			name:    "URL without object key",
+			url:     "gs://charts",
+			wantErr: "invalid bucket URL 'gs://charts'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			client := &mockBucketClient{
+				bucketName: "charts",
+				objects: map[string][]byte{
+					"stable/index.yaml": []byte("index"),
+				},
+			}
+			getter := &BucketGetter{
+				newClient: func(_ context.Context) (BucketClient, error) {
+					return client, nil
+				},
+				timeout: time.Second,
+			}
+
+			res, err := getter.Get(tt.url)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(res.String()).To(Equal(tt.want))
+			g.Expect(client.closed).To(BeTrue())
+		})
+	}
+}
+
+func TestGetClientOpts_bucketProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		secret     *corev1.Secret
+		wantScheme string
+		wantErr    string
+	}{
+		{
+			name:       "S3 bucket with static credentials",
+			url:        "s3://charts/stable/",
+			secret:     &corev1.Secret{Data: map[string][]byte{"accesskey": []byte("key"), "secretkey": []byte("secret")}},
+			wantScheme: S3Scheme,
+		},
+		{
+			name:       "S3 bucket without credentials",
+			url:        "s3://charts/stable/",
+			wantScheme: S3Scheme,
+		},
+		{
+			name:    "S3 bucket with invalid credentials",
+			url:     "s3://charts/stable/",
+			secret:  &corev1.Secret{Data: map[string][]byte{"accesskey": []byte("key")}},
+			wantErr: "required fields 'accesskey' and 'secretkey'",
+		},
+		{
+			name:       "GCS bucket with service account",
+			url:        "gs://charts/stable/",
+			secret:     &corev1.Secret{Data: map[string][]byte{"serviceaccount": []byte("{}")}},
+			wantScheme: GCSScheme,
+		},
+		{
+			name:    "GCS bucket with invalid credentials",
+			url:     "gs://charts/stable/",
+			secret:  &corev1.Secret{Data: map[string][]byte{"accesskey": []byte("key")}},
+			wantErr: "required fields 'serviceaccount'",
+		},
+		{
+			name: "HTTP/S repository",
+			url:  "https://example.com/charts/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			helmRepo := &helmv1.HelmRepository{
+				Spec: helmv1.HelmRepositorySpec{
+					URL: tt.url,
+				},
+			}
+			clientBuilder := fakeclient.NewClientBuilder()
+			if tt.secret != nil {
+				tt.secret.Name = "auth"
+				clientBuilder.WithObjects(tt.secret)
+				helmRepo.Spec.SecretRef = &meta.LocalObjectReference{Name: tt.secret.Name}
+			}
+
+			clientOpts, _, err := GetClientOpts(context.TODO(), clientBuilder.Build(), helmRepo, tt.url)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			providers := helmgetter.Providers{{Schemes: []string{"https"}, New: helmgetter.NewHTTPGetter}}
+			if tt.wantScheme == "" {
+				g.Expect(clientOpts.BucketProvider).To(BeNil())
+				g.Expect(clientOpts.Getters(providers)).To(HaveLen(1))
+				return
+			}
+			g.Expect(clientOpts.BucketProvider).ToNot(BeNil())
+			g.Expect(clientOpts.BucketProvider.Schemes).To(Equal([]string{tt.wantScheme}))
+
+			getter, err := clientOpts.Getters(providers).ByScheme(tt.wantScheme)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(getter).To(BeAssignableToTypeOf(&BucketGetter{}))
+		})
+	}
+}
+
+func Test_allowsAmbientCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		repoName string
+		provider string
+		want     bool
+	}{
+		{
+			name:     "HelmRepository with provider",
+			repoName: "charts",
+			provider: "gcp",
+			want:     true,
+		},
+		{
+			name:     "HelmRepository with other provider",
+			repoName: "charts",
+			provider: "aws",
+		},
+		{
+			name:     "HelmRepository without provider",
+			repoName: "charts",
+		},
+		{
+			name:     "dependency repository shim",
+			provider: "gcp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &helmv1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: tt.repoName},
+				Spec: helmv1.HelmRepositorySpec{
+					URL:      "gs://charts/stable/",
+					Provider: tt.provider,
+				},
+			}
+			g.Expect(allowsAmbientCredentials(obj, "gcp")).To(Equal(tt.want))
+		})
+	}
+}
@@ -56,6 +56,19 @@ type ClientOpts struct {
 	TlsConfig     *tls.Config
 	GetterOpts    []helmgetter.Option
 	Insecure      bool
+	// BucketProvider is the getter provider for Helm repositories hosted
+	// in an object storage bucket, configured with the credentials of the
+	// HelmRepository. It is nil for other Helm repositories.
+	BucketProvider *helmgetter.Provider
+}
+
+// Getters returns the given getter providers, preceded by the BucketProvider
+// if set.
+func (o ClientOpts) Getters(providers helmgetter.Providers) helmgetter.Providers {
+	if o.BucketProvider == nil {
+		return providers
+	}
+	return append(helmgetter.Providers{*o.BucketProvider}, providers...)
 }
 
 // MustLoginToRegistry returns true if the client options contain at least
@@ -170,6 +183,13 @@ func GetClientOpts(ctx context.Context, c client.Client, obj *sourcev1.HelmRepos
 			}
 		}
 	}
+	if !ociRepo {
+		hrOpts.BucketProvider, err = newBucketProvider(obj, url, authSecret, hrOpts.TlsConfig)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to configure Helm client: %w", err)
+		}
+	}
+
 	if deprecatedTLSConfig {
 		err = ErrDeprecatedTLSConfig
 	}
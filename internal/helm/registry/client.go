@@ -30,6 +30,19 @@ import (
 // The client is meant to be used for a single reconciliation.
 // The file is meant to be used for a single reconciliation and deleted after.
 func ClientGenerator(tlsConfig *tls.Config, isLogin, insecureHTTP bool) (*registry.Client, string, error) {
+	return generateClient(tlsConfig, isLogin, insecureHTTP, nil)
+}
+
+// ClientGeneratorWithTransport returns a ClientGenerator which wraps the HTTP
+// transport of the generated clients with the given function, e.g. to respect
+// the Retry-After header of the registry.
+func ClientGeneratorWithTransport(wrap func(http.RoundTripper) http.RoundTripper) func(*tls.Config, bool, bool) (*registry.Client, string, error) {
+	return func(tlsConfig *tls.Config, isLogin, insecureHTTP bool) (*registry.Client, string, error) {
+		return generateClient(tlsConfig, isLogin, insecureHTTP, wrap)
+	}
+}
+
+func generateClient(tlsConfig *tls.Config, isLogin, insecureHTTP bool, wrap func(http.RoundTripper) http.RoundTripper) (*registry.Client, string, error) {
 	if isLogin {
 		// create a temporary file to store the credentials
 		// this is needed because otherwise the credentials are stored in ~/.docker/config.json.
@@ -39,7 +52,7 @@ func ClientGenerator(tlsConfig *tls.Config, isLogin, insecureHTTP bool) (*regist
 		}
 
 		var errs []error
-		rClient, err := newClient(credentialsFile.Name(), tlsConfig, insecureHTTP)
+		rClient, err := newClient(credentialsFile.Name(), tlsConfig, insecureHTTP, wrap)
 		if err != nil {
 			errs = append(errs, err)
 			// attempt to delete the temporary file
@@ -54,25 +67,29 @@ func ClientGenerator(tlsConfig *tls.Config, isLogin, insecureHTTP bool) (*regist
 		return rClient, credentialsFile.Name(), nil
 	}
 
-	rClient, err := newClient("", tlsConfig, insecureHTTP)
+	rClient, err := newClient("", tlsConfig, insecureHTTP, wrap)
 	if err != nil {
 		return nil, "", err
 	}
 	return rClient, "", nil
 }
 
-func newClient(credentialsFile string, tlsConfig *tls.Config, insecureHTTP bool) (*registry.Client, error) {
+func newClient(credentialsFile string, tlsConfig *tls.Config, insecureHTTP bool, wrap func(http.RoundTripper) http.RoundTripper) (*registry.Client, error) {
 	opts := []registry.ClientOption{
 		registry.ClientOptWriter(io.Discard),
 	}
 	if insecureHTTP {
 		opts = append(opts, registry.ClientOptPlainHTTP())
 	}
-	if tlsConfig != nil {
+	if tlsConfig != nil || wrap != nil {
 		t := http.DefaultTransport.(*http.Transport).Clone()
 		t.TLSClientConfig = tlsConfig
+		var rt http.RoundTripper = t
+		if wrap != nil {
+			rt = wrap(t)
+		}
 		opts = append(opts, registry.ClientOptHTTPClient(&http.Client{
-			Transport: t,
+			Transport: rt,
 		}))
 	}
 	if credentialsFile != "" {
@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestClientGeneratorWithTransport(t *testing.T) {
+	tests := []struct {
+		name    string
+		isLogin bool
+	}{
+		{
+			name: "without login",
+		},
+		{
+			name:    "with login",
+			isLogin: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var wrapped []http.RoundTripper
+			generator := ClientGeneratorWithTransport(func(next http.RoundTripper) http.RoundTripper {
+				wrapped = append(wrapped, next)
+				return next
+			})
+
+			client, file, err := generator(nil, tt.isLogin, false)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(client).ToNot(BeNil())
+			if tt.isLogin {
+				g.Expect(file).ToNot(BeEmpty())
+				g.Expect(os.Remove(file)).To(Succeed())
+			} else {
+				g.Expect(file).To(BeEmpty())
+			}
+			g.Expect(wrapped).To(HaveLen(1))
+			g.Expect(wrapped[0]).To(BeAssignableToTypeOf(&http.Transport{}))
+		})
+	}
+}
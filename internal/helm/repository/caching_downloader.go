@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/repo"
+
+	"github.com/fluxcd/source-controller/internal/cache"
+)
+
+// ChartDigestResolver is implemented by a Downloader of which the chart
+// versions can be resolved to an immutable digest, such as the manifest
+// digest of an OCI chart.
+type ChartDigestResolver interface {
+	// ResolveChartDigest returns the digest the given repo.ChartVersion
+	// currently resolves to.
+	ResolveChartDigest(chart *repo.ChartVersion) (string, error)
+}
+
+// CachingDownloader is a Downloader which stores the chart tarballs
+// downloaded by the wrapped Downloader in a cache.ChartCache, and serves
+// subsequent downloads of the same chart version from the cache.
+type CachingDownloader struct {
+	Downloader
+
+	// scope identifies the owner of the repository and its credentials,
+	// and is used as part of the cache key so charts are only shared
+	// between downloads with the same access to the repository.
+	scope string
+	// cache is the cache.ChartCache to store the chart tarballs in.
+	cache *cache.ChartCache
+	// recordEvent is called with a cache.CacheEventTypeHit or
+	// cache.CacheEventTypeMiss on every download, if set.
+	recordEvent func(event string)
+}
+
+// NewCachingDownloader returns a CachingDownloader for the given Downloader,
+// of which the cached charts are scoped to the given scope. If c is nil, the
+// Downloader is returned as is.
+func NewCachingDownloader(d Downloader, scope string, c *cache.ChartCache, recordEvent func(event string)) Downloader {
+	if c == nil {
+		return d
+	}
+	return &CachingDownloader{
+		Downloader:  d,
+		scope:       scope,
+		cache:       c,
+		recordEvent: recordEvent,
+	}
+}
+
+// DownloadChart returns the chart tarball for the given repo.ChartVersion
+// from the cache, or downloads it using the wrapped Downloader and adds it
+// to the cache.
+// When the repo.ChartVersion has a digest from a Helm repository index, the
+// downloaded tarball is only added to the cache if it matches the digest.
+// When the digest of a chart version can not be resolved by a
+// ChartDigestResolver, the chart is downloaded without using the cache.
+func (d *CachingDownloader) DownloadChart(chart *repo.ChartVersion) (*bytes.Buffer, error) {
+	key, err := d.cacheKey(chart)
+	if err != nil {
+		return d.Downloader.DownloadChart(chart)
+	}
+	if data, ok := d.cache.Get(key); ok {
+		d.record(cache.CacheEventTypeHit)
+		return bytes.NewBuffer(data), nil
+	}
+	d.record(cache.CacheEventTypeMiss)
+
+	res, err := d.Downloader.DownloadChart(chart)
+	if err != nil {
+		return nil, err
+	}
+
+	// The digest of an OCI chart version is the digest of the manifest
+	// (in the '<algorithm>:<hex>' format) rather than of the tarball,
+	// in which case the pull by digest already verified the content.
+	var digest string
+	if !strings.Contains(chart.Digest, ":") {
+		digest = chart.Digest
+	}
+	if err = d.cache.Set(key, res.Bytes(), digest); errors.Is(err, cache.ErrDigestMismatch) {
+		return nil, fmt.Errorf("failed to verify chart '%s' version '%s': %w", chart.Name, chart.Version, err)
+	}
+	return res, nil
+}
+
+// cacheKey returns the cache key for the given repo.ChartVersion, composed
+// of the scope, chart name and digest. If the digest is unknown, it is
+// resolved by the wrapped Downloader if it is a ChartDigestResolver, or the
+// version is used otherwise.
+func (d *CachingDownloader) cacheKey(chart *repo.ChartVersion) (string, error) {
+	digest := chart.Digest
+	if digest == "" {
+		if r, ok := d.Downloader.(ChartDigestResolver); ok {
+			var err error
+			if digest, err = r.ResolveChartDigest(chart); err != nil {
+				return "", err
+			}
+		}
+	}
+	if digest != "" {
+		return fmt.Sprintf("%s|%s|%s", d.scope, chart.Name, digest), nil
+	}
+	return fmt.Sprintf("%s|%s|%s", d.scope, chart.Name, chart.Version), nil
+}
+
+func (d *CachingDownloader) record(event string) {
+	if d.recordEvent != nil {
+		d.recordEvent(event)
+	}
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+
+	"github.com/fluxcd/source-controller/internal/cache"
+	"github.com/fluxcd/source-controller/internal/oci"
+)
+
+type mockDownloader struct {
+	response  []byte
+	err       error
+	downloads int
+}
+
+func (d *mockDownloader) GetChartVersion(_, _ string) (*repo.ChartVersion, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (d *mockDownloader) GetLatestChartVersion(_ string) (*repo.ChartVersion, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (d *mockDownloader) DownloadChart(_ *repo.ChartVersion) (*bytes.Buffer, error) {
+	d.downloads++
+	if d.err != nil {
+		return nil, d.err
+	}
+	return bytes.NewBuffer(d.response), nil
+}
+
+func (d *mockDownloader) VerifyChart(_ context.Context, _ *repo.ChartVersion) (oci.VerificationResult, error) {
+	return oci.VerificationResultIgnored, nil
+}
+
+func (d *mockDownloader) Clear() error {
+	return nil
+}
+
+type mockDigestResolverDownloader struct {
+	mockDownloader
+	digests []string
+	err     error
+}
+
+func (d *mockDigestResolverDownloader) ResolveChartDigest(_ *repo.ChartVersion) (string, error) {
+	if d.err != nil {
+		return "", d.err
+	}
+	dig := d.digests[0]
+	d.digests = d.digests[1:]
+	return dig, nil
+}
+
+func TestNewCachingDownloader(t *testing.T) {
+	g := NewWithT(t)
+
+	d := &mockDownloader{}
+	g.Expect(NewCachingDownloader(d, "https://example.com/", nil, nil)).To(Equal(d))
+	g.Expect(NewCachingDownloader(d, "https://example.com/", cache.NewChartCache(10), nil)).
+		To(BeAssignableToTypeOf(&CachingDownloader{}))
+}
+
+func TestCachingDownloader_DownloadChart(t *testing.T) {
+	data := []byte("chart tarball")
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	chartVersion := func(version, digest string) *repo.ChartVersion {
+		return &repo.ChartVersion{
+			Metadata: &chart.Metadata{Name: "podinfo", Version: version},
+			URLs:     []string{"https://example.com/podinfo-" + version + ".tgz"},
+			Digest:   digest,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		chartVersions []*repo.ChartVersion
+		downloadErr   error
+		wantDownloads int
+		wantEvents    []string
+		wantItems     int
+		wantErr       string
+	}{
+		{
+			name:          "caches chart version",
+			chartVersions: []*repo.ChartVersion{chartVersion("1.0.0", ""), chartVersion("1.0.0", "")},
+			wantDownloads: 1,
+			wantEvents:    []string{cache.CacheEventTypeMiss, cache.CacheEventTypeHit},
+			wantItems:     1,
+		},
+		{
+			name:          "caches chart versions by digest",
+			chartVersions: []*repo.ChartVersion{chartVersion("1.0.0", digest), chartVersion("1.0.1", digest)},
+			wantDownloads: 1,
+			wantEvents:    []string{cache.CacheEventTypeMiss, cache.CacheEventTypeHit},
+			wantItems:     1,
+		},
+		{
+			name:          "does not verify OCI manifest digest",
+			chartVersions: []*repo.ChartVersion{chartVersion("1.0.0", "sha256:"+strings.Repeat("a", 64))},
+			wantDownloads: 1,
+			wantEvents:    []string{cache.CacheEventTypeMiss},
+			wantItems:     1,
+		},
+		{
+			name:          "fails on digest mismatch",
+			chartVersions: []*repo.ChartVersion{chartVersion("1.0.0", "invalid")},
+			wantDownloads: 1,
+			wantEvents:    []string{cache.CacheEventTypeMiss},
+			wantErr:       "failed to verify chart 'podinfo' version '1.0.0': digest mismatch",
+		},
+		{
+			name:          "does not cache download error",
+			chartVersions: []*repo.ChartVersion{chartVersion("1.0.0", "")},
+			downloadErr:   errors.New("download error"),
+			wantDownloads: 1,
+			wantEvents:    []string{cache.CacheEventTypeMiss},
+			wantErr:       "download error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := cache.NewChartCache(1024)
+			d := &mockDownloader{response: data, err: tt.downloadErr}
+			var events []string
+			cd := NewCachingDownloader(d, "https://example.com/", c, func(event string) {
+				events = append(events, event)
+			})
+
+			var err error
+			for _, cv := range tt.chartVersions {
+				var res *bytes.Buffer
+				res, err = cd.DownloadChart(cv)
+				if err != nil {
+					break
+				}
+				g.Expect(res.Bytes()).To(Equal(data))
+			}
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(d.downloads).To(Equal(tt.wantDownloads))
+			g.Expect(events).To(Equal(tt.wantEvents))
+			g.Expect(c.ItemCount()).To(Equal(tt.wantItems))
+		})
+	}
+}
+
+func TestCachingDownloader_DownloadChart_scope(t *testing.T) {
+	g := NewWithT(t)
+
+	data := []byte("chart tarball")
+	cv := &repo.ChartVersion{
+		Metadata: &chart.Metadata{Name: "podinfo", Version: "1.0.0"},
+		URLs:     []string{"https://example.com/podinfo-1.0.0.tgz"},
+	}
+
+	c := cache.NewChartCache(1024)
+	private := &mockDownloader{response: data}
+	_, err := NewCachingDownloader(private, "tenant-a/private|https://example.com/", c, nil).DownloadChart(cv)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	public := &mockDownloader{response: data}
+	_, err = NewCachingDownloader(public, "https://example.com/", c, nil).DownloadChart(cv)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(private.downloads).To(Equal(1))
+	g.Expect(public.downloads).To(Equal(1))
+	g.Expect(c.ItemCount()).To(Equal(2))
+}
+
+func TestCachingDownloader_DownloadChart_resolveDigest(t *testing.T) {
+	data := []byte("chart tarball")
+	digestA := "sha256:" + strings.Repeat("a", 64)
+	digestB := "sha256:" + strings.Repeat("b", 64)
+
+	cv := &repo.ChartVersion{
+		Metadata: &chart.Metadata{Name: "podinfo", Version: "1.0.0"},
+		URLs:     []string{"oci://example.com/charts/podinfo:1.0.0"},
+	}
+
+	tests := []struct {
+		name          string
+		digests       []string
+		resolveErr    error
+		wantDownloads int
+		wantEvents    []string
+		wantItems     int
+	}{
+		{
+			name:          "caches chart by resolved digest",
+			digests:       []string{digestA, digestA},
+			wantDownloads: 1,
+			wantEvents:    []string{cache.CacheEventTypeMiss, cache.CacheEventTypeHit},
+			wantItems:     1,
+		},
+		{
+			name:          "does not serve moved tag from cache",
+			digests:       []string{digestA, digestB},
+			wantDownloads: 2,
+			wantEvents:    []string{cache.CacheEventTypeMiss, cache.CacheEventTypeMiss},
+			wantItems:     2,
+		},
+		{
+			name:          "bypasses cache on resolve error",
+			resolveErr:    errors.New("resolve error"),
+			wantDownloads: 2,
+			wantItems:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := cache.NewChartCache(1024)
+			d := &mockDigestResolverDownloader{
+				mockDownloader: mockDownloader{response: data},
+				digests:        tt.digests,
+				err:            tt.resolveErr,
+			}
+			var events []string
+			cd := NewCachingDownloader(d, "oci://example.com/charts", c, func(event string) {
+				events = append(events, event)
+			})
+
+			for i := 0; i < 2; i++ {
+				res, err := cd.DownloadChart(cv)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(res.Bytes()).To(Equal(data))
+			}
+			g.Expect(d.downloads).To(Equal(tt.wantDownloads))
+			g.Expect(events).To(Equal(tt.wantEvents))
+			g.Expect(c.ItemCount()).To(Equal(tt.wantItems))
+		})
+	}
+}
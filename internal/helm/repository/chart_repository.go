@@ -36,7 +36,6 @@ import (
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/repo"
-	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/pkg/version"
 
@@ -49,10 +48,12 @@ var (
 	ErrNoChartIndex = errors.New("no chart index")
 )
 
-// IndexFromFile loads a repo.IndexFile from the given path. It returns an
+// IndexFromFile loads a repo.IndexFile from the given path. If chart names
+// are given, only the entries of these charts are loaded. It returns an
 // error if the file does not exist, is not a regular file, exceeds the
 // maximum index file size, or if the file cannot be parsed.
-func IndexFromFile(path string) (*repo.IndexFile, error) {
+// The index is parsed from the file one chart at a time, see StreamIndex.
+func IndexFromFile(path string, charts ...string) (*repo.IndexFile, error) {
 	st, err := os.Lstat(path)
 	if err != nil {
 		return nil, err
@@ -63,11 +64,12 @@ func IndexFromFile(path string) (*repo.IndexFile, error) {
 	if st.Size() > helm.MaxIndexSize {
 		return nil, fmt.Errorf("%s exceeds the maximum index file size of %d bytes", path, helm.MaxIndexSize)
 	}
-	b, err := os.ReadFile(path)
+	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
-	return IndexFromBytes(b)
+	defer f.Close()
+	return LoadIndex(io.LimitReader(f, helm.MaxIndexSize), charts...)
 }
 
 // IndexFromBytes loads a repo.IndexFile from the given bytes. It returns an
@@ -77,36 +79,16 @@ func IndexFromBytes(b []byte) (*repo.IndexFile, error) {
 	if len(b) == 0 {
 		return nil, repo.ErrEmptyIndexYaml
 	}
+	return LoadIndex(bytes.NewReader(b))
+}
 
-	i := &repo.IndexFile{}
-	if err := jsonOrYamlUnmarshal(b, i); err != nil {
-		return nil, err
-	}
-
-	if i.APIVersion == "" {
-		return nil, repo.ErrNoAPIVersion
-	}
-
-	for _, cvs := range i.Entries {
-		for idx := len(cvs) - 1; idx >= 0; idx-- {
-			if cvs[idx] == nil {
-				continue
-			}
-			// When metadata section missing, initialize with no data
-			if cvs[idx].Metadata == nil {
-				cvs[idx].Metadata = &chart.Metadata{}
-			}
-			if cvs[idx].APIVersion == "" {
-				cvs[idx].APIVersion = chart.APIVersionV1
-			}
-			if err := cvs[idx].Validate(); ignoreSkippableChartValidationError(err) != nil {
-				cvs = append(cvs[:idx], cvs[idx+1:]...)
-			}
-		}
-	}
-
-	i.SortEntries()
-	return i, nil
+// IndexCache is used by a ChartRepository to store and retrieve the compact
+// index of a single chart, in JSON format.
+type IndexCache interface {
+	// Get returns the compact index of the chart with the given name.
+	Get(chart string) ([]byte, bool)
+	// Set stores the compact index of the chart with the given name.
+	Set(chart string, index []byte)
 }
 
 // ChartRepository represents a Helm chart repository, and the configuration
@@ -126,6 +108,28 @@ type ChartRepository struct {
 	// Options to configure the Client with while downloading the Index
 	// or a chart from the URL.
 	Options []getter.Option
+	// VersionPolicy restricts the versions which can be returned by
+	// GetChartVersion, if set.
+	VersionPolicy *VersionPolicy
+	// PartialIndex configures GetChartVersion and GetLatestChartVersion to
+	// only load the entries of the requested chart into the Index, instead
+	// of all entries.
+	PartialIndex bool
+	// IndexCache can be set to store and retrieve the entries of a chart
+	// loaded into the Index when PartialIndex is enabled.
+	IndexCache IndexCache
+	// FallbackToRemote configures GetChartVersion and GetLatestChartVersion
+	// to download the index from the URL when PartialIndex is enabled, and
+	// the index at Path does not contain the entries of the requested chart.
+	// This is the case for the index of a HelmRepository Artifact, which
+	// only contains the entries of the charts referenced by HelmCharts.
+	FallbackToRemote bool
+	// Revision is the digest of the remote index the index at Path was
+	// generated from, such as the revision of a HelmRepository Artifact.
+	// When set, the entries loaded from the remote index are also stored
+	// in the IndexCache if the digest of the remote index equals the
+	// Revision.
+	Revision digest.Digest
 
 	tlsConfig *tls.Config
 
@@ -169,20 +173,35 @@ func newChartRepository() *ChartRepository {
 // GetChartVersion returns the repo.ChartVersion for the given name, the version is expected
 // to be a semver.Constraints compatible string. If version is empty, the latest
 // stable version will be returned and prerelease versions will be ignored.
+// Versions which are not allowed by the VersionPolicy are never returned.
 func (r *ChartRepository) GetChartVersion(name, ver string) (*repo.ChartVersion, error) {
 	// See if we already have the index in cache or try to load it.
-	if err := r.StrategicallyLoadIndex(); err != nil {
+	if err := r.loadIndexFor(name); err != nil {
+		return nil, &ErrExternal{Err: err}
+	}
+
+	cv, err := r.getChartVersion(name, ver, r.VersionPolicy)
+	if err != nil {
+		return nil, &ErrReference{Err: err}
+	}
+	return cv, nil
+}
+
+// GetLatestChartVersion returns the repo.ChartVersion of the latest stable
+// version of the chart with the given name, regardless of the VersionPolicy.
+func (r *ChartRepository) GetLatestChartVersion(name string) (*repo.ChartVersion, error) {
+	if err := r.loadIndexFor(name); err != nil {
 		return nil, &ErrExternal{Err: err}
 	}
 
-	cv, err := r.getChartVersion(name, ver)
+	cv, err := r.getChartVersion(name, "", nil)
 	if err != nil {
 		return nil, &ErrReference{Err: err}
 	}
 	return cv, nil
 }
 
-func (r *ChartRepository) getChartVersion(name, ver string) (*repo.ChartVersion, error) {
+func (r *ChartRepository) getChartVersion(name, ver string, policy *VersionPolicy) (*repo.ChartVersion, error) {
 	r.RLock()
 	defer r.RUnlock()
 
@@ -201,12 +220,59 @@ func (r *ChartRepository) getChartVersion(name, ver string) (*repo.ChartVersion,
 	if len(ver) != 0 {
 		for _, cv := range cvs {
 			if ver == cv.Version {
+				if !policy.AllowsChartVersion(cv) {
+					return nil, fmt.Errorf("'%s' chart version '%s' is not allowed by the version policy", name, ver)
+				}
 				return cv, nil
 			}
 		}
 	}
 
 	// Continue to look for a (semantic) version match
+	matched, err := matchChartVersions(name, cvs, ver, policy)
+	if err != nil {
+		return nil, err
+	}
+	return matched[0], nil
+}
+
+// GetChartVersions returns up to max repo.ChartVersions of the chart with the
+// given name which match the semver.Constraints compatible version and are
+// allowed by the VersionPolicy, ordered from latest to oldest. If version is
+// empty, prerelease versions are ignored.
+func (r *ChartRepository) GetChartVersions(name, ver string, max int) (repo.ChartVersions, error) {
+	if err := r.loadIndexFor(name); err != nil {
+		return nil, &ErrExternal{Err: err}
+	}
+
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.Index == nil {
+		return nil, &ErrReference{Err: ErrNoChartIndex}
+	}
+	cvs, ok := r.Index.Entries[name]
+	if !ok {
+		return nil, &ErrReference{Err: repo.ErrNoChartName}
+	}
+	if len(cvs) == 0 {
+		return nil, &ErrReference{Err: repo.ErrNoChartVersion}
+	}
+
+	matched, err := matchChartVersions(name, cvs, ver, r.VersionPolicy)
+	if err != nil {
+		return nil, &ErrReference{Err: err}
+	}
+	if max > 0 && len(matched) > max {
+		matched = matched[:max]
+	}
+	return matched, nil
+}
+
+// matchChartVersions returns the chart versions from cvs which match the
+// semver.Constraints compatible version and are allowed by the policy,
+// ordered from latest to oldest. It returns an error if none match.
+func matchChartVersions(name string, cvs repo.ChartVersions, ver string, policy *VersionPolicy) (repo.ChartVersions, error) {
 	verConstraint, err := semver.NewConstraint("*")
 	if err != nil {
 		return nil, err
@@ -229,7 +295,7 @@ func (r *ChartRepository) getChartVersion(name, ver string) (*repo.ChartVersion,
 			continue
 		}
 
-		if !verConstraint.Check(v) {
+		if !verConstraint.Check(v) || !policy.Allows(v, cv.Created) {
 			continue
 		}
 
@@ -237,6 +303,9 @@ func (r *ChartRepository) getChartVersion(name, ver string) (*repo.ChartVersion,
 		lookup[v] = cv
 	}
 	if len(matchedVersions) == 0 {
+		if policy != nil {
+			return nil, fmt.Errorf("no '%s' chart with version matching '%s' allowed by the version policy found", name, ver)
+		}
 		return nil, fmt.Errorf("no '%s' chart with version matching '%s' found", name, ver)
 	}
 
@@ -259,8 +328,11 @@ func (r *ChartRepository) getChartVersion(name, ver string) (*repo.ChartVersion,
 		})()
 	})
 
-	latest := matchedVersions[0]
-	return lookup[latest], nil
+	matched := make(repo.ChartVersions, 0, len(matchedVersions))
+	for _, v := range matchedVersions {
+		matched = append(matched, lookup[v])
+	}
+	return matched, nil
 }
 
 // DownloadChart confirms the given repo.ChartVersion has a downloadable URL,
@@ -346,6 +418,157 @@ func (r *ChartRepository) StrategicallyLoadIndex() (err error) {
 	return
 }
 
+// loadIndexFor lazy-loads the Index if required for the chart with the given
+// name. If PartialIndex is enabled, only the entries of the chart are loaded
+// from the IndexCache or Path, and added to the Index. Otherwise, the whole
+// Index is loaded using StrategicallyLoadIndex.
+func (r *ChartRepository) loadIndexFor(name string) error {
+	if !r.PartialIndex {
+		return r.StrategicallyLoadIndex()
+	}
+
+	r.RLock()
+	var ok bool
+	if r.Index != nil {
+		_, ok = r.Index.Entries[name]
+	}
+	r.RUnlock()
+	if ok {
+		return nil
+	}
+
+	if r.IndexCache != nil {
+		if b, ok := r.IndexCache.Get(name); ok {
+			if i, err := IndexFromBytes(b); err == nil {
+				r.addEntries(name, i)
+				return nil
+			}
+		}
+	}
+
+	if !r.HasFile() {
+		if err := r.CacheIndex(); err != nil {
+			return fmt.Errorf("failed to cache index: %w", err)
+		}
+	}
+
+	r.RLock()
+	i, err := IndexFromFile(r.Path, name)
+	cached := r.cached
+	r.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+
+	if _, ok := i.Entries[name]; !ok {
+		if r.FallbackToRemote && !cached {
+			return r.loadRemoteIndexFor(name)
+		}
+		r.addEntries(name, i)
+		return nil
+	}
+	r.addEntries(name, i)
+	r.storeEntries(name, i)
+	return nil
+}
+
+// loadRemoteIndexFor downloads the index from the URL using CacheIndex, and
+// adds the entries of the chart with the given name to the Index.
+func (r *ChartRepository) loadRemoteIndexFor(name string) error {
+	if err := r.CacheIndex(); err != nil {
+		return fmt.Errorf("failed to cache index: %w", err)
+	}
+
+	r.RLock()
+	i, err := IndexFromFile(r.Path, name)
+	r.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+	r.addEntries(name, i)
+	r.storeEntries(name, i)
+	return nil
+}
+
+// storeEntries stores the entries of the chart with the given name from the
+// given index in the IndexCache, if any. The entries of an index downloaded
+// from the URL are only stored if its digest equals the Revision, as the
+// remote index may otherwise be of another revision than the index at Path.
+func (r *ChartRepository) storeEntries(name string, i *repo.IndexFile) {
+	if r.IndexCache == nil {
+		return
+	}
+	if _, ok := i.Entries[name]; !ok {
+		return
+	}
+
+	r.RLock()
+	cached := r.cached
+	r.RUnlock()
+	if cached && (r.Revision.Validate() != nil || r.Digest(r.Revision.Algorithm()) != r.Revision) {
+		return
+	}
+
+	if b, err := json.Marshal(i); err == nil {
+		r.IndexCache.Set(name, b)
+	}
+}
+
+// addEntries adds the entries of the chart with the given name from the
+// given index to the Index, or sets the Index if it is nil.
+func (r *ChartRepository) addEntries(name string, i *repo.IndexFile) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.Index == nil {
+		r.Index = i
+		return
+	}
+	if cvs, ok := i.Entries[name]; ok {
+		r.Index.Entries[name] = cvs
+	}
+}
+
+// ValidateIndex parses the index at the configured Path without retaining
+// any of its entries, and returns an error if it can not be loaded.
+func (r *ChartRepository) ValidateIndex() error {
+	return r.streamFromPath(func(f io.Reader) error {
+		_, err := StreamIndex(f, nil, func(string, repo.ChartVersions) error {
+			return nil
+		})
+		return err
+	})
+}
+
+// WriteJSON writes the index at the configured Path to the given io.Writer
+// in JSON format, only keeping the entries of the charts for which keep
+// returns true, see WriteIndexJSON.
+func (r *ChartRepository) WriteJSON(w io.Writer, keep func(name string) bool) error {
+	return r.streamFromPath(func(f io.Reader) error {
+		return WriteIndexJSON(f, w, keep)
+	})
+}
+
+func (r *ChartRepository) streamFromPath(fn func(f io.Reader) error) error {
+	r.RLock()
+	defer r.RUnlock()
+
+	if len(r.Path) == 0 {
+		return fmt.Errorf("no cache path")
+	}
+
+	f, err := os.Open(r.Path)
+	if err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+	defer f.Close()
+
+	if err = fn(io.LimitReader(f, helm.MaxIndexSize)); err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+	return nil
+}
+
 // LoadFromPath attempts to load the Index from the configured Path.
 // It returns an error if no Path is set, or if the load failed.
 func (r *ChartRepository) LoadFromPath() error {
@@ -490,23 +713,6 @@ func (r *ChartRepository) VerifyChart(_ context.Context, _ *repo.ChartVersion) (
 	return oci.VerificationResultIgnored, fmt.Errorf("not implemented")
 }
 
-// jsonOrYamlUnmarshal unmarshals the given byte slice containing JSON or YAML
-// into the provided interface.
-//
-// It automatically detects whether the data is in JSON or YAML format by
-// checking its validity as JSON. If the data is valid JSON, it will use the
-// `encoding/json` package to unmarshal it. Otherwise, it will use the
-// `sigs.k8s.io/yaml` package to unmarshal the YAML data.
-//
-// Can potentially be replaced when Helm PR for JSON support has been merged.
-// xref: https://github.com/helm/helm/pull/12245
-func jsonOrYamlUnmarshal(b []byte, i interface{}) error {
-	if json.Valid(b) {
-		return json.Unmarshal(b, i)
-	}
-	return yaml.UnmarshalStrict(b, i)
-}
-
 // ignoreSkippableChartValidationError inspect the given error and returns nil if
 // the error isn't important for index loading
 //
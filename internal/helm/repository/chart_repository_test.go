@@ -269,6 +269,7 @@ func TestChartRepository_GetChartVersion(t *testing.T) {
 		name         string
 		chartName    string
 		chartVersion string
+		policy       *VersionPolicy
 		wantVersion  string
 		wantErr      string
 	}{
@@ -313,12 +314,55 @@ func TestChartRepository_GetChartVersion(t *testing.T) {
 			chartVersion: "0.1.5",
 			wantVersion:  "0.1.5+c.now",
 		},
+		{
+			name:         "policy excludes pre-release",
+			chartName:    "chart",
+			chartVersion: ">=1.0.0-0",
+			policy:       &VersionPolicy{ExcludePrerelease: true},
+			wantVersion:  "1.0.0",
+		},
+		{
+			name:         "policy soak time",
+			chartName:    "chart",
+			chartVersion: "0.1.5",
+			policy:       &VersionPolicy{SoakTime: 30 * time.Minute},
+			wantVersion:  "0.1.5+a.min.hour",
+		},
+		{
+			name:         "policy allow list",
+			chartName:    "chart",
+			chartVersion: "*",
+			policy:       mustVersionPolicy([]string{"<0.2.0"}, nil),
+			wantVersion:  "0.1.5+c.now",
+		},
+		{
+			name:         "policy deny list",
+			chartName:    "chart",
+			chartVersion: "*",
+			policy:       mustVersionPolicy(nil, []string{"1.0.0"}),
+			wantVersion:  "0.2.0",
+		},
+		{
+			name:         "policy denies exact match",
+			chartName:    "chart",
+			chartVersion: "1.0.0",
+			policy:       mustVersionPolicy(nil, []string{"1.0.0"}),
+			wantErr:      "'chart' chart version '1.0.0' is not allowed by the version policy",
+		},
+		{
+			name:         "unfulfilled policy",
+			chartName:    "chart",
+			chartVersion: ">=1.0.0",
+			policy:       mustVersionPolicy(nil, []string{">=1.0.0"}),
+			wantErr:      "no 'chart' chart with version matching '>=1.0.0' allowed by the version policy found",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 
+			r.VersionPolicy = tt.policy
 			cv, err := r.GetChartVersion(tt.chartName, tt.chartVersion)
 			if tt.wantErr != "" {
 				g.Expect(err).To(HaveOccurred())
@@ -334,6 +378,226 @@ func TestChartRepository_GetChartVersion(t *testing.T) {
 	}
 }
 
+func TestChartRepository_GetLatestChartVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	r := newChartRepository()
+	r.Index = repo.NewIndexFile()
+	for _, v := range []string{"0.1.0", "1.0.0", "1.1.0-rc.1"} {
+		g.Expect(r.Index.MustAdd(&chart.Metadata{Name: "chart", Version: v},
+			fmt.Sprintf("chart-%s.tgz", v), "http://example.com/charts", "sha256:1234567890")).To(Succeed())
+	}
+	r.Index.SortEntries()
+	r.VersionPolicy = mustVersionPolicy(nil, []string{"1.0.0"})
+
+	cv, err := r.GetLatestChartVersion("chart")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cv.Version).To(Equal("1.0.0"))
+
+	_, err = r.GetLatestChartVersion("non-existing")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestChartRepository_GetChartVersions(t *testing.T) {
+	g := NewWithT(t)
+
+	r := newChartRepository()
+	r.Index = repo.NewIndexFile()
+	for _, v := range []string{"0.1.0", "1.0.0", "1.1.0", "1.2.0-rc.1"} {
+		g.Expect(r.Index.MustAdd(&chart.Metadata{Name: "chart", Version: v},
+			fmt.Sprintf("chart-%s.tgz", v), "http://example.com/charts", "sha256:1234567890")).To(Succeed())
+	}
+	r.Index.SortEntries()
+
+	versions := func(cvs repo.ChartVersions) []string {
+		var res []string
+		for _, cv := range cvs {
+			res = append(res, cv.Version)
+		}
+		return res
+	}
+
+	cvs, err := r.GetChartVersions("chart", "", 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(versions(cvs)).To(Equal([]string{"1.1.0", "1.0.0"}))
+
+	cvs, err = r.GetChartVersions("chart", ">=1.0.0-0", 0)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(versions(cvs)).To(Equal([]string{"1.2.0-rc.1", "1.1.0", "1.0.0"}))
+
+	r.VersionPolicy = mustVersionPolicy(nil, []string{"1.1.0"})
+	cvs, err = r.GetChartVersions("chart", "", 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(versions(cvs)).To(Equal([]string{"1.0.0", "0.1.0"}))
+
+	_, err = r.GetChartVersions("chart", ">=2.0.0", 1)
+	g.Expect(err).To(HaveOccurred())
+	_, err = r.GetChartVersions("non-existing", "", 1)
+	g.Expect(err).To(HaveOccurred())
+}
+
+type mockIndexCache map[string][]byte
+
+func (c mockIndexCache) Get(chart string) ([]byte, bool) {
+	b, ok := c[chart]
+	return b, ok
+}
+
+func (c mockIndexCache) Set(chart string, index []byte) {
+	c[chart] = index
+}
+
+func TestChartRepository_PartialIndex(t *testing.T) {
+	g := NewWithT(t)
+
+	r := newChartRepository()
+	r.Path = testFile
+	r.PartialIndex = true
+	indexCache := mockIndexCache{}
+	r.IndexCache = indexCache
+
+	cv, err := r.GetChartVersion("nginx", "")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cv.Version).To(Equal("0.2.0"))
+	g.Expect(r.Index.Entries).To(HaveLen(1))
+	g.Expect(indexCache).To(HaveKey("nginx"))
+
+	cv, err = r.GetLatestChartVersion("alpine")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cv.Version).To(Equal("1.0.0"))
+	g.Expect(r.Index.Entries).To(HaveLen(2))
+	g.Expect(indexCache).To(HaveKey("alpine"))
+
+	// Entries are loaded from the IndexCache when available.
+	r = newChartRepository()
+	r.Path = filepath.Join(t.TempDir(), "index.yaml")
+	g.Expect(os.WriteFile(r.Path, []byte("invalid"), 0o640)).To(Succeed())
+	r.PartialIndex = true
+	r.IndexCache = indexCache
+
+	cv, err = r.GetChartVersion("nginx", "0.1.0")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cv.Version).To(Equal("0.1.0"))
+
+	_, err = r.GetChartVersion("missing", "")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestChartRepository_PartialIndex_FallbackToRemote(t *testing.T) {
+	g := NewWithT(t)
+
+	b, err := os.ReadFile(chartmuseumTestFile)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The local index only contains the entries of the nginx chart.
+	var buf bytes.Buffer
+	g.Expect(WriteIndexJSON(bytes.NewReader(b), &buf, func(name string) bool {
+		return name == "nginx"
+	})).To(Succeed())
+	path := filepath.Join(t.TempDir(), "index.json")
+	g.Expect(os.WriteFile(path, buf.Bytes(), 0o640)).To(Succeed())
+
+	mg := &mockGetter{Response: b}
+	r := newChartRepository()
+	r.URL = "https://example.com"
+	r.Client = mg
+	r.Path = path
+	r.PartialIndex = true
+	r.FallbackToRemote = true
+	indexCache := mockIndexCache{}
+	r.IndexCache = indexCache
+	defer r.Clear()
+
+	cv, err := r.GetChartVersion("nginx", "")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cv.Version).To(Equal("0.2.0"))
+	g.Expect(mg.LastCalledURL).To(BeEmpty())
+	g.Expect(indexCache).To(HaveKey("nginx"))
+
+	cv, err = r.GetChartVersion("alpine", "")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cv.Version).To(Equal("1.0.0"))
+	g.Expect(mg.LastCalledURL).To(Equal("https://example.com/index.yaml"))
+	g.Expect(r.Path).ToNot(Equal(path))
+	g.Expect(indexCache).ToNot(HaveKey("alpine"))
+
+	// The entries of the remote index are cached when it is of the same
+	// revision as the local index.
+	r = newChartRepository()
+	r.URL = "https://example.com"
+	r.Client = &mockGetter{Response: b}
+	r.Path = path
+	r.PartialIndex = true
+	r.FallbackToRemote = true
+	r.Revision = digest.FromBytes(b)
+	r.IndexCache = indexCache
+	defer r.Clear()
+
+	cv, err = r.GetChartVersion("alpine", "")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cv.Version).To(Equal("1.0.0"))
+	g.Expect(indexCache).To(HaveKey("alpine"))
+
+	mg = &mockGetter{Response: b}
+	r = newChartRepository()
+	r.URL = "https://example.com"
+	r.Client = mg
+	r.Path = path
+	r.PartialIndex = true
+	r.FallbackToRemote = true
+	r.IndexCache = indexCache
+
+	cv, err = r.GetChartVersion("alpine", "")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cv.Version).To(Equal("1.0.0"))
+	g.Expect(mg.LastCalledURL).To(BeEmpty())
+
+	// Without fallback, the missing entries result in an error.
+	r = newChartRepository()
+	r.Path = path
+	r.PartialIndex = true
+	_, err = r.GetChartVersion("alpine", "")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestChartRepository_ValidateIndex(t *testing.T) {
+	g := NewWithT(t)
+
+	r := newChartRepository()
+	r.Path = testFile
+	g.Expect(r.ValidateIndex()).To(Succeed())
+	g.Expect(r.Index).To(BeNil())
+
+	r.Path = filepath.Join(t.TempDir(), "index.yaml")
+	g.Expect(os.WriteFile(r.Path, []byte("entries: {}\n"), 0o640)).To(Succeed())
+	g.Expect(r.ValidateIndex()).To(MatchError(ContainSubstring(repo.ErrNoAPIVersion.Error())))
+
+	r.Path = ""
+	g.Expect(r.ValidateIndex()).To(HaveOccurred())
+}
+
+func TestChartRepository_WriteJSON(t *testing.T) {
+	g := NewWithT(t)
+
+	r := newChartRepository()
+	r.Path = testFile
+
+	var buf bytes.Buffer
+	g.Expect(r.WriteJSON(&buf, nil)).To(Succeed())
+	i, err := IndexFromBytes(buf.Bytes())
+	g.Expect(err).ToNot(HaveOccurred())
+	verifyLocalIndex(t, i)
+
+	buf.Reset()
+	g.Expect(r.WriteJSON(&buf, func(name string) bool {
+		return name == "nginx"
+	})).To(Succeed())
+	i, err = IndexFromBytes(buf.Bytes())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(i.Entries).To(HaveLen(1))
+	g.Expect(i.Entries).To(HaveKey("nginx"))
+}
+
 func TestChartRepository_DownloadChart(t *testing.T) {
 	tests := []struct {
 		name         string
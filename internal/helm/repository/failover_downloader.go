@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// FailoverDownloader is a Downloader which downloads the chart tarballs from
+// the mirrors of the repository of the wrapped ChartRepository, in order,
+// when the download from the repository itself fails.
+type FailoverDownloader struct {
+	*ChartRepository
+
+	// mirrors are the ChartRepository objects of the mirrors, configured
+	// with their own URL and credentials.
+	mirrors []*ChartRepository
+}
+
+// NewFailoverDownloader returns a FailoverDownloader for the given
+// ChartRepository and its mirrors. If there are no mirrors, the
+// ChartRepository is returned as is.
+func NewFailoverDownloader(r *ChartRepository, mirrors ...*ChartRepository) Downloader {
+	if len(mirrors) == 0 {
+		return r
+	}
+	return &FailoverDownloader{
+		ChartRepository: r,
+		mirrors:         mirrors,
+	}
+}
+
+// DownloadChart downloads the chart tarball for the given repo.ChartVersion
+// from the repository, or from the first mirror it can be downloaded from.
+// Chart URLs of the repository are rewritten to the URL of the mirror.
+// When the repo.ChartVersion has a digest, a tarball which does not match
+// it is rejected, and the next mirror is tried.
+func (d *FailoverDownloader) DownloadChart(chart *repo.ChartVersion) (*bytes.Buffer, error) {
+	res, err := downloadVerifiedChart(d.ChartRepository, chart)
+	if err == nil {
+		return res, nil
+	}
+	errs := []error{err}
+	for _, m := range d.mirrors {
+		res, err = downloadVerifiedChart(m, mirrorChartVersion(chart, d.URL, m.URL))
+		if err == nil {
+			return res, nil
+		}
+		errs = append(errs, fmt.Errorf("mirror '%s': %w", m.URL, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Clear calls Clear on the ChartRepository and all mirrors.
+func (d *FailoverDownloader) Clear() error {
+	var errs []error
+	for _, r := range append([]*ChartRepository{d.ChartRepository}, d.mirrors...) {
+		if err := r.Clear(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// downloadVerifiedChart downloads the chart tarball for the given
+// repo.ChartVersion from the given ChartRepository, and verifies it against
+// the digest of the chart version (if set).
+func downloadVerifiedChart(r *ChartRepository, chart *repo.ChartVersion) (*bytes.Buffer, error) {
+	res, err := r.DownloadChart(chart)
+	if err != nil {
+		return nil, err
+	}
+	if chart.Digest == "" {
+		return res, nil
+	}
+	sum := sha256.Sum256(res.Bytes())
+	if expected, got := strings.TrimPrefix(chart.Digest, "sha256:"), hex.EncodeToString(sum[:]); expected != got {
+		return nil, fmt.Errorf("failed to verify chart '%s' version '%s': digest mismatch: expected '%s', got '%s'",
+			chart.Name, chart.Version, expected, got)
+	}
+	return res, nil
+}
+
+// mirrorChartVersion returns a copy of the given repo.ChartVersion with the
+// chart URLs starting with the repository URL rewritten to start with the
+// mirror URL. Relative URLs are resolved against the mirror URL on download.
+func mirrorChartVersion(chart *repo.ChartVersion, url, mirrorURL string) *repo.ChartVersion {
+	mirrored := *chart
+	mirrored.URLs = make([]string, 0, len(chart.URLs))
+	for _, u := range chart.URLs {
+		if strings.HasPrefix(u, url) {
+			u = mirrorURL + strings.TrimPrefix(u, url)
+		}
+		mirrored.URLs = append(mirrored.URLs, u)
+	}
+	return &mirrored
+}
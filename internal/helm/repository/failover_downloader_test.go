@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	helmgetter "helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// urlGetter is a getter.Getter implementation returning the response for
+// the requested URL, or an error if there is none.
+type urlGetter struct {
+	responses map[string][]byte
+	requested []string
+}
+
+func (g *urlGetter) Get(u string, _ ...helmgetter.Option) (*bytes.Buffer, error) {
+	g.requested = append(g.requested, u)
+	if r, ok := g.responses[u]; ok {
+		return bytes.NewBuffer(r), nil
+	}
+	return nil, fmt.Errorf("failed to fetch %s : 404 Not Found", u)
+}
+
+func TestNewFailoverDownloader(t *testing.T) {
+	g := NewWithT(t)
+
+	r := newChartRepository()
+	g.Expect(NewFailoverDownloader(r)).To(Equal(r))
+	g.Expect(NewFailoverDownloader(r, newChartRepository())).To(BeAssignableToTypeOf(&FailoverDownloader{}))
+}
+
+func TestFailoverDownloader_DownloadChart(t *testing.T) {
+	data := []byte("chart tarball")
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name          string
+		urls          []string
+		digest        string
+		responses     map[string][]byte
+		wantRequested []string
+		wantErr       string
+	}{
+		{
+			name:   "downloads from repository",
+			urls:   []string{"https://example.com/charts/podinfo-1.0.0.tgz"},
+			digest: digest,
+			responses: map[string][]byte{
+				"https://example.com/charts/podinfo-1.0.0.tgz": data,
+			},
+			wantRequested: []string{"https://example.com/charts/podinfo-1.0.0.tgz"},
+		},
+		{
+			name: "falls back to mirror with rewritten URL",
+			urls: []string{"https://example.com/charts/podinfo-1.0.0.tgz"},
+			responses: map[string][]byte{
+				"https://mirror-2.example.com/podinfo-1.0.0.tgz": data,
+			},
+			wantRequested: []string{
+				"https://example.com/charts/podinfo-1.0.0.tgz",
+				"https://mirror-1.example.com/podinfo-1.0.0.tgz",
+				"https://mirror-2.example.com/podinfo-1.0.0.tgz",
+			},
+		},
+		{
+			name: "falls back to mirror with relative URL",
+			urls: []string{"podinfo-1.0.0.tgz"},
+			responses: map[string][]byte{
+				"https://mirror-1.example.com/podinfo-1.0.0.tgz": data,
+			},
+			wantRequested: []string{
+				"https://example.com/charts/podinfo-1.0.0.tgz",
+				"https://mirror-1.example.com/podinfo-1.0.0.tgz",
+			},
+		},
+		{
+			name:   "rejects tarball with digest mismatch",
+			urls:   []string{"podinfo-1.0.0.tgz"},
+			digest: digest,
+			responses: map[string][]byte{
+				"https://example.com/charts/podinfo-1.0.0.tgz":   []byte("invalid"),
+				"https://mirror-1.example.com/podinfo-1.0.0.tgz": []byte("invalid"),
+				"https://mirror-2.example.com/podinfo-1.0.0.tgz": data,
+			},
+			wantRequested: []string{
+				"https://example.com/charts/podinfo-1.0.0.tgz",
+				"https://mirror-1.example.com/podinfo-1.0.0.tgz",
+				"https://mirror-2.example.com/podinfo-1.0.0.tgz",
+			},
+		},
+		{
+			name:   "fails when all mirrors fail",
+			urls:   []string{"podinfo-1.0.0.tgz"},
+			digest: digest,
+			responses: map[string][]byte{
+				"https://mirror-1.example.com/podinfo-1.0.0.tgz": []byte("invalid"),
+			},
+			wantRequested: []string{
+				"https://example.com/charts/podinfo-1.0.0.tgz",
+				"https://mirror-1.example.com/podinfo-1.0.0.tgz",
+				"https://mirror-2.example.com/podinfo-1.0.0.tgz",
+			},
+			wantErr: "mirror 'https://mirror-1.example.com/': failed to verify chart 'podinfo' version '1.0.0': digest mismatch",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			getter := &urlGetter{responses: tt.responses}
+			newRepo := func(url string) *ChartRepository {
+				r := newChartRepository()
+				r.URL = url
+				r.Client = getter
+				return r
+			}
+			d := NewFailoverDownloader(newRepo("https://example.com/charts/"),
+				newRepo("https://mirror-1.example.com/"), newRepo("https://mirror-2.example.com/"))
+
+			res, err := d.DownloadChart(&repo.ChartVersion{
+				Metadata: &chart.Metadata{Name: "podinfo", Version: "1.0.0"},
+				URLs:     tt.urls,
+				Digest:   tt.digest,
+			})
+			g.Expect(getter.requested).To(Equal(tt.wantRequested))
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(res.Bytes()).To(Equal(data))
+		})
+	}
+}
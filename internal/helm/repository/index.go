@@ -0,0 +1,758 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	yaml3 "gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+// indexHeader contains the fields of a repo.IndexFile, except for the
+// entries.
+type indexHeader struct {
+	ServerInfo  map[string]interface{} `json:"serverInfo,omitempty"`
+	APIVersion  string                 `json:"apiVersion"`
+	Generated   time.Time              `json:"generated"`
+	PublicKeys  []string               `json:"publicKeys,omitempty"`
+	Annotations map[string]string      `json:"annotations,omitempty"`
+}
+
+// StreamIndex parses a Helm repository index in YAML or JSON format from
+// the given io.Reader, and calls fn with the (sorted) chart versions of every
+// chart in the index for which keep returns true. If keep is nil, all charts
+// are kept. The entries of charts which are not kept are skipped without
+// being decoded.
+//
+// The index is parsed one chart at a time, which bounds the memory usage
+// to the entries of the largest chart instead of the size of the index. For
+// a YAML index, the charts which define an anchor are retained to resolve
+// aliases, see streamYAMLIndex. It returns the repo.IndexFile without any
+// entries, or an error if the index can not be parsed, or if the API version
+// is not set.
+func StreamIndex(r io.Reader, keep func(name string) bool, fn func(name string, cvs repo.ChartVersions) error) (*repo.IndexFile, error) {
+	if keep == nil {
+		keep = func(string) bool { return true }
+	}
+	visit := func(name string, cvs repo.ChartVersions) error {
+		return fn(name, sanitizeChartVersions(cvs))
+	}
+
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, repo.ErrEmptyIndexYaml
+		}
+		return nil, err
+	}
+
+	var header *indexHeader
+	if first == '{' {
+		header, err = streamJSONIndex(br, keep, visit)
+	} else {
+		header, err = streamYAMLIndex(br, keep, visit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if header.APIVersion == "" {
+		return nil, repo.ErrNoAPIVersion
+	}
+
+	return &repo.IndexFile{
+		ServerInfo:  header.ServerInfo,
+		APIVersion:  header.APIVersion,
+		Generated:   header.Generated,
+		Entries:     map[string]repo.ChartVersions{},
+		PublicKeys:  header.PublicKeys,
+		Annotations: header.Annotations,
+	}, nil
+}
+
+// LoadIndex parses a Helm repository index in YAML or JSON format from the
+// given io.Reader, only keeping the entries of the charts with the given
+// names. If no names are given, all entries are kept.
+func LoadIndex(r io.Reader, charts ...string) (*repo.IndexFile, error) {
+	var keep func(string) bool
+	if len(charts) > 0 {
+		keep = func(name string) bool {
+			for _, c := range charts {
+				if c == name {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	entries := map[string]repo.ChartVersions{}
+	i, err := StreamIndex(r, keep, func(name string, cvs repo.ChartVersions) error {
+		entries[name] = cvs
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	i.Entries = entries
+	return i, nil
+}
+
+// WriteIndexJSON parses a Helm repository index in YAML or JSON format from
+// the given io.Reader, and writes it to the given io.Writer as compact JSON,
+// only keeping the entries of the charts for which keep returns true. If keep
+// is nil, all charts are kept. The entries are converted one chart at a time,
+// see StreamIndex.
+func WriteIndexJSON(r io.Reader, w io.Writer, keep func(name string) bool) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(`{"entries":{`); err != nil {
+		return err
+	}
+
+	var n int
+	i, err := StreamIndex(r, keep, func(name string, cvs repo.ChartVersions) error {
+		if n > 0 {
+			if err := bw.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		n++
+		k, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(cvs)
+		if err != nil {
+			return err
+		}
+		if _, err = bw.Write(k); err != nil {
+			return err
+		}
+		if err = bw.WriteByte(':'); err != nil {
+			return err
+		}
+		_, err = bw.Write(v)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	h, err := json.Marshal(&indexHeader{
+		ServerInfo:  i.ServerInfo,
+		APIVersion:  i.APIVersion,
+		Generated:   i.Generated,
+		PublicKeys:  i.PublicKeys,
+		Annotations: i.Annotations,
+	})
+	if err != nil {
+		return err
+	}
+	// Replace the opening brace of the header object with a separator,
+	// to continue the object opened for the entries.
+	if _, err = bw.WriteString("},"); err != nil {
+		return err
+	}
+	if _, err = bw.Write(h[1:]); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ChartSummary summarizes the versions of a chart in a Helm repository
+// index.
+type ChartSummary struct {
+	// Name of the chart.
+	Name string
+	// LatestVersion is the highest SemVer version of the chart.
+	LatestVersion string
+	// Versions is the number of versions of the chart.
+	Versions int
+}
+
+// SummarizeIndex parses a Helm repository index in YAML or JSON format from
+// the given io.Reader, and returns a summary of every chart in the index,
+// sorted by name. The index is parsed one chart at a time, see StreamIndex.
+func SummarizeIndex(r io.Reader) ([]ChartSummary, error) {
+	var summaries []ChartSummary
+	_, err := StreamIndex(r, nil, func(name string, cvs repo.ChartVersions) error {
+		s := ChartSummary{Name: name, Versions: len(cvs)}
+		for _, cv := range cvs {
+			if cv != nil {
+				s.LatestVersion = cv.Version
+				break
+			}
+		}
+		summaries = append(summaries, s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries, nil
+}
+
+// streamJSONIndex parses a JSON index from the bufio.Reader, calling fn for
+// the entries of every chart for which keep returns true.
+func streamJSONIndex(br *bufio.Reader, keep func(string) bool, fn func(string, repo.ChartVersions) error) (*indexHeader, error) {
+	dec := json.NewDecoder(br)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	header := map[string]json.RawMessage{}
+	for dec.More() {
+		key, err := stringToken(dec)
+		if err != nil {
+			return nil, err
+		}
+		if key != "entries" {
+			var raw json.RawMessage
+			if err = dec.Decode(&raw); err != nil {
+				return nil, err
+			}
+			header[key] = raw
+			continue
+		}
+
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if tok == nil {
+			continue
+		}
+		if d, ok := tok.(json.Delim); !ok || d != '{' {
+			return nil, fmt.Errorf("invalid index: expected entries to be an object")
+		}
+		for dec.More() {
+			name, err := stringToken(dec)
+			if err != nil {
+				return nil, err
+			}
+			if !keep(name) {
+				var skip json.RawMessage
+				if err = dec.Decode(&skip); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			var cvs repo.ChartVersions
+			if err = dec.Decode(&cvs); err != nil {
+				return nil, err
+			}
+			if err = fn(name, cvs); err != nil {
+				return nil, err
+			}
+		}
+		if err = expectDelim(dec, '}'); err != nil {
+			return nil, err
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	h := &indexHeader{}
+	if err = json.Unmarshal(b, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// streamYAMLIndex parses a YAML index from the bufio.Reader, calling fn for
+// the entries of every chart for which keep returns true.
+//
+// The entries are scanned line by line, relying on the block style in which
+// Helm writes an index. Only the lines of the chart being scanned are
+// buffered, and only the entries of kept charts are decoded. The lines of
+// charts which define an anchor are retained together with the other
+// top-level fields, so that aliases referring to them can be resolved. An
+// index with entries in flow style is decoded as a whole.
+func streamYAMLIndex(br *bufio.Reader, keep func(string) bool, fn func(string, repo.ChartVersions) error) (*indexHeader, error) {
+	var (
+		// before and after hold the top-level fields which precede and
+		// follow the entries.
+		before, after bytes.Buffer
+		// entries holds the entries in flow style, or the lines of the
+		// charts which define an anchor.
+		entries bytes.Buffer
+		section = &before
+
+		flow, inEntries, started bool
+		chartIndent              = -1
+		chart                    string
+		chartAnchor              bool
+		block, doc               bytes.Buffer
+		seen                     = map[string]struct{}{}
+		line                     []byte
+		err                      error
+	)
+
+	flushChart := func() error {
+		if chart == "" {
+			return nil
+		}
+		name := chart
+		defer func() {
+			chart, chartAnchor = "", false
+			block.Reset()
+		}()
+		if keep(name) {
+			doc.Reset()
+			doc.Write(before.Bytes())
+			doc.WriteString("entries:\n")
+			doc.Write(entries.Bytes())
+			doc.Write(block.Bytes())
+			if _, err := decodeYAMLIndex(doc.Bytes(), func(n string) bool { return n == name }, fn); err != nil {
+				return err
+			}
+		}
+		if chartAnchor {
+			entries.Write(block.Bytes())
+		}
+		return nil
+	}
+
+	for {
+		line, err = readYAMLLine(br, line)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if len(line) == 0 {
+			break
+		}
+		content := bytes.TrimLeft(line, " ")
+		indent := len(line) - len(content)
+		content = bytes.TrimSpace(content)
+
+		if len(content) == 0 || content[0] == '#' {
+			if chart != "" {
+				block.Write(line)
+			} else {
+				section.Write(line)
+			}
+			if err != nil {
+				break
+			}
+			continue
+		}
+		if indent == 0 && !started {
+			if content[0] == '%' {
+				continue
+			}
+			if marker, rest := yamlDocumentMarker(content); marker {
+				if len(rest) > 0 && rest[0] != '#' {
+					return nil, fmt.Errorf("invalid index: expected a mapping")
+				}
+				continue
+			}
+		}
+		if indent == 0 && started {
+			if marker, _ := yamlDocumentMarker(content); marker {
+				break
+			}
+		}
+		started = true
+
+		if inEntries && (indent > 0 || isYAMLSequenceItem(content)) {
+			if chartIndent < 0 {
+				chartIndent = indent
+			}
+			switch {
+			case indent == chartIndent && !isYAMLSequenceItem(content):
+				if err := flushChart(); err != nil {
+					return nil, err
+				}
+				name, _, ok := parseYAMLKey(content)
+				if !ok {
+					return nil, fmt.Errorf("invalid index: expected chart name in entries, got '%s'", content)
+				}
+				if _, ok = seen[name]; ok {
+					return nil, fmt.Errorf("invalid index: key %q already set in map", name)
+				}
+				seen[name] = struct{}{}
+				chart = name
+			case indent < chartIndent || chart == "":
+				return nil, fmt.Errorf("invalid index: expected entries to be a mapping")
+			}
+			block.Write(line)
+			chartAnchor = chartAnchor || hasYAMLAnchor(content)
+			if err != nil {
+				break
+			}
+			continue
+		}
+
+		if indent == 0 && !isYAMLSequenceItem(content) {
+			if inEntries {
+				if err := flushChart(); err != nil {
+					return nil, err
+				}
+				inEntries = false
+			}
+			if section == &entries {
+				section = &after
+			}
+			if key, value, ok := parseYAMLKey(content); ok && key == "entries" {
+				if section == &after {
+					return nil, fmt.Errorf("invalid index: key %q already set in map", key)
+				}
+				section = &after
+				if isYAMLBlockValue(value) {
+					inEntries = true
+				} else {
+					flow = true
+					section = &entries
+					section.Write(line)
+				}
+				if err != nil {
+					break
+				}
+				continue
+			}
+		}
+		section.Write(line)
+		if err != nil {
+			break
+		}
+	}
+	if err := flushChart(); err != nil {
+		return nil, err
+	}
+
+	// Decode the top-level fields, together with the entries in flow style
+	// or the retained charts defining an anchor the fields may refer to.
+	doc.Reset()
+	doc.Write(before.Bytes())
+	if flow {
+		doc.Write(entries.Bytes())
+	} else if entries.Len() > 0 {
+		doc.WriteString("entries:\n")
+		doc.Write(entries.Bytes())
+	}
+	doc.Write(after.Bytes())
+	if !flow {
+		keep = func(string) bool { return false }
+	}
+	header, err := decodeYAMLIndex(doc.Bytes(), keep, fn)
+	if err != nil {
+		return nil, err
+	}
+	h := &indexHeader{}
+	if err = unmarshalYAMLNode(header, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// decodeYAMLIndex parses the given YAML index into a tree of nodes, which
+// resolves anchors and aliases across the whole document, and calls fn for
+// the entries of every chart for which keep returns true. It returns a
+// mapping node with the top-level fields other than the entries.
+func decodeYAMLIndex(b []byte, keep func(string) bool, fn func(string, repo.ChartVersions) error) (*yaml3.Node, error) {
+	header := &yaml3.Node{Kind: yaml3.MappingNode, Tag: "!!map"}
+
+	var doc yaml3.Node
+	if err := yaml3.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	root := &doc
+	if root.Kind == 0 {
+		return header, nil
+	}
+	if root.Kind == yaml3.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml3.MappingNode {
+		return nil, fmt.Errorf("invalid index: expected a mapping")
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != "entries" {
+			header.Content = append(header.Content, key, value)
+			continue
+		}
+
+		entries := resolveYAMLAlias(value)
+		if entries.Kind == yaml3.ScalarNode && entries.Tag == "!!null" {
+			continue
+		}
+		if entries.Kind != yaml3.MappingNode {
+			return nil, fmt.Errorf("invalid index: expected entries to be a mapping")
+		}
+		seen := map[string]struct{}{}
+		for j := 0; j+1 < len(entries.Content); j += 2 {
+			name := resolveYAMLAlias(entries.Content[j]).Value
+			if _, ok := seen[name]; ok {
+				return nil, fmt.Errorf("invalid index: key %q already set in map", name)
+			}
+			seen[name] = struct{}{}
+			if !keep(name) {
+				continue
+			}
+			var cvs repo.ChartVersions
+			if err := unmarshalYAMLNode(entries.Content[j+1], &cvs); err != nil {
+				return nil, fmt.Errorf("failed to parse entries of chart '%s': %w", name, err)
+			}
+			if err := fn(name, cvs); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return header, nil
+}
+
+// readYAMLLine reads the next line from the bufio.Reader into buf, including
+// the line break.
+func readYAMLLine(br *bufio.Reader, buf []byte) ([]byte, error) {
+	buf = buf[:0]
+	for {
+		b, err := br.ReadSlice('\n')
+		buf = append(buf, b...)
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return buf, err
+		}
+	}
+}
+
+// parseYAMLKey parses the (plain or quoted) key of a mapping entry from the
+// given trimmed line, and returns it with the remainder of the line after
+// the key indicator.
+func parseYAMLKey(s []byte) (string, []byte, bool) {
+	if len(s) == 0 {
+		return "", nil, false
+	}
+
+	var key string
+	switch s[0] {
+	case '"', '\'':
+		end := -1
+		for i := 1; i < len(s); i++ {
+			if s[0] == '"' && s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] != s[0] {
+				continue
+			}
+			if s[0] == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			end = i
+			break
+		}
+		if end < 0 {
+			return "", nil, false
+		}
+		if err := yaml3.Unmarshal(s[:end+1], &key); err != nil {
+			return "", nil, false
+		}
+		s = bytes.TrimLeft(s[end+1:], " \t")
+		if len(s) == 0 || s[0] != ':' {
+			return "", nil, false
+		}
+		s = s[1:]
+	case '-', '?', '[', ']', '{', '}', '&', '*', '!', '|', '>', '%', '@', '`', ',', '#':
+		return "", nil, false
+	default:
+		i := 0
+		for ; i < len(s); i++ {
+			if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ' || s[i+1] == '\t') {
+				break
+			}
+		}
+		if i == len(s) {
+			return "", nil, false
+		}
+		key = string(bytes.TrimRight(s[:i], " \t"))
+		s = s[i+1:]
+	}
+	if len(s) > 0 && s[0] != ' ' && s[0] != '\t' {
+		return "", nil, false
+	}
+	return key, bytes.TrimSpace(s), true
+}
+
+// isYAMLBlockValue returns if the given value of a mapping entry is empty,
+// ignoring an anchor and comment, meaning the value is a block on the
+// following lines.
+func isYAMLBlockValue(value []byte) bool {
+	if len(value) > 0 && value[0] == '&' {
+		if i := bytes.IndexAny(value, " \t"); i > 0 {
+			value = bytes.TrimSpace(value[i:])
+		} else {
+			value = nil
+		}
+	}
+	return len(value) == 0 || value[0] == '#'
+}
+
+// isYAMLSequenceItem returns if the given trimmed line is an item of a block
+// sequence.
+func isYAMLSequenceItem(s []byte) bool {
+	return len(s) > 0 && s[0] == '-' && (len(s) == 1 || s[1] == ' ' || s[1] == '\t')
+}
+
+// yamlDocumentMarker returns if the given trimmed line starts with a
+// document marker, and the remainder of the line.
+func yamlDocumentMarker(s []byte) (bool, []byte) {
+	if !bytes.HasPrefix(s, []byte("---")) && !bytes.HasPrefix(s, []byte("...")) {
+		return false, nil
+	}
+	if len(s) > 3 && s[3] != ' ' && s[3] != '\t' {
+		return false, nil
+	}
+	return true, bytes.TrimSpace(s[3:])
+}
+
+// hasYAMLAnchor returns if the given line may define an anchor. It may
+// report false positives for quoted text, which only causes the line to be
+// retained.
+func hasYAMLAnchor(s []byte) bool {
+	for i := bytes.IndexByte(s, '&'); i >= 0; {
+		if (i == 0 || bytes.IndexByte([]byte(" \t-:[{,?"), s[i-1]) >= 0) &&
+			i+1 < len(s) && s[i+1] != ' ' && s[i+1] != '\t' {
+			return true
+		}
+		j := bytes.IndexByte(s[i+1:], '&')
+		if j < 0 {
+			break
+		}
+		i += j + 1
+	}
+	return false
+}
+
+// unmarshalYAMLNode strictly decodes the given YAML node into v, after
+// expanding the aliases in the node to copies of the nodes they refer to.
+func unmarshalYAMLNode(n *yaml3.Node, v interface{}) error {
+	b, err := yaml3.Marshal(expandYAMLAliases(n))
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalStrict(b, v)
+}
+
+// expandYAMLAliases returns a copy of the given YAML node of which the
+// aliases are replaced with (anchor-less) copies of the nodes they refer to.
+func expandYAMLAliases(n *yaml3.Node) *yaml3.Node {
+	n = resolveYAMLAlias(n)
+	c := *n
+	c.Anchor = ""
+	if len(n.Content) > 0 {
+		c.Content = make([]*yaml3.Node, len(n.Content))
+		for i, child := range n.Content {
+			c.Content[i] = expandYAMLAliases(child)
+		}
+	}
+	return &c
+}
+
+// resolveYAMLAlias returns the node the given YAML node refers to if it is
+// an alias, or the node itself.
+func resolveYAMLAlias(n *yaml3.Node) *yaml3.Node {
+	for n.Kind == yaml3.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// sanitizeChartVersions initializes missing metadata of the given chart
+// versions, and sorts them from newest to oldest.
+// Versions which fail validation are removed in the same way as Helm does
+// while loading an index, which does not change the length of the entries
+// of a chart.
+func sanitizeChartVersions(cvs repo.ChartVersions) repo.ChartVersions {
+	valid := cvs
+	for idx := len(valid) - 1; idx >= 0; idx-- {
+		if valid[idx] == nil {
+			continue
+		}
+		// When metadata section missing, initialize with no data
+		if valid[idx].Metadata == nil {
+			valid[idx].Metadata = &chart.Metadata{}
+		}
+		if valid[idx].APIVersion == "" {
+			valid[idx].APIVersion = chart.APIVersionV1
+		}
+		if err := valid[idx].Validate(); ignoreSkippableChartValidationError(err) != nil {
+			valid = append(valid[:idx], valid[idx+1:]...)
+		}
+	}
+	sort.Sort(sort.Reverse(cvs))
+	return cvs
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			if _, err = br.ReadByte(); err != nil {
+				return 0, err
+			}
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("invalid index: expected '%s'", delim)
+	}
+	return nil
+}
+
+func stringToken(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	s, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid index: expected object key")
+	}
+	return s, nil
+}
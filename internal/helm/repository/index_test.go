@@ -0,0 +1,353 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func TestLoadIndex(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		data        string
+		charts      []string
+		wantEntries []string
+		wantErr     string
+	}{
+		{
+			name:        "YAML index with all charts",
+			file:        testFile,
+			wantEntries: []string{"alpine", "nginx", "chartWithNoURL"},
+		},
+		{
+			name:        "YAML index with selected charts",
+			file:        testFile,
+			charts:      []string{"nginx", "missing"},
+			wantEntries: []string{"nginx"},
+		},
+		{
+			name:        "JSON index with all charts",
+			file:        chartmuseumJSONTestFile,
+			wantEntries: []string{"alpine", "nginx", "chartWithNoURL"},
+		},
+		{
+			name:        "JSON index with selected charts",
+			file:        chartmuseumJSONTestFile,
+			charts:      []string{"alpine"},
+			wantEntries: []string{"alpine"},
+		},
+		{
+			name: "compact YAML sequences",
+			data: `apiVersion: v1
+entries:
+  nginx:
+  - name: nginx
+    version: 0.2.0
+    urls:
+    - https://example.com/nginx-0.2.0.tgz
+  alpine:
+  - name: alpine
+    version: 1.0.0
+generated: "2024-01-01T00:00:00Z"
+`,
+			charts:      []string{"nginx"},
+			wantEntries: []string{"nginx"},
+		},
+		{
+			name: "YAML document marker, comments and quoted names",
+			data: `---
+# Generated by a Helm repository
+apiVersion: v1
+entries:
+  # Charts
+  "nginx":
+  - name: nginx
+    version: 0.2.0
+
+  'alpine': # Alpine
+  - name: alpine
+    version: 1.0.0
+`,
+			charts:      []string{"nginx", "alpine"},
+			wantEntries: []string{"nginx", "alpine"},
+		},
+		{
+			name:        "flow style entries",
+			data:        "apiVersion: v1\nentries: {nginx: [{name: nginx, version: 0.2.0}],\n  alpine: [{name: alpine, version: 1.0.0}]}\n",
+			charts:      []string{"alpine"},
+			wantEntries: []string{"alpine"},
+		},
+		{
+			name:    "duplicate chart",
+			data:    "apiVersion: v1\nentries:\n  nginx:\n  - name: nginx\n  nginx:\n  - name: nginx\n",
+			wantErr: `key "nginx" already set in map`,
+		},
+		{
+			name:        "inline empty entries",
+			data:        "apiVersion: v1\nentries: {}\n",
+			wantEntries: []string{},
+		},
+		{
+			name:    "empty index",
+			data:    "  \n",
+			wantErr: repo.ErrEmptyIndexYaml.Error(),
+		},
+		{
+			name:    "JSON index without API version",
+			data:    `{"entries":{"nginx":[{"name":"nginx"}]}}`,
+			wantErr: repo.ErrNoAPIVersion.Error(),
+		},
+		{
+			name:    "invalid chart entry",
+			data:    "apiVersion: v1\nentries:\n  nginx:\n    - name: nginx\n      unknown: field\n",
+			wantErr: "unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			b := []byte(tt.data)
+			if tt.file != "" {
+				var err error
+				b, err = os.ReadFile(tt.file)
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+
+			i, err := LoadIndex(bytes.NewReader(b), tt.charts...)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(i.APIVersion).To(Equal("v1"))
+
+			var names []string
+			for name := range i.Entries {
+				names = append(names, name)
+			}
+			g.Expect(names).To(ConsistOf(tt.wantEntries))
+		})
+	}
+}
+
+func TestLoadIndex_anchors(t *testing.T) {
+	data := `apiVersion: v1
+entries:
+  nginx:
+  - name: nginx
+    version: 0.2.0
+    <<: &common
+      home: https://example.com
+      sources:
+      - https://github.com/example/charts
+    description: &description |
+      Deploy a basic pod
+
+      on Kubernetes
+    urls:
+    - https://example.com/nginx-0.2.0.tgz
+  alpine:
+  - <<: *common
+    name: alpine
+    version: 1.0.0
+    description: *description
+    urls:
+    - https://example.com/alpine-1.0.0.tgz
+annotations:
+  description: *description
+generated: "2024-01-01T00:00:00Z"
+`
+
+	tests := []struct {
+		name   string
+		charts []string
+	}{
+		{
+			name: "all charts",
+		},
+		{
+			name:   "anchors in skipped chart",
+			charts: []string{"alpine"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			i, err := LoadIndex(strings.NewReader(data), tt.charts...)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(i.Annotations).To(HaveKeyWithValue("description", "Deploy a basic pod\n\non Kubernetes\n"))
+
+			alpine := i.Entries["alpine"]
+			g.Expect(alpine).To(HaveLen(1))
+			g.Expect(alpine[0].Home).To(Equal("https://example.com"))
+			g.Expect(alpine[0].Sources).To(Equal([]string{"https://github.com/example/charts"}))
+			g.Expect(alpine[0].Description).To(HavePrefix("Deploy a basic pod"))
+			g.Expect(alpine[0].URLs).To(Equal([]string{"https://example.com/alpine-1.0.0.tgz"}))
+
+			if len(tt.charts) > 0 {
+				g.Expect(i.Entries).ToNot(HaveKey("nginx"))
+				return
+			}
+			nginx := i.Entries["nginx"]
+			g.Expect(nginx).To(HaveLen(1))
+			g.Expect(nginx[0].Home).To(Equal("https://example.com"))
+			g.Expect(nginx[0].Description).To(Equal(alpine[0].Description))
+		})
+	}
+}
+
+func TestLoadIndex_ParityWithIndexFromBytes(t *testing.T) {
+	g := NewWithT(t)
+
+	b, err := os.ReadFile(chartmuseumTestFile)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	i, err := LoadIndex(bytes.NewReader(b))
+	g.Expect(err).ToNot(HaveOccurred())
+	verifyLocalIndex(t, i)
+
+	i, err = LoadIndex(bytes.NewReader(b), "nginx")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(i.Entries).To(HaveLen(1))
+	g.Expect(i.Entries["nginx"]).To(HaveLen(2))
+	g.Expect(i.Entries["nginx"][0].Version).To(Equal("0.2.0"))
+}
+
+func TestStreamIndex(t *testing.T) {
+	g := NewWithT(t)
+
+	b, err := os.ReadFile(testFile)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var visited []string
+	i, err := StreamIndex(bytes.NewReader(b), func(name string) bool {
+		return name != "alpine"
+	}, func(name string, cvs repo.ChartVersions) error {
+		visited = append(visited, name)
+		g.Expect(cvs).ToNot(BeEmpty())
+		return nil
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(i.APIVersion).To(Equal("v1"))
+	g.Expect(i.Entries).To(BeEmpty())
+	g.Expect(visited).To(ConsistOf("nginx", "chartWithNoURL"))
+}
+
+func TestWriteIndexJSON(t *testing.T) {
+	for _, file := range []string{testFile, chartmuseumJSONTestFile} {
+		t.Run(file, func(t *testing.T) {
+			g := NewWithT(t)
+
+			b, err := os.ReadFile(file)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			g.Expect(WriteIndexJSON(bytes.NewReader(b), &buf, nil)).To(Succeed())
+			g.Expect(strings.HasPrefix(buf.String(), "{")).To(BeTrue())
+
+			i, err := IndexFromBytes(buf.Bytes())
+			g.Expect(err).ToNot(HaveOccurred())
+			verifyLocalIndex(t, i)
+		})
+	}
+}
+
+func TestSummarizeIndex(t *testing.T) {
+	for _, file := range []string{testFile, chartmuseumJSONTestFile} {
+		t.Run(file, func(t *testing.T) {
+			g := NewWithT(t)
+
+			b, err := os.ReadFile(file)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			summaries, err := SummarizeIndex(bytes.NewReader(b))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(summaries).To(Equal([]ChartSummary{
+				{Name: "alpine", LatestVersion: "1.0.0", Versions: 1},
+				{Name: "chartWithNoURL", LatestVersion: "1.0.0", Versions: 1},
+				{Name: "nginx", LatestVersion: "0.2.0", Versions: 2},
+			}))
+		})
+	}
+
+	t.Run("invalid index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := SummarizeIndex(strings.NewReader("entries: {}\n"))
+		g.Expect(err).To(MatchError(repo.ErrNoAPIVersion))
+	})
+}
+
+func TestLoadIndex_boundedAllocation(t *testing.T) {
+	g := NewWithT(t)
+
+	var b bytes.Buffer
+	b.WriteString("apiVersion: v1\nentries:\n")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&b, "  chart-%d:\n", i)
+		for v := 0; v < 10; v++ {
+			fmt.Fprintf(&b, `  - apiVersion: v2
+    appVersion: "1.%[2]d"
+    created: "2024-01-01T00:00:00Z"
+    description: A Helm chart for Kubernetes
+    digest: %064[2]d
+    home: https://example.com/chart-%[1]d
+    name: chart-%[1]d
+    sources:
+    - https://github.com/example/chart-%[1]d
+    urls:
+    - https://example.com/chart-%[1]d-0.%[2]d.0.tgz
+    version: 0.%[2]d.0
+`, i, v)
+		}
+	}
+	b.WriteString("generated: \"2024-01-01T00:00:00Z\"\n")
+	data := b.Bytes()
+
+	var (
+		i      *repo.IndexFile
+		err    error
+		before runtime.MemStats
+		after  runtime.MemStats
+	)
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	i, err = LoadIndex(bytes.NewReader(data), "chart-1000")
+	runtime.ReadMemStats(&after)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(i.Entries).To(HaveLen(1))
+	g.Expect(i.Entries["chart-1000"]).To(HaveLen(10))
+	g.Expect(i.Entries["chart-1000"][0].Version).To(Equal("0.9.0"))
+
+	// Skipping the other charts must not allocate in proportion to the
+	// size of the index.
+	allocated := after.TotalAlloc - before.TotalAlloc
+	g.Expect(allocated).To(BeNumerically("<", len(data)/4),
+		"allocated %d bytes to load a single chart from an index of %d bytes", allocated, len(data))
+}
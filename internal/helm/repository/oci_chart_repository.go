@@ -27,6 +27,7 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"time"
 
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/getter"
@@ -35,6 +36,8 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/opencontainers/go-digest"
 
 	"github.com/fluxcd/pkg/version"
 	"github.com/fluxcd/source-controller/internal/oci"
@@ -50,6 +53,15 @@ type RegistryClient interface {
 	Tags(url string) ([]string, error)
 }
 
+// TagCache is used by an OCIChartRepository to store and retrieve the tags
+// of the charts in the registry.
+type TagCache interface {
+	// Get returns the tags of the chart with the given reference.
+	Get(ref string) ([]string, bool)
+	// Set stores the tags of the chart with the given reference.
+	Set(ref string, tags []string)
+}
+
 // OCIChartRepository represents a Helm chart repository, and the configuration
 // required to download the repository tags and charts from the repository.
 // All methods are thread safe unless defined otherwise.
@@ -78,6 +90,17 @@ type OCIChartRepository struct {
 
 	// insecureHTTP indicates that the chart is hosted on an insecure HTTP registry.
 	insecureHTTP bool
+
+	// remoteOpts is a list of options to use while resolving the digest of
+	// a chart tag in the registry.
+	remoteOpts []remote.Option
+
+	// versionPolicy restricts the versions which can be returned by
+	// GetChartVersion.
+	versionPolicy *VersionPolicy
+
+	// tagCache is used to store and retrieve the tags of the charts, if set.
+	tagCache TagCache
 }
 
 // OCIChartRepositoryOption is a function that can be passed to NewOCIChartRepository
@@ -99,6 +122,33 @@ func WithInsecureHTTP() OCIChartRepositoryOption {
 	}
 }
 
+// WithRemoteOptions returns a ChartRepositoryOption that will set the options
+// used to resolve the digest of a chart tag
+func WithRemoteOptions(opts ...remote.Option) OCIChartRepositoryOption {
+	return func(r *OCIChartRepository) error {
+		r.remoteOpts = opts
+		return nil
+	}
+}
+
+// WithVersionPolicy returns a ChartRepositoryOption that will set the policy
+// chart versions must satisfy to be returned by GetChartVersion
+func WithVersionPolicy(policy *VersionPolicy) OCIChartRepositoryOption {
+	return func(r *OCIChartRepository) error {
+		r.versionPolicy = policy
+		return nil
+	}
+}
+
+// WithTagCache returns a ChartRepositoryOption that will set the cache used
+// to store and retrieve the tags of the charts
+func WithTagCache(cache TagCache) OCIChartRepositoryOption {
+	return func(r *OCIChartRepository) error {
+		r.tagCache = cache
+		return nil
+	}
+}
+
 // WithOCIRegistryClient returns a ChartRepositoryOption that will set the registry client
 func WithOCIRegistryClient(client RegistryClient) OCIChartRepositoryOption {
 	return func(r *OCIChartRepository) error {
@@ -167,22 +217,124 @@ func NewOCIChartRepository(repositoryURL string, chartRepoOpts ...OCIChartReposi
 // GetChartVersion returns the repo.ChartVersion for the given name, the version is expected
 // to be a semver.Constraints compatible string. If version is empty, the latest
 // stable version will be returned and prerelease versions will be ignored.
+// The version can be pinned to a manifest digest using the '<version>@<digest>'
+// format, in which case the chart is fetched by digest.
+// Versions which are not allowed by the version policy are never returned.
 // adapted from https://github.com/helm/helm/blob/49819b4ef782e80b0c7f78c30bd76b51ebb56dc8/pkg/downloader/chart_downloader.go#L162
 func (r *OCIChartRepository) GetChartVersion(name, ver string) (*repo.ChartVersion, error) {
-	cv, err := r.getChartVersion(name, ver)
+	if r.versionPolicy != nil && r.versionPolicy.SoakTime > 0 {
+		return nil, &ErrReference{Err: fmt.Errorf("version policy soak time is not supported for OCI Helm repositories")}
+	}
+
+	if tag, dig, ok := strings.Cut(ver, "@"); ok {
+		cv, err := r.getPinnedChartVersion(name, tag, dig)
+		if err != nil {
+			return nil, err
+		}
+		if !r.versionPolicy.AllowsChartVersion(cv) {
+			return nil, &ErrReference{Err: fmt.Errorf("'%s' chart version '%s' is not allowed by the version policy", name, tag)}
+		}
+		return cv, nil
+	}
+
+	cv, err := r.getChartVersion(name, ver, r.versionPolicy)
+	if err != nil {
+		return nil, &ErrExternal{Err: err}
+	}
+	return cv, nil
+}
+
+// GetLatestChartVersion returns the repo.ChartVersion of the latest stable
+// version of the chart with the given name, regardless of the version policy.
+func (r *OCIChartRepository) GetLatestChartVersion(name string) (*repo.ChartVersion, error) {
+	cv, err := r.getChartVersion(name, "", nil)
 	if err != nil {
 		return nil, &ErrExternal{Err: err}
 	}
 	return cv, nil
 }
 
-func (r *OCIChartRepository) getChartVersion(name, ver string) (*repo.ChartVersion, error) {
+// getPinnedChartVersion returns the repo.ChartVersion for the given name
+// and tag, pinned to the given manifest digest. It returns an error if the
+// tag no longer resolves to the digest in the registry.
+func (r *OCIChartRepository) getPinnedChartVersion(name, tag, dig string) (*repo.ChartVersion, error) {
+	d, err := digest.Parse(dig)
+	if err != nil {
+		return nil, &ErrReference{Err: fmt.Errorf("invalid digest '%s' for chart '%s': %w", dig, name, err)}
+	}
+	if _, err := version.ParseVersion(tag); err != nil {
+		return nil, &ErrReference{Err: fmt.Errorf("invalid version '%s' for chart '%s' pinned to digest: %w", tag, name, err)}
+	}
+
+	cpURL := r.URL
+	cpURL.Path = path.Join(cpURL.Path, name)
+
+	current, err := r.resolveDigest(fmt.Sprintf("%s:%s", cpURL.String(), tag))
+	if err != nil {
+		return nil, &ErrExternal{Err: fmt.Errorf("could not resolve digest for %q: %w", name, err)}
+	}
+	if current != d.String() {
+		return nil, &ErrExternal{Err: fmt.Errorf("tag '%s' of chart '%s' has moved: expected digest '%s', got '%s'",
+			tag, name, d.String(), current)}
+	}
+
+	return &repo.ChartVersion{
+		URLs: []string{fmt.Sprintf("%s@%s", cpURL.String(), d.String())},
+		Metadata: &chart.Metadata{
+			Name:    name,
+			Version: tag,
+		},
+		Digest: d.String(),
+	}, nil
+}
+
+// resolveDigest returns the manifest digest the given reference currently
+// resolves to in the registry.
+func (r *OCIChartRepository) resolveDigest(ref string) (string, error) {
+	var nameOpts []name.Option
+	if r.insecureHTTP {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+
+	nameRef, err := name.ParseReference(strings.TrimPrefix(ref, fmt.Sprintf("%s://", registry.OCIScheme)), nameOpts...)
+	if err != nil {
+		return "", fmt.Errorf("invalid chart reference: %w", err)
+	}
+
+	desc, err := remote.Head(nameRef, r.remoteOpts...)
+	if err == nil {
+		return desc.Digest.String(), nil
+	}
+	rdesc, err := remote.Get(nameRef, r.remoteOpts...)
+	if err != nil {
+		return "", err
+	}
+	return rdesc.Descriptor.Digest.String(), nil
+}
+
+// ResolveChartDigest returns the manifest digest of the given
+// repo.ChartVersion, resolving the tag of its URL in the registry if the
+// chart version is not pinned to a digest.
+func (r *OCIChartRepository) ResolveChartDigest(chart *repo.ChartVersion) (string, error) {
+	if chart.Digest != "" {
+		return chart.Digest, nil
+	}
+	if len(chart.URLs) == 0 {
+		return "", fmt.Errorf("chart '%s' has no downloadable URLs", chart.Name)
+	}
+	return r.resolveDigest(chart.URLs[0])
+}
+
+func (r *OCIChartRepository) getChartVersion(name, ver string, policy *VersionPolicy) (*repo.ChartVersion, error) {
 	cpURL := r.URL
 	cpURL.Path = path.Join(cpURL.Path, name)
 
 	// if ver is a valid semver version, take a shortcut here so we don't need to list all tags which can be an
 	// expensive operation.
-	if _, err := version.ParseVersion(ver); err == nil {
+	if v, err := version.ParseVersion(ver); err == nil {
+		if !policy.Allows(v, time.Time{}) {
+			return nil, fmt.Errorf("'%s' chart version '%s' is not allowed by the version policy", name, ver)
+		}
 		return &repo.ChartVersion{
 			URLs: []string{fmt.Sprintf("%s:%s", cpURL.String(), ver)},
 			Metadata: &chart.Metadata{
@@ -197,7 +349,7 @@ func (r *OCIChartRepository) getChartVersion(name, ver string) (*repo.ChartVersi
 
 	cvs, err := r.getTags(cpURL.String())
 	if err != nil {
-		return nil, fmt.Errorf("could not get tags for %q: %s", name, err)
+		return nil, fmt.Errorf("could not get tags for %q: %w", name, err)
 	}
 
 	if len(cvs) == 0 {
@@ -208,7 +360,7 @@ func (r *OCIChartRepository) getChartVersion(name, ver string) (*repo.ChartVersi
 	// If empty, try to get the highest available tag
 	// If exact version, try to find it
 	// If semver constraint string, try to find a match
-	tag, err := getLastMatchingVersionOrConstraint(cvs, ver)
+	tag, err := getLastMatchingVersionOrConstraint(cvs, ver, policy)
 	return &repo.ChartVersion{
 		URLs: []string{fmt.Sprintf("%s:%s", cpURL.String(), tag)},
 		Metadata: &chart.Metadata{
@@ -218,18 +370,40 @@ func (r *OCIChartRepository) getChartVersion(name, ver string) (*repo.ChartVersi
 	}, err
 }
 
+// ListTags lists the tags of the chart with the given name in the registry,
+// without consulting the TagCache, and stores them in the TagCache if set.
+// It returns an error if the tags can not be listed, or if the chart does
+// not have any tags.
+func (r *OCIChartRepository) ListTags(name string) ([]string, error) {
+	cpURL := r.URL
+	cpURL.Path = path.Join(cpURL.Path, name)
+	return r.fetchTags(cpURL.String())
+}
+
 // This function shall be called for OCI registries only
 // It assumes that the ref has been validated to be an OCI reference.
 func (r *OCIChartRepository) getTags(ref string) ([]string, error) {
+	if r.tagCache != nil {
+		if tags, ok := r.tagCache.Get(ref); ok && len(tags) > 0 {
+			return tags, nil
+		}
+	}
+	return r.fetchTags(ref)
+}
+
+func (r *OCIChartRepository) fetchTags(ref string) ([]string, error) {
 	// Retrieve list of repository tags
 	tags, err := r.RegistryClient.Tags(strings.TrimPrefix(ref, fmt.Sprintf("%s://", registry.OCIScheme)))
 	if err != nil {
-		return nil, fmt.Errorf("could not fetch tags for %q: %s", ref, err)
+		return nil, fmt.Errorf("could not fetch tags for %q: %w", ref, err)
 	}
 	if len(tags) == 0 {
 		return nil, fmt.Errorf("unable to locate any tags in provided repository: %s", ref)
 	}
 
+	if r.tagCache != nil {
+		r.tagCache.Set(ref, tags)
+	}
 	return tags, nil
 }
 
@@ -310,11 +484,14 @@ func (r *OCIChartRepository) Clear() error {
 
 // getLastMatchingVersionOrConstraint returns the last version that matches the given version string.
 // If the version string is empty, the highest available version is returned.
-func getLastMatchingVersionOrConstraint(cvs []string, ver string) (string, error) {
+func getLastMatchingVersionOrConstraint(cvs []string, ver string, policy *VersionPolicy) (string, error) {
 	// Check for exact matches first
 	if ver != "" {
 		for _, cv := range cvs {
 			if ver == cv {
+				if !policy.AllowsChartVersion(&repo.ChartVersion{Metadata: &chart.Metadata{Version: cv}}) {
+					return "", fmt.Errorf("version %s is not allowed by the version policy", ver)
+				}
 				return cv, nil
 			}
 		}
@@ -340,7 +517,7 @@ func getLastMatchingVersionOrConstraint(cvs []string, ver string) (string, error
 			continue
 		}
 
-		if !verConstraint.Check(v) {
+		if !verConstraint.Check(v) || !policy.Allows(v, time.Time{}) {
 			continue
 		}
 
@@ -18,12 +18,19 @@ package repository
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"net/http/httptest"
 	"net/url"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	gcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	. "github.com/onsi/gomega"
 	"helm.sh/helm/v3/pkg/chart"
 	helmgetter "helm.sh/helm/v3/pkg/getter"
@@ -122,6 +129,7 @@ func TestOCIChartRepository_Get(t *testing.T) {
 		registryClient RegistryClient
 		url            string
 		version        string
+		policy         *VersionPolicy
 		expected       string
 		expectedErr    string
 	}{
@@ -174,6 +182,38 @@ func TestOCIChartRepository_Get(t *testing.T) {
 			url:            testURL,
 			expectedErr:    "could not locate a version matching provided version string >2.0.0",
 		},
+		{
+			name:           "should exclude pre-release by policy",
+			registryClient: registryClient,
+			version:        ">=1.0.0-0",
+			policy:         &VersionPolicy{ExcludePrerelease: true},
+			url:            testURL,
+			expected:       "1.0.0",
+		},
+		{
+			name:           "should exclude denied version by policy",
+			registryClient: registryClient,
+			version:        "",
+			policy:         mustVersionPolicy(nil, []string{"1.0.0"}),
+			url:            testURL,
+			expected:       "0.10.0",
+		},
+		{
+			name:           "should return error for perfect match denied by policy",
+			registryClient: nil,
+			version:        "1.0.0",
+			policy:         mustVersionPolicy(nil, []string{"1.0.0"}),
+			url:            testURL,
+			expectedErr:    "'podinfo' chart version '1.0.0' is not allowed by the version policy",
+		},
+		{
+			name:           "should return error for policy with soak time",
+			registryClient: registryClient,
+			version:        "",
+			policy:         &VersionPolicy{SoakTime: time.Hour},
+			url:            testURL,
+			expectedErr:    "version policy soak time is not supported for OCI Helm repositories",
+		},
 		{
 			name:           "shouldn't error out with trailing slash",
 			registryClient: registryClient,
@@ -187,7 +227,8 @@ func TestOCIChartRepository_Get(t *testing.T) {
 
 		t.Run(tc.name, func(t *testing.T) {
 			g := NewWithT(t)
-			r, err := NewOCIChartRepository(tc.url, WithOCIRegistryClient(tc.registryClient), WithOCIGetter(providers))
+			r, err := NewOCIChartRepository(tc.url, WithOCIRegistryClient(tc.registryClient), WithOCIGetter(providers),
+				WithVersionPolicy(tc.policy))
 			g.Expect(err).ToNot(HaveOccurred())
 			g.Expect(r).ToNot(BeNil())
 
@@ -209,6 +250,146 @@ func TestOCIChartRepository_Get(t *testing.T) {
 	}
 }
 
+type mockTagCache map[string][]string
+
+func (c mockTagCache) Get(ref string) ([]string, bool) {
+	tags, ok := c[ref]
+	return tags, ok
+}
+
+func (c mockTagCache) Set(ref string, tags []string) {
+	c[ref] = tags
+}
+
+func TestOCIChartRepository_TagCache(t *testing.T) {
+	g := NewWithT(t)
+
+	registryClient := &mockRegistryClient{
+		tags: []string{"0.1.0", "0.2.0"},
+	}
+	tagCache := mockTagCache{}
+	r, err := NewOCIChartRepository("oci://localhost:5000/my_repo",
+		WithOCIRegistryClient(registryClient), WithTagCache(tagCache))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// Listing the tags stores them in the cache.
+	tags, err := r.ListTags("podinfo")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(tags).To(Equal([]string{"0.1.0", "0.2.0"}))
+	g.Expect(registryClient.LastCalledURL).To(Equal("localhost:5000/my_repo/podinfo"))
+	g.Expect(tagCache).To(HaveKeyWithValue("oci://localhost:5000/my_repo/podinfo", tags))
+
+	// Resolving a version constraint uses the cached tags.
+	registryClient.LastCalledURL = ""
+	registryClient.tags = []string{"0.1.0", "0.2.0", "0.3.0"}
+	cv, err := r.GetChartVersion("podinfo", "*")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cv.Version).To(Equal("0.2.0"))
+	g.Expect(registryClient.LastCalledURL).To(BeEmpty())
+
+	// Listing the tags bypasses the cache, and refreshes it.
+	tags, err = r.ListTags("podinfo")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(tags).To(HaveLen(3))
+	cv, err = r.GetChartVersion("podinfo", "*")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cv.Version).To(Equal("0.3.0"))
+
+	// Charts without tags are not cached.
+	registryClient.tags = nil
+	_, err = r.ListTags("empty")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(tagCache).ToNot(HaveKey("oci://localhost:5000/my_repo/empty"))
+}
+
+func TestOCIChartRepository_GetChartVersion_Pinned(t *testing.T) {
+	srv := httptest.NewServer(gcrregistry.New())
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	pushImage := func(t *testing.T, tag string) string {
+		ref, err := name.ParseReference(fmt.Sprintf("%s/my_repo/podinfo:%s", host, tag), name.Insecure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = remote.Write(ref, img); err != nil {
+			t.Fatal(err)
+		}
+		dig, err := img.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dig.String()
+	}
+	pinned := pushImage(t, "1.0.0")
+	moved := pushImage(t, "1.1.0")
+	_ = pushImage(t, "1.1.0")
+
+	testURL := fmt.Sprintf("oci://%s/my_repo", host)
+
+	testCases := []struct {
+		name        string
+		version     string
+		expected    string
+		expectedErr string
+		wantRefErr  bool
+	}{
+		{
+			name:     "should return chart version pinned to digest",
+			version:  "1.0.0@" + pinned,
+			expected: fmt.Sprintf("%s/podinfo@%s", testURL, pinned),
+		},
+		{
+			name:        "should error when tag has moved",
+			version:     "1.1.0@" + moved,
+			expectedErr: "tag '1.1.0' of chart 'podinfo' has moved",
+		},
+		{
+			name:        "should error on unknown tag",
+			version:     "2.0.0@" + pinned,
+			expectedErr: "could not resolve digest for \"podinfo\"",
+		},
+		{
+			name:        "should error on invalid digest",
+			version:     "1.0.0@sha256:invalid",
+			expectedErr: "invalid digest 'sha256:invalid'",
+			wantRefErr:  true,
+		},
+		{
+			name:        "should error on version range",
+			version:     ">=1.0.0@" + pinned,
+			expectedErr: "invalid version '>=1.0.0'",
+			wantRefErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r, err := NewOCIChartRepository(testURL, WithInsecureHTTP())
+			g.Expect(err).ToNot(HaveOccurred())
+
+			cv, err := r.GetChartVersion("podinfo", tc.version)
+			if tc.expectedErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tc.expectedErr))
+				var refErr *ErrReference
+				g.Expect(errors.As(err, &refErr)).To(Equal(tc.wantRefErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(cv.URLs).To(Equal([]string{tc.expected}))
+			g.Expect(cv.Version).To(Equal(strings.Split(tc.version, "@")[0]))
+			g.Expect(cv.Digest).To(Equal(pinned))
+		})
+	}
+}
+
 func TestOCIChartRepository_DownloadChart(t *testing.T) {
 	testCases := []struct {
 		name         string
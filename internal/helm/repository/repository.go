@@ -30,6 +30,9 @@ type Downloader interface {
 	// GetChartVersion returns the repo.ChartVersion for the given name and version
 	// from the remote Helm repository or OCI Helm repository.
 	GetChartVersion(name, version string) (*repo.ChartVersion, error)
+	// GetLatestChartVersion returns the repo.ChartVersion of the latest stable version
+	// of the chart with the given name, regardless of any version policy.
+	GetLatestChartVersion(name string) (*repo.ChartVersion, error)
 	// DownloadChart downloads a chart from the remote Helm repository or OCI Helm repository.
 	DownloadChart(chart *repo.ChartVersion) (*bytes.Buffer, error)
 	// VerifyChart verifies the chart against a signature.
@@ -69,6 +69,8 @@ func ValidateDepURL(repositoryURL string) error {
 		return nil
 	case strings.HasPrefix(repositoryURL, "https://") || strings.HasPrefix(repositoryURL, "http://"):
 		return nil
+	case strings.HasPrefix(repositoryURL, "s3://") || strings.HasPrefix(repositoryURL, "gs://"):
+		return nil
 	case strings.HasPrefix(repositoryURL, alias):
 		return fmt.Errorf("%w: %s", errInvalidAliasedDep, repositoryURL)
 	default:
@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/repo"
+
+	"github.com/fluxcd/pkg/version"
+)
+
+// VersionPolicy restricts the chart versions which can be selected from a
+// repository, in addition to the version constraint.
+type VersionPolicy struct {
+	// ExcludePrerelease excludes pre-release versions.
+	ExcludePrerelease bool
+	// SoakTime is the minimum time since a version was created before it
+	// can be selected. Versions without a creation time are excluded.
+	SoakTime time.Duration
+
+	// allow is a list of constraints of which at least one must match for
+	// a version to be selected. If empty, all versions are allowed.
+	allow []*semver.Constraints
+	// deny is a list of constraints of which none must match for a version
+	// to be selected.
+	deny []*semver.Constraints
+}
+
+// NewVersionPolicy returns a VersionPolicy with the given options. The allow
+// and deny lists contain versions or SemVer constraints, and an error is
+// returned if an entry can not be parsed.
+func NewVersionPolicy(excludePrerelease bool, soakTime time.Duration, allow, deny []string) (*VersionPolicy, error) {
+	p := &VersionPolicy{
+		ExcludePrerelease: excludePrerelease,
+		SoakTime:          soakTime,
+	}
+	var err error
+	if p.allow, err = parseConstraints(allow); err != nil {
+		return nil, fmt.Errorf("invalid allow list: %w", err)
+	}
+	if p.deny, err = parseConstraints(deny); err != nil {
+		return nil, fmt.Errorf("invalid deny list: %w", err)
+	}
+	return p, nil
+}
+
+// Allows returns whether the given version, created at the given time,
+// satisfies the VersionPolicy. A nil VersionPolicy allows any version.
+func (p *VersionPolicy) Allows(v *semver.Version, created time.Time) bool {
+	if p == nil {
+		return true
+	}
+	if p.ExcludePrerelease && v.Prerelease() != "" {
+		return false
+	}
+	if p.SoakTime > 0 && (created.IsZero() || time.Since(created) < p.SoakTime) {
+		return false
+	}
+	if len(p.allow) > 0 && !matchesAny(p.allow, v) {
+		return false
+	}
+	return !matchesAny(p.deny, v)
+}
+
+// AllowsChartVersion returns whether the given repo.ChartVersion satisfies
+// the VersionPolicy. Chart versions which are not a valid SemVer version are
+// only allowed by a nil VersionPolicy.
+func (p *VersionPolicy) AllowsChartVersion(cv *repo.ChartVersion) bool {
+	if p == nil {
+		return true
+	}
+	v, err := version.ParseVersion(cv.Version)
+	if err != nil {
+		return false
+	}
+	return p.Allows(v, cv.Created)
+}
+
+func parseConstraints(list []string) ([]*semver.Constraints, error) {
+	var res []*semver.Constraints
+	for _, s := range list {
+		c, err := semver.NewConstraint(s)
+		if err != nil {
+			return nil, fmt.Errorf("'%s': %w", s, err)
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+func matchesAny(constraints []*semver.Constraints, v *semver.Version) bool {
+	for _, c := range constraints {
+		if c.Check(v) {
+			return true
+		}
+	}
+	return false
+}
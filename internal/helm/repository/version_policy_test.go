@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func mustVersionPolicy(allow, deny []string) *VersionPolicy {
+	p, err := NewVersionPolicy(false, 0, allow, deny)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestNewVersionPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	p, err := NewVersionPolicy(true, time.Hour, []string{"1.0.0", ">=2.0.0 <3.0.0"}, []string{"2.1.x"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(p.ExcludePrerelease).To(BeTrue())
+	g.Expect(p.SoakTime).To(Equal(time.Hour))
+
+	_, err = NewVersionPolicy(false, 0, []string{"invalid"}, nil)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid allow list: 'invalid'"))
+
+	_, err = NewVersionPolicy(false, 0, nil, []string{"invalid"})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid deny list: 'invalid'"))
+}
+
+func TestVersionPolicy_Allows(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *VersionPolicy
+		version string
+		created time.Time
+		want    bool
+	}{
+		{
+			name:    "nil policy",
+			version: "1.0.0-rc.1",
+			want:    true,
+		},
+		{
+			name:    "pre-release excluded",
+			policy:  &VersionPolicy{ExcludePrerelease: true},
+			version: "1.0.0-rc.1",
+			want:    false,
+		},
+		{
+			name:    "stable release with pre-release excluded",
+			policy:  &VersionPolicy{ExcludePrerelease: true},
+			version: "1.0.0",
+			want:    true,
+		},
+		{
+			name:    "soaked version",
+			policy:  &VersionPolicy{SoakTime: time.Hour},
+			version: "1.0.0",
+			created: time.Now().Add(-2 * time.Hour),
+			want:    true,
+		},
+		{
+			name:    "version within soak time",
+			policy:  &VersionPolicy{SoakTime: time.Hour},
+			version: "1.0.0",
+			created: time.Now().Add(-time.Minute),
+			want:    false,
+		},
+		{
+			name:    "version without created time with soak time",
+			policy:  &VersionPolicy{SoakTime: time.Hour},
+			version: "1.0.0",
+			want:    false,
+		},
+		{
+			name:    "allowed version",
+			policy:  mustVersionPolicy([]string{"1.0.0", "2.x"}, nil),
+			version: "2.1.0",
+			want:    true,
+		},
+		{
+			name:    "version not in allow list",
+			policy:  mustVersionPolicy([]string{"1.0.0", "2.x"}, nil),
+			version: "1.1.0",
+			want:    false,
+		},
+		{
+			name:    "denied version",
+			policy:  mustVersionPolicy(nil, []string{"1.0.0", "2.x"}),
+			version: "2.1.0",
+			want:    false,
+		},
+		{
+			name:    "allowed and denied version",
+			policy:  mustVersionPolicy([]string{"2.x"}, []string{"2.1.0"}),
+			version: "2.1.0",
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			v := semver.MustParse(tt.version)
+			g.Expect(tt.policy.Allows(v, tt.created)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestVersionPolicy_AllowsChartVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	cv := func(version string) *repo.ChartVersion {
+		return &repo.ChartVersion{Metadata: &chart.Metadata{Name: "chart", Version: version}}
+	}
+
+	var nilPolicy *VersionPolicy
+	g.Expect(nilPolicy.AllowsChartVersion(cv("invalid"))).To(BeTrue())
+
+	p := mustVersionPolicy(nil, []string{"1.0.0"})
+	g.Expect(p.AllowsChartVersion(cv("invalid"))).To(BeFalse())
+	g.Expect(p.AllowsChartVersion(cv("1.0.0"))).To(BeFalse())
+	g.Expect(p.AllowsChartVersion(cv("1.1.0"))).To(BeTrue())
+}
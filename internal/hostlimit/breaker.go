@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostlimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// State is the state of the circuit breaker of a host.
+type State int
+
+const (
+	// StateClosed allows all operations on the host.
+	StateClosed State = iota
+	// StateOpen fails all operations on the host fast, until the backoff
+	// elapsed.
+	StateOpen
+	// StateHalfOpen allows a single probe operation on the host, which
+	// closes the circuit breaker on success, or opens it again on failure.
+	StateHalfOpen
+)
+
+// String returns the name of the State.
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker is the circuit breaker state of a host.
+type breaker struct {
+	state     State
+	failures  int
+	backoff   time.Duration
+	openUntil time.Time
+	probing   bool
+}
+
+// HostUnavailableError is returned by Limiter.Reserve when the circuit
+// breaker of the host is open.
+type HostUnavailableError struct {
+	// Host is the host of the operation.
+	Host string
+	// Failures is the number of consecutive failed operations on the host.
+	Failures int
+	// RetryAfter is the duration after which the operation may be
+	// attempted again.
+	RetryAfter time.Duration
+}
+
+// Error implements error interface.
+func (e *HostUnavailableError) Error() string {
+	return fmt.Sprintf("host '%s' is unavailable after %d consecutive failures, retrying in %s",
+		e.Host, e.Failures, e.RetryAfter.Round(time.Second))
+}
+
+// allow returns a HostUnavailableError if the circuit breaker of the host
+// does not allow an operation at the given time. Otherwise, it returns true
+// if the operation is a probe. It must be called with the lock held.
+func (l *Limiter) allow(host string, s *hostState, now time.Time) (bool, error) {
+	b := &s.breaker
+	switch {
+	case b.state == StateClosed:
+		return false, nil
+	case b.state == StateOpen && !now.Before(b.openUntil):
+		return true, nil
+	case b.state == StateOpen:
+		return false, &HostUnavailableError{Host: host, Failures: b.failures, RetryAfter: b.openUntil.Sub(now)}
+	case !b.probing:
+		// A probe was released without a result.
+		return true, nil
+	default:
+		return false, &HostUnavailableError{Host: host, Failures: b.failures, RetryAfter: l.opts.MinBreakerBackoff}
+	}
+}
+
+// recordFailure records a failed operation, and opens the circuit breaker
+// when the failure threshold is reached, or when a probe failed. It must be
+// called with the lock held.
+func (l *Limiter) recordFailure(host string, s *hostState, now time.Time) {
+	b := &s.breaker
+	b.failures++
+	if l.opts.FailureThreshold <= 0 {
+		return
+	}
+	switch {
+	case b.state == StateHalfOpen:
+		b.backoff = min(2*b.backoff, l.opts.MaxBreakerBackoff)
+	case b.state == StateClosed && b.failures >= l.opts.FailureThreshold:
+		b.backoff = l.opts.MinBreakerBackoff
+	default:
+		return
+	}
+	b.openUntil = now.Add(b.backoff)
+	l.setBreakerState(host, s, StateOpen)
+}
+
+// recordSuccess records a successful operation, and closes the circuit
+// breaker. It must be called with the lock held.
+func (l *Limiter) recordSuccess(host string, s *hostState) {
+	s.breaker.failures = 0
+	s.breaker.backoff = 0
+	l.setBreakerState(host, s, StateClosed)
+}
+
+// setBreakerState sets the state of the circuit breaker of the host. It must
+// be called with the lock held.
+func (l *Limiter) setBreakerState(host string, s *hostState, state State) {
+	s.breaker.state = state
+	l.metrics.setBreakerState(host, state)
+}
+
+// IsHostFailure returns true if the error indicates the remote host is
+// unavailable, as opposed to an error returned by an available host, like a
+// failed authentication or a missing reference.
+func IsHostFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	// Not all clients wrap the underlying errors, fall back to the messages
+	// of common network errors and gateway responses.
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{
+		"connection refused",
+		"connection reset",
+		"no such host",
+		"i/o timeout",
+		"tls handshake timeout",
+		"context deadline exceeded",
+		"network is unreachable",
+		"502 bad gateway",
+		"503 service unavailable",
+		"504 gateway timeout",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
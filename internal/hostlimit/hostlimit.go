@@ -0,0 +1,272 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hostlimit limits the concurrency and rate of remote operations per
+// host, shared by the clients of all reconcilers, and stops operations on
+// hosts which keep failing with a circuit breaker.
+package hostlimit
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultConcurrencyDelay is the delay returned by Limiter.Reserve when
+	// the maximum number of concurrent operations on a host is reached.
+	DefaultConcurrencyDelay = 5 * time.Second
+	// DefaultMinBreakerBackoff is the default duration a circuit breaker
+	// stays open after it opened.
+	DefaultMinBreakerBackoff = 30 * time.Second
+	// DefaultMaxBreakerBackoff is the default maximum duration a circuit
+	// breaker stays open after consecutive failed probes.
+	DefaultMaxBreakerBackoff = 10 * time.Minute
+)
+
+// Options configures a Limiter.
+type Options struct {
+	// MaxConcurrent is the maximum number of concurrent operations per host.
+	// Zero is unlimited.
+	MaxConcurrent int
+	// RequestsPerSecond is the maximum number of operations started per
+	// second per host. Zero is unlimited.
+	RequestsPerSecond float64
+	// Burst is the maximum number of operations started at once per host,
+	// when RequestsPerSecond is set. Defaults to 1.
+	Burst int
+	// FailureThreshold is the number of consecutive failed operations after
+	// which the circuit breaker of a host opens. Zero disables the circuit
+	// breaker.
+	FailureThreshold int
+	// MinBreakerBackoff is the duration a circuit breaker stays open before
+	// a probe operation is allowed, doubled after every failed probe.
+	// Defaults to DefaultMinBreakerBackoff.
+	MinBreakerBackoff time.Duration
+	// MaxBreakerBackoff is the maximum duration a circuit breaker stays open.
+	// Defaults to DefaultMaxBreakerBackoff.
+	MaxBreakerBackoff time.Duration
+}
+
+// Limiter limits the concurrency and rate of operations per remote host,
+// delays operations on hosts which responded with a Retry-After header, and
+// fails operations fast on hosts of which the circuit breaker is open.
+// A nil Limiter does not limit any operation.
+type Limiter struct {
+	opts    Options
+	metrics *Recorder
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	inFlight   int
+	rate       *rate.Limiter
+	retryAfter time.Time
+	breaker    breaker
+}
+
+// New returns a Limiter for the given Options, which records its
+// observations with the given Recorder, if not nil.
+func New(opts Options, recorder *Recorder) *Limiter {
+	if opts.Burst < 1 {
+		opts.Burst = 1
+	}
+	if opts.MinBreakerBackoff <= 0 {
+		opts.MinBreakerBackoff = DefaultMinBreakerBackoff
+	}
+	if opts.MaxBreakerBackoff < opts.MinBreakerBackoff {
+		opts.MaxBreakerBackoff = max(DefaultMaxBreakerBackoff, opts.MinBreakerBackoff)
+	}
+	return &Limiter{
+		opts:    opts,
+		metrics: recorder,
+		hosts:   make(map[string]*hostState),
+	}
+}
+
+// MustMakeLimiter returns a new Limiter for the given Options, with its
+// metrics registered in the controller-runtime metrics registry.
+func MustMakeLimiter(opts Options) *Limiter {
+	return New(opts, MustMakeMetrics())
+}
+
+// Reserve reserves an operation on the given host. If the operation may
+// start, it returns a Reservation which must be released once the operation
+// is done, and a zero delay. If the operation is limited, it returns the
+// delay after which it should be attempted again. If the circuit breaker of
+// the host is open, it returns a HostUnavailableError.
+func (l *Limiter) Reserve(host string) (*Reservation, time.Duration, error) {
+	if l == nil || host == "" {
+		return nil, 0, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := l.host(host)
+	now := time.Now()
+	probe, err := l.allow(host, s, now)
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.retryAfter.After(now) {
+		l.metrics.incDelayed(host, DelayReasonRetryAfter)
+		return nil, s.retryAfter.Sub(now), nil
+	}
+	if l.opts.MaxConcurrent > 0 && s.inFlight >= l.opts.MaxConcurrent {
+		l.metrics.incDelayed(host, DelayReasonConcurrency)
+		return nil, DefaultConcurrencyDelay, nil
+	}
+	if s.rate != nil {
+		r := s.rate.ReserveN(now, 1)
+		if d := r.DelayFrom(now); d > 0 {
+			r.CancelAt(now)
+			l.metrics.incDelayed(host, DelayReasonRate)
+			return nil, d, nil
+		}
+	}
+
+	if probe {
+		l.setBreakerState(host, s, StateHalfOpen)
+		s.breaker.probing = true
+	}
+	s.inFlight++
+	l.metrics.setInFlight(host, s.inFlight)
+	return &Reservation{limiter: l, host: host, state: s, probe: probe}, 0, nil
+}
+
+// DelayUntil delays all operations on the given host until t.
+func (l *Limiter) DelayUntil(host string, t time.Time) {
+	if l == nil || host == "" {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s := l.host(host); t.After(s.retryAfter) {
+		s.retryAfter = t
+	}
+}
+
+// host returns the state of the given host. It must be called with the lock
+// held.
+func (l *Limiter) host(host string) *hostState {
+	s, ok := l.hosts[host]
+	if !ok {
+		s = &hostState{}
+		if l.opts.RequestsPerSecond > 0 {
+			s.rate = rate.NewLimiter(rate.Limit(l.opts.RequestsPerSecond), l.opts.Burst)
+		}
+		l.hosts[host] = s
+	}
+	return s
+}
+
+// Reservation is a reserved operation on a host. The methods of a nil
+// Reservation are no-ops.
+type Reservation struct {
+	limiter *Limiter
+	host    string
+	state   *hostState
+	probe   bool
+
+	done     bool
+	released bool
+}
+
+// Host returns the host of the Reservation.
+func (r *Reservation) Host() string {
+	if r == nil {
+		return ""
+	}
+	return r.host
+}
+
+// Done records the result of the operation with the circuit breaker of the
+// host, and returns the state of the circuit breaker before and after. An
+// error for which IsHostFailure returns true counts as a failure, any other
+// result shows the host is available. Only the first result is recorded.
+func (r *Reservation) Done(err error) (from, to State) {
+	if r == nil {
+		return StateClosed, StateClosed
+	}
+
+	l := r.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	from = r.state.breaker.state
+	if r.done {
+		return from, from
+	}
+	r.done = true
+	if r.probe {
+		r.state.breaker.probing = false
+	}
+	if IsHostFailure(err) {
+		l.metrics.incFailures(r.host)
+		l.recordFailure(r.host, r.state, time.Now())
+	} else {
+		l.recordSuccess(r.host, r.state)
+	}
+	return from, r.state.breaker.state
+}
+
+// Release releases the operation, allowing another one to start. A probe
+// operation released without a result allows a new probe.
+func (r *Reservation) Release() {
+	if r == nil {
+		return
+	}
+
+	l := r.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r.released {
+		return
+	}
+	r.released = true
+	if r.probe && !r.done {
+		r.state.breaker.probing = false
+	}
+	r.state.inFlight--
+	l.metrics.setInFlight(r.host, r.state.inFlight)
+}
+
+// Host returns the host name of the given URL, Git SCP-like address
+// ('git@github.com:org/repo') or endpoint ('s3.amazonaws.com:443'), without
+// port. It returns an empty string if no host can be determined.
+func Host(address string) string {
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			return ""
+		}
+		return strings.ToLower(u.Hostname())
+	}
+	if _, after, ok := strings.Cut(address, "@"); ok {
+		address = after
+	}
+	host, _, _ := strings.Cut(address, "/")
+	host, _, _ = strings.Cut(host, ":")
+	return strings.ToLower(host)
+}
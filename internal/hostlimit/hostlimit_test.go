@@ -0,0 +1,259 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostlimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLimiter_Reserve(t *testing.T) {
+	t.Run("nil limiter does not limit", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var l *Limiter
+		res, delay, err := l.Reserve("github.com")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(delay).To(BeZero())
+		res.Done(nil)
+		res.Release()
+	})
+
+	t.Run("max concurrent", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := New(Options{MaxConcurrent: 1}, NewRecorder())
+		res, delay, err := l.Reserve("github.com")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(delay).To(BeZero())
+
+		_, delay, _ = l.Reserve("github.com")
+		g.Expect(delay).To(Equal(DefaultConcurrencyDelay))
+
+		// Other hosts are not affected.
+		_, delay, _ = l.Reserve("gitlab.com")
+		g.Expect(delay).To(BeZero())
+
+		// Releasing twice only releases once.
+		res.Release()
+		res.Release()
+		_, delay, _ = l.Reserve("github.com")
+		g.Expect(delay).To(BeZero())
+		_, delay, _ = l.Reserve("github.com")
+		g.Expect(delay).To(Equal(DefaultConcurrencyDelay))
+	})
+
+	t.Run("requests per second", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := New(Options{RequestsPerSecond: 0.1, Burst: 2}, nil)
+		for i := 0; i < 2; i++ {
+			res, delay, err := l.Reserve("ghcr.io")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(delay).To(BeZero())
+			res.Release()
+		}
+		_, delay, _ := l.Reserve("ghcr.io")
+		g.Expect(delay).To(BeNumerically("~", 10*time.Second, time.Second))
+	})
+
+	t.Run("delay until", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := New(Options{}, nil)
+		l.DelayUntil("ghcr.io", time.Now().Add(time.Minute))
+		// An earlier time does not shorten the delay.
+		l.DelayUntil("ghcr.io", time.Now().Add(time.Second))
+
+		_, delay, _ := l.Reserve("ghcr.io")
+		g.Expect(delay).To(BeNumerically("~", time.Minute, time.Second))
+	})
+}
+
+func TestLimiter_CircuitBreaker(t *testing.T) {
+	hostErr := errors.New("dial tcp: connection refused")
+
+	// fail records a failed operation on the host, and returns the state of
+	// the circuit breaker after.
+	fail := func(g *WithT, l *Limiter, host string) State {
+		res, delay, err := l.Reserve(host)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(delay).To(BeZero())
+		defer res.Release()
+		_, to := res.Done(hostErr)
+		return to
+	}
+
+	// expire lets the open circuit breaker of the host allow a probe.
+	expire := func(l *Limiter, host string) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.hosts[host].breaker.openUntil = time.Now()
+	}
+
+	t.Run("disabled without threshold", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := New(Options{}, nil)
+		for i := 0; i < 10; i++ {
+			g.Expect(fail(g, l, "github.com")).To(Equal(StateClosed))
+		}
+	})
+
+	t.Run("opens at threshold and fails fast", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := New(Options{FailureThreshold: 2, MinBreakerBackoff: time.Minute}, NewRecorder())
+		g.Expect(fail(g, l, "github.com")).To(Equal(StateClosed))
+		g.Expect(fail(g, l, "github.com")).To(Equal(StateOpen))
+
+		_, _, err := l.Reserve("github.com")
+		var unavailable *HostUnavailableError
+		g.Expect(errors.As(err, &unavailable)).To(BeTrue())
+		g.Expect(unavailable.Host).To(Equal("github.com"))
+		g.Expect(unavailable.Failures).To(Equal(2))
+		g.Expect(unavailable.RetryAfter).To(BeNumerically("~", time.Minute, time.Second))
+
+		// Other hosts are not affected.
+		_, _, err = l.Reserve("gitlab.com")
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("non host failures reset the count", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := New(Options{FailureThreshold: 2}, nil)
+		g.Expect(fail(g, l, "github.com")).To(Equal(StateClosed))
+
+		res, _, err := l.Reserve("github.com")
+		g.Expect(err).ToNot(HaveOccurred())
+		res.Done(errors.New("authentication required"))
+		res.Release()
+
+		g.Expect(fail(g, l, "github.com")).To(Equal(StateClosed))
+	})
+
+	t.Run("successful probe closes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := New(Options{FailureThreshold: 1}, nil)
+		g.Expect(fail(g, l, "github.com")).To(Equal(StateOpen))
+		expire(l, "github.com")
+
+		probe, _, err := l.Reserve("github.com")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// Only a single probe is allowed at once.
+		_, _, err = l.Reserve("github.com")
+		g.Expect(err).To(HaveOccurred())
+
+		from, to := probe.Done(nil)
+		g.Expect(from).To(Equal(StateHalfOpen))
+		g.Expect(to).To(Equal(StateClosed))
+		probe.Release()
+
+		_, _, err = l.Reserve("github.com")
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("probe released without result allows a new probe", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := New(Options{FailureThreshold: 1}, nil)
+		g.Expect(fail(g, l, "github.com")).To(Equal(StateOpen))
+		expire(l, "github.com")
+
+		probe, _, err := l.Reserve("github.com")
+		g.Expect(err).ToNot(HaveOccurred())
+		probe.Release()
+
+		_, _, err = l.Reserve("github.com")
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("failed probe doubles the backoff", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := New(Options{
+			FailureThreshold:  1,
+			MinBreakerBackoff: time.Minute,
+			MaxBreakerBackoff: 3 * time.Minute,
+		}, nil)
+		g.Expect(fail(g, l, "github.com")).To(Equal(StateOpen))
+
+		for _, want := range []time.Duration{2 * time.Minute, 3 * time.Minute} {
+			expire(l, "github.com")
+			g.Expect(fail(g, l, "github.com")).To(Equal(StateOpen))
+
+			_, _, err := l.Reserve("github.com")
+			var unavailable *HostUnavailableError
+			g.Expect(errors.As(err, &unavailable)).To(BeTrue())
+			g.Expect(unavailable.RetryAfter).To(BeNumerically("~", want, time.Second))
+		}
+	})
+}
+
+func TestIsHostFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "deadline exceeded", err: fmt.Errorf("failed to clone: %w", context.DeadlineExceeded), want: true},
+		{name: "net error", err: &net.OpError{Op: "dial", Err: errors.New("refused")}, want: true},
+		{name: "no such host", err: errors.New("dial tcp: lookup example.invalid: no such host"), want: true},
+		{name: "bad gateway", err: errors.New("unexpected status code 502 Bad Gateway"), want: true},
+		{name: "authentication", err: errors.New("authentication required"), want: false},
+		{name: "not found", err: errors.New("unexpected status code 404 Not Found"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(IsHostFailure(tt.err)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestHost(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{address: "https://github.com/fluxcd/flux2", want: "github.com"},
+		{address: "ssh://git@GitHub.com:22/fluxcd/flux2", want: "github.com"},
+		{address: "git@github.com:fluxcd/flux2.git", want: "github.com"},
+		{address: "oci://ghcr.io/stefanprodan/manifests/podinfo", want: "ghcr.io"},
+		{address: "ghcr.io/stefanprodan/charts", want: "ghcr.io"},
+		{address: "minio.minio.svc:9000", want: "minio.minio.svc"},
+		{address: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(Host(tt.address)).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostlimit
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// DelayReasonConcurrency is the delay reason when the maximum number of
+	// concurrent operations on a host is reached.
+	DelayReasonConcurrency = "concurrency"
+	// DelayReasonRate is the delay reason when the maximum rate of
+	// operations on a host is reached.
+	DelayReasonRate = "rate"
+	// DelayReasonRetryAfter is the delay reason when a host responded with a
+	// Retry-After header.
+	DelayReasonRetryAfter = "retry_after"
+)
+
+// Recorder is a recorder for host limit metrics.
+type Recorder struct {
+	inFlightGauge     *prometheus.GaugeVec
+	delayedCounter    *prometheus.CounterVec
+	retryAfterCounter *prometheus.CounterVec
+	breakerStateGauge *prometheus.GaugeVec
+	failuresCounter   *prometheus.CounterVec
+}
+
+// NewRecorder returns a new Recorder.
+// The configured labels are: host, and reason for delayed operations.
+// The circuit breaker state is one of:
+//   - 0 (closed)
+//   - 1 (open)
+//   - 2 (half-open)
+func NewRecorder() *Recorder {
+	return &Recorder{
+		inFlightGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "gotk_host_operations_in_flight",
+				Help: "Number of remote operations in flight per host.",
+			},
+			[]string{"host"},
+		),
+		delayedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gotk_host_operations_delayed_total",
+				Help: "Total number of remote operations delayed by the host limiter.",
+			},
+			[]string{"host", "reason"},
+		),
+		retryAfterCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gotk_host_retry_after_total",
+				Help: "Total number of responses with a Retry-After header per host.",
+			},
+			[]string{"host"},
+		),
+		breakerStateGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "gotk_host_circuit_breaker_state",
+				Help: "State of the circuit breaker per host, 0 for closed, 1 for open and 2 for half-open.",
+			},
+			[]string{"host"},
+		),
+		failuresCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gotk_host_operation_failures_total",
+				Help: "Total number of remote operations failed because the host was unavailable.",
+			},
+			[]string{"host"},
+		),
+	}
+}
+
+// Collectors returns the metrics.Collector objects for the Recorder.
+func (r *Recorder) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		r.inFlightGauge,
+		r.delayedCounter,
+		r.retryAfterCounter,
+		r.breakerStateGauge,
+		r.failuresCounter,
+	}
+}
+
+func (r *Recorder) setInFlight(host string, n int) {
+	if r != nil {
+		r.inFlightGauge.WithLabelValues(host).Set(float64(n))
+	}
+}
+
+func (r *Recorder) incDelayed(host, reason string) {
+	if r != nil {
+		r.delayedCounter.WithLabelValues(host, reason).Inc()
+	}
+}
+
+func (r *Recorder) incRetryAfter(host string) {
+	if r != nil {
+		r.retryAfterCounter.WithLabelValues(host).Inc()
+	}
+}
+
+func (r *Recorder) setBreakerState(host string, state State) {
+	if r != nil {
+		r.breakerStateGauge.WithLabelValues(host).Set(float64(state))
+	}
+}
+
+func (r *Recorder) incFailures(host string) {
+	if r != nil {
+		r.failuresCounter.WithLabelValues(host).Inc()
+	}
+}
+
+// MustMakeMetrics creates a new Recorder, and registers the metrics
+// collectors in the controller-runtime metrics registry.
+func MustMakeMetrics() *Recorder {
+	r := NewRecorder()
+	metrics.Registry.MustRegister(r.Collectors()...)
+
+	return r
+}
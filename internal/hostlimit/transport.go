@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostlimit
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// MaxRetryAfter is the maximum Retry-After delay a request is retried
+	// after. Responses with a longer delay are returned to the caller, while
+	// operations on the host keep being delayed.
+	MaxRetryAfter = time.Minute
+	// maxRetries is the maximum number of times a request is retried after
+	// a Retry-After delay.
+	maxRetries = 3
+)
+
+// RoundTripper returns an http.RoundTripper which respects the Retry-After
+// header of '429 Too Many Requests' and '503 Service Unavailable' responses,
+// by delaying further operations on the host, and by retrying the request
+// after the delay if it does not exceed MaxRetryAfter.
+func (l *Limiter) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if l == nil {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &retryAfterTransport{limiter: l, next: next}
+}
+
+type retryAfterTransport struct {
+	limiter *Limiter
+	next    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := Host(req.URL.String())
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil {
+			return resp, err
+		}
+
+		delay, ok := retryAfter(resp)
+		if !ok {
+			return resp, nil
+		}
+		t.limiter.DelayUntil(host, time.Now().Add(delay))
+		t.limiter.metrics.incRetryAfter(host)
+
+		if attempt >= maxRetries || delay > MaxRetryAfter || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		// Drain and close the body to allow the connection to be reused.
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryAfter returns the delay of the Retry-After header of a '429 Too Many
+// Requests' or '503 Service Unavailable' response, in either delay-seconds or
+// HTTP-date format.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostlimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLimiter_RoundTripper(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		failures   int32
+		body       bool
+		wantStatus int
+		wantCalls  int32
+		wantDelay  bool
+	}{
+		{
+			name:       "retries after delay",
+			retryAfter: "0",
+			failures:   2,
+			body:       true,
+			wantStatus: http.StatusOK,
+			wantCalls:  3,
+		},
+		{
+			name:       "returns response after max retries",
+			retryAfter: "0",
+			failures:   10,
+			wantStatus: http.StatusTooManyRequests,
+			wantCalls:  maxRetries + 1,
+		},
+		{
+			name:       "returns response with delay exceeding max",
+			retryAfter: "3600",
+			failures:   1,
+			wantStatus: http.StatusTooManyRequests,
+			wantCalls:  1,
+			wantDelay:  true,
+		},
+		{
+			name:       "does not retry without Retry-After",
+			failures:   1,
+			wantStatus: http.StatusTooManyRequests,
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.failures {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			l := New(Options{}, NewRecorder())
+			client := &http.Client{Transport: l.RoundTripper(http.DefaultTransport)}
+
+			var req *http.Request
+			var err error
+			if tt.body {
+				req, err = http.NewRequest(http.MethodPost, server.URL, strings.NewReader("body"))
+			} else {
+				req, err = http.NewRequest(http.MethodGet, server.URL, nil)
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			resp, err := client.Do(req)
+			g.Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			g.Expect(resp.StatusCode).To(Equal(tt.wantStatus))
+			g.Expect(calls.Load()).To(Equal(tt.wantCalls))
+
+			_, delay, _ := l.Reserve(Host(server.URL))
+			if tt.wantDelay {
+				g.Expect(delay).To(BeNumerically(">", 59*time.Minute))
+			} else {
+				g.Expect(delay).To(BeZero())
+			}
+		})
+	}
+}
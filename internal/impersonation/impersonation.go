@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package impersonation provides clients which impersonate the Kubernetes
+// ServiceAccount of an object to read its Secrets, to let RBAC decide which
+// credentials a tenant may use in multi-tenant lockdown mode.
+package impersonation
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrServiceAccountRequired is returned by Impersonator.ClientFor when the
+// object does not name a ServiceAccount, and no default ServiceAccount is
+// configured.
+var ErrServiceAccountRequired = errors.New("a ServiceAccount is required in multi-tenant lockdown mode, " +
+	"but none is specified and no default is configured")
+
+// Options configures an Impersonator.
+type Options struct {
+	// Lockdown enables the multi-tenant lockdown mode, in which the Secrets
+	// of an object are read by impersonating its ServiceAccount.
+	Lockdown bool
+	// DefaultServiceAccount is the name of the ServiceAccount impersonated
+	// for objects which do not name a ServiceAccount. If empty, objects must
+	// name a ServiceAccount in lockdown mode.
+	DefaultServiceAccount string
+}
+
+// Impersonator returns clients which impersonate the ServiceAccount of an
+// object in multi-tenant lockdown mode. A nil Impersonator, or one without
+// lockdown mode enabled, does not impersonate.
+type Impersonator struct {
+	opts       Options
+	config     *rest.Config
+	clientOpts client.Options
+	newClient  func(config *rest.Config, opts client.Options) (client.Client, error)
+
+	mu      sync.Mutex
+	clients map[string]client.Client
+}
+
+// New returns an Impersonator for the given Options, which creates clients
+// from the given REST config, scheme and REST mapper.
+func New(config *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper, opts Options) *Impersonator {
+	return &Impersonator{
+		opts:   opts,
+		config: config,
+		clientOpts: client.Options{
+			Scheme: scheme,
+			Mapper: mapper,
+		},
+		newClient: client.New,
+		clients:   make(map[string]client.Client),
+	}
+}
+
+// Lockdown returns true if the multi-tenant lockdown mode is enabled.
+func (i *Impersonator) Lockdown() bool {
+	return i != nil && i.opts.Lockdown
+}
+
+// ServiceAccountName returns the name of the ServiceAccount to impersonate
+// for an object which names the given ServiceAccount, falling back to the
+// default ServiceAccount. It returns ErrServiceAccountRequired if neither
+// is set.
+func (i *Impersonator) ServiceAccountName(name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	if i != nil && i.opts.DefaultServiceAccount != "" {
+		return i.opts.DefaultServiceAccount, nil
+	}
+	return "", ErrServiceAccountRequired
+}
+
+// ClientFor returns a client which impersonates the given ServiceAccount in
+// the namespace, or the default ServiceAccount if the name is empty. The
+// clients are not backed by a cache, and are reused per ServiceAccount.
+func (i *Impersonator) ClientFor(namespace, serviceAccountName string) (client.Client, error) {
+	name, err := i.ServiceAccountName(serviceAccountName)
+	if err != nil {
+		return nil, err
+	}
+	username := serviceaccount.MakeUsername(namespace, name)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if c, ok := i.clients[username]; ok {
+		return c, nil
+	}
+
+	config := rest.CopyConfig(i.config)
+	config.Impersonate = rest.ImpersonationConfig{UserName: username}
+	c, err := i.newClient(config, i.clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client impersonating '%s': %w", username, err)
+	}
+	i.clients[username] = c
+	return c, nil
+}
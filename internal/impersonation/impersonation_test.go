@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package impersonation
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestImpersonator_Lockdown(t *testing.T) {
+	g := NewWithT(t)
+
+	var i *Impersonator
+	g.Expect(i.Lockdown()).To(BeFalse())
+	g.Expect(New(&rest.Config{}, nil, nil, Options{}).Lockdown()).To(BeFalse())
+	g.Expect(New(&rest.Config{}, nil, nil, Options{Lockdown: true}).Lockdown()).To(BeTrue())
+}
+
+func TestImpersonator_ClientFor(t *testing.T) {
+	tests := []struct {
+		name               string
+		defaultSA          string
+		serviceAccountName string
+		wantUsername       string
+		wantErr            error
+	}{
+		{
+			name:               "named service account",
+			defaultSA:          "default",
+			serviceAccountName: "tenant",
+			wantUsername:       "system:serviceaccount:tenant-ns:tenant",
+		},
+		{
+			name:         "default service account",
+			defaultSA:    "default",
+			wantUsername: "system:serviceaccount:tenant-ns:default",
+		},
+		{
+			name:    "no service account",
+			wantErr: ErrServiceAccountRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			i := New(&rest.Config{Host: "https://example.com"}, nil, nil, Options{
+				Lockdown:              true,
+				DefaultServiceAccount: tt.defaultSA,
+			})
+			var usernames []string
+			i.newClient = func(config *rest.Config, _ client.Options) (client.Client, error) {
+				usernames = append(usernames, config.Impersonate.UserName)
+				return fake.NewClientBuilder().Build(), nil
+			}
+
+			c, err := i.ClientFor("tenant-ns", tt.serviceAccountName)
+			if tt.wantErr != nil {
+				g.Expect(err).To(MatchError(tt.wantErr))
+				g.Expect(usernames).To(BeEmpty())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(usernames).To(Equal([]string{tt.wantUsername}))
+			// The config of the Impersonator is not modified.
+			g.Expect(i.config.Impersonate.UserName).To(BeEmpty())
+
+			// Clients are reused per ServiceAccount.
+			c2, err := i.ClientFor("tenant-ns", tt.serviceAccountName)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(c2).To(BeIdenticalTo(c))
+			g.Expect(usernames).To(HaveLen(1))
+		})
+	}
+
+	t.Run("client error", func(t *testing.T) {
+		g := NewWithT(t)
+
+		i := New(&rest.Config{}, nil, nil, Options{Lockdown: true, DefaultServiceAccount: "default"})
+		i.newClient = func(*rest.Config, client.Options) (client.Client, error) {
+			return nil, errors.New("boom")
+		}
+		_, err := i.ClientFor("tenant-ns", "")
+		g.Expect(err).To(MatchError("failed to create client impersonating 'system:serviceaccount:tenant-ns:default': boom"))
+	})
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package predicates
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+// ApprovedRevisionPredicate implements predicate functions to allow update
+// events for objects of which the sourcev1.ApprovedRevisionAnnotation
+// changed, so that an approved revision is promoted without waiting for the
+// next reconciliation.
+type ApprovedRevisionPredicate struct {
+	predicate.Funcs
+}
+
+// Update allows events for objects of which the approved revision changed to
+// a non-empty value.
+func (ApprovedRevisionPredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		return false
+	}
+
+	approved := e.ObjectNew.GetAnnotations()[sourcev1.ApprovedRevisionAnnotation]
+	return approved != "" && approved != e.ObjectOld.GetAnnotations()[sourcev1.ApprovedRevisionAnnotation]
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package predicates
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+func TestApprovedRevisionPredicate_Update(t *testing.T) {
+	tests := []struct {
+		name string
+		old  map[string]string
+		new  map[string]string
+		want bool
+	}{
+		{
+			name: "no annotation",
+			want: false,
+		},
+		{
+			name: "annotation set",
+			new:  map[string]string{sourcev1.ApprovedRevisionAnnotation: "main@sha1:abc"},
+			want: true,
+		},
+		{
+			name: "annotation changed",
+			old:  map[string]string{sourcev1.ApprovedRevisionAnnotation: "main@sha1:abc"},
+			new:  map[string]string{sourcev1.ApprovedRevisionAnnotation: "main@sha1:def"},
+			want: true,
+		},
+		{
+			name: "annotation unchanged",
+			old:  map[string]string{sourcev1.ApprovedRevisionAnnotation: "main@sha1:abc"},
+			new:  map[string]string{sourcev1.ApprovedRevisionAnnotation: "main@sha1:abc"},
+			want: false,
+		},
+		{
+			name: "annotation removed",
+			old:  map[string]string{sourcev1.ApprovedRevisionAnnotation: "main@sha1:abc"},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			oldObj := &sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Annotations: tt.old}}
+			newObj := &sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Annotations: tt.new}}
+
+			p := ApprovedRevisionPredicate{}
+			g.Expect(p.Update(event.UpdateEvent{ObjectOld: oldObj, ObjectNew: newObj})).To(Equal(tt.want))
+		})
+	}
+}
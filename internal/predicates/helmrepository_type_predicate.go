@@ -26,8 +26,8 @@ import (
 )
 
 // HelmRepositoryOCIMigrationPredicate implements predicate functions to allow
-// events for HelmRepository OCI that need migration to static object. Non-OCI
-// HelmRepositories are always allowed.
+// events for HelmRepository OCI that need migration to static object, or
+// that are probed. Non-OCI HelmRepositories are always allowed.
 type HelmRepositoryOCIMigrationPredicate struct {
 	predicate.Funcs
 }
@@ -48,8 +48,8 @@ func (HelmRepositoryOCIMigrationPredicate) Delete(e event.DeleteEvent) bool {
 }
 
 // HelmRepositoryOCIRequireMigration returns if a given HelmRepository of type
-// OCI requires migration to static object. For non-OCI HelmRepository, it
-// returns true.
+// OCI requires migration to static object, or requires reconciliation as
+// probing is enabled. For non-OCI HelmRepository, it returns true.
 func HelmRepositoryOCIRequireMigration(o client.Object) bool {
 	if o == nil {
 		return false
@@ -65,6 +65,11 @@ func HelmRepositoryOCIRequireMigration(o client.Object) bool {
 		return true
 	}
 
+	if hr.Spec.Probe {
+		// Always allow probed OCI HelmRepository.
+		return true
+	}
+
 	if controllerutil.ContainsFinalizer(hr, sourcev1.SourceFinalizer) || !hasEmptyHelmRepositoryStatus(hr) {
 		return true
 	}
@@ -80,6 +80,14 @@ func TestHelmRepositoryOCIMigrationPredicate_Create(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "new oci helm repo with probe",
+			beforeFunc: func(o *sourcev1.HelmRepository) {
+				o.Spec.Type = sourcev1.HelmRepositoryTypeOCI
+				o.Spec.Probe = true
+			},
+			want: true,
+		},
 		{
 			name: "new default helm repo",
 			beforeFunc: func(o *sourcev1.HelmRepository) {
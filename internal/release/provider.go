@@ -0,0 +1,247 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+	defaultGitLabAPIURL = "https://gitlab.com/api/v4"
+	defaultGiteaAPIURL  = "https://gitea.com/api/v1"
+)
+
+// provider implements the API specifics of a forge.
+type provider interface {
+	// apiURL returns the base URL of the API.
+	apiURL() *url.URL
+	// releasesURL returns the URL to list the releases of the repository.
+	releasesURL() string
+	// authHeader returns the name and value of the header authenticating
+	// a request with the given token.
+	authHeader(token string) (string, string)
+	// assetHeader returns the headers required to download an asset.
+	assetHeader() http.Header
+	// decodeReleases decodes the response of the releasesURL.
+	decodeReleases(r io.Reader) ([]Release, error)
+}
+
+// newProvider returns the provider with the given name for the repository.
+func newProvider(name, apiURL, repository string) (provider, error) {
+	repository = strings.Trim(repository, "/")
+	switch name {
+	case ProviderGitHub, ProviderGitea:
+		if parts := strings.Split(repository, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid repository '%s': must be in the format '<owner>/<name>'", repository)
+		}
+	case ProviderGitLab:
+		if repository == "" {
+			return nil, fmt.Errorf("invalid repository '%s': must be the path of the project", repository)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported provider '%s'", name)
+	}
+
+	if apiURL == "" {
+		switch name {
+		case ProviderGitHub:
+			apiURL = defaultGitHubAPIURL
+		case ProviderGitLab:
+			apiURL = defaultGitLabAPIURL
+		case ProviderGitea:
+			apiURL = defaultGiteaAPIURL
+		}
+	}
+	base, err := url.Parse(strings.TrimSuffix(apiURL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid API URL '%s'", apiURL)
+	}
+
+	switch name {
+	case ProviderGitHub:
+		return &githubProvider{base: base, repository: repository}, nil
+	case ProviderGitLab:
+		return &gitlabProvider{base: base, repository: repository}, nil
+	default:
+		return &giteaProvider{base: base, repository: repository}, nil
+	}
+}
+
+// githubProvider implements the GitHub REST API.
+type githubProvider struct {
+	base       *url.URL
+	repository string
+}
+
+func (p *githubProvider) apiURL() *url.URL {
+	return p.base
+}
+
+func (p *githubProvider) releasesURL() string {
+	return p.base.JoinPath("repos", p.repository, "releases").String() + "?per_page=100"
+}
+
+func (p *githubProvider) authHeader(token string) (string, string) {
+	return "Authorization", "Bearer " + token
+}
+
+func (p *githubProvider) assetHeader() http.Header {
+	// The API URL of an asset serves its metadata, unless binary content
+	// is explicitly accepted.
+	return http.Header{"Accept": []string{"application/octet-stream"}}
+}
+
+func (p *githubProvider) decodeReleases(r io.Reader) ([]Release, error) {
+	var releases []struct {
+		TagName    string `json:"tag_name"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+		Assets     []struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(r).Decode(&releases); err != nil {
+		return nil, err
+	}
+
+	result := make([]Release, 0, len(releases))
+	for _, rel := range releases {
+		release := Release{Tag: rel.TagName, Draft: rel.Draft, Prerelease: rel.Prerelease}
+		for _, a := range rel.Assets {
+			if validAssetName(a.Name) {
+				release.Assets = append(release.Assets, Asset{Name: a.Name, URL: a.URL})
+			}
+		}
+		result = append(result, release)
+	}
+	return result, nil
+}
+
+// gitlabProvider implements the GitLab REST API. GitLab does not mark
+// releases as pre-release, but releases with a release date in the future
+// are considered drafts.
+type gitlabProvider struct {
+	base       *url.URL
+	repository string
+}
+
+func (p *gitlabProvider) apiURL() *url.URL {
+	return p.base
+}
+
+func (p *gitlabProvider) releasesURL() string {
+	// The project path is a single, escaped, path segment.
+	return p.base.String() + "/projects/" + url.PathEscape(p.repository) + "/releases?per_page=100"
+}
+
+func (p *gitlabProvider) authHeader(token string) (string, string) {
+	return "PRIVATE-TOKEN", token
+}
+
+func (p *gitlabProvider) assetHeader() http.Header {
+	return nil
+}
+
+func (p *gitlabProvider) decodeReleases(r io.Reader) ([]Release, error) {
+	var releases []struct {
+		TagName         string `json:"tag_name"`
+		UpcomingRelease bool   `json:"upcoming_release"`
+		Assets          struct {
+			Links []struct {
+				Name           string `json:"name"`
+				URL            string `json:"url"`
+				DirectAssetURL string `json:"direct_asset_url"`
+			} `json:"links"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(r).Decode(&releases); err != nil {
+		return nil, err
+	}
+
+	result := make([]Release, 0, len(releases))
+	for _, rel := range releases {
+		release := Release{Tag: rel.TagName, Draft: rel.UpcomingRelease}
+		for _, l := range rel.Assets.Links {
+			if !validAssetName(l.Name) {
+				continue
+			}
+			u := l.DirectAssetURL
+			if u == "" {
+				u = l.URL
+			}
+			release.Assets = append(release.Assets, Asset{Name: l.Name, URL: u})
+		}
+		result = append(result, release)
+	}
+	return result, nil
+}
+
+// giteaProvider implements the Gitea (and Forgejo) REST API.
+type giteaProvider struct {
+	base       *url.URL
+	repository string
+}
+
+func (p *giteaProvider) apiURL() *url.URL {
+	return p.base
+}
+
+func (p *giteaProvider) releasesURL() string {
+	return p.base.JoinPath("repos", p.repository, "releases").String() + "?limit=50"
+}
+
+func (p *giteaProvider) authHeader(token string) (string, string) {
+	return "Authorization", "token " + token
+}
+
+func (p *giteaProvider) assetHeader() http.Header {
+	return nil
+}
+
+func (p *giteaProvider) decodeReleases(r io.Reader) ([]Release, error) {
+	var releases []struct {
+		TagName    string `json:"tag_name"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+		Assets     []struct {
+			Name               string `json:"name"`
+			BrowserDownloadURL string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(r).Decode(&releases); err != nil {
+		return nil, err
+	}
+
+	result := make([]Release, 0, len(releases))
+	for _, rel := range releases {
+		release := Release{Tag: rel.TagName, Draft: rel.Draft, Prerelease: rel.Prerelease}
+		for _, a := range rel.Assets {
+			if validAssetName(a.Name) {
+				release.Assets = append(release.Assets, Asset{Name: a.Name, URL: a.BrowserDownloadURL})
+			}
+		}
+		result = append(result, release)
+	}
+	return result, nil
+}
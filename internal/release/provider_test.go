@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_newProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   string
+		apiURL     string
+		repository string
+		wantURL    string
+		wantErr    string
+	}{
+		{
+			name:       "GitHub default API",
+			provider:   ProviderGitHub,
+			repository: "fluxcd/flux2",
+			wantURL:    "https://api.github.com/repos/fluxcd/flux2/releases?per_page=100",
+		},
+		{
+			name:       "GitHub Enterprise API",
+			provider:   ProviderGitHub,
+			apiURL:     "https://github.example.com/api/v3/",
+			repository: "/fluxcd/flux2/",
+			wantURL:    "https://github.example.com/api/v3/repos/fluxcd/flux2/releases?per_page=100",
+		},
+		{
+			name:       "GitLab project in subgroup",
+			provider:   ProviderGitLab,
+			repository: "group/subgroup/project",
+			wantURL:    "https://gitlab.com/api/v4/projects/group%2Fsubgroup%2Fproject/releases?per_page=100",
+		},
+		{
+			name:       "Gitea default API",
+			provider:   ProviderGitea,
+			repository: "owner/repo",
+			wantURL:    "https://gitea.com/api/v1/repos/owner/repo/releases?limit=50",
+		},
+		{
+			name:       "invalid GitHub repository",
+			provider:   ProviderGitHub,
+			repository: "group/subgroup/project",
+			wantErr:    "must be in the format '<owner>/<name>'",
+		},
+		{
+			name:       "invalid API URL",
+			provider:   ProviderGitea,
+			apiURL:     "ftp://gitea.example.com",
+			repository: "owner/repo",
+			wantErr:    "invalid API URL 'ftp://gitea.example.com'",
+		},
+		{
+			name:       "unsupported provider",
+			provider:   "bitbucket",
+			repository: "owner/repo",
+			wantErr:    "unsupported provider 'bitbucket'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p, err := newProvider(tt.provider, tt.apiURL, tt.repository)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(p.releasesURL()).To(Equal(tt.wantURL))
+		})
+	}
+}
+
+func TestClient_ListReleases(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   string
+		repository string
+		path       string
+		authHeader string
+		authValue  string
+		response   string
+		want       []Release
+	}{
+		{
+			name:       "GitHub",
+			provider:   ProviderGitHub,
+			repository: "owner/repo",
+			path:       "/repos/owner/repo/releases",
+			authHeader: "Authorization",
+			authValue:  "Bearer token",
+			response: `[
+				{"tag_name": "v1.1.0-rc.1", "prerelease": true, "assets": []},
+				{"tag_name": "v1.0.0", "assets": [
+					{"name": "app.tar.gz", "url": "https://api.github.com/repos/owner/repo/releases/assets/1"},
+					{"name": "../escape", "url": "https://api.github.com/repos/owner/repo/releases/assets/2"}
+				]},
+				{"tag_name": "v2.0.0", "draft": true}
+			]`,
+			want: []Release{
+				{Tag: "v1.1.0-rc.1", Prerelease: true},
+				{Tag: "v1.0.0", Assets: []Asset{
+					{Name: "app.tar.gz", URL: "https://api.github.com/repos/owner/repo/releases/assets/1"},
+				}},
+				{Tag: "v2.0.0", Draft: true},
+			},
+		},
+		{
+			name:       "GitLab",
+			provider:   ProviderGitLab,
+			repository: "group/project",
+			path:       "/projects/group%2Fproject/releases",
+			authHeader: "PRIVATE-TOKEN",
+			authValue:  "token",
+			response: `[
+				{"tag_name": "v2.0.0", "upcoming_release": true},
+				{"tag_name": "v1.0.0", "assets": {"links": [
+					{"name": "app.tar.gz", "url": "https://example.com/app.tar.gz", "direct_asset_url": "https://gitlab.com/group/project/-/releases/v1.0.0/downloads/app.tar.gz"},
+					{"name": "install.yaml", "url": "https://example.com/install.yaml"}
+				]}}
+			]`,
+			want: []Release{
+				{Tag: "v2.0.0", Draft: true},
+				{Tag: "v1.0.0", Assets: []Asset{
+					{Name: "app.tar.gz", URL: "https://gitlab.com/group/project/-/releases/v1.0.0/downloads/app.tar.gz"},
+					{Name: "install.yaml", URL: "https://example.com/install.yaml"},
+				}},
+			},
+		},
+		{
+			name:       "Gitea",
+			provider:   ProviderGitea,
+			repository: "owner/repo",
+			path:       "/repos/owner/repo/releases",
+			authHeader: "Authorization",
+			authValue:  "token token",
+			response: `[
+				{"tag_name": "v1.0.0", "prerelease": false, "assets": [
+					{"name": "app.tar.gz", "browser_download_url": "https://gitea.com/owner/repo/releases/download/v1.0.0/app.tar.gz"}
+				]}
+			]`,
+			want: []Release{
+				{Tag: "v1.0.0", Assets: []Asset{
+					{Name: "app.tar.gz", URL: "https://gitea.com/owner/repo/releases/download/v1.0.0/app.tar.gz"},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.EscapedPath() != tt.path {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if r.Header.Get(tt.authHeader) != tt.authValue {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			c, err := NewClient(tt.provider, server.URL, tt.repository, WithToken("token"))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			got, err := c.ListReleases(context.TODO())
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+
+			c, err = NewClient(tt.provider, server.URL, tt.repository)
+			g.Expect(err).ToNot(HaveOccurred())
+			_, err = c.ListReleases(context.TODO())
+			g.Expect(err).To(MatchError(ContainSubstring("failed to list releases")))
+		})
+	}
+}
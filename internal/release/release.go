@@ -0,0 +1,318 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package release provides a minimal client for listing the releases of a
+// repository hosted on a forge, and downloading their assets.
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/fluxcd/pkg/version"
+)
+
+const (
+	// ProviderGitHub is the provider name of GitHub (Enterprise).
+	ProviderGitHub = "github"
+	// ProviderGitLab is the provider name of GitLab.
+	ProviderGitLab = "gitlab"
+	// ProviderGitea is the provider name of Gitea and Forgejo.
+	ProviderGitea = "gitea"
+)
+
+// Release is a release of a repository.
+type Release struct {
+	// Tag is the name of the Git tag of the release.
+	Tag string
+	// Prerelease is true if the release is marked as pre-release on the
+	// forge.
+	Prerelease bool
+	// Draft is true if the release is not published yet.
+	Draft bool
+	// Assets are the files attached to the release.
+	Assets []Asset
+}
+
+// Asset is a file attached to a Release.
+type Asset struct {
+	// Name is the file name of the asset.
+	Name string
+	// URL is the location the asset can be downloaded from.
+	URL string
+}
+
+// DefaultMaxDownloadSize is the default max allowed size in bytes of a
+// downloaded asset.
+const DefaultMaxDownloadSize int64 = 100 << 20
+
+// Client lists the releases of a repository, and downloads their assets.
+// The token of the Client is only sent to the host of the API, assets
+// hosted elsewhere are downloaded without it.
+type Client struct {
+	provider        provider
+	httpClient      *http.Client
+	token           string
+	maxDownloadSize int64
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithToken configures the Client to authenticate with the given token.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient configures the Client to make requests using the given
+// http.Client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithMaxDownloadSize configures the max allowed size in bytes of an asset
+// downloaded by the Client. DefaultMaxDownloadSize is used when it is not
+// set.
+func WithMaxDownloadSize(n int64) Option {
+	return func(c *Client) {
+		c.maxDownloadSize = n
+	}
+}
+
+// NewClient returns a Client for the repository with the given provider.
+// When apiURL is empty, the API of the public instance of the provider is
+// used.
+func NewClient(providerName, apiURL, repository string, opts ...Option) (*Client, error) {
+	p, err := newProvider(providerName, apiURL, repository)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		provider:        p,
+		httpClient:      http.DefaultClient,
+		maxDownloadSize: DefaultMaxDownloadSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxDownloadSize <= 0 {
+		c.maxDownloadSize = DefaultMaxDownloadSize
+	}
+
+	// Remove the token from requests redirected to another host. Unlike
+	// the Authorization header, the http.Client does not remove custom
+	// headers like the one of GitLab.
+	hc := *c.httpClient
+	checkRedirect := hc.CheckRedirect
+	hc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !c.trusted(req.URL) {
+			key, _ := c.provider.authHeader(c.token)
+			req.Header.Del(key)
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	c.httpClient = &hc
+	return c, nil
+}
+
+// ListReleases returns the most recent releases of the repository.
+func (c *Client) ListReleases(ctx context.Context) ([]Release, error) {
+	resp, err := c.get(ctx, c.provider.releasesURL(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	releases, err := c.provider.decodeReleases(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode releases: %w", err)
+	}
+	return releases, nil
+}
+
+// Download writes the contents of the given asset to w. It returns an error
+// if the asset exceeds the max download size.
+func (c *Client) Download(ctx context.Context, asset Asset, w io.Writer) error {
+	resp, err := c.get(ctx, asset.URL, c.provider.assetHeader())
+	if err != nil {
+		return fmt.Errorf("failed to download asset '%s': %w", asset.Name, err)
+	}
+	defer resp.Body.Close()
+
+	n, err := io.Copy(w, io.LimitReader(resp.Body, c.maxDownloadSize+1))
+	if err == nil && n > c.maxDownloadSize {
+		err = fmt.Errorf("asset exceeds the max size of %d bytes", c.maxDownloadSize)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download asset '%s': %w", asset.Name, err)
+	}
+	return nil
+}
+
+// get makes a GET request for the given URL with the given headers, which
+// is authenticated if the URL is trusted. It returns an error if the
+// response status code is not successful.
+func (c *Client) get(ctx context.Context, u string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.token != "" && c.trusted(req.URL) {
+		req.Header.Set(c.provider.authHeader(c.token))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET '%s': %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+// trusted returns if the token of the Client may be sent to the given URL,
+// which is the case if it has the scheme and host of the API URL.
+func (c *Client) trusted(u *url.URL) bool {
+	base := c.provider.apiURL()
+	return strings.EqualFold(u.Scheme, base.Scheme) && strings.EqualFold(u.Host, base.Host)
+}
+
+// LatestBySemver returns the release with the highest version tag within the
+// given semver range, or the highest version when the range is empty. Draft
+// releases are never selected, and releases marked as pre-release on the
+// forge only if includePrereleases is true. When filter is not empty, only
+// releases with a tag matching the regular expression are taken into account.
+//
+// As with the tags of a GitRepository, tags with a semver pre-release suffix
+// only match the range if it contains a pre-release as well.
+func LatestBySemver(releases []Release, semverRange, filter string, includePrereleases bool) (*Release, error) {
+	if semverRange == "" {
+		semverRange = "*"
+	}
+	constraint, err := semver.NewConstraint(semverRange)
+	if err != nil {
+		return nil, fmt.Errorf("semver '%s' parse error: %w", semverRange, err)
+	}
+
+	var match *regexp.Regexp
+	if filter != "" {
+		if match, err = regexp.Compile(filter); err != nil {
+			return nil, fmt.Errorf("semver filter '%s' parse error: %w", filter, err)
+		}
+	}
+
+	type candidate struct {
+		release *Release
+		version *semver.Version
+	}
+	var candidates []candidate
+	for i := range releases {
+		r := &releases[i]
+		if r.Draft || (r.Prerelease && !includePrereleases) {
+			continue
+		}
+		if match != nil && !match.MatchString(r.Tag) {
+			continue
+		}
+		v, err := version.ParseVersion(r.Tag)
+		if err != nil {
+			continue
+		}
+		if constraint.Check(v) {
+			candidates = append(candidates, candidate{release: r, version: v})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no release found matching semver '%s'", semverRange)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].version.GreaterThan(candidates[j].version)
+	})
+	return candidates[0].release, nil
+}
+
+// MatchAssets returns the assets of the release with a name matching any of
+// the given shell file name patterns, sorted by name. It returns an error if
+// a pattern is malformed, or does not match any asset.
+func (r *Release) MatchAssets(patterns []string) ([]Asset, error) {
+	matched := make(map[string]Asset)
+	for _, pattern := range patterns {
+		var found bool
+		for _, a := range r.Assets {
+			ok, err := path.Match(pattern, a.Name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid asset pattern '%s': %w", pattern, err)
+			}
+			if ok {
+				matched[a.Name] = a
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no asset of release '%s' matches '%s'", r.Tag, pattern)
+		}
+	}
+
+	assets := make([]Asset, 0, len(matched))
+	for _, a := range matched {
+		assets = append(assets, a)
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].Name < assets[j].Name
+	})
+	return assets, nil
+}
+
+// Asset returns the asset of the release with the given name.
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// validAssetName returns true if the name can safely be used as a file name.
+func validAssetName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
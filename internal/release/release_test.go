@@ -0,0 +1,303 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestClient_Download(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/assets/1":
+			if r.Header.Get("Accept") != "application/octet-stream" {
+				_, _ = w.Write([]byte(`{"name":"app.tar.gz"}`))
+				return
+			}
+			_, _ = w.Write([]byte("content"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		token   string
+		asset   Asset
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "downloads asset",
+			token: "token",
+			asset: Asset{Name: "app.tar.gz", URL: server.URL + "/assets/1"},
+			want:  "content",
+		},
+		{
+			name:    "missing asset",
+			token:   "token",
+			asset:   Asset{Name: "missing.tar.gz", URL: server.URL + "/assets/2"},
+			wantErr: "failed to download asset 'missing.tar.gz'",
+		},
+		{
+			name:    "unauthorized",
+			asset:   Asset{Name: "app.tar.gz", URL: server.URL + "/assets/1"},
+			wantErr: "401 Unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c, err := NewClient(ProviderGitHub, server.URL, "owner/repo", WithToken(tt.token), WithHTTPClient(server.Client()))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			err = c.Download(context.TODO(), tt.asset, &buf)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(buf.String()).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestClient_Download_otherHost(t *testing.T) {
+	var gotHeader http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Clone()
+		_, _ = w.Write([]byte("content"))
+	}))
+	defer other.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, r, other.URL+"/app.tar.gz", http.StatusFound)
+	}))
+	defer api.Close()
+
+	tests := []struct {
+		name  string
+		asset Asset
+	}{
+		{
+			name:  "asset on other host",
+			asset: Asset{Name: "app.tar.gz", URL: other.URL + "/app.tar.gz"},
+		},
+		{
+			name:  "asset redirected to other host",
+			asset: Asset{Name: "app.tar.gz", URL: api.URL + "/downloads/app.tar.gz"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			gotHeader = nil
+
+			c, err := NewClient(ProviderGitLab, api.URL, "group/project", WithToken("token"))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			g.Expect(c.Download(context.TODO(), tt.asset, &buf)).To(Succeed())
+			g.Expect(buf.String()).To(Equal("content"))
+			g.Expect(gotHeader).ToNot(BeNil())
+			g.Expect(gotHeader).ToNot(HaveKey("Private-Token"))
+		})
+	}
+}
+
+func TestClient_Download_maxSize(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	asset := Asset{Name: "app.tar.gz", URL: server.URL + "/app.tar.gz"}
+
+	c, err := NewClient(ProviderGitea, server.URL, "owner/repo", WithMaxDownloadSize(7))
+	g.Expect(err).ToNot(HaveOccurred())
+	var buf bytes.Buffer
+	g.Expect(c.Download(context.TODO(), asset, &buf)).To(Succeed())
+	g.Expect(buf.String()).To(Equal("content"))
+
+	c, err = NewClient(ProviderGitea, server.URL, "owner/repo", WithMaxDownloadSize(6))
+	g.Expect(err).ToNot(HaveOccurred())
+	err = c.Download(context.TODO(), asset, &bytes.Buffer{})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("asset exceeds the max size of 6 bytes"))
+}
+
+func TestLatestBySemver(t *testing.T) {
+	releases := []Release{
+		{Tag: "v1.0.0"},
+		{Tag: "v1.2.0"},
+		{Tag: "v1.1.0"},
+		{Tag: "v2.0.0", Draft: true},
+		{Tag: "v1.3.0", Prerelease: true},
+		{Tag: "v1.4.0-rc.1"},
+		{Tag: "nightly"},
+		{Tag: "app-v1.5.0"},
+	}
+
+	tests := []struct {
+		name               string
+		semver             string
+		filter             string
+		includePrereleases bool
+		want               string
+		wantErr            string
+	}{
+		{
+			name:   "selects latest version",
+			semver: "*",
+			want:   "v1.2.0",
+		},
+		{
+			name:   "selects latest version within range",
+			semver: "<1.2.0",
+			want:   "v1.1.0",
+		},
+		{
+			name:               "includes releases marked as pre-release",
+			semver:             "*",
+			includePrereleases: true,
+			want:               "v1.3.0",
+		},
+		{
+			name:   "selects semver pre-release with pre-release range",
+			semver: ">=1.0.0-0",
+			want:   "v1.4.0-rc.1",
+		},
+		{
+			name:   "filters tags",
+			semver: "*",
+			filter: `^v1\.[01]\.`,
+			want:   "v1.1.0",
+		},
+		{
+			name:    "no match",
+			semver:  ">=3.0.0",
+			wantErr: "no release found matching semver '>=3.0.0'",
+		},
+		{
+			name:    "invalid range",
+			semver:  "invalid",
+			wantErr: "semver 'invalid' parse error",
+		},
+		{
+			name:    "invalid filter",
+			semver:  "*",
+			filter:  "(",
+			wantErr: "semver filter '(' parse error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := LatestBySemver(releases, tt.semver, tt.filter, tt.includePrereleases)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got.Tag).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestRelease_MatchAssets(t *testing.T) {
+	release := &Release{
+		Tag: "v1.0.0",
+		Assets: []Asset{
+			{Name: "app_linux_amd64.tar.gz"},
+			{Name: "app_darwin_arm64.tar.gz"},
+			{Name: "checksums.txt"},
+			{Name: "install.yaml"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+		wantErr  string
+	}{
+		{
+			name:     "matches patterns",
+			patterns: []string{"app_*.tar.gz", "install.yaml"},
+			want:     []string{"app_darwin_arm64.tar.gz", "app_linux_amd64.tar.gz", "install.yaml"},
+		},
+		{
+			name:     "deduplicates assets",
+			patterns: []string{"*.yaml", "install.*"},
+			want:     []string{"install.yaml"},
+		},
+		{
+			name:     "pattern without match",
+			patterns: []string{"install.yaml", "*.zip"},
+			wantErr:  "no asset of release 'v1.0.0' matches '*.zip'",
+		},
+		{
+			name:     "invalid pattern",
+			patterns: []string{"["},
+			wantErr:  "invalid asset pattern '['",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := release.MatchAssets(tt.patterns)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			var names []string
+			for _, a := range got {
+				names = append(names, a.Name)
+			}
+			g.Expect(names).To(Equal(tt.want))
+		})
+	}
+}
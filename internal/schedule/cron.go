@@ -0,0 +1,214 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchLimit is the maximum period searched for a time matching a cron
+// expression, to bound the search for expressions that can never match,
+// such as '0 0 31 2 *'.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// field is the set of values of a cron field, as a bit set.
+type field uint64
+
+func (f field) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// bounds are the minimum and maximum value of a cron field.
+type bounds struct {
+	min, max int
+}
+
+var (
+	minuteBounds = bounds{0, 59}
+	hourBounds   = bounds{0, 23}
+	domBounds    = bounds{1, 31}
+	monthBounds  = bounds{1, 12}
+	dowBounds    = bounds{0, 7}
+)
+
+// cron is a parsed cron expression in the standard five field format
+// '<minute> <hour> <day of month> <month> <day of week>'.
+type cron struct {
+	minute, hour, dom, month, dow field
+	// domAny and dowAny are true if the day of month and day of week are not
+	// restricted. When both are restricted, a day matches if either does.
+	domAny, dowAny bool
+}
+
+// parseCron parses the given cron expression. Fields support '*', single
+// values, ranges ('1-5'), lists ('1,3,5') and steps ('*/15', '0-30/10').
+// Both 0 and 7 are Sunday in the day of week field.
+func parseCron(expr string) (*cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression '%s' must have 5 fields, got %d", expr, len(fields))
+	}
+
+	c := &cron{
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}
+	var err error
+	for _, f := range []struct {
+		name  string
+		value string
+		b     bounds
+		dst   *field
+	}{
+		{"minute", fields[0], minuteBounds, &c.minute},
+		{"hour", fields[1], hourBounds, &c.hour},
+		{"day of month", fields[2], domBounds, &c.dom},
+		{"month", fields[3], monthBounds, &c.month},
+		{"day of week", fields[4], dowBounds, &c.dow},
+	} {
+		if *f.dst, err = parseField(f.value, f.b); err != nil {
+			return nil, fmt.Errorf("invalid %s field in cron expression '%s': %w", f.name, expr, err)
+		}
+	}
+	if c.dow.has(7) {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+// parseField parses a comma separated list of cron field values within the
+// bounds.
+func parseField(s string, b bounds) (field, error) {
+	var f field
+	for _, part := range strings.Split(s, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step '%s'", stepPart)
+			}
+		}
+
+		var lo, hi int
+		switch {
+		case rangePart == "*":
+			lo, hi = b.min, b.max
+		case strings.Contains(rangePart, "-"):
+			l, h, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = parseValue(l, b); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(h, b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range '%s'", rangePart)
+			}
+		default:
+			v, err := parseValue(rangePart, b)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if hasStep {
+				hi = b.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			f |= 1 << uint(v)
+		}
+	}
+	return f, nil
+}
+
+// parseValue parses a single cron field value within the bounds.
+func parseValue(s string, b bounds) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s'", s)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, b.min, b.max)
+	}
+	return v, nil
+}
+
+// matchesDay returns true if the day of t matches the day of month and day
+// of week fields.
+func (c *cron) matchesDay(t time.Time) bool {
+	dom, dow := c.dom.has(t.Day()), c.dow.has(int(t.Weekday()))
+	if !c.domAny && !c.dowAny {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// next returns the first time at or after t matching the expression, in the
+// location of t. It returns false if there is no match within the search
+// limit.
+func (c *cron) next(t time.Time) (time.Time, bool) {
+	loc := t.Location()
+	if trunc := t.Truncate(time.Minute); !trunc.Equal(t) {
+		t = trunc.Add(time.Minute)
+	}
+	limit := t.Add(searchLimit)
+	for t.Before(limit) {
+		switch {
+		case !c.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !c.hour.has(t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !c.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// prev returns the last time at or before t matching the expression, in the
+// location of t. It returns false if there is no match within the search
+// limit.
+func (c *cron) prev(t time.Time) (time.Time, bool) {
+	loc := t.Location()
+	t = t.Truncate(time.Minute)
+	limit := t.Add(-searchLimit)
+	for t.After(limit) {
+		switch {
+		case !c.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc).Add(-time.Minute)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Add(-time.Minute)
+		case !c.hour.has(t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(-time.Minute)
+		case !c.minute.has(t.Minute()):
+			t = t.Add(-time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_parseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr string
+	}{
+		{name: "every minute", expr: "* * * * *"},
+		{name: "lists, ranges and steps", expr: "*/15 9-16 1,15 1-12/2 1-5"},
+		{name: "Sunday as 7", expr: "0 0 * * 7"},
+		{name: "too few fields", expr: "0 9 * *", wantErr: "must have 5 fields, got 4"},
+		{name: "value out of range", expr: "60 * * * *", wantErr: "invalid minute field"},
+		{name: "invalid range", expr: "0 16-9 * * *", wantErr: "invalid range '16-9'"},
+		{name: "invalid step", expr: "*/0 * * * *", wantErr: "invalid step '0'"},
+		{name: "invalid value", expr: "0 0 * JAN *", wantErr: "invalid value 'JAN'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := parseCron(tt.expr)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.wantErr)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func Test_cron_next(t *testing.T) {
+	// 2024-01-03 is a Wednesday.
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, time.January, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		from     time.Time
+		wantNext time.Time
+		wantPrev time.Time
+	}{
+		{
+			name:     "matching time",
+			expr:     "30 9 * * *",
+			from:     at(3, 9, 30),
+			wantNext: at(3, 9, 30),
+			wantPrev: at(3, 9, 30),
+		},
+		{
+			name:     "later the same day",
+			expr:     "0 9 * * *",
+			from:     at(3, 8, 15),
+			wantNext: at(3, 9, 0),
+			wantPrev: at(2, 9, 0),
+		},
+		{
+			name:     "weekdays skip the weekend",
+			expr:     "0 9 * * 1-5",
+			from:     at(5, 17, 0),
+			wantNext: at(8, 9, 0),
+			wantPrev: at(5, 9, 0),
+		},
+		{
+			name:     "steps",
+			expr:     "*/20 * * * *",
+			from:     at(3, 10, 41),
+			wantNext: at(3, 11, 0),
+			wantPrev: at(3, 10, 40),
+		},
+		{
+			name:     "day of month or day of week",
+			expr:     "0 0 15 * 0",
+			from:     at(3, 12, 0),
+			wantNext: at(7, 0, 0),
+			wantPrev: time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "seconds round up to the next minute",
+			expr:     "* * * * *",
+			from:     at(3, 10, 0).Add(30 * time.Second),
+			wantNext: at(3, 10, 1),
+			wantPrev: at(3, 10, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c, err := parseCron(tt.expr)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			next, ok := c.next(tt.from)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(next).To(Equal(tt.wantNext))
+
+			prev, ok := c.prev(tt.from)
+			g.Expect(ok).To(BeTrue())
+			g.Expect(prev).To(Equal(tt.wantPrev))
+		})
+	}
+
+	t.Run("never matching expression", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c, err := parseCron("0 0 31 2 *")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, ok := c.next(at(3, 0, 0))
+		g.Expect(ok).To(BeFalse())
+		_, ok = c.prev(at(3, 0, 0))
+		g.Expect(ok).To(BeFalse())
+	})
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schedule evaluates recurring time windows defined by cron
+// expressions, to determine when changes may be picked up.
+package schedule
+
+import (
+	"fmt"
+	"time"
+	// Embed the time zone database, as the controller image does not
+	// include one.
+	_ "time/tzdata"
+)
+
+// maxSteps is the maximum number of windows stepped through to find the
+// next time a Schedule is open.
+const maxSteps = 1000
+
+// Window is a recurring period of time, starting at every time matched by a
+// cron expression.
+type Window struct {
+	// Cron is the expression matching the start of the window, in the
+	// standard five field format.
+	Cron string
+	// Duration is the length of the window.
+	Duration time.Duration
+}
+
+type window struct {
+	cron     *cron
+	duration time.Duration
+}
+
+// Schedule is open during any of its windows, or at any time if it has no
+// windows, unless it is within one of its deny windows.
+type Schedule struct {
+	location *time.Location
+	windows  []window
+	deny     []window
+}
+
+// New returns a Schedule for the given windows and deny windows, evaluated
+// in the location with the given IANA time zone name. An empty name is UTC.
+func New(timeZone string, windows, deny []Window) (*Schedule, error) {
+	loc := time.UTC
+	if timeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone '%s': %w", timeZone, err)
+		}
+	}
+
+	s := &Schedule{location: loc}
+	var err error
+	if s.windows, err = parseWindows(windows); err != nil {
+		return nil, err
+	}
+	if s.deny, err = parseWindows(deny); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func parseWindows(windows []Window) ([]window, error) {
+	result := make([]window, 0, len(windows))
+	for _, w := range windows {
+		if w.Duration <= 0 {
+			return nil, fmt.Errorf("duration of window '%s' must be positive", w.Cron)
+		}
+		c, err := parseCron(w.Cron)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, window{cron: c, duration: w.Duration})
+	}
+	return result, nil
+}
+
+// end returns the end of the window t is in, or false if t is not within
+// the window.
+func (w window) end(t time.Time) (time.Time, bool) {
+	start, ok := w.cron.prev(t)
+	if !ok {
+		return time.Time{}, false
+	}
+	if end := start.Add(w.duration); end.After(t) {
+		return end, true
+	}
+	return time.Time{}, false
+}
+
+// Open returns true if the Schedule is open at t.
+func (s *Schedule) Open(t time.Time) bool {
+	_, ok := s.check(t.In(s.location))
+	return ok
+}
+
+// NextOpen returns the first time at or after t the Schedule is open, in
+// the location of the Schedule. It returns false if the Schedule does not
+// open within a reasonable period.
+func (s *Schedule) NextOpen(t time.Time) (time.Time, bool) {
+	t = t.In(s.location)
+	for i := 0; i < maxSteps; i++ {
+		next, ok := s.check(t)
+		if ok {
+			return t, true
+		}
+		if next.IsZero() {
+			return time.Time{}, false
+		}
+		t = next
+	}
+	return time.Time{}, false
+}
+
+// check returns true if the Schedule is open at t. If not, it returns the
+// earliest time after t the Schedule may be open, or zero if it never
+// opens.
+func (s *Schedule) check(t time.Time) (time.Time, bool) {
+	// Skip to the end of the latest ending deny window t is in.
+	var denyEnd time.Time
+	for _, w := range s.deny {
+		if end, ok := w.end(t); ok && end.After(denyEnd) {
+			denyEnd = end
+		}
+	}
+	if !denyEnd.IsZero() {
+		return denyEnd, false
+	}
+
+	if len(s.windows) == 0 {
+		return t, true
+	}
+
+	// Skip to the earliest start of a window after t.
+	var nextStart time.Time
+	for _, w := range s.windows {
+		if _, ok := w.end(t); ok {
+			return t, true
+		}
+		if start, ok := w.cron.next(t); ok && (nextStart.IsZero() || start.Before(nextStart)) {
+			nextStart = start
+		}
+	}
+	return nextStart, false
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeZone string
+		windows  []Window
+		deny     []Window
+		wantErr  string
+	}{
+		{
+			name:     "valid schedule",
+			timeZone: "Europe/Amsterdam",
+			windows:  []Window{{Cron: "0 9 * * 1-5", Duration: 7 * time.Hour}},
+			deny:     []Window{{Cron: "0 0 20 12 *", Duration: 14 * 24 * time.Hour}},
+		},
+		{
+			name:     "invalid time zone",
+			timeZone: "Mars/Olympus_Mons",
+			wantErr:  "invalid time zone 'Mars/Olympus_Mons'",
+		},
+		{
+			name:    "invalid cron expression",
+			windows: []Window{{Cron: "0 9 * *", Duration: time.Hour}},
+			wantErr: "must have 5 fields",
+		},
+		{
+			name:    "zero duration",
+			deny:    []Window{{Cron: "0 9 * * *"}},
+			wantErr: "duration of window '0 9 * * *' must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := New(tt.timeZone, tt.windows, tt.deny)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.wantErr)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestSchedule_NextOpen(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2024-12-18 is a Wednesday.
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2024, month, day, hour, min, 0, 0, amsterdam)
+	}
+
+	weekdays := []Window{{Cron: "0 9 * * 1-5", Duration: 7 * time.Hour}}
+	freeze := []Window{{Cron: "0 0 20 12 *", Duration: 14 * 24 * time.Hour}}
+
+	tests := []struct {
+		name     string
+		windows  []Window
+		deny     []Window
+		t        time.Time
+		wantOpen bool
+		wantNext time.Time
+	}{
+		{
+			name:     "no windows is always open",
+			t:        at(time.December, 18, 3, 0),
+			wantOpen: true,
+			wantNext: at(time.December, 18, 3, 0),
+		},
+		{
+			name:     "within window",
+			windows:  weekdays,
+			t:        at(time.December, 18, 15, 59),
+			wantOpen: true,
+			wantNext: at(time.December, 18, 15, 59),
+		},
+		{
+			name:     "end of window is exclusive",
+			windows:  weekdays,
+			t:        at(time.December, 18, 16, 0),
+			wantNext: at(time.December, 19, 9, 0),
+		},
+		{
+			name:     "in the evening before the weekend",
+			windows:  weekdays,
+			t:        at(time.November, 29, 18, 0),
+			wantNext: at(time.December, 2, 9, 0),
+		},
+		{
+			name:     "within deny window without windows",
+			deny:     freeze,
+			t:        at(time.December, 24, 12, 0),
+			wantNext: time.Date(2025, time.January, 3, 0, 0, 0, 0, amsterdam),
+		},
+		{
+			name:     "deny window takes precedence",
+			windows:  weekdays,
+			deny:     freeze,
+			t:        at(time.December, 20, 10, 0),
+			wantNext: time.Date(2025, time.January, 3, 9, 0, 0, 0, amsterdam),
+		},
+		{
+			name:     "before deny window",
+			windows:  weekdays,
+			deny:     freeze,
+			t:        at(time.December, 19, 10, 0),
+			wantOpen: true,
+			wantNext: at(time.December, 19, 10, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			s, err := New("Europe/Amsterdam", tt.windows, tt.deny)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			g.Expect(s.Open(tt.t.UTC())).To(Equal(tt.wantOpen))
+
+			next, ok := s.NextOpen(tt.t.UTC())
+			g.Expect(ok).To(BeTrue())
+			g.Expect(next).To(BeTemporally("==", tt.wantNext))
+		})
+	}
+
+	t.Run("never opens", func(t *testing.T) {
+		g := NewWithT(t)
+
+		s, err := New("", []Window{{Cron: "0 0 31 2 *", Duration: time.Hour}}, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(s.Open(at(time.December, 18, 0, 0))).To(BeFalse())
+		_, ok := s.NextOpen(at(time.December, 18, 0, 0))
+		g.Expect(ok).To(BeFalse())
+	})
+}
@@ -61,6 +61,9 @@ import (
 	"github.com/fluxcd/source-controller/internal/features"
 	"github.com/fluxcd/source-controller/internal/helm"
 	"github.com/fluxcd/source-controller/internal/helm/registry"
+	"github.com/fluxcd/source-controller/internal/hostlimit"
+	"github.com/fluxcd/source-controller/internal/impersonation"
+	"github.com/fluxcd/source-controller/internal/release"
 )
 
 const controllerName = "source-controller"
@@ -101,6 +104,8 @@ func main() {
 		helmIndexLimit           int64
 		helmChartLimit           int64
 		helmChartFileLimit       int64
+		httpSourceLimit          int64
+		releaseAssetLimit        int64
 		clientOptions            client.Options
 		logOptions               logger.Options
 		leaderElectionOptions    leaderelection.Options
@@ -109,11 +114,17 @@ func main() {
 		watchOptions             helper.WatchOptions
 		intervalJitterOptions    jitter.IntervalOptions
 		helmCacheMaxSize         int
+		helmCacheMaxBytes        int64
 		helmCacheTTL             string
 		helmCachePurgeInterval   string
+		helmChartCacheMaxSize    int64
+		helmTagCacheMaxSize      int
+		helmTagCacheTTL          string
 		artifactRetentionTTL     time.Duration
 		artifactRetentionRecords int
 		artifactDigestAlgo       string
+		hostLimitOptions         hostlimit.Options
+		impersonationOptions     impersonation.Options
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-addr", envOrDefault("METRICS_ADDR", ":8080"),
@@ -134,14 +145,26 @@ func main() {
 		"The max allowed size in bytes of a Helm chart file.")
 	flag.Int64Var(&helmChartFileLimit, "helm-chart-file-max-size", helm.MaxChartFileSize,
 		"The max allowed size in bytes of a file in a Helm chart.")
+	flag.Int64Var(&httpSourceLimit, "http-source-max-size", controller.DefaultHTTPSourceMaxFileSize,
+		"The max allowed size in bytes of the file fetched for an HTTPSource, and of the files extracted from it.")
+	flag.Int64Var(&releaseAssetLimit, "release-asset-max-size", release.DefaultMaxDownloadSize,
+		"The max allowed size in bytes of an asset downloaded for a ReleaseSource.")
 	flag.DurationVar(&requeueDependency, "requeue-dependency", 30*time.Second,
 		"The interval at which failing dependencies are reevaluated.")
 	flag.IntVar(&helmCacheMaxSize, "helm-cache-max-size", 0,
 		"The maximum size of the cache in number of indexes.")
+	flag.Int64Var(&helmCacheMaxBytes, "helm-cache-max-bytes", 0,
+		"The maximum size in bytes of the cache of Helm repository index entries. Takes precedence over --helm-cache-max-size.")
 	flag.StringVar(&helmCacheTTL, "helm-cache-ttl", "15m",
 		"The TTL of an index in the cache. Valid time units are ns, us (or µs), ms, s, m, h.")
 	flag.StringVar(&helmCachePurgeInterval, "helm-cache-purge-interval", "1m",
 		"The interval at which the cache is purged. Valid time units are ns, us (or µs), ms, s, m, h.")
+	flag.Int64Var(&helmChartCacheMaxSize, "helm-chart-cache-max-size", 0,
+		"The maximum size in bytes of the cache of downloaded Helm chart tarballs, shared between HelmCharts.")
+	flag.IntVar(&helmTagCacheMaxSize, "helm-tag-cache-max-size", 1000,
+		"The maximum size of the cache in number of chart tag lists of OCI Helm repositories, shared between HelmRepositories and HelmCharts.")
+	flag.StringVar(&helmTagCacheTTL, "helm-tag-cache-ttl", "15m",
+		"The TTL of a chart tag list in the cache. Valid time units are ns, us (or µs), ms, s, m, h.")
 	flag.StringSliceVar(&git.KexAlgos, "ssh-kex-algos", []string{},
 		"The list of key exchange algorithms to use for ssh connections, arranged from most preferred to the least.")
 	flag.StringSliceVar(&git.HostKeyAlgos, "ssh-hostkey-algos", []string{},
@@ -152,6 +175,24 @@ func main() {
 		"The maximum number of artifacts to be kept in storage after a garbage collection.")
 	flag.StringVar(&artifactDigestAlgo, "artifact-digest-algo", intdigest.Canonical.String(),
 		"The algorithm to use to calculate the digest of artifacts.")
+	flag.IntVar(&hostLimitOptions.MaxConcurrent, "host-max-concurrent", 0,
+		"The maximum number of concurrent remote operations per host of a GitRepository, HelmRepository, HelmChart, OCIRepository or Bucket, shared between all objects. Zero is unlimited.")
+	flag.Float64Var(&hostLimitOptions.RequestsPerSecond, "host-requests-per-second", 0,
+		"The maximum number of remote operations started per second per host of a GitRepository, HelmRepository, HelmChart, OCIRepository or Bucket, shared between all objects. Zero is unlimited.")
+	flag.IntVar(&hostLimitOptions.Burst, "host-requests-burst", 1,
+		"The maximum number of remote operations started at once per host, when --host-requests-per-second is set.")
+	flag.IntVar(&hostLimitOptions.FailureThreshold, "host-failure-threshold", 0,
+		"The number of consecutive failed remote operations after which the circuit breaker of a host opens, failing operations on the host fast. Zero disables the circuit breaker.")
+	flag.DurationVar(&hostLimitOptions.MinBreakerBackoff, "host-breaker-min-backoff", hostlimit.DefaultMinBreakerBackoff,
+		"The duration the circuit breaker of a host stays open before a probe operation is allowed, doubled after every failed probe.")
+	flag.DurationVar(&hostLimitOptions.MaxBreakerBackoff, "host-breaker-max-backoff", hostlimit.DefaultMaxBreakerBackoff,
+		"The maximum duration the circuit breaker of a host stays open.")
+	flag.BoolVar(&impersonationOptions.Lockdown, "multi-tenant-lockdown", false,
+		"Read the Secrets of every object by impersonating its ServiceAccount, requiring objects to specify a ServiceAccount or a default to be configured.")
+	flag.StringVar(&impersonationOptions.DefaultServiceAccount, "default-service-account", "",
+		"The ServiceAccount impersonated for objects which do not specify one, when --multi-tenant-lockdown is enabled.")
+
+	_ = flag.CommandLine.MarkDeprecated("helm-cache-max-size", "use --helm-cache-max-bytes instead")
 
 	clientOptions.BindFlags(flag.CommandLine)
 	logOptions.BindFlags(flag.CommandLine)
@@ -181,11 +222,15 @@ func main() {
 
 	metrics := helper.NewMetrics(mgr, metrics.MustMakeRecorder(), v1.SourceFinalizer)
 	cacheRecorder := cache.MustMakeMetrics()
+	hostLimiter := hostlimit.MustMakeLimiter(hostLimitOptions)
+	impersonator := impersonation.New(mgr.GetConfig(), mgr.GetScheme(), mgr.GetRESTMapper(), impersonationOptions)
 	eventRecorder := mustSetupEventRecorder(mgr, eventsAddr, controllerName)
 	storage := mustInitStorage(storagePath, storageAdvAddr, artifactRetentionTTL, artifactRetentionRecords, artifactDigestAlgo)
 
 	mustSetupHelmLimits(helmIndexLimit, helmChartLimit, helmChartFileLimit)
-	helmIndexCache, helmIndexCacheItemTTL := mustInitHelmCache(helmCacheMaxSize, helmCacheTTL, helmCachePurgeInterval)
+	helmIndexCache, helmIndexCacheItemTTL := mustInitHelmCache(helmCacheMaxSize, helmCacheMaxBytes, helmCacheTTL, helmCachePurgeInterval)
+	helmChartCache := initHelmChartCache(helmChartCacheMaxSize)
+	helmTagCache := mustInitHelmTagCache(helmTagCacheMaxSize, helmTagCacheTTL, helmCachePurgeInterval)
 
 	ctx := ctrl.SetupSignalHandler()
 
@@ -194,6 +239,8 @@ func main() {
 		EventRecorder:  eventRecorder,
 		Metrics:        metrics,
 		Storage:        storage,
+		HostLimiter:    hostLimiter,
+		Impersonator:   impersonator,
 		ControllerName: controllerName,
 	}).SetupWithManagerAndOptions(mgr, controller.GitRepositoryReconcilerOptions{
 		DependencyRequeueInterval: requeueDependency,
@@ -204,17 +251,20 @@ func main() {
 	}
 
 	if err := (&controller.HelmRepositoryReconciler{
-		Client:         mgr.GetClient(),
-		EventRecorder:  eventRecorder,
-		Metrics:        metrics,
-		Storage:        storage,
-		Getters:        getters,
-		ControllerName: controllerName,
-		Cache:          helmIndexCache,
-		TTL:            helmIndexCacheItemTTL,
-		CacheRecorder:  cacheRecorder,
+		Client:                  mgr.GetClient(),
+		EventRecorder:           eventRecorder,
+		Metrics:                 metrics,
+		Storage:                 storage,
+		Getters:                 getters,
+		ControllerName:          controllerName,
+		CacheRecorder:           cacheRecorder,
+		RegistryClientGenerator: registry.ClientGeneratorWithTransport(hostLimiter.RoundTripper),
+		TagCache:                helmTagCache,
+		HostLimiter:             hostLimiter,
+		Impersonator:            impersonator,
 	}).SetupWithManagerAndOptions(mgr, controller.HelmRepositoryReconcilerOptions{
-		RateLimiter: helper.GetRateLimiter(rateLimiterOptions),
+		DependencyRequeueInterval: requeueDependency,
+		RateLimiter:               helper.GetRateLimiter(rateLimiterOptions),
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", v1.HelmRepositoryKind)
 		os.Exit(1)
@@ -222,7 +272,7 @@ func main() {
 
 	if err := (&controller.HelmChartReconciler{
 		Client:                  mgr.GetClient(),
-		RegistryClientGenerator: registry.ClientGenerator,
+		RegistryClientGenerator: registry.ClientGeneratorWithTransport(hostLimiter.RoundTripper),
 		Storage:                 storage,
 		Getters:                 getters,
 		EventRecorder:           eventRecorder,
@@ -231,8 +281,13 @@ func main() {
 		Cache:                   helmIndexCache,
 		TTL:                     helmIndexCacheItemTTL,
 		CacheRecorder:           cacheRecorder,
+		ChartCache:              helmChartCache,
+		TagCache:                helmTagCache,
+		HostLimiter:             hostLimiter,
+		Impersonator:            impersonator,
 	}).SetupWithManagerAndOptions(ctx, mgr, controller.HelmChartReconcilerOptions{
-		RateLimiter: helper.GetRateLimiter(rateLimiterOptions),
+		DependencyRequeueInterval: requeueDependency,
+		RateLimiter:               helper.GetRateLimiter(rateLimiterOptions),
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", v1.HelmChartKind)
 		os.Exit(1)
@@ -243,9 +298,12 @@ func main() {
 		EventRecorder:  eventRecorder,
 		Metrics:        metrics,
 		Storage:        storage,
+		HostLimiter:    hostLimiter,
+		Impersonator:   impersonator,
 		ControllerName: controllerName,
 	}).SetupWithManagerAndOptions(mgr, controller.BucketReconcilerOptions{
-		RateLimiter: helper.GetRateLimiter(rateLimiterOptions),
+		DependencyRequeueInterval: requeueDependency,
+		RateLimiter:               helper.GetRateLimiter(rateLimiterOptions),
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", v1beta2.BucketKind)
 		os.Exit(1)
@@ -255,14 +313,98 @@ func main() {
 		Client:         mgr.GetClient(),
 		Storage:        storage,
 		EventRecorder:  eventRecorder,
+		HostLimiter:    hostLimiter,
+		Impersonator:   impersonator,
 		ControllerName: controllerName,
 		Metrics:        metrics,
 	}).SetupWithManagerAndOptions(mgr, controller.OCIRepositoryReconcilerOptions{
-		RateLimiter: helper.GetRateLimiter(rateLimiterOptions),
+		DependencyRequeueInterval: requeueDependency,
+		RateLimiter:               helper.GetRateLimiter(rateLimiterOptions),
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", v1beta2.OCIRepositoryKind)
 		os.Exit(1)
 	}
+
+	if err := (&controller.HelmChartSetReconciler{
+		Client:         mgr.GetClient(),
+		EventRecorder:  eventRecorder,
+		Metrics:        metrics,
+		Storage:        storage,
+		ControllerName: controllerName,
+	}).SetupWithManagerAndOptions(ctx, mgr, controller.HelmChartSetReconcilerOptions{
+		DependencyRequeueInterval: requeueDependency,
+		RateLimiter:               helper.GetRateLimiter(rateLimiterOptions),
+	}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", v1beta2.HelmChartSetKind)
+		os.Exit(1)
+	}
+
+	if err := (&controller.HTTPSourceReconciler{
+		Client:         mgr.GetClient(),
+		EventRecorder:  eventRecorder,
+		Metrics:        metrics,
+		Storage:        storage,
+		ControllerName: controllerName,
+		Impersonator:   impersonator,
+		MaxFileSize:    httpSourceLimit,
+	}).SetupWithManagerAndOptions(mgr, controller.HTTPSourceReconcilerOptions{
+		DependencyRequeueInterval: requeueDependency,
+		RateLimiter:               helper.GetRateLimiter(rateLimiterOptions),
+	}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", v1beta2.HTTPSourceKind)
+		os.Exit(1)
+	}
+
+	if err := (&controller.CompositeSourceReconciler{
+		Client:         mgr.GetClient(),
+		EventRecorder:  eventRecorder,
+		Metrics:        metrics,
+		Storage:        storage,
+		ControllerName: controllerName,
+	}).SetupWithManagerAndOptions(ctx, mgr, controller.CompositeSourceReconcilerOptions{
+		DependencyRequeueInterval: requeueDependency,
+		RateLimiter:               helper.GetRateLimiter(rateLimiterOptions),
+	}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", v1beta2.CompositeSourceKind)
+		os.Exit(1)
+	}
+
+	allowConfigSourceSecrets, err := features.Enabled(features.ConfigSourceSecrets)
+	if err != nil {
+		setupLog.Error(err, "unable to check feature gate "+features.ConfigSourceSecrets)
+		os.Exit(1)
+	}
+	if err := (&controller.ConfigSourceReconciler{
+		Client:         mgr.GetClient(),
+		EventRecorder:  eventRecorder,
+		Metrics:        metrics,
+		Storage:        storage,
+		ControllerName: controllerName,
+		Impersonator:   impersonator,
+	}).SetupWithManagerAndOptions(mgr, controller.ConfigSourceReconcilerOptions{
+		AllowSecrets:              allowConfigSourceSecrets,
+		DependencyRequeueInterval: requeueDependency,
+		RateLimiter:               helper.GetRateLimiter(rateLimiterOptions),
+	}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", v1beta2.ConfigSourceKind)
+		os.Exit(1)
+	}
+
+	if err := (&controller.ReleaseSourceReconciler{
+		Client:         mgr.GetClient(),
+		EventRecorder:  eventRecorder,
+		Metrics:        metrics,
+		Storage:        storage,
+		ControllerName: controllerName,
+		Impersonator:   impersonator,
+		MaxAssetSize:   releaseAssetLimit,
+	}).SetupWithManagerAndOptions(mgr, controller.ReleaseSourceReconcilerOptions{
+		DependencyRequeueInterval: requeueDependency,
+		RateLimiter:               helper.GetRateLimiter(rateLimiterOptions),
+	}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", v1beta2.ReleaseSourceKind)
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	go func() {
@@ -385,8 +527,8 @@ func mustSetupHelmLimits(indexLimit, chartLimit, chartFileLimit int64) {
 	helm.MaxChartFileSize = chartFileLimit
 }
 
-func mustInitHelmCache(maxSize int, itemTTL, purgeInterval string) (*cache.Cache, time.Duration) {
-	if maxSize <= 0 {
+func mustInitHelmCache(maxSize int, maxBytes int64, itemTTL, purgeInterval string) (*cache.Cache, time.Duration) {
+	if maxSize <= 0 && maxBytes <= 0 {
 		setupLog.Info("caching of Helm index files is disabled")
 		return nil, -1
 	}
@@ -403,9 +545,41 @@ func mustInitHelmCache(maxSize int, itemTTL, purgeInterval string) (*cache.Cache
 		os.Exit(1)
 	}
 
+	if maxBytes > 0 {
+		return cache.NewWithMaxBytes(maxBytes, interval), ttl
+	}
 	return cache.New(maxSize, interval), ttl
 }
 
+func initHelmChartCache(maxSize int64) *cache.ChartCache {
+	if maxSize <= 0 {
+		setupLog.Info("caching of Helm chart tarballs is disabled")
+		return nil
+	}
+	return cache.NewChartCache(maxSize)
+}
+
+func mustInitHelmTagCache(maxSize int, itemTTL, purgeInterval string) *cache.TagCache {
+	if maxSize <= 0 {
+		setupLog.Info("caching of OCI Helm chart tags is disabled")
+		return nil
+	}
+
+	interval, err := time.ParseDuration(purgeInterval)
+	if err != nil {
+		setupLog.Error(err, "unable to parse Helm tag cache purge interval")
+		os.Exit(1)
+	}
+
+	ttl, err := time.ParseDuration(itemTTL)
+	if err != nil {
+		setupLog.Error(err, "unable to parse Helm tag cache item TTL")
+		os.Exit(1)
+	}
+
+	return cache.NewTagCache(maxSize, ttl, interval)
+}
+
 func mustInitStorage(path string, storageAdvAddr string, artifactRetentionTTL time.Duration, artifactRetentionRecords int, artifactDigestAlgo string) *controller.Storage {
 	if storageAdvAddr == "" {
 		storageAdvAddr = determineAdvStorageAddr(storageAdvAddr)
@@ -68,6 +68,16 @@ func NewClient(ctx context.Context, secret *corev1.Secret) (*GCSClient, error) {
 	return c, nil
 }
 
+// NewAnonymousClient creates a new GCP storage client which does not
+// authenticate, for accessing public buckets.
+func NewAnonymousClient(ctx context.Context) (*GCSClient, error) {
+	client, err := gcpstorage.NewClient(ctx, option.WithoutAuthentication())
+	if err != nil {
+		return nil, err
+	}
+	return &GCSClient{Client: client}, nil
+}
+
 // ValidateSecret validates the credential secret. The provided Secret may
 // be nil.
 func ValidateSecret(secret *corev1.Secret) error {
@@ -21,6 +21,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -36,8 +37,29 @@ type MinioClient struct {
 	*minio.Client
 }
 
+// Option is a functional option for configuring the Minio storage client.
+type Option func(*options)
+
+type options struct {
+	wrapTransport func(http.RoundTripper) http.RoundTripper
+}
+
+// WithTransportWrapper configures the Minio storage client to make its
+// requests using the http.RoundTripper returned by the given function for
+// the transport of the client.
+func WithTransportWrapper(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return func(o *options) {
+		o.wrapTransport = wrap
+	}
+}
+
 // NewClient creates a new Minio storage client.
-func NewClient(bucket *sourcev1.Bucket, secret *corev1.Secret, tlsConfig *tls.Config) (*MinioClient, error) {
+func NewClient(bucket *sourcev1.Bucket, secret *corev1.Secret, tlsConfig *tls.Config, opts ...Option) (*MinioClient, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	opt := minio.Options{
 		Region: bucket.Spec.Region,
 		Secure: !bucket.Spec.Insecure,
@@ -73,6 +95,17 @@ func NewClient(bucket *sourcev1.Bucket, secret *corev1.Secret, tlsConfig *tls.Co
 		opt.Transport = transport
 	}
 
+	if o.wrapTransport != nil {
+		if opt.Transport == nil {
+			transport, err := minio.DefaultTransport(opt.Secure)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create default minio transport: %w", err)
+			}
+			opt.Transport = transport
+		}
+		opt.Transport = o.wrapTransport(opt.Transport)
+	}
+
 	client, err := minio.New(bucket.Spec.Endpoint, &opt)
 	if err != nil {
 		return nil, err
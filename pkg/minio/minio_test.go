@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -212,6 +213,20 @@ func TestNewClientAwsProvider(t *testing.T) {
 	assert.Assert(t, minioClient != nil)
 }
 
+func TestNewClientTransportWrapper(t *testing.T) {
+	for _, tlsConfig := range []*tls.Config{nil, testTLSConfig} {
+		var wrapped http.RoundTripper
+		minioClient, err := NewClient(bucketStub(bucket, testMinioAddress), secret.DeepCopy(), tlsConfig,
+			WithTransportWrapper(func(next http.RoundTripper) http.RoundTripper {
+				wrapped = next
+				return next
+			}))
+		assert.NilError(t, err)
+		assert.Assert(t, minioClient != nil)
+		assert.Assert(t, wrapped != nil)
+	}
+}
+
 func TestBucketExists(t *testing.T) {
 	ctx := context.Background()
 	exists, err := testMinioClient.BucketExists(ctx, bucketName)